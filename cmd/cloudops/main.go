@@ -48,6 +48,8 @@ func main() {
 func Init() {
 	// 初始化配置
 	config.InitViper()
+	// 生成的 Prometheus 配置需要保留敏感字段的原始值
+	di.InitPrometheusConfig()
 	// 初始化 Web 服务器和其他组件
 	cmd := di.InitWebServer()
 	// 初始化翻译器
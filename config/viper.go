@@ -50,3 +50,13 @@ func InitWebHookViper() {
 		panic(err)
 	}
 }
+
+func InitAgentViper() {
+	configFile := pflag.String("config", "config/agent.yaml", "配置文件路径")
+	pflag.Parse()
+	viper.SetConfigFile(*configFile)
+	err := viper.ReadInConfig()
+	if err != nil {
+		panic(err)
+	}
+}
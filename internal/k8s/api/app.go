@@ -26,17 +26,29 @@ package api
  */
 
 import (
+	"github.com/GoSimplicity/AI-CloudOps/internal/k8s/service/uesr"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"github.com/GoSimplicity/AI-CloudOps/pkg/utils/apiresponse"
+	ijwt "github.com/GoSimplicity/AI-CloudOps/pkg/utils/jwt"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
 type K8sAppHandler struct {
-	l *zap.Logger
+	l               *zap.Logger
+	appService      uesr.AppService
+	instanceService uesr.InstanceService
+	projectService  uesr.ProjectService
+	cronjobService  uesr.CronjobService
 }
 
-func NewK8sAppHandler(l *zap.Logger) *K8sAppHandler {
+func NewK8sAppHandler(l *zap.Logger, appService uesr.AppService, instanceService uesr.InstanceService, projectService uesr.ProjectService, cronjobService uesr.CronjobService) *K8sAppHandler {
 	return &K8sAppHandler{
-		l: l,
+		l:               l,
+		appService:      appService,
+		instanceService: instanceService,
+		projectService:  projectService,
+		cronjobService:  cronjobService,
 	}
 }
 
@@ -97,130 +109,278 @@ func (k *K8sAppHandler) RegisterRouters(server *gin.Engine) {
 
 // GetClusterNamespacesUnique 获取唯一的命名空间列表
 func (k *K8sAppHandler) GetClusterNamespacesUnique(ctx *gin.Context) {
-	// TODO: 实现获取唯一命名空间列表的逻辑
+	apiresponse.HandleRequest(ctx, nil, func() (interface{}, error) {
+		return k.appService.GetClusterNamespacesUnique(ctx)
+	})
 }
 
 // CreateK8sInstanceOne 创建单个 Kubernetes 实例
 func (k *K8sAppHandler) CreateK8sInstanceOne(ctx *gin.Context) {
-	// TODO: 实现创建单个 Kubernetes 实例的逻辑
+	var req model.K8sInstance
+
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+
+	apiresponse.HandleRequest(ctx, &req, func() (interface{}, error) {
+		req.UserID = uc.Uid
+		return nil, k.instanceService.CreateInstance(ctx, &req)
+	})
 }
 
 // UpdateK8sInstanceOne 更新单个 Kubernetes 实例
 func (k *K8sAppHandler) UpdateK8sInstanceOne(ctx *gin.Context) {
-	// TODO: 实现更新单个 Kubernetes 实例的逻辑
+	var req model.K8sInstance
+
+	apiresponse.HandleRequest(ctx, &req, func() (interface{}, error) {
+		return nil, k.instanceService.UpdateInstance(ctx, &req)
+	})
 }
 
 // BatchDeleteK8sInstance 批量删除 Kubernetes 实例
 func (k *K8sAppHandler) BatchDeleteK8sInstance(ctx *gin.Context) {
-	// TODO: 实现批量删除 Kubernetes 实例的逻辑
+	var req model.BatchDeleteReq
+
+	apiresponse.HandleRequest(ctx, &req, func() (interface{}, error) {
+		return nil, k.instanceService.BatchDeleteInstances(ctx, req.IDs)
+	})
 }
 
 // BatchRestartK8sInstance 批量重启 Kubernetes 实例
 func (k *K8sAppHandler) BatchRestartK8sInstance(ctx *gin.Context) {
-	// TODO: 实现批量重启 Kubernetes 实例的逻辑
+	var req model.BatchDeleteReq
+
+	apiresponse.HandleRequest(ctx, &req, func() (interface{}, error) {
+		return nil, k.instanceService.BatchRestartInstances(ctx, req.IDs)
+	})
 }
 
 // GetK8sInstanceByApp 根据应用获取 Kubernetes 实例
 func (k *K8sAppHandler) GetK8sInstanceByApp(ctx *gin.Context) {
-	// TODO: 实现根据应用获取 Kubernetes 实例的逻辑
+	appID, err := apiresponse.GetQueryID(ctx)
+	if err != nil {
+		apiresponse.BadRequestError(ctx, err.Error())
+		return
+	}
+
+	apiresponse.HandleRequest(ctx, nil, func() (interface{}, error) {
+		return k.instanceService.GetInstancesByApp(ctx, appID)
+	})
 }
 
 // GetK8sInstanceList 获取 Kubernetes 实例列表
 func (k *K8sAppHandler) GetK8sInstanceList(ctx *gin.Context) {
-	// TODO: 实现获取 Kubernetes 实例列表的逻辑
+	apiresponse.HandleRequest(ctx, nil, func() (interface{}, error) {
+		return k.instanceService.GetInstanceList(ctx)
+	})
 }
 
 // GetK8sInstanceOne 获取单个 Kubernetes 实例
 func (k *K8sAppHandler) GetK8sInstanceOne(ctx *gin.Context) {
-	// TODO: 实现获取单个 Kubernetes 实例的逻辑
+	id, err := apiresponse.GetParamID(ctx)
+	if err != nil {
+		apiresponse.BadRequestError(ctx, err.Error())
+		return
+	}
+
+	apiresponse.HandleRequest(ctx, nil, func() (interface{}, error) {
+		return k.instanceService.GetInstanceByID(ctx, id)
+	})
 }
 
 // GetK8sAppList 获取 Kubernetes 应用列表
 func (k *K8sAppHandler) GetK8sAppList(ctx *gin.Context) {
-	// TODO: 实现获取 Kubernetes 应用列表的逻辑
+	apiresponse.HandleRequest(ctx, nil, func() (interface{}, error) {
+		return k.appService.GetAppList(ctx)
+	})
 }
 
 // CreateK8sAppOne 创建单个 Kubernetes 应用
 func (k *K8sAppHandler) CreateK8sAppOne(ctx *gin.Context) {
-	// TODO: 实现创建单个 Kubernetes 应用的逻辑
+	var req model.K8sApp
+
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+
+	apiresponse.HandleRequest(ctx, &req, func() (interface{}, error) {
+		req.UserID = uc.Uid
+		return nil, k.appService.CreateApp(ctx, &req)
+	})
 }
 
 // UpdateK8sAppOne 更新单个 Kubernetes 应用
 func (k *K8sAppHandler) UpdateK8sAppOne(ctx *gin.Context) {
-	// TODO: 实现更新单个 Kubernetes 应用的逻辑
+	var req model.K8sApp
+
+	id, err := apiresponse.GetParamID(ctx)
+	if err != nil {
+		apiresponse.BadRequestError(ctx, err.Error())
+		return
+	}
+
+	apiresponse.HandleRequest(ctx, &req, func() (interface{}, error) {
+		req.ID = id
+		return nil, k.appService.UpdateApp(ctx, &req)
+	})
 }
 
 // DeleteK8sAppOne 删除单个 Kubernetes 应用
 func (k *K8sAppHandler) DeleteK8sAppOne(ctx *gin.Context) {
-	// TODO: 实现删除单个 Kubernetes 应用的逻辑
+	id, err := apiresponse.GetParamID(ctx)
+	if err != nil {
+		apiresponse.BadRequestError(ctx, err.Error())
+		return
+	}
+
+	apiresponse.HandleRequest(ctx, nil, func() (interface{}, error) {
+		return nil, k.appService.DeleteApp(ctx, id)
+	})
 }
 
 // GetK8sAppOne 获取单个 Kubernetes 应用
 func (k *K8sAppHandler) GetK8sAppOne(ctx *gin.Context) {
-	// TODO: 实现获取单个 Kubernetes 应用的逻辑
+	id, err := apiresponse.GetParamID(ctx)
+	if err != nil {
+		apiresponse.BadRequestError(ctx, err.Error())
+		return
+	}
+
+	apiresponse.HandleRequest(ctx, nil, func() (interface{}, error) {
+		return k.appService.GetAppByID(ctx, id)
+	})
 }
 
 // GetK8sPodListByDeploy 根据部署获取 Kubernetes Pod 列表
 func (k *K8sAppHandler) GetK8sPodListByDeploy(ctx *gin.Context) {
-	// TODO: 实现根据部署获取 Kubernetes Pod 列表的逻辑
+	id, err := apiresponse.GetParamID(ctx)
+	if err != nil {
+		apiresponse.BadRequestError(ctx, err.Error())
+		return
+	}
+
+	apiresponse.HandleRequest(ctx, nil, func() (interface{}, error) {
+		return k.appService.GetPodListByApp(ctx, id)
+	})
 }
 
 // GetK8sAppListForSelect 获取用于选择的 Kubernetes 应用列表
 func (k *K8sAppHandler) GetK8sAppListForSelect(ctx *gin.Context) {
-	// TODO: 实现获取用于选择的 Kubernetes 应用列表的逻辑
+	apiresponse.HandleRequest(ctx, nil, func() (interface{}, error) {
+		return k.appService.GetAppListForSelect(ctx)
+	})
 }
 
 // GetK8sProjectList 获取 Kubernetes 项目列表
 func (k *K8sAppHandler) GetK8sProjectList(ctx *gin.Context) {
-	// TODO: 实现获取 Kubernetes 项目列表的逻辑
+	apiresponse.HandleRequest(ctx, nil, func() (interface{}, error) {
+		return k.projectService.GetProjectList(ctx)
+	})
 }
 
 // GetK8sProjectListForSelect 获取用于选择的 Kubernetes 项目列表
 func (k *K8sAppHandler) GetK8sProjectListForSelect(ctx *gin.Context) {
-	// TODO: 实现获取用于选择的 Kubernetes 项目列表的逻辑
+	apiresponse.HandleRequest(ctx, nil, func() (interface{}, error) {
+		return k.projectService.GetProjectListForSelect(ctx)
+	})
 }
 
 // CreateK8sProject 创建 Kubernetes 项目
 func (k *K8sAppHandler) CreateK8sProject(ctx *gin.Context) {
-	// TODO: 实现创建 Kubernetes 项目的逻辑
+	var req model.K8sProject
+
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+
+	apiresponse.HandleRequest(ctx, &req, func() (interface{}, error) {
+		req.UserID = uc.Uid
+		return nil, k.projectService.CreateProject(ctx, &req)
+	})
 }
 
 // UpdateK8sProject 更新 Kubernetes 项目
 func (k *K8sAppHandler) UpdateK8sProject(ctx *gin.Context) {
-	// TODO: 实现更新 Kubernetes 项目的逻辑
+	var req model.K8sProject
+
+	apiresponse.HandleRequest(ctx, &req, func() (interface{}, error) {
+		return nil, k.projectService.UpdateProject(ctx, &req)
+	})
 }
 
 // DeleteK8sProjectOne 删除单个 Kubernetes 项目
 func (k *K8sAppHandler) DeleteK8sProjectOne(ctx *gin.Context) {
-	// TODO: 实现删除单个 Kubernetes 项目的逻辑
+	id, err := apiresponse.GetParamID(ctx)
+	if err != nil {
+		apiresponse.BadRequestError(ctx, err.Error())
+		return
+	}
+
+	apiresponse.HandleRequest(ctx, nil, func() (interface{}, error) {
+		return nil, k.projectService.DeleteProject(ctx, id)
+	})
 }
 
 // GetK8sCronjobList 获取 CronJob 列表
 func (k *K8sAppHandler) GetK8sCronjobList(ctx *gin.Context) {
-	// TODO: 实现获取 CronJob 列表的逻辑
+	apiresponse.HandleRequest(ctx, nil, func() (interface{}, error) {
+		return k.cronjobService.GetCronjobList(ctx)
+	})
 }
 
 // CreateK8sCronjobOne 创建单个 CronJob
 func (k *K8sAppHandler) CreateK8sCronjobOne(ctx *gin.Context) {
-	// TODO: 实现创建单个 CronJob 的逻辑
+	var req model.K8sCronjob
+
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+
+	apiresponse.HandleRequest(ctx, &req, func() (interface{}, error) {
+		req.UserID = uc.Uid
+		return nil, k.cronjobService.CreateCronjob(ctx, &req)
+	})
 }
 
 // UpdateK8sCronjobOne 更新单个 CronJob
 func (k *K8sAppHandler) UpdateK8sCronjobOne(ctx *gin.Context) {
-	// TODO: 实现更新单个 CronJob 的逻辑
+	var req model.K8sCronjob
+
+	id, err := apiresponse.GetParamID(ctx)
+	if err != nil {
+		apiresponse.BadRequestError(ctx, err.Error())
+		return
+	}
+
+	apiresponse.HandleRequest(ctx, &req, func() (interface{}, error) {
+		req.ID = id
+		return nil, k.cronjobService.UpdateCronjob(ctx, &req)
+	})
 }
 
 // GetK8sCronjobOne 获取单个 CronJob
 func (k *K8sAppHandler) GetK8sCronjobOne(ctx *gin.Context) {
-	// TODO: 实现获取单个 CronJob 的逻辑
+	id, err := apiresponse.GetParamID(ctx)
+	if err != nil {
+		apiresponse.BadRequestError(ctx, err.Error())
+		return
+	}
+
+	apiresponse.HandleRequest(ctx, nil, func() (interface{}, error) {
+		return k.cronjobService.GetCronjobByID(ctx, id)
+	})
 }
 
 // GetK8sCronjobLastPod 获取 CronJob 最近的 Pod
 func (k *K8sAppHandler) GetK8sCronjobLastPod(ctx *gin.Context) {
-	// TODO: 实现获取 CronJob 最近的 Pod 的逻辑
+	id, err := apiresponse.GetParamID(ctx)
+	if err != nil {
+		apiresponse.BadRequestError(ctx, err.Error())
+		return
+	}
+
+	apiresponse.HandleRequest(ctx, nil, func() (interface{}, error) {
+		return k.cronjobService.GetCronjobLastPod(ctx, id)
+	})
 }
 
 // BatchDeleteK8sCronjob 批量删除 CronJob
 func (k *K8sAppHandler) BatchDeleteK8sCronjob(ctx *gin.Context) {
-	// TODO: 实现批量删除 CronJob 的逻辑
+	var req model.BatchDeleteReq
+
+	apiresponse.HandleRequest(ctx, &req, func() (interface{}, error) {
+		return nil, k.cronjobService.BatchDeleteCronjobs(ctx, req.IDs)
+	})
 }
@@ -24,3 +24,124 @@ package uesr
  * THE SOFTWARE.
  *
  */
+
+import (
+	"context"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type AppDAO interface {
+	// GetAllApps 查询所有应用
+	GetAllApps(ctx context.Context) ([]*model.K8sApp, error)
+	// CreateApp 在同一事务中创建应用及其实例，apply 不为空时在提交前调用，返回错误时回滚
+	CreateApp(ctx context.Context, app *model.K8sApp, apply func() error) error
+	// UpdateApp 更新应用，apply 不为空时在提交前调用，返回错误时回滚
+	UpdateApp(ctx context.Context, app *model.K8sApp, apply func() error) error
+	// DeleteApp 删除应用
+	DeleteApp(ctx context.Context, id int) error
+	// GetAppByID 根据 ID 查询应用，包含关联的实例
+	GetAppByID(ctx context.Context, id int) (*model.K8sApp, error)
+	// GetAppsByProjectID 根据项目 ID 查询应用
+	GetAppsByProjectID(ctx context.Context, projectID int) ([]*model.K8sApp, error)
+}
+
+type appDAO struct {
+	db *gorm.DB
+	l  *zap.Logger
+}
+
+func NewAppDAO(db *gorm.DB, l *zap.Logger) AppDAO {
+	return &appDAO{
+		db: db,
+		l:  l,
+	}
+}
+
+// GetAllApps 查询所有应用
+func (a *appDAO) GetAllApps(ctx context.Context) ([]*model.K8sApp, error) {
+	var apps []*model.K8sApp
+
+	if err := a.db.WithContext(ctx).Find(&apps).Error; err != nil {
+		a.l.Error("GetAllApps 查询所有应用失败", zap.Error(err))
+		return nil, err
+	}
+
+	return apps, nil
+}
+
+// CreateApp 在同一事务中创建应用及其实例，apply 不为空时在提交前调用，返回错误时回滚
+func (a *appDAO) CreateApp(ctx context.Context, app *model.K8sApp, apply func() error) error {
+	return a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("K8sInstances").Create(app).Error; err != nil {
+			a.l.Error("CreateApp 创建应用失败", zap.Error(err))
+			return err
+		}
+
+		for i := range app.K8sInstances {
+			app.K8sInstances[i].K8sAppID = app.ID
+			if err := tx.Create(&app.K8sInstances[i]).Error; err != nil {
+				a.l.Error("CreateApp 创建实例失败", zap.String("instanceName", app.K8sInstances[i].Name), zap.Error(err))
+				return err
+			}
+		}
+
+		if apply != nil {
+			return apply()
+		}
+
+		return nil
+	})
+}
+
+// UpdateApp 更新应用，apply 不为空时在提交前调用，返回错误时回滚
+func (a *appDAO) UpdateApp(ctx context.Context, app *model.K8sApp, apply func() error) error {
+	return a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.K8sApp{}).Omit("K8sInstances").Where("id = ?", app.ID).Updates(app).Error; err != nil {
+			a.l.Error("UpdateApp 更新应用失败", zap.Int("id", app.ID), zap.Error(err))
+			return err
+		}
+
+		if apply != nil {
+			return apply()
+		}
+
+		return nil
+	})
+}
+
+// DeleteApp 删除应用
+func (a *appDAO) DeleteApp(ctx context.Context, id int) error {
+	if err := a.db.WithContext(ctx).Where("id = ?", id).Delete(&model.K8sApp{}).Error; err != nil {
+		a.l.Error("DeleteApp 删除应用失败", zap.Int("id", id), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// GetAppByID 根据 ID 查询应用，包含关联的实例
+func (a *appDAO) GetAppByID(ctx context.Context, id int) (*model.K8sApp, error) {
+	var app model.K8sApp
+
+	if err := a.db.WithContext(ctx).Preload("K8sInstances").Where("id = ?", id).First(&app).Error; err != nil {
+		a.l.Error("GetAppByID 查询应用失败", zap.Int("id", id), zap.Error(err))
+		return nil, fmt.Errorf("应用 ID %d 未找到: %w", id, err)
+	}
+
+	return &app, nil
+}
+
+// GetAppsByProjectID 根据项目 ID 查询应用
+func (a *appDAO) GetAppsByProjectID(ctx context.Context, projectID int) ([]*model.K8sApp, error) {
+	var apps []*model.K8sApp
+
+	if err := a.db.WithContext(ctx).Where("k8s_project_id = ?", projectID).Find(&apps).Error; err != nil {
+		a.l.Error("GetAppsByProjectID 查询应用失败", zap.Int("projectID", projectID), zap.Error(err))
+		return nil, err
+	}
+
+	return apps, nil
+}
@@ -0,0 +1,187 @@
+package uesr
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"github.com/glebarez/sqlite"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{IgnoreRelationshipsWhenMigrating: true})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	// 内存数据库每个连接各自独立，只使用一个连接
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取数据库连接失败: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	// sqlite 的索引名称在库内全局唯一，两张表都使用 udx_name，需要先为应用表的索引改名
+	if err := db.AutoMigrate(&model.K8sApp{}); err != nil {
+		t.Fatalf("创建表失败: %v", err)
+	}
+	if err := db.Exec("DROP INDEX udx_name").Error; err != nil {
+		t.Fatalf("删除索引失败: %v", err)
+	}
+	if err := db.Exec("CREATE UNIQUE INDEX udx_k8s_apps_name ON k8s_apps(deleted_at, name, cluster)").Error; err != nil {
+		t.Fatalf("创建索引失败: %v", err)
+	}
+	if err := db.AutoMigrate(&model.K8sInstance{}); err != nil {
+		t.Fatalf("创建表失败: %v", err)
+	}
+
+	return db
+}
+
+func countRows(t *testing.T, db *gorm.DB, value interface{}) int64 {
+	t.Helper()
+
+	var count int64
+	if err := db.Model(value).Count(&count).Error; err != nil {
+		t.Fatalf("查询数量失败: %v", err)
+	}
+
+	return count
+}
+
+func TestCreateAppRollsBackWhenApplyFails(t *testing.T) {
+	db := newTestDB(t)
+	appDao := NewAppDAO(db, zap.NewNop())
+	ctx := context.Background()
+
+	newApp := func() *model.K8sApp {
+		return &model.K8sApp{
+			Name:         "web",
+			Cluster:      "prod",
+			Namespace:    "default",
+			K8sInstances: []model.K8sInstance{{Name: "web-a"}, {Name: "web-b"}},
+		}
+	}
+
+	applyErr := errors.New("apply failed")
+	if err := appDao.CreateApp(ctx, newApp(), func() error { return applyErr }); !errors.Is(err, applyErr) {
+		t.Fatalf("CreateApp() err = %v, want %v", err, applyErr)
+	}
+	if n := countRows(t, db, &model.K8sApp{}); n != 0 {
+		t.Errorf("下发失败后应用数量 = %d, want 0", n)
+	}
+	if n := countRows(t, db, &model.K8sInstance{}); n != 0 {
+		t.Errorf("下发失败后实例数量 = %d, want 0", n)
+	}
+
+	// 回滚后重试不会因为名称重复失败
+	app := newApp()
+	if err := appDao.CreateApp(ctx, app, func() error { return nil }); err != nil {
+		t.Fatalf("重试 CreateApp() err = %v", err)
+	}
+
+	var instanceCount int64
+	if err := db.Model(&model.K8sInstance{}).Where("k8s_app_id = ?", app.ID).Count(&instanceCount).Error; err != nil {
+		t.Fatalf("查询实例数量失败: %v", err)
+	}
+	if instanceCount != 2 {
+		t.Errorf("实例数量 = %d, want 2", instanceCount)
+	}
+}
+
+func TestUpdateInstanceRollsBackWhenApplyFails(t *testing.T) {
+	db := newTestDB(t)
+	instanceDao := NewInstanceDAO(db, zap.NewNop())
+	ctx := context.Background()
+
+	instance := &model.K8sInstance{Name: "web-a", Cluster: "prod"}
+	if err := instanceDao.CreateInstance(ctx, instance, nil); err != nil {
+		t.Fatalf("CreateInstance() err = %v", err)
+	}
+
+	update := &model.K8sInstance{Model: model.Model{ID: instance.ID}, Name: "web-a", Cluster: "staging"}
+	if err := instanceDao.UpdateInstance(ctx, update, func() error { return errors.New("apply failed") }); err == nil {
+		t.Fatal("UpdateInstance() 应返回下发错误")
+	}
+
+	var clusters []string
+	if err := db.Model(&model.K8sInstance{}).Where("id = ?", instance.ID).Pluck("cluster", &clusters).Error; err != nil {
+		t.Fatalf("查询实例失败: %v", err)
+	}
+	if len(clusters) != 1 || clusters[0] != "prod" {
+		t.Errorf("下发失败后 Cluster = %v, want prod", clusters)
+	}
+}
+
+func TestCronjobRollsBackWhenApplyFails(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{IgnoreRelationshipsWhenMigrating: true})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取数据库连接失败: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&model.K8sCronjob{}); err != nil {
+		t.Fatalf("创建表失败: %v", err)
+	}
+
+	cronjobDao := NewCronjobDAO(db, zap.NewNop())
+	ctx := context.Background()
+	applyErr := errors.New("apply failed")
+
+	if err := cronjobDao.CreateCronjob(ctx, &model.K8sCronjob{Name: "backup", Schedule: "0 * * * *"}, func() error { return applyErr }); !errors.Is(err, applyErr) {
+		t.Fatalf("CreateCronjob() err = %v, want %v", err, applyErr)
+	}
+	if n := countRows(t, db, &model.K8sCronjob{}); n != 0 {
+		t.Errorf("下发失败后定时任务数量 = %d, want 0", n)
+	}
+
+	cronjob := &model.K8sCronjob{Name: "backup", Schedule: "0 * * * *"}
+	if err := cronjobDao.CreateCronjob(ctx, cronjob, nil); err != nil {
+		t.Fatalf("重试 CreateCronjob() err = %v", err)
+	}
+
+	update := &model.K8sCronjob{Model: model.Model{ID: cronjob.ID}, Name: "backup", Schedule: "*/5 * * * *"}
+	if err := cronjobDao.UpdateCronjob(ctx, update, func() error { return applyErr }); !errors.Is(err, applyErr) {
+		t.Fatalf("UpdateCronjob() err = %v, want %v", err, applyErr)
+	}
+
+	var schedules []string
+	if err := db.Model(&model.K8sCronjob{}).Where("id = ?", cronjob.ID).Pluck("schedule", &schedules).Error; err != nil {
+		t.Fatalf("查询定时任务失败: %v", err)
+	}
+	if len(schedules) != 1 || schedules[0] != "0 * * * *" {
+		t.Errorf("下发失败后 Schedule = %v, want 0 * * * *", schedules)
+	}
+}
@@ -24,3 +24,116 @@ package uesr
  * THE SOFTWARE.
  *
  */
+
+import (
+	"context"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type CronjobDAO interface {
+	// GetAllCronjobs 查询所有定时任务
+	GetAllCronjobs(ctx context.Context) ([]*model.K8sCronjob, error)
+	// CreateCronjob 创建定时任务，apply 不为空时在提交前调用，返回错误时回滚
+	CreateCronjob(ctx context.Context, cronjob *model.K8sCronjob, apply func() error) error
+	// UpdateCronjob 更新定时任务，apply 不为空时在提交前调用，返回错误时回滚
+	UpdateCronjob(ctx context.Context, cronjob *model.K8sCronjob, apply func() error) error
+	// BatchDeleteCronjobs 批量删除定时任务
+	BatchDeleteCronjobs(ctx context.Context, ids []int) error
+	// GetCronjobByID 根据 ID 查询定时任务
+	GetCronjobByID(ctx context.Context, id int) (*model.K8sCronjob, error)
+	// GetCronjobsByIDs 根据 ID 列表查询定时任务
+	GetCronjobsByIDs(ctx context.Context, ids []int) ([]*model.K8sCronjob, error)
+}
+
+type cronjobDAO struct {
+	db *gorm.DB
+	l  *zap.Logger
+}
+
+func NewCronjobDAO(db *gorm.DB, l *zap.Logger) CronjobDAO {
+	return &cronjobDAO{
+		db: db,
+		l:  l,
+	}
+}
+
+// GetAllCronjobs 查询所有定时任务
+func (c *cronjobDAO) GetAllCronjobs(ctx context.Context) ([]*model.K8sCronjob, error) {
+	var cronjobs []*model.K8sCronjob
+
+	if err := c.db.WithContext(ctx).Find(&cronjobs).Error; err != nil {
+		c.l.Error("GetAllCronjobs 查询所有定时任务失败", zap.Error(err))
+		return nil, err
+	}
+
+	return cronjobs, nil
+}
+
+// CreateCronjob 创建定时任务，apply 不为空时在提交前调用，返回错误时回滚
+func (c *cronjobDAO) CreateCronjob(ctx context.Context, cronjob *model.K8sCronjob, apply func() error) error {
+	return c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(cronjob).Error; err != nil {
+			c.l.Error("CreateCronjob 创建定时任务失败", zap.Error(err))
+			return err
+		}
+
+		if apply != nil {
+			return apply()
+		}
+
+		return nil
+	})
+}
+
+// UpdateCronjob 更新定时任务，apply 不为空时在提交前调用，返回错误时回滚
+func (c *cronjobDAO) UpdateCronjob(ctx context.Context, cronjob *model.K8sCronjob, apply func() error) error {
+	return c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.K8sCronjob{}).Where("id = ?", cronjob.ID).Updates(cronjob).Error; err != nil {
+			c.l.Error("UpdateCronjob 更新定时任务失败", zap.Int("id", cronjob.ID), zap.Error(err))
+			return err
+		}
+
+		if apply != nil {
+			return apply()
+		}
+
+		return nil
+	})
+}
+
+// BatchDeleteCronjobs 批量删除定时任务
+func (c *cronjobDAO) BatchDeleteCronjobs(ctx context.Context, ids []int) error {
+	if err := c.db.WithContext(ctx).Where("id IN ?", ids).Delete(&model.K8sCronjob{}).Error; err != nil {
+		c.l.Error("BatchDeleteCronjobs 批量删除定时任务失败", zap.Ints("ids", ids), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// GetCronjobByID 根据 ID 查询定时任务
+func (c *cronjobDAO) GetCronjobByID(ctx context.Context, id int) (*model.K8sCronjob, error) {
+	var cronjob model.K8sCronjob
+
+	if err := c.db.WithContext(ctx).Where("id = ?", id).First(&cronjob).Error; err != nil {
+		c.l.Error("GetCronjobByID 查询定时任务失败", zap.Int("id", id), zap.Error(err))
+		return nil, fmt.Errorf("定时任务 ID %d 未找到: %w", id, err)
+	}
+
+	return &cronjob, nil
+}
+
+// GetCronjobsByIDs 根据 ID 列表查询定时任务
+func (c *cronjobDAO) GetCronjobsByIDs(ctx context.Context, ids []int) ([]*model.K8sCronjob, error) {
+	var cronjobs []*model.K8sCronjob
+
+	if err := c.db.WithContext(ctx).Where("id IN ?", ids).Find(&cronjobs).Error; err != nil {
+		c.l.Error("GetCronjobsByIDs 查询定时任务失败", zap.Ints("ids", ids), zap.Error(err))
+		return nil, err
+	}
+
+	return cronjobs, nil
+}
@@ -24,3 +24,130 @@ package uesr
  * THE SOFTWARE.
  *
  */
+
+import (
+	"context"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type InstanceDAO interface {
+	// GetAllInstances 查询所有实例
+	GetAllInstances(ctx context.Context) ([]*model.K8sInstance, error)
+	// CreateInstance 创建实例，apply 不为空时在提交前调用，返回错误时回滚
+	CreateInstance(ctx context.Context, instance *model.K8sInstance, apply func() error) error
+	// UpdateInstance 更新实例，apply 不为空时在提交前调用，返回错误时回滚
+	UpdateInstance(ctx context.Context, instance *model.K8sInstance, apply func() error) error
+	// BatchDeleteInstances 批量删除实例
+	BatchDeleteInstances(ctx context.Context, ids []int) error
+	// GetInstanceByID 根据 ID 查询实例
+	GetInstanceByID(ctx context.Context, id int) (*model.K8sInstance, error)
+	// GetInstancesByIDs 根据 ID 列表查询实例
+	GetInstancesByIDs(ctx context.Context, ids []int) ([]*model.K8sInstance, error)
+	// GetInstancesByAppID 根据应用 ID 查询实例
+	GetInstancesByAppID(ctx context.Context, appID int) ([]*model.K8sInstance, error)
+}
+
+type instanceDAO struct {
+	db *gorm.DB
+	l  *zap.Logger
+}
+
+func NewInstanceDAO(db *gorm.DB, l *zap.Logger) InstanceDAO {
+	return &instanceDAO{
+		db: db,
+		l:  l,
+	}
+}
+
+// GetAllInstances 查询所有实例
+func (i *instanceDAO) GetAllInstances(ctx context.Context) ([]*model.K8sInstance, error) {
+	var instances []*model.K8sInstance
+
+	if err := i.db.WithContext(ctx).Find(&instances).Error; err != nil {
+		i.l.Error("GetAllInstances 查询所有实例失败", zap.Error(err))
+		return nil, err
+	}
+
+	return instances, nil
+}
+
+// CreateInstance 创建实例，apply 不为空时在提交前调用，返回错误时回滚
+func (i *instanceDAO) CreateInstance(ctx context.Context, instance *model.K8sInstance, apply func() error) error {
+	return i.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(instance).Error; err != nil {
+			i.l.Error("CreateInstance 创建实例失败", zap.Error(err))
+			return err
+		}
+
+		if apply != nil {
+			return apply()
+		}
+
+		return nil
+	})
+}
+
+// UpdateInstance 更新实例，apply 不为空时在提交前调用，返回错误时回滚
+func (i *instanceDAO) UpdateInstance(ctx context.Context, instance *model.K8sInstance, apply func() error) error {
+	return i.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.K8sInstance{}).Where("id = ?", instance.ID).Updates(instance).Error; err != nil {
+			i.l.Error("UpdateInstance 更新实例失败", zap.Int("id", instance.ID), zap.Error(err))
+			return err
+		}
+
+		if apply != nil {
+			return apply()
+		}
+
+		return nil
+	})
+}
+
+// BatchDeleteInstances 批量删除实例
+func (i *instanceDAO) BatchDeleteInstances(ctx context.Context, ids []int) error {
+	if err := i.db.WithContext(ctx).Where("id IN ?", ids).Delete(&model.K8sInstance{}).Error; err != nil {
+		i.l.Error("BatchDeleteInstances 批量删除实例失败", zap.Ints("ids", ids), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// GetInstanceByID 根据 ID 查询实例
+func (i *instanceDAO) GetInstanceByID(ctx context.Context, id int) (*model.K8sInstance, error) {
+	var instance model.K8sInstance
+
+	if err := i.db.WithContext(ctx).Where("id = ?", id).First(&instance).Error; err != nil {
+		i.l.Error("GetInstanceByID 查询实例失败", zap.Int("id", id), zap.Error(err))
+		return nil, fmt.Errorf("实例 ID %d 未找到: %w", id, err)
+	}
+
+	return &instance, nil
+}
+
+// GetInstancesByIDs 根据 ID 列表查询实例
+func (i *instanceDAO) GetInstancesByIDs(ctx context.Context, ids []int) ([]*model.K8sInstance, error) {
+	var instances []*model.K8sInstance
+
+	if err := i.db.WithContext(ctx).Where("id IN ?", ids).Find(&instances).Error; err != nil {
+		i.l.Error("GetInstancesByIDs 查询实例失败", zap.Ints("ids", ids), zap.Error(err))
+		return nil, err
+	}
+
+	return instances, nil
+}
+
+// GetInstancesByAppID 根据应用 ID 查询实例
+func (i *instanceDAO) GetInstancesByAppID(ctx context.Context, appID int) ([]*model.K8sInstance, error) {
+	var instances []*model.K8sInstance
+
+	if err := i.db.WithContext(ctx).Where("k8s_app_id = ?", appID).Find(&instances).Error; err != nil {
+		i.l.Error("GetInstancesByAppID 查询实例失败", zap.Int("appID", appID), zap.Error(err))
+		return nil, err
+	}
+
+	return instances, nil
+}
@@ -24,3 +24,90 @@ package uesr
  * THE SOFTWARE.
  *
  */
+
+import (
+	"context"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type ProjectDAO interface {
+	// GetAllProjects 查询所有项目
+	GetAllProjects(ctx context.Context) ([]*model.K8sProject, error)
+	// CreateProject 创建项目
+	CreateProject(ctx context.Context, project *model.K8sProject) error
+	// UpdateProject 更新项目
+	UpdateProject(ctx context.Context, project *model.K8sProject) error
+	// DeleteProject 删除项目
+	DeleteProject(ctx context.Context, id int) error
+	// GetProjectByID 根据 ID 查询项目
+	GetProjectByID(ctx context.Context, id int) (*model.K8sProject, error)
+}
+
+type projectDAO struct {
+	db *gorm.DB
+	l  *zap.Logger
+}
+
+func NewProjectDAO(db *gorm.DB, l *zap.Logger) ProjectDAO {
+	return &projectDAO{
+		db: db,
+		l:  l,
+	}
+}
+
+// GetAllProjects 查询所有项目
+func (p *projectDAO) GetAllProjects(ctx context.Context) ([]*model.K8sProject, error) {
+	var projects []*model.K8sProject
+
+	if err := p.db.WithContext(ctx).Find(&projects).Error; err != nil {
+		p.l.Error("GetAllProjects 查询所有项目失败", zap.Error(err))
+		return nil, err
+	}
+
+	return projects, nil
+}
+
+// CreateProject 创建项目
+func (p *projectDAO) CreateProject(ctx context.Context, project *model.K8sProject) error {
+	if err := p.db.WithContext(ctx).Create(project).Error; err != nil {
+		p.l.Error("CreateProject 创建项目失败", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// UpdateProject 更新项目
+func (p *projectDAO) UpdateProject(ctx context.Context, project *model.K8sProject) error {
+	if err := p.db.WithContext(ctx).Model(&model.K8sProject{}).Where("id = ?", project.ID).Updates(project).Error; err != nil {
+		p.l.Error("UpdateProject 更新项目失败", zap.Int("id", project.ID), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// DeleteProject 删除项目
+func (p *projectDAO) DeleteProject(ctx context.Context, id int) error {
+	if err := p.db.WithContext(ctx).Where("id = ?", id).Delete(&model.K8sProject{}).Error; err != nil {
+		p.l.Error("DeleteProject 删除项目失败", zap.Int("id", id), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// GetProjectByID 根据 ID 查询项目
+func (p *projectDAO) GetProjectByID(ctx context.Context, id int) (*model.K8sProject, error) {
+	var project model.K8sProject
+
+	if err := p.db.WithContext(ctx).Where("id = ?", id).First(&project).Error; err != nil {
+		p.l.Error("GetProjectByID 查询项目失败", zap.Int("id", id), zap.Error(err))
+		return nil, fmt.Errorf("项目 ID %d 未找到: %w", id, err)
+	}
+
+	return &project, nil
+}
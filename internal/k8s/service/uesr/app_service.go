@@ -24,3 +24,453 @@ package uesr
  * THE SOFTWARE.
  *
  */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/k8s/client"
+	"github.com/GoSimplicity/AI-CloudOps/internal/k8s/dao/admin"
+	"github.com/GoSimplicity/AI-CloudOps/internal/k8s/dao/uesr"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"github.com/GoSimplicity/AI-CloudOps/pkg/utils/apiresponse"
+	pkg "github.com/GoSimplicity/AI-CloudOps/pkg/utils/k8s"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8sErr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"sort"
+	"sync"
+)
+
+type AppService interface {
+	// GetClusterNamespacesUnique 获取所有集群去重后的命名空间列表
+	GetClusterNamespacesUnique(ctx context.Context) ([]apiresponse.SelectOption, error)
+	// GetAppList 获取应用列表
+	GetAppList(ctx context.Context) ([]*model.K8sApp, error)
+	// GetAppListForSelect 获取用于选择的应用列表
+	GetAppListForSelect(ctx context.Context) ([]apiresponse.SelectOptionInt, error)
+	// GetAppByID 获取单个应用
+	GetAppByID(ctx context.Context, id int) (*model.K8sApp, error)
+	// CreateApp 创建应用，并在集群中渲染 Service 与实例的 Deployment
+	CreateApp(ctx context.Context, app *model.K8sApp) error
+	// UpdateApp 更新应用，并重新渲染 Service 与所有实例的 Deployment
+	UpdateApp(ctx context.Context, app *model.K8sApp) error
+	// DeleteApp 删除应用，并清理集群中的 Service 与 Deployment
+	DeleteApp(ctx context.Context, id int) error
+	// GetPodListByApp 获取应用下所有实例的 Pod 列表
+	GetPodListByApp(ctx context.Context, id int) ([]*model.K8sPod, error)
+}
+
+type appService struct {
+	appDao      uesr.AppDAO
+	instanceDao uesr.InstanceDAO
+	projectDao  uesr.ProjectDAO
+	clusterDao  admin.ClusterDAO
+	client      client.K8sClient
+	l           *zap.Logger
+}
+
+func NewAppService(appDao uesr.AppDAO, instanceDao uesr.InstanceDAO, projectDao uesr.ProjectDAO, clusterDao admin.ClusterDAO, client client.K8sClient, l *zap.Logger) AppService {
+	return &appService{
+		appDao:      appDao,
+		instanceDao: instanceDao,
+		projectDao:  projectDao,
+		clusterDao:  clusterDao,
+		client:      client,
+		l:           l,
+	}
+}
+
+// GetClusterNamespacesUnique 获取所有集群去重后的命名空间列表
+func (a *appService) GetClusterNamespacesUnique(ctx context.Context) ([]apiresponse.SelectOption, error) {
+	clusters, err := a.clusterDao.ListAllClusters(ctx)
+	if err != nil {
+		a.l.Error("获取集群列表失败", zap.Error(err))
+		return nil, err
+	}
+
+	namespaceSet := make(map[string]struct{})
+	var mu sync.Mutex
+	g, gCtx := errgroup.WithContext(ctx)
+	g.SetLimit(10) // 限制并发数为 10
+
+	for _, cluster := range clusters {
+		cluster := cluster // 避免闭包变量捕获问题
+		g.Go(func() error {
+			kubeClient, err := pkg.GetKubeClient(cluster.ID, a.client, a.l)
+			if err != nil {
+				// 单个集群不可用时跳过，不影响其他集群
+				a.l.Warn("获取 Kubernetes 客户端失败，跳过该集群", zap.String("clusterName", cluster.Name), zap.Error(err))
+				return nil
+			}
+
+			namespaces, err := kubeClient.CoreV1().Namespaces().List(gCtx, metav1.ListOptions{})
+			if err != nil {
+				a.l.Warn("获取命名空间列表失败，跳过该集群", zap.String("clusterName", cluster.Name), zap.Error(err))
+				return nil
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, ns := range namespaces.Items {
+				namespaceSet[ns.Name] = struct{}{}
+			}
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(namespaceSet))
+	for name := range namespaceSet {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	options := make([]apiresponse.SelectOption, 0, len(names))
+	for _, name := range names {
+		options = append(options, apiresponse.SelectOption{Label: name, Value: name})
+	}
+
+	return options, nil
+}
+
+// GetAppList 获取应用列表
+func (a *appService) GetAppList(ctx context.Context) ([]*model.K8sApp, error) {
+	apps, err := a.appDao.GetAllApps(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	projectNames := make(map[int]string)
+	for _, app := range apps {
+		if app.K8sProjectID == 0 {
+			continue
+		}
+
+		if _, ok := projectNames[app.K8sProjectID]; !ok {
+			project, err := a.projectDao.GetProjectByID(ctx, app.K8sProjectID)
+			if err != nil {
+				a.l.Warn("获取应用所属项目失败", zap.Int("appID", app.ID), zap.Error(err))
+				projectNames[app.K8sProjectID] = ""
+				continue
+			}
+			projectNames[app.K8sProjectID] = project.Name
+		}
+
+		app.K8sProjectName = projectNames[app.K8sProjectID]
+	}
+
+	return apps, nil
+}
+
+// GetAppListForSelect 获取用于选择的应用列表
+func (a *appService) GetAppListForSelect(ctx context.Context) ([]apiresponse.SelectOptionInt, error) {
+	apps, err := a.appDao.GetAllApps(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	options := make([]apiresponse.SelectOptionInt, 0, len(apps))
+	for _, app := range apps {
+		options = append(options, apiresponse.SelectOptionInt{Label: app.Name, Value: app.ID})
+	}
+
+	return options, nil
+}
+
+// GetAppByID 获取单个应用，实例中包含集群内的就绪状态
+func (a *appService) GetAppByID(ctx context.Context, id int) (*model.K8sApp, error) {
+	app, err := a.appDao.GetAppByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	kubeClient, err := getKubeClientByClusterName(ctx, app.Cluster, a.clusterDao, a.client, a.l)
+	if err != nil {
+		// 集群不可用时仍返回数据库中的配置
+		a.l.Warn("获取应用所在集群客户端失败", zap.Int("appID", id), zap.Error(err))
+		return app, nil
+	}
+
+	for i := range app.K8sInstances {
+		fillInstanceStatus(ctx, kubeClient, app, &app.K8sInstances[i])
+	}
+
+	return app, nil
+}
+
+// CreateApp 创建应用，并在集群中渲染 Service 与实例的 Deployment
+func (a *appService) CreateApp(ctx context.Context, app *model.K8sApp) error {
+	if app.Namespace == "" {
+		return errors.New("应用必须指定命名空间")
+	}
+
+	// 先完成渲染，配置错误时不落库
+	service, err := pkg.BuildServiceFromApp(app)
+	if err != nil {
+		return fmt.Errorf("渲染 Service 失败: %w", err)
+	}
+
+	instances := app.K8sInstances
+	deployments := make([]*appsv1.Deployment, 0, len(instances))
+	for i := range instances {
+		deployment, err := pkg.BuildDeploymentFromInstance(app, &instances[i])
+		if err != nil {
+			return fmt.Errorf("渲染 Deployment 失败: %w", err)
+		}
+		deployments = append(deployments, deployment)
+	}
+
+	kubeClient, err := getKubeClientByClusterName(ctx, app.Cluster, a.clusterDao, a.client, a.l)
+	if err != nil {
+		return err
+	}
+
+	if err := pkg.EnsureNamespace(ctx, kubeClient, app.Namespace); err != nil {
+		a.l.Error("确保命名空间存在失败", zap.String("namespace", app.Namespace), zap.Error(err))
+		return err
+	}
+
+	for i := range instances {
+		instances[i].Cluster = app.Cluster
+		instances[i].UserID = app.UserID
+	}
+
+	// 下发到集群成功后才提交，失败时回滚，重试时按创建或更新处理已下发的资源
+	if err := a.appDao.CreateApp(ctx, app, func() error {
+		return applyAppResources(ctx, kubeClient, app, service, deployments, a.l)
+	}); err != nil {
+		return err
+	}
+
+	a.l.Info("创建应用成功", zap.String("appName", app.Name), zap.Int("instanceCount", len(instances)))
+	return nil
+}
+
+// UpdateApp 更新应用，并重新渲染 Service 与所有实例的 Deployment
+func (a *appService) UpdateApp(ctx context.Context, app *model.K8sApp) error {
+	existing, err := a.appDao.GetAppByID(ctx, app.ID)
+	if err != nil {
+		return err
+	}
+
+	// 名称、集群和命名空间决定了集群内资源的位置，修改会导致旧资源无人管理
+	if existing.Name != app.Name || existing.Cluster != app.Cluster || existing.Namespace != app.Namespace {
+		return errors.New("应用的名称、集群和命名空间不允许修改")
+	}
+
+	service, err := pkg.BuildServiceFromApp(app)
+	if err != nil {
+		return fmt.Errorf("渲染 Service 失败: %w", err)
+	}
+
+	deployments := make([]*appsv1.Deployment, 0, len(existing.K8sInstances))
+	for i := range existing.K8sInstances {
+		deployment, err := pkg.BuildDeploymentFromInstance(app, &existing.K8sInstances[i])
+		if err != nil {
+			return fmt.Errorf("渲染 Deployment 失败: %w", err)
+		}
+		deployments = append(deployments, deployment)
+	}
+
+	kubeClient, err := getKubeClientByClusterName(ctx, app.Cluster, a.clusterDao, a.client, a.l)
+	if err != nil {
+		return err
+	}
+
+	return a.appDao.UpdateApp(ctx, app, func() error {
+		return applyAppResources(ctx, kubeClient, app, service, deployments, a.l)
+	})
+}
+
+// DeleteApp 删除应用，并清理集群中的 Service 与 Deployment
+func (a *appService) DeleteApp(ctx context.Context, id int) error {
+	app, err := a.appDao.GetAppByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	kubeClient, err := getKubeClientByClusterName(ctx, app.Cluster, a.clusterDao, a.client, a.l)
+	if err != nil {
+		return err
+	}
+
+	instanceIDs := make([]int, 0, len(app.K8sInstances))
+	for _, instance := range app.K8sInstances {
+		if err := deleteDeployment(ctx, kubeClient, app.Namespace, pkg.InstanceDeploymentName(app, &instance), a.l); err != nil {
+			return err
+		}
+		instanceIDs = append(instanceIDs, instance.ID)
+	}
+
+	if err := kubeClient.CoreV1().Services(app.Namespace).Delete(ctx, app.Name, metav1.DeleteOptions{}); err != nil && !k8sErr.IsNotFound(err) {
+		a.l.Error("删除 Service 失败", zap.String("serviceName", app.Name), zap.Error(err))
+		return err
+	}
+
+	if len(instanceIDs) > 0 {
+		if err := a.instanceDao.BatchDeleteInstances(ctx, instanceIDs); err != nil {
+			return err
+		}
+	}
+
+	return a.appDao.DeleteApp(ctx, id)
+}
+
+// GetPodListByApp 获取应用下所有实例的 Pod 列表
+func (a *appService) GetPodListByApp(ctx context.Context, id int) ([]*model.K8sPod, error) {
+	app, err := a.appDao.GetAppByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	kubeClient, err := getKubeClientByClusterName(ctx, app.Cluster, a.clusterDao, a.client, a.l)
+	if err != nil {
+		return nil, err
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{pkg.AppLabelKey: app.Name}).String()
+	pods, err := kubeClient.CoreV1().Pods(app.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		a.l.Error("获取应用 Pod 列表失败", zap.String("appName", app.Name), zap.Error(err))
+		return nil, err
+	}
+
+	return pkg.BuildK8sPods(pods), nil
+}
+
+// getKubeClientByClusterName 根据集群名称获取 Kubernetes 客户端
+func getKubeClientByClusterName(ctx context.Context, clusterName string, clusterDao admin.ClusterDAO, client client.K8sClient, l *zap.Logger) (*kubernetes.Clientset, error) {
+	cluster, err := clusterDao.GetClusterByName(ctx, clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("集群 %s 不存在: %w", clusterName, err)
+	}
+
+	return pkg.GetKubeClient(cluster.ID, client, l)
+}
+
+// applyAppResources 下发应用的 Service 与所有实例的 Deployment
+func applyAppResources(ctx context.Context, kubeClient *kubernetes.Clientset, app *model.K8sApp, service *corev1.Service, deployments []*appsv1.Deployment, l *zap.Logger) error {
+	if err := applyService(ctx, kubeClient, app.Namespace, app.Name, service, l); err != nil {
+		return err
+	}
+
+	for _, deployment := range deployments {
+		if err := applyDeployment(ctx, kubeClient, deployment, l); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyService 创建或更新 Service，渲染结果为空时删除已存在的 Service
+func applyService(ctx context.Context, kubeClient *kubernetes.Clientset, namespace, name string, service *corev1.Service, l *zap.Logger) error {
+	existing, err := kubeClient.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil && !k8sErr.IsNotFound(err) {
+		l.Error("获取 Service 失败", zap.String("serviceName", name), zap.Error(err))
+		return err
+	}
+	found := err == nil
+
+	if service == nil {
+		if found {
+			if err := kubeClient.CoreV1().Services(namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !k8sErr.IsNotFound(err) {
+				l.Error("删除 Service 失败", zap.String("serviceName", name), zap.Error(err))
+				return err
+			}
+		}
+		return nil
+	}
+
+	if !found {
+		if _, err := kubeClient.CoreV1().Services(namespace).Create(ctx, service, metav1.CreateOptions{}); err != nil {
+			l.Error("创建 Service 失败", zap.String("serviceName", name), zap.Error(err))
+			return err
+		}
+		return nil
+	}
+
+	// 保留 ClusterIP 等由集群分配的字段
+	existing.Labels = service.Labels
+	existing.Spec.Type = service.Spec.Type
+	existing.Spec.Selector = service.Spec.Selector
+	existing.Spec.Ports = service.Spec.Ports
+
+	if _, err := kubeClient.CoreV1().Services(namespace).Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		l.Error("更新 Service 失败", zap.String("serviceName", name), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// applyDeployment 创建或更新 Deployment
+func applyDeployment(ctx context.Context, kubeClient *kubernetes.Clientset, deployment *appsv1.Deployment, l *zap.Logger) error {
+	deployments := kubeClient.AppsV1().Deployments(deployment.Namespace)
+
+	existing, err := deployments.Get(ctx, deployment.Name, metav1.GetOptions{})
+	if err != nil {
+		if !k8sErr.IsNotFound(err) {
+			l.Error("获取 Deployment 失败", zap.String("deploymentName", deployment.Name), zap.Error(err))
+			return err
+		}
+
+		if _, err := deployments.Create(ctx, deployment, metav1.CreateOptions{}); err != nil {
+			l.Error("创建 Deployment 失败", zap.String("deploymentName", deployment.Name), zap.Error(err))
+			return err
+		}
+		return nil
+	}
+
+	// 保留重启注解，避免更新配置时触发额外的滚动重启
+	annotations := existing.Spec.Template.Annotations
+	existing.Labels = deployment.Labels
+	existing.Spec.Replicas = deployment.Spec.Replicas
+	existing.Spec.Template = deployment.Spec.Template
+	existing.Spec.Template.Annotations = annotations
+
+	if _, err := deployments.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		l.Error("更新 Deployment 失败", zap.String("deploymentName", deployment.Name), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// deleteDeployment 删除 Deployment，不存在时忽略
+func deleteDeployment(ctx context.Context, kubeClient *kubernetes.Clientset, namespace, name string, l *zap.Logger) error {
+	err := kubeClient.AppsV1().Deployments(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !k8sErr.IsNotFound(err) {
+		l.Error("删除 Deployment 失败", zap.String("deploymentName", name), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// fillInstanceStatus 填充实例的命名空间、应用名称和集群内的就绪状态
+func fillInstanceStatus(ctx context.Context, kubeClient *kubernetes.Clientset, app *model.K8sApp, instance *model.K8sInstance) {
+	instance.Namespace = app.Namespace
+	instance.K8sAppName = app.Name
+
+	deployment, err := kubeClient.AppsV1().Deployments(app.Namespace).Get(ctx, pkg.InstanceDeploymentName(app, instance), metav1.GetOptions{})
+	if err != nil {
+		if k8sErr.IsNotFound(err) {
+			instance.ReadyStatus = "NotFound"
+		} else {
+			instance.ReadyStatus = "Unknown"
+		}
+		return
+	}
+
+	instance.ReadyStatus = pkg.GetDeploymentReadyStatus(deployment)
+}
@@ -24,3 +24,252 @@ package uesr
  * THE SOFTWARE.
  *
  */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/k8s/client"
+	"github.com/GoSimplicity/AI-CloudOps/internal/k8s/dao/admin"
+	"github.com/GoSimplicity/AI-CloudOps/internal/k8s/dao/uesr"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	pkg "github.com/GoSimplicity/AI-CloudOps/pkg/utils/k8s"
+	"go.uber.org/zap"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8sErr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"time"
+)
+
+type CronjobService interface {
+	// GetCronjobList 获取定时任务列表
+	GetCronjobList(ctx context.Context) ([]*model.K8sCronjob, error)
+	// GetCronjobByID 获取单个定时任务
+	GetCronjobByID(ctx context.Context, id int) (*model.K8sCronjob, error)
+	// CreateCronjob 创建定时任务，并在集群中渲染 CronJob
+	CreateCronjob(ctx context.Context, cronjob *model.K8sCronjob) error
+	// UpdateCronjob 更新定时任务，并重新渲染 CronJob
+	UpdateCronjob(ctx context.Context, cronjob *model.K8sCronjob) error
+	// BatchDeleteCronjobs 批量删除定时任务及其 CronJob
+	BatchDeleteCronjobs(ctx context.Context, ids []int) error
+	// GetCronjobLastPod 获取定时任务最近一次调度产生的 Pod
+	GetCronjobLastPod(ctx context.Context, id int) (*model.K8sPod, error)
+}
+
+type cronjobService struct {
+	cronjobDao uesr.CronjobDAO
+	clusterDao admin.ClusterDAO
+	client     client.K8sClient
+	l          *zap.Logger
+}
+
+func NewCronjobService(cronjobDao uesr.CronjobDAO, clusterDao admin.ClusterDAO, client client.K8sClient, l *zap.Logger) CronjobService {
+	return &cronjobService{
+		cronjobDao: cronjobDao,
+		clusterDao: clusterDao,
+		client:     client,
+		l:          l,
+	}
+}
+
+// GetCronjobList 获取定时任务列表
+func (c *cronjobService) GetCronjobList(ctx context.Context) ([]*model.K8sCronjob, error) {
+	cronjobs, err := c.cronjobDao.GetAllCronjobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cronjob := range cronjobs {
+		c.fillLastSchedule(ctx, cronjob)
+	}
+
+	return cronjobs, nil
+}
+
+// GetCronjobByID 获取单个定时任务
+func (c *cronjobService) GetCronjobByID(ctx context.Context, id int) (*model.K8sCronjob, error) {
+	cronjob, err := c.cronjobDao.GetCronjobByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	c.fillLastSchedule(ctx, cronjob)
+
+	return cronjob, nil
+}
+
+// CreateCronjob 创建定时任务，并在集群中渲染 CronJob
+func (c *cronjobService) CreateCronjob(ctx context.Context, cronjob *model.K8sCronjob) error {
+	if cronjob.Namespace == "" || cronjob.Schedule == "" || cronjob.Image == "" {
+		return errors.New("定时任务必须指定命名空间、调度表达式和镜像")
+	}
+
+	kubeClient, err := getKubeClientByClusterName(ctx, cronjob.Cluster, c.clusterDao, c.client, c.l)
+	if err != nil {
+		return err
+	}
+
+	if err := pkg.EnsureNamespace(ctx, kubeClient, cronjob.Namespace); err != nil {
+		c.l.Error("确保命名空间存在失败", zap.String("namespace", cronjob.Namespace), zap.Error(err))
+		return err
+	}
+
+	// 下发到集群成功后才提交，失败时回滚
+	return c.cronjobDao.CreateCronjob(ctx, cronjob, func() error {
+		if _, err := kubeClient.BatchV1().CronJobs(cronjob.Namespace).Create(ctx, pkg.BuildCronJob(cronjob), metav1.CreateOptions{}); err != nil {
+			c.l.Error("创建 CronJob 失败", zap.String("cronjobName", cronjob.Name), zap.Error(err))
+			return err
+		}
+
+		return nil
+	})
+}
+
+// UpdateCronjob 更新定时任务，并重新渲染 CronJob
+func (c *cronjobService) UpdateCronjob(ctx context.Context, cronjob *model.K8sCronjob) error {
+	existing, err := c.cronjobDao.GetCronjobByID(ctx, cronjob.ID)
+	if err != nil {
+		return err
+	}
+
+	// 名称、集群和命名空间决定了集群内资源的位置，修改会导致旧资源无人管理
+	if existing.Name != cronjob.Name || existing.Cluster != cronjob.Cluster || existing.Namespace != cronjob.Namespace {
+		return errors.New("定时任务的名称、集群和命名空间不允许修改")
+	}
+
+	kubeClient, err := getKubeClientByClusterName(ctx, cronjob.Cluster, c.clusterDao, c.client, c.l)
+	if err != nil {
+		return err
+	}
+
+	// 下发到集群成功后才提交，失败时回滚
+	return c.cronjobDao.UpdateCronjob(ctx, cronjob, func() error {
+		return applyCronJob(ctx, kubeClient, pkg.BuildCronJob(cronjob), c.l)
+	})
+}
+
+// applyCronJob 创建或更新 CronJob，只覆盖平台管理的标签、调度表达式和任务模板
+func applyCronJob(ctx context.Context, kubeClient *kubernetes.Clientset, desired *batchv1.CronJob, l *zap.Logger) error {
+	cronJobs := kubeClient.BatchV1().CronJobs(desired.Namespace)
+
+	current, err := cronJobs.Get(ctx, desired.Name, metav1.GetOptions{})
+	if err != nil {
+		if !k8sErr.IsNotFound(err) {
+			l.Error("获取 CronJob 失败", zap.String("cronjobName", desired.Name), zap.Error(err))
+			return err
+		}
+
+		if _, err := cronJobs.Create(ctx, desired, metav1.CreateOptions{}); err != nil {
+			l.Error("创建 CronJob 失败", zap.String("cronjobName", desired.Name), zap.Error(err))
+			return err
+		}
+		return nil
+	}
+
+	current.Labels = desired.Labels
+	current.Spec.Schedule = desired.Spec.Schedule
+	current.Spec.JobTemplate = desired.Spec.JobTemplate
+
+	if _, err := cronJobs.Update(ctx, current, metav1.UpdateOptions{}); err != nil {
+		l.Error("更新 CronJob 失败", zap.String("cronjobName", desired.Name), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// BatchDeleteCronjobs 批量删除定时任务及其 CronJob
+func (c *cronjobService) BatchDeleteCronjobs(ctx context.Context, ids []int) error {
+	cronjobs, err := c.cronjobDao.GetCronjobsByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	// 删除 CronJob 时一并清理其创建的 Job 与 Pod
+	propagation := metav1.DeletePropagationBackground
+
+	for _, cronjob := range cronjobs {
+		kubeClient, err := getKubeClientByClusterName(ctx, cronjob.Cluster, c.clusterDao, c.client, c.l)
+		if err != nil {
+			return err
+		}
+
+		err = kubeClient.BatchV1().CronJobs(cronjob.Namespace).Delete(ctx, cronjob.Name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+		if err != nil && !k8sErr.IsNotFound(err) {
+			c.l.Error("删除 CronJob 失败", zap.String("cronjobName", cronjob.Name), zap.Error(err))
+			return err
+		}
+	}
+
+	return c.cronjobDao.BatchDeleteCronjobs(ctx, ids)
+}
+
+// GetCronjobLastPod 获取定时任务最近一次调度产生的 Pod
+func (c *cronjobService) GetCronjobLastPod(ctx context.Context, id int) (*model.K8sPod, error) {
+	cronjob, err := c.cronjobDao.GetCronjobByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	kubeClient, err := getKubeClientByClusterName(ctx, cronjob.Cluster, c.clusterDao, c.client, c.l)
+	if err != nil {
+		return nil, err
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{pkg.CronjobLabelKey: cronjob.Name}).String()
+	pods, err := kubeClient.CoreV1().Pods(cronjob.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		c.l.Error("获取定时任务 Pod 列表失败", zap.String("cronjobName", cronjob.Name), zap.Error(err))
+		return nil, err
+	}
+
+	if len(pods.Items) == 0 {
+		return nil, fmt.Errorf("定时任务 %s 尚未产生 Pod", cronjob.Name)
+	}
+
+	latest := pods.Items[0]
+	for _, pod := range pods.Items[1:] {
+		if pod.CreationTimestamp.After(latest.CreationTimestamp.Time) {
+			latest = pod
+		}
+	}
+
+	k8sPods := pkg.BuildK8sPods(&corev1.PodList{Items: []corev1.Pod{latest}})
+
+	return k8sPods[0], nil
+}
+
+// fillLastSchedule 填充定时任务最近一次的调度时间和 Pod 名称
+func (c *cronjobService) fillLastSchedule(ctx context.Context, cronjob *model.K8sCronjob) {
+	kubeClient, err := getKubeClientByClusterName(ctx, cronjob.Cluster, c.clusterDao, c.client, c.l)
+	if err != nil {
+		c.l.Warn("获取定时任务所在集群客户端失败", zap.String("cluster", cronjob.Cluster), zap.Error(err))
+		return
+	}
+
+	current, err := kubeClient.BatchV1().CronJobs(cronjob.Namespace).Get(ctx, cronjob.Name, metav1.GetOptions{})
+	if err != nil {
+		return
+	}
+
+	if current.Status.LastScheduleTime != nil {
+		cronjob.LastScheduleTime = current.Status.LastScheduleTime.Format(time.DateTime)
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{pkg.CronjobLabelKey: cronjob.Name}).String()
+	pods, err := kubeClient.CoreV1().Pods(cronjob.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil || len(pods.Items) == 0 {
+		return
+	}
+
+	latest := pods.Items[0]
+	for _, pod := range pods.Items[1:] {
+		if pod.CreationTimestamp.After(latest.CreationTimestamp.Time) {
+			latest = pod
+		}
+	}
+	cronjob.LastSchedulePodName = latest.Name
+}
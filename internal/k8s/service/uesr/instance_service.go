@@ -24,3 +24,276 @@ package uesr
  * THE SOFTWARE.
  *
  */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/k8s/client"
+	"github.com/GoSimplicity/AI-CloudOps/internal/k8s/dao/admin"
+	"github.com/GoSimplicity/AI-CloudOps/internal/k8s/dao/uesr"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	pkg "github.com/GoSimplicity/AI-CloudOps/pkg/utils/k8s"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sync"
+	"time"
+)
+
+type InstanceService interface {
+	// GetInstanceList 获取实例列表
+	GetInstanceList(ctx context.Context) ([]*model.K8sInstance, error)
+	// GetInstanceByID 获取单个实例
+	GetInstanceByID(ctx context.Context, id int) (*model.K8sInstance, error)
+	// GetInstancesByApp 获取应用下的实例列表
+	GetInstancesByApp(ctx context.Context, appID int) ([]*model.K8sInstance, error)
+	// CreateInstance 创建实例，并在集群中渲染 Deployment
+	CreateInstance(ctx context.Context, instance *model.K8sInstance) error
+	// UpdateInstance 更新实例，并重新渲染 Deployment
+	UpdateInstance(ctx context.Context, instance *model.K8sInstance) error
+	// BatchDeleteInstances 批量删除实例及其 Deployment
+	BatchDeleteInstances(ctx context.Context, ids []int) error
+	// BatchRestartInstances 批量重启实例对应的 Deployment
+	BatchRestartInstances(ctx context.Context, ids []int) error
+}
+
+type instanceService struct {
+	instanceDao uesr.InstanceDAO
+	appDao      uesr.AppDAO
+	clusterDao  admin.ClusterDAO
+	client      client.K8sClient
+	l           *zap.Logger
+}
+
+func NewInstanceService(instanceDao uesr.InstanceDAO, appDao uesr.AppDAO, clusterDao admin.ClusterDAO, client client.K8sClient, l *zap.Logger) InstanceService {
+	return &instanceService{
+		instanceDao: instanceDao,
+		appDao:      appDao,
+		clusterDao:  clusterDao,
+		client:      client,
+		l:           l,
+	}
+}
+
+// GetInstanceList 获取实例列表
+func (i *instanceService) GetInstanceList(ctx context.Context) ([]*model.K8sInstance, error) {
+	instances, err := i.instanceDao.GetAllInstances(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	i.fillInstances(ctx, instances)
+
+	return instances, nil
+}
+
+// GetInstanceByID 获取单个实例
+func (i *instanceService) GetInstanceByID(ctx context.Context, id int) (*model.K8sInstance, error) {
+	instance, err := i.instanceDao.GetInstanceByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	i.fillInstances(ctx, []*model.K8sInstance{instance})
+
+	return instance, nil
+}
+
+// GetInstancesByApp 获取应用下的实例列表
+func (i *instanceService) GetInstancesByApp(ctx context.Context, appID int) ([]*model.K8sInstance, error) {
+	instances, err := i.instanceDao.GetInstancesByAppID(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+
+	i.fillInstances(ctx, instances)
+
+	return instances, nil
+}
+
+// CreateInstance 创建实例，并在集群中渲染 Deployment
+func (i *instanceService) CreateInstance(ctx context.Context, instance *model.K8sInstance) error {
+	app, err := i.appDao.GetAppByID(ctx, instance.K8sAppID)
+	if err != nil {
+		return fmt.Errorf("实例所属应用不存在: %w", err)
+	}
+
+	// 实例始终部署在应用所在的集群
+	instance.Cluster = app.Cluster
+
+	deployment, err := pkg.BuildDeploymentFromInstance(app, instance)
+	if err != nil {
+		return fmt.Errorf("渲染 Deployment 失败: %w", err)
+	}
+
+	kubeClient, err := getKubeClientByClusterName(ctx, app.Cluster, i.clusterDao, i.client, i.l)
+	if err != nil {
+		return err
+	}
+
+	// 下发到集群成功后才提交，失败时回滚
+	return i.instanceDao.CreateInstance(ctx, instance, func() error {
+		return applyDeployment(ctx, kubeClient, deployment, i.l)
+	})
+}
+
+// UpdateInstance 更新实例，并重新渲染 Deployment
+func (i *instanceService) UpdateInstance(ctx context.Context, instance *model.K8sInstance) error {
+	existing, err := i.instanceDao.GetInstanceByID(ctx, instance.ID)
+	if err != nil {
+		return err
+	}
+
+	// 应用和实例名称决定集群中的 Deployment 名称，修改会导致旧资源无人管理
+	if existing.Name != instance.Name || existing.K8sAppID != instance.K8sAppID {
+		return errors.New("实例的名称和所属应用不允许修改")
+	}
+
+	app, err := i.appDao.GetAppByID(ctx, instance.K8sAppID)
+	if err != nil {
+		return fmt.Errorf("实例所属应用不存在: %w", err)
+	}
+
+	instance.Cluster = app.Cluster
+
+	deployment, err := pkg.BuildDeploymentFromInstance(app, instance)
+	if err != nil {
+		return fmt.Errorf("渲染 Deployment 失败: %w", err)
+	}
+
+	kubeClient, err := getKubeClientByClusterName(ctx, app.Cluster, i.clusterDao, i.client, i.l)
+	if err != nil {
+		return err
+	}
+
+	// 下发到集群成功后才提交，失败时回滚
+	return i.instanceDao.UpdateInstance(ctx, instance, func() error {
+		return applyDeployment(ctx, kubeClient, deployment, i.l)
+	})
+}
+
+// BatchDeleteInstances 批量删除实例及其 Deployment
+func (i *instanceService) BatchDeleteInstances(ctx context.Context, ids []int) error {
+	instances, err := i.instanceDao.GetInstancesByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	if err := i.forEachInstance(ctx, instances, func(kubeClient *kubernetes.Clientset, app *model.K8sApp, instance *model.K8sInstance) error {
+		return deleteDeployment(ctx, kubeClient, app.Namespace, pkg.InstanceDeploymentName(app, instance), i.l)
+	}); err != nil {
+		return err
+	}
+
+	return i.instanceDao.BatchDeleteInstances(ctx, ids)
+}
+
+// BatchRestartInstances 批量重启实例对应的 Deployment
+func (i *instanceService) BatchRestartInstances(ctx context.Context, ids []int) error {
+	instances, err := i.instanceDao.GetInstancesByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	return i.forEachInstance(ctx, instances, func(kubeClient *kubernetes.Clientset, app *model.K8sApp, instance *model.K8sInstance) error {
+		deployments := kubeClient.AppsV1().Deployments(app.Namespace)
+		name := pkg.InstanceDeploymentName(app, instance)
+
+		deployment, err := deployments.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			i.l.Error("获取 Deployment 失败", zap.String("deploymentName", name), zap.Error(err))
+			return err
+		}
+
+		if deployment.Spec.Template.Annotations == nil {
+			deployment.Spec.Template.Annotations = make(map[string]string)
+		}
+		deployment.Spec.Template.Annotations["kubectl.kubernetes.io/restartedAt"] = time.Now().Format(time.RFC3339)
+
+		if _, err := deployments.Update(ctx, deployment, metav1.UpdateOptions{}); err != nil {
+			i.l.Error("重启 Deployment 失败", zap.String("deploymentName", name), zap.Error(err))
+			return err
+		}
+
+		return nil
+	})
+}
+
+// forEachInstance 并发地对每个实例执行集群操作，汇总所有失败
+func (i *instanceService) forEachInstance(ctx context.Context, instances []*model.K8sInstance, action func(kubeClient *kubernetes.Clientset, app *model.K8sApp, instance *model.K8sInstance) error) error {
+	var wg sync.WaitGroup
+	errChan := make(chan error, len(instances))
+
+	for _, instance := range instances {
+		app, err := i.appDao.GetAppByID(ctx, instance.K8sAppID)
+		if err != nil {
+			return fmt.Errorf("实例 %s 所属应用不存在: %w", instance.Name, err)
+		}
+
+		kubeClient, err := getKubeClientByClusterName(ctx, app.Cluster, i.clusterDao, i.client, i.l)
+		if err != nil {
+			return err
+		}
+
+		wg.Add(1)
+		go func(instance *model.K8sInstance, app *model.K8sApp) {
+			defer wg.Done()
+			if err := action(kubeClient, app, instance); err != nil {
+				errChan <- fmt.Errorf("实例 %s 操作失败: %w", instance.Name, err)
+			}
+		}(instance, app)
+	}
+
+	wg.Wait()
+	close(errChan)
+
+	var errs []error
+	for err := range errChan {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// fillInstances 填充实例的前端字段，同一应用和集群只查询一次
+func (i *instanceService) fillInstances(ctx context.Context, instances []*model.K8sInstance) {
+	apps := make(map[int]*model.K8sApp)
+	clients := make(map[string]*kubernetes.Clientset)
+
+	for _, instance := range instances {
+		app, ok := apps[instance.K8sAppID]
+		if !ok {
+			var err error
+			app, err = i.appDao.GetAppByID(ctx, instance.K8sAppID)
+			if err != nil {
+				i.l.Warn("获取实例所属应用失败", zap.Int("instanceID", instance.ID), zap.Error(err))
+				app = nil
+			}
+			apps[instance.K8sAppID] = app
+		}
+
+		if app == nil {
+			continue
+		}
+
+		kubeClient, ok := clients[app.Cluster]
+		if !ok {
+			var err error
+			kubeClient, err = getKubeClientByClusterName(ctx, app.Cluster, i.clusterDao, i.client, i.l)
+			if err != nil {
+				i.l.Warn("获取实例所在集群客户端失败", zap.String("cluster", app.Cluster), zap.Error(err))
+				kubeClient = nil
+			}
+			clients[app.Cluster] = kubeClient
+		}
+
+		if kubeClient == nil {
+			instance.Namespace = app.Namespace
+			instance.K8sAppName = app.Name
+			continue
+		}
+
+		fillInstanceStatus(ctx, kubeClient, app, instance)
+	}
+}
@@ -24,3 +24,83 @@ package uesr
  * THE SOFTWARE.
  *
  */
+
+import (
+	"context"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/k8s/dao/uesr"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"github.com/GoSimplicity/AI-CloudOps/pkg/utils/apiresponse"
+	"go.uber.org/zap"
+)
+
+type ProjectService interface {
+	// GetProjectList 获取项目列表
+	GetProjectList(ctx context.Context) ([]*model.K8sProject, error)
+	// GetProjectListForSelect 获取用于选择的项目列表
+	GetProjectListForSelect(ctx context.Context) ([]apiresponse.SelectOptionInt, error)
+	// CreateProject 创建项目
+	CreateProject(ctx context.Context, project *model.K8sProject) error
+	// UpdateProject 更新项目
+	UpdateProject(ctx context.Context, project *model.K8sProject) error
+	// DeleteProject 删除项目
+	DeleteProject(ctx context.Context, id int) error
+}
+
+type projectService struct {
+	projectDao uesr.ProjectDAO
+	appDao     uesr.AppDAO
+	l          *zap.Logger
+}
+
+func NewProjectService(projectDao uesr.ProjectDAO, appDao uesr.AppDAO, l *zap.Logger) ProjectService {
+	return &projectService{
+		projectDao: projectDao,
+		appDao:     appDao,
+		l:          l,
+	}
+}
+
+// GetProjectList 获取项目列表
+func (p *projectService) GetProjectList(ctx context.Context) ([]*model.K8sProject, error) {
+	return p.projectDao.GetAllProjects(ctx)
+}
+
+// GetProjectListForSelect 获取用于选择的项目列表
+func (p *projectService) GetProjectListForSelect(ctx context.Context) ([]apiresponse.SelectOptionInt, error) {
+	projects, err := p.projectDao.GetAllProjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	options := make([]apiresponse.SelectOptionInt, 0, len(projects))
+	for _, project := range projects {
+		options = append(options, apiresponse.SelectOptionInt{Label: project.Name, Value: project.ID})
+	}
+
+	return options, nil
+}
+
+// CreateProject 创建项目
+func (p *projectService) CreateProject(ctx context.Context, project *model.K8sProject) error {
+	return p.projectDao.CreateProject(ctx, project)
+}
+
+// UpdateProject 更新项目
+func (p *projectService) UpdateProject(ctx context.Context, project *model.K8sProject) error {
+	return p.projectDao.UpdateProject(ctx, project)
+}
+
+// DeleteProject 删除项目，项目下仍有应用时拒绝删除
+func (p *projectService) DeleteProject(ctx context.Context, id int) error {
+	apps, err := p.appDao.GetAppsByProjectID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if len(apps) > 0 {
+		return fmt.Errorf("项目下仍有 %d 个应用，请先删除应用", len(apps))
+	}
+
+	return p.projectDao.DeleteProject(ctx, id)
+}
@@ -59,7 +59,10 @@ func (m *JWTMiddleware) CheckLogin() gin.HandlerFunc {
 			path == "/api/monitor/prometheus_configs/prometheus" ||
 			path == "/api/monitor/prometheus_configs/prometheus_alert" ||
 			path == "/api/monitor/prometheus_configs/prometheus_record" ||
-			path == "/api/monitor/prometheus_configs/alertManager" {
+			path == "/api/monitor/prometheus_configs/prometheus_files" ||
+			path == "/api/monitor/prometheus_configs/alertManager" ||
+			path == "/api/monitor/prometheus_configs/alertManager_files" ||
+			path == "/api/monitor/prometheus_configs/report" {
 			return
 		}
 
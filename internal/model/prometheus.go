@@ -27,26 +27,30 @@ package model
 
 import (
 	"github.com/prometheus/alertmanager/template"
+	"time"
 )
 
 // MonitorScrapePool 采集池的配置
 type MonitorScrapePool struct {
 	Model
-	Name                  string     `json:"name" binding:"required,min=1,max=50" gorm:"uniqueIndex:udx_name;size:100;comment:采集池名称，支持使用通配符*进行模糊搜索"` // 采集池名称，支持使用通配符*进行模糊搜索
-	PrometheusInstances   StringList `json:"prometheusInstances,omitempty" gorm:"type:text;comment:选择多个Prometheus实例"`                                // 选择多个Prometheus实例
-	AlertManagerInstances StringList `json:"alertManagerInstances,omitempty" gorm:"type:text;comment:选择多个AlertManager实例"`                            // 选择多个AlertManager实例
-	UserID                int        `json:"userId" gorm:"comment:创建该采集池的用户ID"`                                                                      // 创建该采集池的用户ID
-	ScrapeInterval        int        `json:"scrapeInterval,omitempty" gorm:"default:30;type:int;comment:采集间隔（秒）"`                                    // 采集间隔（秒）
-	ScrapeTimeout         int        `json:"scrapeTimeout,omitempty" gorm:"default:10;type:int;comment:采集超时时间（秒）"`                                   // 采集超时时间（秒）
-	ExternalLabels        StringList `json:"externalLabels,omitempty" gorm:"type:text;comment:remote_write时添加的标签组，格式为 key=v，例如 scrape_ip=1.1.1.1"`   // remote_write时添加的标签组，格式为 key=v，例如 scrape_ip=1.1.1.1
-	SupportAlert          int        `json:"supportAlert" gorm:"type:int;comment:是否支持告警：1支持，2不支持"`                                                   // 是否支持告警：1支持，2不支持
-	SupportRecord         int        `json:"supportRecord" gorm:"type:int;comment:是否支持预聚合：1支持，2不支持"`                                                 // 是否支持预聚合：1支持，2不支持
-	RemoteReadUrl         string     `json:"remoteReadUrl,omitempty" gorm:"size:255;comment:远程读取的地址"`                                                // 远程读取的地址
-	AlertManagerUrl       string     `json:"alertManagerUrl,omitempty" gorm:"size:255;comment:AlertManager的地址"`                                      // AlertManager的地址
-	RuleFilePath          string     `json:"ruleFilePath,omitempty" gorm:"size:255;comment:规则文件路径"`                                                  // 规则文件路径
-	RecordFilePath        string     `json:"recordFilePath,omitempty" gorm:"size:255;comment:记录文件路径"`                                                // 记录文件路径
-	RemoteWriteUrl        string     `json:"remoteWriteUrl,omitempty" gorm:"size:255;comment:远程写入的地址"`                                               // 远程写入的地址
-	RemoteTimeoutSeconds  int        `json:"remoteTimeoutSeconds,omitempty" gorm:"default:5;type:int;comment:远程写入的超时时间（秒）"`                          // 远程写入的超时时间（秒）
+	Name                  string                `json:"name" binding:"required,min=1,max=50" gorm:"uniqueIndex:udx_name;size:100;comment:采集池名称，支持使用通配符*进行模糊搜索"`     // 采集池名称，支持使用通配符*进行模糊搜索
+	PrometheusInstances   StringList            `json:"prometheusInstances,omitempty" gorm:"type:text;comment:选择多个Prometheus实例"`                                    // 选择多个Prometheus实例
+	AlertManagerInstances StringList            `json:"alertManagerInstances,omitempty" gorm:"type:text;comment:选择多个AlertManager实例"`                                // 选择多个AlertManager实例
+	UserID                int                   `json:"userId" gorm:"comment:创建该采集池的用户ID"`                                                                          // 创建该采集池的用户ID
+	ScrapeInterval        int                   `json:"scrapeInterval,omitempty" gorm:"default:30;type:int;comment:采集间隔（秒）"`                                        // 采集间隔（秒）
+	ScrapeTimeout         int                   `json:"scrapeTimeout,omitempty" gorm:"default:10;type:int;comment:采集超时时间（秒）"`                                       // 采集超时时间（秒）
+	ExternalLabels        StringList            `json:"externalLabels,omitempty" gorm:"type:text;comment:remote_write时添加的标签组，格式为 key=v，例如 scrape_ip=1.1.1.1"`       // remote_write时添加的标签组，格式为 key=v，例如 scrape_ip=1.1.1.1
+	SupportAlert          int                   `json:"supportAlert" gorm:"type:int;comment:是否支持告警：1支持，2不支持"`                                                       // 是否支持告警：1支持，2不支持
+	SupportRecord         int                   `json:"supportRecord" gorm:"type:int;comment:是否支持预聚合：1支持，2不支持"`                                                     // 是否支持预聚合：1支持，2不支持
+	RemoteReadUrl         string                `json:"remoteReadUrl,omitempty" gorm:"size:255;comment:远程读取的地址"`                                                    // 远程读取的地址
+	AlertManagerUrl       string                `json:"alertManagerUrl,omitempty" gorm:"size:255;comment:AlertManager的地址"`                                          // AlertManager的地址
+	RuleFilePath          string                `json:"ruleFilePath,omitempty" gorm:"size:255;comment:规则文件路径"`                                                      // 规则文件路径
+	RecordFilePath        string                `json:"recordFilePath,omitempty" gorm:"size:255;comment:记录文件路径"`                                                    // 记录文件路径
+	RemoteWriteUrl        string                `json:"remoteWriteUrl,omitempty" gorm:"size:255;comment:远程写入的地址"`                                                   // 远程写入的地址
+	RemoteTimeoutSeconds  int                   `json:"remoteTimeoutSeconds,omitempty" gorm:"default:5;type:int;comment:远程写入的超时时间（秒）"`                              // 远程写入的超时时间（秒）
+	RuleReplicas          int                   `json:"ruleReplicas,omitempty" gorm:"default:1;type:int;comment:每个规则组分配的Prometheus实例数，大于1时由多个实例同时评估"`               // 每个规则组分配的Prometheus实例数，大于1时由多个实例同时评估
+	RemoteWriteConfigs    []RemoteWriteEndpoint `json:"remoteWriteConfigs,omitempty" gorm:"type:text;serializer:json;comment:remote_write目标列表，设置后替代RemoteWriteUrl"` // remote_write目标列表，设置后替代RemoteWriteUrl
+	RemoteReadConfigs     []RemoteReadEndpoint  `json:"remoteReadConfigs,omitempty" gorm:"type:text;serializer:json;comment:remote_read目标列表，设置后替代RemoteReadUrl"`    // remote_read目标列表，设置后替代RemoteReadUrl
 
 	// 前端使用字段
 	ExternalLabelsFront string `json:"externalLabelsFront,omitempty" gorm:"-"` // 前端显示的ExternalLabels字符串
@@ -54,6 +58,46 @@ type MonitorScrapePool struct {
 	CreateUserName      string `json:"createUserName,omitempty" gorm:"-"`      // 创建者用户名，用于前端展示
 }
 
+// RemoteWriteEndpoint 采集池的一个 remote_write 目标
+type RemoteWriteEndpoint struct {
+	Name                          string                  `json:"name,omitempty"`                          // 名称，同一采集池内唯一
+	Url                           string                  `json:"url"`                                     // 写入地址
+	RemoteTimeoutSeconds          int                     `json:"remoteTimeoutSeconds,omitempty"`          // 请求超时时间（秒）
+	Headers                       map[string]string       `json:"headers,omitempty"`                       // 附加的请求头
+	BasicAuthUsername             string                  `json:"basicAuthUsername,omitempty"`             // Basic Auth用户名
+	BasicAuthPassword             string                  `json:"basicAuthPassword,omitempty"`             // Basic Auth密码
+	BearerToken                   string                  `json:"bearerToken,omitempty"`                   // 鉴权Token
+	WriteRelabelConfigsYamlString string                  `json:"writeRelabelConfigsYamlString,omitempty"` // write_relabel配置的YAML字符串
+	QueueConfig                   *RemoteWriteQueueConfig `json:"queueConfig,omitempty"`                   // 队列参数，未设置的字段使用 Prometheus 默认值
+	SendMetadata                  int                     `json:"sendMetadata,omitempty"`                  // 是否发送指标元数据：1发送（默认），2不发送
+	MetadataSendIntervalSeconds   int                     `json:"metadataSendIntervalSeconds,omitempty"`   // 发送指标元数据的间隔（秒）
+}
+
+// RemoteWriteQueueConfig remote_write 的队列参数，0表示使用 Prometheus 默认值
+type RemoteWriteQueueConfig struct {
+	Capacity                 int  `json:"capacity,omitempty"`                 // 每个分片缓存的样本数
+	MaxShards                int  `json:"maxShards,omitempty"`                // 最大分片数
+	MinShards                int  `json:"minShards,omitempty"`                // 最小分片数
+	MaxSamplesPerSend        int  `json:"maxSamplesPerSend,omitempty"`        // 每次发送的最大样本数
+	BatchSendDeadlineSeconds int  `json:"batchSendDeadlineSeconds,omitempty"` // 样本在缓存中等待发送的最长时间（秒）
+	MinBackoffMs             int  `json:"minBackoffMs,omitempty"`             // 重试的最小退避时间（毫秒）
+	MaxBackoffMs             int  `json:"maxBackoffMs,omitempty"`             // 重试的最大退避时间（毫秒）
+	RetryOnRateLimit         bool `json:"retryOnRateLimit,omitempty"`         // 收到429时是否重试
+}
+
+// RemoteReadEndpoint 采集池的一个 remote_read 目标
+type RemoteReadEndpoint struct {
+	Name                 string            `json:"name,omitempty"`                 // 名称，同一采集池内唯一
+	Url                  string            `json:"url"`                            // 读取地址
+	RemoteTimeoutSeconds int               `json:"remoteTimeoutSeconds,omitempty"` // 请求超时时间（秒）
+	Headers              map[string]string `json:"headers,omitempty"`              // 附加的请求头
+	BasicAuthUsername    string            `json:"basicAuthUsername,omitempty"`    // Basic Auth用户名
+	BasicAuthPassword    string            `json:"basicAuthPassword,omitempty"`    // Basic Auth密码
+	BearerToken          string            `json:"bearerToken,omitempty"`          // 鉴权Token
+	ReadRecent           bool              `json:"readRecent,omitempty"`           // 查询本地存储已覆盖的时间范围时是否也读取远程存储
+	RequiredMatchers     map[string]string `json:"requiredMatchers,omitempty"`     // 查询必须包含这些标签匹配条件才会读取远程存储
+}
+
 // MonitorAlertManagerPool AlertManager 实例池的配置
 type MonitorAlertManagerPool struct {
 	Model
@@ -66,6 +110,11 @@ type MonitorAlertManagerPool struct {
 	RepeatInterval        string     `json:"repeatInterval,omitempty" gorm:"size:50;comment:默认重复发送时间"`                                                          // 默认重复发送时间
 	GroupBy               StringList `json:"groupBy,omitempty" gorm:"type:text;comment:分组的标签"`                                                                  // 分组的标签
 	Receiver              string     `json:"receiver,omitempty" gorm:"size:100;comment:兜底接收者"`                                                                  // 兜底接收者
+	SmtpSmarthost         string     `json:"smtpSmarthost,omitempty" gorm:"size:255;comment:邮件通知使用的SMTP服务器地址，格式为 host:port"`                                    // 邮件通知使用的SMTP服务器地址，格式为 host:port
+	SmtpFrom              string     `json:"smtpFrom,omitempty" gorm:"size:255;comment:邮件通知的发件人地址"`                                                             // 邮件通知的发件人地址
+	SmtpAuthUsername      string     `json:"smtpAuthUsername,omitempty" gorm:"size:255;comment:SMTP认证用户名"`                                                      // SMTP认证用户名
+	SmtpAuthPassword      string     `json:"smtpAuthPassword,omitempty" gorm:"type:text;comment:SMTP认证密码"`                                                      // SMTP认证密码
+	SmtpRequireTLS        int        `json:"smtpRequireTls" gorm:"type:int;comment:SMTP是否要求STARTTLS：1要求，2不要求"`                                                  // SMTP是否要求STARTTLS：1要求，2不要求
 
 	// 前端使用字段
 	GroupByFront   string `json:"groupByFront,omitempty" gorm:"-"`   // 前端显示的GroupBy字符串
@@ -82,7 +131,7 @@ type MonitorAlertEvent struct {
 	RuleID        int        `json:"ruleId" gorm:"comment:关联的告警规则ID"`                                                        // 关联的告警规则ID
 	SendGroupID   int        `json:"sendGroupId" gorm:"comment:关联的发送组ID"`                                                    // 关联的发送组ID
 	EventTimes    int        `json:"eventTimes" gorm:"comment:触发次数"`                                                         // 触发次数
-	SilenceID     string     `json:"silenceId,omitempty" gorm:"size:100;comment:AlertManager返回的静默ID"`                        // AlertManager返回的静默ID
+	SilenceID     string     `json:"silenceId,omitempty" gorm:"size:100;comment:关联的静默记录ID"`                                  // 关联的静默记录ID，即 MonitorSilence 的ID
 	RenLingUserID int        `json:"renLingUserId" gorm:"comment:认领告警的用户ID"`                                                 // 认领告警的用户ID
 	Labels        StringList `json:"labels,omitempty" gorm:"type:text;comment:标签组，格式为 key=v"`                                // 标签组，格式为 key=v
 
@@ -101,18 +150,23 @@ type MonitorAlertEvent struct {
 // MonitorAlertRule 告警规则的配置
 type MonitorAlertRule struct {
 	Model
-	Name        string     `json:"name" binding:"required,min=1,max=50" gorm:"uniqueIndex:udx_name;size:100;comment:告警规则名称，支持通配符*进行模糊搜索"` // 告警规则名称，支持通配符*进行模糊搜索
-	UserID      int        `json:"userId" gorm:"comment:创建该告警规则的用户ID"`                                                                    // 创建该告警规则的用户ID
-	PoolID      int        `json:"poolId" gorm:"comment:关联的Prometheus实例池ID"`                                                              // 关联的Prometheus实例池ID
-	SendGroupID int        `json:"sendGroupId" gorm:"comment:关联的发送组ID"`                                                                   // 关联的发送组ID
-	TreeNodeID  int        `json:"treeNodeId" gorm:"comment:绑定的树节点ID"`                                                                    // 绑定的树节点ID
-	Enable      int        `json:"enable" gorm:"type:int;comment:是否启用告警规则：1启用，2禁用"`                                                       // 是否启用告警规则：1启用，2禁用
-	Expr        string     `json:"expr" gorm:"type:text;comment:告警规则表达式"`                                                                 // 告警规则表达式
-	Severity    string     `json:"severity,omitempty" gorm:"size:50;comment:告警级别，如critical、warning"`                                      // 告警级别，如critical、warning
-	GrafanaLink string     `json:"grafanaLink,omitempty" gorm:"type:text;comment:Grafana大盘链接"`                                            // Grafana大盘链接
-	ForTime     string     `json:"forTime,omitempty" gorm:"size:50;comment:持续时间，达到此时间才触发告警"`                                              // 持续时间，达到此时间才触发告警
-	Labels      StringList `json:"labels,omitempty" gorm:"type:text;comment:标签组，格式为 key=v"`                                               // 标签组，格式为 key=v
-	Annotations StringList `json:"annotations,omitempty" gorm:"type:text;comment:注解，格式为 key=v"`                                           // 注解，格式为 key=v
+	Name            string            `json:"name" binding:"required,min=1,max=50" gorm:"uniqueIndex:udx_name;size:100;comment:告警规则名称，支持通配符*进行模糊搜索"` // 告警规则名称，支持通配符*进行模糊搜索
+	UserID          int               `json:"userId" gorm:"comment:创建该告警规则的用户ID"`                                                                    // 创建该告警规则的用户ID
+	PoolID          int               `json:"poolId" gorm:"comment:关联的Prometheus实例池ID"`                                                              // 关联的Prometheus实例池ID
+	SendGroupID     int               `json:"sendGroupId" gorm:"comment:关联的发送组ID"`                                                                   // 关联的发送组ID
+	TreeNodeID      int               `json:"treeNodeId" gorm:"comment:绑定的树节点ID"`                                                                    // 绑定的树节点ID
+	Enable          int               `json:"enable" gorm:"type:int;comment:是否启用告警规则：1启用，2禁用"`                                                       // 是否启用告警规则：1启用，2禁用
+	Expr            string            `json:"expr" gorm:"type:text;comment:告警规则表达式"`                                                                 // 告警规则表达式
+	Severity        string            `json:"severity,omitempty" gorm:"size:50;comment:告警级别，如critical、warning"`                                      // 告警级别，如critical、warning
+	GrafanaLink     string            `json:"grafanaLink,omitempty" gorm:"type:text;comment:Grafana大盘链接"`                                            // Grafana大盘链接
+	ForTime         string            `json:"forTime,omitempty" gorm:"size:50;comment:持续时间，达到此时间才触发告警"`                                              // 持续时间，达到此时间才触发告警
+	Labels          StringList        `json:"labels,omitempty" gorm:"type:text;comment:标签组，格式为 key=v"`                                               // 标签组，格式为 key=v
+	Annotations     StringList        `json:"annotations,omitempty" gorm:"type:text;comment:注解，格式为 key=v"`                                           // 注解，格式为 key=v
+	RequireTestPass int               `json:"requireTestPass" gorm:"type:int;default:2;comment:保存时是否要求单元测试通过：1要求，2不要求"`                              // 保存时是否要求单元测试通过：1要求，2不要求
+	RuleGroupID     int               `json:"ruleGroupId" gorm:"index;comment:所属的规则组ID，0表示单独成组"`                                                     // 所属的规则组ID，0表示单独成组
+	GroupOrder      int               `json:"groupOrder" gorm:"comment:在规则组内的评估顺序，越小越先评估"`                                                           // 在规则组内的评估顺序，越小越先评估
+	TemplateID      int               `json:"templateId" gorm:"index;comment:生成该规则的告警规则模板ID，0表示不是由模板生成"`                                             // 生成该规则的告警规则模板ID，0表示不是由模板生成
+	TemplateParams  map[string]string `json:"templateParams,omitempty" gorm:"type:text;serializer:json;comment:实例化模板时该节点覆盖的参数"`                      // 实例化模板时该节点覆盖的参数
 
 	// 前端使用字段
 	NodePath       string `json:"nodePath,omitempty" gorm:"-"`       // 节点路径，形式为 a.b.c.d
@@ -120,6 +174,7 @@ type MonitorAlertRule struct {
 	Key            string `json:"key" gorm:"-"`                      // 前端表格的Key
 	PoolName       string `json:"poolName,omitempty" gorm:"-"`       // 前端表格显示的Prometheus实例池名称
 	SendGroupName  string `json:"sendGroupName,omitempty" gorm:"-"`  // 前端表格显示的发送组名称
+	RuleGroupName  string `json:"ruleGroupName,omitempty" gorm:"-"`  // 前端表格显示的规则组名称
 	CreateUserName string `json:"createUserName,omitempty" gorm:"-"` // 前端表格显示的创建者用户名
 	LabelsFront    string `json:"labelsFront,omitempty" gorm:"-"`    // 前端显示的标签组字符串
 }
@@ -127,21 +182,30 @@ type MonitorAlertRule struct {
 // MonitorSendGroup 发送组的配置
 type MonitorSendGroup struct {
 	Model
-	Name                string     `json:"name" binding:"required,min=1,max=50" gorm:"uniqueIndex:udx_name;size:100;comment:发送组英文名称，供AlertManager配置文件使用，支持通配符*进行模糊搜索"` // 发送组英文名称，供AlertManager配置文件使用，支持通配符*进行模糊搜索
-	NameZh              string     `json:"nameZh" binding:"required,min=1,max=50" gorm:"uniqueIndex:udx_name;size:100;comment:发送组中文名称，供告警规则选择发送组时使用，支持通配符*进行模糊搜索"`     // 发送组中文名称，供告警规则选择发送组时使用，支持通配符*进行模糊搜索
-	Enable              int        `json:"enable" gorm:"type:int;comment:是否启用发送组：1启用，2禁用"`                                                                             // 是否启用发送组：1启用，2禁用
-	UserID              int        `json:"userId" gorm:"comment:创建该发送组的用户ID"`                                                                                          // 创建该发送组的用户ID
-	PoolID              int        `json:"poolId" gorm:"comment:关联的AlertManager实例ID"`                                                                                  // 关联的Prometheus实例池ID
-	OnDutyGroupID       int        `json:"onDutyGroupId" gorm:"comment:值班组ID"`                                                                                         // 值班组ID
-	StaticReceiveUsers  []*User    `json:"staticReceiveUsers" gorm:"many2many:static_receive_users;comment:静态配置的接收人列表，多对多关系"`                                          // 静态配置的接收人列表，多对多关系
-	FeiShuQunRobotToken string     `json:"feiShuQunRobotToken,omitempty" gorm:"size:255;comment:飞书机器人Token，对应IM群"`                                                     // 飞书机器人Token，对应IM群
-	RepeatInterval      string     `json:"repeatInterval,omitempty" gorm:"size:50;comment:默认重复发送时间"`                                                                   // 默认重复发送时间
-	SendResolved        int        `json:"sendResolved" gorm:"type:int;comment:是否发送恢复通知：1发送，2不发送"`                                                                     // 是否发送恢复通知：1发送，2不发送
-	NotifyMethods       StringList `json:"notifyMethods,omitempty" gorm:"type:text;comment:通知方法，如：email, im, phone, sms"`                                              // 通知方法，如：email, im, phone, sms
-	NeedUpgrade         int        `json:"needUpgrade" gorm:"type:int;comment:是否需要告警升级：1需要，2不需要"`                                                                      // 是否需要告警升级：1需要，2不需要
-	FirstUpgradeUsers   []*User    `json:"firstUpgradeUsers" gorm:"many2many:first_upgrade_users;comment:第一升级人列表，多对多关系"`                                               // 第一升级人列表，多对多关系
-	UpgradeMinutes      int        `json:"upgradeMinutes,omitempty" gorm:"type:int;comment:告警多久未恢复则升级（分钟）"`                                                            // 告警多久未恢复则升级（分钟）
-	SecondUpgradeUsers  []*User    `json:"secondUpgradeUsers" gorm:"many2many:second_upgrade_users;comment:第二升级人列表，多对多关系"`                                             // 第二升级人列表，多对多关系
+	Name                  string             `json:"name" binding:"required,min=1,max=50" gorm:"uniqueIndex:udx_name;size:100;comment:发送组英文名称，供AlertManager配置文件使用，支持通配符*进行模糊搜索"` // 发送组英文名称，供AlertManager配置文件使用，支持通配符*进行模糊搜索
+	NameZh                string             `json:"nameZh" binding:"required,min=1,max=50" gorm:"uniqueIndex:udx_name;size:100;comment:发送组中文名称，供告警规则选择发送组时使用，支持通配符*进行模糊搜索"`     // 发送组中文名称，供告警规则选择发送组时使用，支持通配符*进行模糊搜索
+	Enable                int                `json:"enable" gorm:"type:int;comment:是否启用发送组：1启用，2禁用"`                                                                             // 是否启用发送组：1启用，2禁用
+	UserID                int                `json:"userId" gorm:"comment:创建该发送组的用户ID"`                                                                                          // 创建该发送组的用户ID
+	PoolID                int                `json:"poolId" gorm:"comment:关联的AlertManager实例ID"`                                                                                  // 关联的Prometheus实例池ID
+	OnDutyGroupID         int                `json:"onDutyGroupId" gorm:"comment:值班组ID"`                                                                                         // 值班组ID
+	StaticReceiveUsers    []*User            `json:"staticReceiveUsers" gorm:"many2many:static_receive_users;comment:静态配置的接收人列表，多对多关系"`                                          // 静态配置的接收人列表，多对多关系
+	FeiShuQunRobotToken   string             `json:"feiShuQunRobotToken,omitempty" gorm:"size:255;comment:飞书机器人Token，对应IM群"`                                                     // 飞书机器人Token，对应IM群
+	RepeatInterval        string             `json:"repeatInterval,omitempty" gorm:"size:50;comment:默认重复发送时间"`                                                                   // 默认重复发送时间
+	SendResolved          int                `json:"sendResolved" gorm:"type:int;comment:是否发送恢复通知：1发送，2不发送"`                                                                     // 是否发送恢复通知：1发送，2不发送
+	NotifyMethods         StringList         `json:"notifyMethods,omitempty" gorm:"type:text;comment:通知方法，如：im, email, webhook"`                                                 // 通知方法，如：im, email, webhook
+	NeedUpgrade           int                `json:"needUpgrade" gorm:"type:int;comment:是否需要告警升级：1需要，2不需要"`                                                                      // 是否需要告警升级：1需要，2不需要
+	FirstUpgradeUsers     []*User            `json:"firstUpgradeUsers" gorm:"many2many:first_upgrade_users;comment:第一升级人列表，多对多关系"`                                               // 第一升级人列表，多对多关系
+	UpgradeMinutes        int                `json:"upgradeMinutes,omitempty" gorm:"type:int;comment:告警多久未恢复则升级（分钟）"`                                                            // 告警多久未恢复则升级（分钟）
+	SecondUpgradeUsers    []*User            `json:"secondUpgradeUsers" gorm:"many2many:second_upgrade_users;comment:第二升级人列表，多对多关系"`                                             // 第二升级人列表，多对多关系
+	MuteTimeIntervalIDs   []int              `json:"muteTimeIntervalIds,omitempty" gorm:"type:text;serializer:json;comment:静默时间段ID，处于任一时间段内时不发送通知"`                              // 静默时间段ID，处于任一时间段内时不发送通知
+	ActiveTimeIntervalIDs []int              `json:"activeTimeIntervalIds,omitempty" gorm:"type:text;serializer:json;comment:生效时间段ID，设置后只在这些时间段内发送通知"`                           // 生效时间段ID，设置后只在这些时间段内发送通知
+	EmailTo               []string           `json:"emailTo,omitempty" gorm:"type:text;serializer:json;comment:邮件通知的收件人地址"`                                                      // 邮件通知的收件人地址
+	Webhooks              []SendGroupWebhook `json:"webhooks,omitempty" gorm:"type:text;serializer:json;comment:额外推送的第三方Webhook"`                                                // 额外推送的第三方Webhook
+	GroupBy               []string           `json:"groupBy,omitempty" gorm:"type:text;serializer:json;comment:分组的标签，为空时使用AlertManager实例的默认值"`                                   // 分组的标签，为空时使用AlertManager实例的默认值
+	GroupWait             string             `json:"groupWait,omitempty" gorm:"size:50;comment:分组第一次等待时间，为空时使用AlertManager实例的默认值"`                                               // 分组第一次等待时间，为空时使用AlertManager实例的默认值
+	GroupInterval         string             `json:"groupInterval,omitempty" gorm:"size:50;comment:分组等待间隔，为空时使用AlertManager实例的默认值"`                                              // 分组等待间隔，为空时使用AlertManager实例的默认值
+	Matchers              []string           `json:"matchers,omitempty" gorm:"type:text;serializer:json;comment:额外的标签匹配条件，支持正则和否定匹配"`                                            // 额外的标签匹配条件，如 severity=~"critical|warning"、env!="test"
+	Routes                []SendGroupRoute   `json:"routes,omitempty" gorm:"type:text;serializer:json;comment:子路由"`                                                              // 子路由，按顺序匹配
 
 	// 前端使用字段
 	TreeNodeIDs     []int    `json:"treeNodeIds,omitempty" gorm:"-"`     // 节点ID的整数数组
@@ -152,6 +216,145 @@ type MonitorSendGroup struct {
 	CreateUserName  string   `json:"createUserName,omitempty" gorm:"-"`  // 前端表格显示的创建者用户名
 }
 
+// 发送组的通知方式，im 由平台 Webhook 推送到飞书等 IM，email 和 webhook 由 AlertManager 直接发送
+const (
+	NotifyMethodIM      = "im"
+	NotifyMethodEmail   = "email"
+	NotifyMethodWebhook = "webhook"
+)
+
+// SendGroupWebhook 发送组额外推送的第三方 Webhook
+type SendGroupWebhook struct {
+	Url                   string `json:"url"`                             // Webhook地址
+	MaxAlerts             uint64 `json:"maxAlerts,omitempty"`             // 单次推送的最大告警数，0表示不限制
+	BasicAuthUsername     string `json:"basicAuthUsername,omitempty"`     // Basic Auth用户名
+	BasicAuthPassword     string `json:"basicAuthPassword,omitempty"`     // Basic Auth密码
+	BearerToken           string `json:"bearerToken,omitempty"`           // 鉴权Token
+	TlsCaContent          string `json:"tlsCaContent,omitempty"`          // TLS CA证书内容
+	TlsServerName         string `json:"tlsServerName,omitempty"`         // TLS服务器名称
+	TlsInsecureSkipVerify int    `json:"tlsInsecureSkipVerify,omitempty"` // 是否跳过TLS证书校验：1跳过，2不跳过
+}
+
+// SendGroupRoute 发送组路由下的子路由，未设置的分组和时间设置继承父路由
+type SendGroupRoute struct {
+	Matchers            []string         `json:"matchers"`                      // 标签匹配条件，至少一个
+	ReceiverSendGroupID int              `json:"receiverSendGroupId,omitempty"` // 接收告警的发送组ID，必须属于同一个AlertManager实例，0表示使用父路由的接收者
+	GroupBy             []string         `json:"groupBy,omitempty"`             // 分组的标签
+	GroupWait           string           `json:"groupWait,omitempty"`           // 分组第一次等待时间
+	GroupInterval       string           `json:"groupInterval,omitempty"`       // 分组等待间隔
+	RepeatInterval      string           `json:"repeatInterval,omitempty"`      // 重复发送时间
+	Continue            bool             `json:"continue,omitempty"`            // 匹配后是否继续匹配后面的子路由
+	Routes              []SendGroupRoute `json:"routes,omitempty"`              // 嵌套的子路由
+}
+
+// MonitorInhibitRule AlertManager 抑制规则，源告警触发时抑制匹配目标条件且相等标签一致的告警
+type MonitorInhibitRule struct {
+	Model
+	Name           string   `json:"name" binding:"required,min=1,max=50" gorm:"uniqueIndex:udx_name;size:100;comment:抑制规则名称，支持通配符*进行模糊搜索"` // 抑制规则名称，支持通配符*进行模糊搜索
+	UserID         int      `json:"userId" gorm:"comment:创建该抑制规则的用户ID"`                                                                    // 创建该抑制规则的用户ID
+	PoolID         int      `json:"poolId" binding:"required" gorm:"index;comment:关联的AlertManager实例ID"`                                    // 关联的AlertManager实例ID
+	Enable         int      `json:"enable" gorm:"type:int;comment:是否启用抑制规则：1启用，2禁用"`                                                       // 是否启用抑制规则：1启用，2禁用
+	SourceMatchers []string `json:"sourceMatchers" binding:"required,min=1" gorm:"type:text;serializer:json;comment:源告警匹配条件"`              // 源告警匹配条件，如 alertname="HostDown"
+	TargetMatchers []string `json:"targetMatchers" binding:"required,min=1" gorm:"type:text;serializer:json;comment:被抑制的目标告警匹配条件"`         // 被抑制的目标告警匹配条件
+	EqualLabels    []string `json:"equalLabels,omitempty" gorm:"type:text;serializer:json;comment:源告警和目标告警必须相等的标签"`                        // 源告警和目标告警必须相等的标签
+	Description    string   `json:"description,omitempty" gorm:"type:text;comment:抑制规则描述"`                                                 // 抑制规则描述
+
+	// 前端使用字段
+	Key            string `json:"key" gorm:"-"`                      // 前端表格使用的Key
+	PoolName       string `json:"poolName,omitempty" gorm:"-"`       // 前端表格显示的AlertManager实例名称
+	CreateUserName string `json:"createUserName,omitempty" gorm:"-"` // 前端表格显示的创建者用户名
+}
+
+// MonitorTimeInterval AlertManager 时间段，发送组可以将其作为静默时间段或生效时间段
+type MonitorTimeInterval struct {
+	Model
+	Name          string             `json:"name" binding:"required,min=1,max=50" gorm:"uniqueIndex:udx_name;size:100;comment:时间段名称，供AlertManager配置文件使用"` // 时间段名称，供AlertManager配置文件使用
+	UserID        int                `json:"userId" gorm:"comment:创建该时间段的用户ID"`                                                                           // 创建该时间段的用户ID
+	PoolID        int                `json:"poolId" binding:"required" gorm:"index;comment:关联的AlertManager实例ID"`                                          // 关联的AlertManager实例ID
+	TimeIntervals []TimeIntervalSpec `json:"timeIntervals" binding:"required,min=1" gorm:"type:text;serializer:json;comment:时间段条件，满足任意一组即处于该时间段"`         // 时间段条件，满足任意一组即处于该时间段
+	Description   string             `json:"description,omitempty" gorm:"type:text;comment:时间段描述"`                                                        // 时间段描述
+
+	// 前端使用字段
+	Key            string `json:"key" gorm:"-"`                      // 前端表格使用的Key
+	PoolName       string `json:"poolName,omitempty" gorm:"-"`       // 前端表格显示的AlertManager实例名称
+	CreateUserName string `json:"createUserName,omitempty" gorm:"-"` // 前端表格显示的创建者用户名
+}
+
+// TimeIntervalSpec 一组时间段条件，各条件同时满足时处于该时间段，语法与 AlertManager 的 time_intervals 一致
+type TimeIntervalSpec struct {
+	Times       []TimeIntervalRange `json:"times,omitempty"`       // 一天中的时间范围
+	Weekdays    []string            `json:"weekdays,omitempty"`    // 星期，如 monday:friday、saturday
+	DaysOfMonth []string            `json:"daysOfMonth,omitempty"` // 每月的日期，如 1:5、-1 表示最后一天
+	Months      []string            `json:"months,omitempty"`      // 月份，如 january:march、12
+	Years       []string            `json:"years,omitempty"`       // 年份，如 2024:2025
+	Location    string              `json:"location,omitempty"`    // 时区，如 Asia/Shanghai，为空时使用 UTC
+}
+
+// TimeIntervalRange 一天中的时间范围，包含开始时间不包含结束时间
+type TimeIntervalRange struct {
+	StartTime string `json:"startTime"` // 开始时间，如 09:00
+	EndTime   string `json:"endTime"`   // 结束时间，如 18:00，24:00 表示一天结束
+}
+
+// 告警事件状态
+const (
+	AlertEventStatusFiring   = "firing" // 告警中
+	AlertEventStatusSilenced = "已屏蔽"    // 已屏蔽
+)
+
+// 静默状态，由开始和结束时间计算
+const (
+	SilenceStatePending = "pending" // 未开始
+	SilenceStateActive  = "active"  // 生效中
+	SilenceStateExpired = "expired" // 已过期
+)
+
+// MonitorSilence 通过 AlertManager v2 API 创建的静默，下发到所属 AlertManager 实例池的每个实例
+type MonitorSilence struct {
+	NoUniqueIndexModel
+	PoolID             int               `json:"poolId" gorm:"index;comment:关联的AlertManager实例ID"`                                    // 关联的AlertManager实例ID
+	EventID            int               `json:"eventId" gorm:"index;comment:创建静默的告警事件ID，0表示手动创建"`                                   // 创建静默的告警事件ID，0表示手动创建
+	Matchers           []string          `json:"matchers" gorm:"type:text;serializer:json;comment:标签匹配条件"`                           // 标签匹配条件，如 alertname="HostDown"、instance=~"10\..*"
+	StartsAt           time.Time         `json:"startsAt" gorm:"comment:开始时间"`                                                       // 开始时间
+	EndsAt             time.Time         `json:"endsAt" gorm:"comment:结束时间，提前结束时更新为结束的时间"`                                           // 结束时间，提前结束时更新为结束的时间
+	Comment            string            `json:"comment" gorm:"type:text;comment:静默说明"`                                              // 静默说明
+	UserID             int               `json:"userId" gorm:"comment:创建静默的用户ID"`                                                    // 创建静默的用户ID
+	CreatedBy          string            `json:"createdBy" gorm:"size:100;comment:提交给AlertManager的创建者"`                              // 提交给AlertManager的创建者
+	InstanceSilenceIDs map[string]string `json:"instanceSilenceIds" gorm:"type:text;serializer:json;comment:各AlertManager实例返回的静默ID"` // 各AlertManager实例返回的静默ID
+
+	// 前端使用字段
+	Key            string            `json:"key" gorm:"-"`                      // 前端表格使用的Key
+	State          string            `json:"state" gorm:"-"`                    // 静默状态：pending、active、expired
+	PoolName       string            `json:"poolName,omitempty" gorm:"-"`       // 前端表格显示的AlertManager实例名称
+	InstanceErrors map[string]string `json:"instanceErrors,omitempty" gorm:"-"` // 本次操作失败的实例及原因
+}
+
+// SilenceListReq 静默列表查询条件
+type SilenceListReq struct {
+	PoolID int    `form:"poolId"` // AlertManager实例ID，0表示全部
+	State  string `form:"state"`  // 静默状态，为空表示全部
+}
+
+// SilenceCreateReq 创建静默，从告警事件创建且未指定匹配条件时使用事件的标签
+type SilenceCreateReq struct {
+	PoolID   int      `json:"poolId"`                      // AlertManager实例ID，从告警事件创建时使用事件发送组所在的实例
+	EventID  int      `json:"eventId"`                     // 告警事件ID
+	UseName  bool     `json:"useName"`                     // 从告警事件创建时是否只按告警名称静默
+	Matchers []string `json:"matchers"`                    // 标签匹配条件，支持 =、!=、=~、!~
+	StartsAt int64    `json:"startsAt"`                    // 开始时间戳（秒），0表示立即开始
+	Duration string   `json:"duration" binding:"required"` // 静默时长，如 2h、1d
+	Comment  string   `json:"comment" binding:"required"`  // 静默说明
+}
+
+// SilenceUpdateReq 修改静默，只能修改未过期的静默
+type SilenceUpdateReq struct {
+	ID       int      `json:"id" binding:"required"`
+	Matchers []string `json:"matchers" binding:"required,min=1"` // 标签匹配条件
+	StartsAt int64    `json:"startsAt"`                          // 开始时间戳（秒），0表示保持原开始时间
+	Duration string   `json:"duration" binding:"required"`       // 从开始时间起的静默时长
+	Comment  string   `json:"comment" binding:"required"`        // 静默说明
+}
+
 // MonitorOnDutyChange 值班换班记录
 type MonitorOnDutyChange struct {
 	Model
@@ -202,17 +405,21 @@ type MonitorOnDutyHistory struct {
 // MonitorRecordRule 记录规则的配置
 type MonitorRecordRule struct {
 	Model
-	Name       string `json:"name" binding:"required,min=1,max=50" gorm:"uniqueIndex:udx_name;size:100;comment:记录规则名称，支持使用通配符*进行模糊搜索"` // 记录规则名称，支持使用通配符*进行模糊搜索
-	RecordName string `json:"recordName" binding:"required,min=1,max=500" gorm:"uniqueIndex;size:500;comment:记录名称，支持使用通配符*进行模糊搜索"`     // 记录名称，支持使用通配符*进行模糊搜索
-	UserID     int    `json:"userId" gorm:"comment:创建该记录规则的用户ID"`                                                                      // 创建该记录规则的用户ID
-	PoolID     int    `json:"poolId" gorm:"comment:关联的Prometheus实例池ID"`                                                                // 关联的Prometheus实例池ID
-	TreeNodeID int    `json:"treeNodeId" gorm:"comment:绑定的树节点ID"`                                                                      // 绑定的树节点ID
-	Enable     int    `json:"enable" gorm:"type:int;comment:是否启用记录规则：1启用，2禁用"`                                                         // 是否启用记录规则：1启用，2禁用
-	ForTime    string `json:"forTime,omitempty" gorm:"size:50;comment:持续时间，达到此时间才触发记录规则"`                                              // 持续时间，达到此时间才触发记录规则
-	Expr       string `json:"expr" gorm:"type:text;comment:记录规则表达式"`                                                                   // 记录规则表达式
+	Name            string `json:"name" binding:"required,min=1,max=50" gorm:"uniqueIndex:udx_name;size:100;comment:记录规则名称，支持使用通配符*进行模糊搜索"` // 记录规则名称，支持使用通配符*进行模糊搜索
+	RecordName      string `json:"recordName" binding:"required,min=1,max=500" gorm:"uniqueIndex;size:500;comment:记录名称，支持使用通配符*进行模糊搜索"`     // 记录名称，支持使用通配符*进行模糊搜索
+	UserID          int    `json:"userId" gorm:"comment:创建该记录规则的用户ID"`                                                                      // 创建该记录规则的用户ID
+	PoolID          int    `json:"poolId" gorm:"comment:关联的Prometheus实例池ID"`                                                                // 关联的Prometheus实例池ID
+	TreeNodeID      int    `json:"treeNodeId" gorm:"comment:绑定的树节点ID"`                                                                      // 绑定的树节点ID
+	Enable          int    `json:"enable" gorm:"type:int;comment:是否启用记录规则：1启用，2禁用"`                                                         // 是否启用记录规则：1启用，2禁用
+	ForTime         string `json:"forTime,omitempty" gorm:"size:50;comment:持续时间，达到此时间才触发记录规则"`                                              // 持续时间，达到此时间才触发记录规则
+	Expr            string `json:"expr" gorm:"type:text;comment:记录规则表达式"`                                                                   // 记录规则表达式
+	RequireTestPass int    `json:"requireTestPass" gorm:"type:int;default:2;comment:保存时是否要求单元测试通过：1要求，2不要求"`                                // 保存时是否要求单元测试通过：1要求，2不要求
+	RuleGroupID     int    `json:"ruleGroupId" gorm:"index;comment:所属的规则组ID，0表示单独成组"`                                                       // 所属的规则组ID，0表示单独成组
+	GroupOrder      int    `json:"groupOrder" gorm:"comment:在规则组内的评估顺序，越小越先评估"`                                                             // 在规则组内的评估顺序，越小越先评估
 
 	// 前端使用字段
 	NodePath         string            `json:"nodePath,omitempty" gorm:"-"`         // 节点路径，形式为 a.b.c.d
+	RuleGroupName    string            `json:"ruleGroupName,omitempty" gorm:"-"`    // 前端表格显示的规则组名称
 	TreeNodeIDs      []int             `json:"treeNodeIds,omitempty" gorm:"-"`      // 节点ID整数数组
 	Key              string            `json:"key" gorm:"-"`                        // 前端表格的Key
 	PoolName         string            `json:"poolName,omitempty" gorm:"-"`         // 前端表格显示的池名称
@@ -227,25 +434,53 @@ type MonitorRecordRule struct {
 // MonitorScrapeJob 监控采集任务的配置
 type MonitorScrapeJob struct {
 	Model
-	Name                     string     `json:"name" binding:"required,min=1,max=50" gorm:"uniqueIndex:udx_name;size:100;comment:采集任务名称，支持使用通配符*进行模糊搜索"` // 采集任务名称，支持使用通配符*进行模糊搜索
-	UserID                   int        `json:"userId" gorm:"comment:任务关联的用户ID"`                                                                         // 任务关联的用户ID
-	Enable                   int        `json:"enable" gorm:"type:int;comment:是否启用采集任务：1为启用，2为禁用"`                                                       // 是否启用采集任务：1为启用，2为禁用
-	ServiceDiscoveryType     string     `json:"serviceDiscoveryType,omitempty" gorm:"size:50;comment:服务发现类型，支持 k8s 或 http"`                              // 服务发现类型，支持 "k8s" 或 "http"
-	MetricsPath              string     `json:"metricsPath,omitempty" gorm:"size:255;comment:监控采集的路径"`                                                   // 监控采集的路径
-	Scheme                   string     `json:"scheme,omitempty" gorm:"size:10;comment:监控采集的协议方案（如 http 或 https）"`                                       // 监控采集的协议方案（如 http 或 https）
-	ScrapeInterval           int        `json:"scrapeInterval,omitempty" gorm:"default:30;type:int;comment:采集的时间间隔（秒）"`                                  // 采集的时间间隔（秒）
-	ScrapeTimeout            int        `json:"scrapeTimeout,omitempty" gorm:"default:10;type:int;comment:采集的超时时间（秒）"`                                   // 采集的超时时间（秒）
-	PoolID                   int        `json:"poolId" gorm:"comment:关联的采集池ID"`                                                                          // 关联的采集池ID
-	RelabelConfigsYamlString string     `json:"relabelConfigsYamlString,omitempty" gorm:"type:text;comment:relabel配置的YAML字符串"`                           // relabel配置的YAML字符串
-	RefreshInterval          int        `json:"refreshInterval,omitempty" gorm:"type:int;comment:刷新目标的时间间隔（针对服务树http类型，秒）"`                              // 刷新目标的时间间隔（针对服务树http类型，秒）
-	Port                     int        `json:"port,omitempty" gorm:"type:int;comment:端口号（针对服务树服务发现接口）"`                                                 // 端口号（针对服务树服务发现接口）
-	TreeNodeIDs              StringList `json:"treeNodeIds,omitempty" gorm:"type:text;comment:服务树接口绑定的树节点ID列表，用于获取IP列表"`                                 // 服务树接口绑定的树节点ID列表，用于获取IP列表
-	KubeConfigFilePath       string     `json:"kubeConfigFilePath,omitempty" gorm:"size:255;comment:连接apiServer的Kubernetes配置文件路径"`                       // 连接apiServer的Kubernetes配置文件路径
-	TlsCaFilePath            string     `json:"tlsCaFilePath,omitempty" gorm:"size:255;comment:TLS CA证书文件路径"`                                            // TLS CA证书文件路径
-	TlsCaContent             string     `json:"tlsCaContent,omitempty" gorm:"type:text;comment:TLS CA证书内容"`                                              // TLS CA证书内容
-	BearerToken              string     `json:"bearerToken,omitempty" gorm:"type:text;comment:鉴权Token内容"`                                                // 鉴权Token内容
-	BearerTokenFile          string     `json:"bearerTokenFile,omitempty" gorm:"size:255;comment:鉴权Token文件路径"`                                           // 鉴权Token文件路径
-	KubernetesSdRole         string     `json:"kubernetesSdRole,omitempty" gorm:"size:50;comment:Kubernetes服务发现角色"`                                      // Kubernetes服务发现角色
+	Name                           string              `json:"name" binding:"required,min=1,max=50" gorm:"uniqueIndex:udx_name;size:100;comment:采集任务名称，支持使用通配符*进行模糊搜索"`           // 采集任务名称，支持使用通配符*进行模糊搜索
+	UserID                         int                 `json:"userId" gorm:"comment:任务关联的用户ID"`                                                                                   // 任务关联的用户ID
+	Enable                         int                 `json:"enable" gorm:"type:int;comment:是否启用采集任务：1为启用，2为禁用"`                                                                 // 是否启用采集任务：1为启用，2为禁用
+	ServiceDiscoveryType           string              `json:"serviceDiscoveryType,omitempty" gorm:"size:50;comment:服务发现类型，支持 http、k8s、static、file_sd、dns、consul"`                // 服务发现类型，支持 http、k8s、static、file_sd、dns、consul
+	MetricsPath                    string              `json:"metricsPath,omitempty" gorm:"size:255;comment:监控采集的路径"`                                                             // 监控采集的路径
+	Scheme                         string              `json:"scheme,omitempty" gorm:"size:10;comment:监控采集的协议方案（如 http 或 https）"`                                                 // 监控采集的协议方案（如 http 或 https）
+	ScrapeInterval                 int                 `json:"scrapeInterval,omitempty" gorm:"default:30;type:int;comment:采集的时间间隔（秒）"`                                            // 采集的时间间隔（秒）
+	ScrapeTimeout                  int                 `json:"scrapeTimeout,omitempty" gorm:"default:10;type:int;comment:采集的超时时间（秒）"`                                             // 采集的超时时间（秒）
+	PoolID                         int                 `json:"poolId" gorm:"comment:关联的采集池ID"`                                                                                    // 关联的采集池ID
+	RelabelConfigsYamlString       string              `json:"relabelConfigsYamlString,omitempty" gorm:"type:text;comment:relabel配置的YAML字符串"`                                     // relabel配置的YAML字符串
+	MetricRelabelConfigsYamlString string              `json:"metricRelabelConfigsYamlString,omitempty" gorm:"type:text;comment:metric_relabel配置的YAML字符串，用于在写入前丢弃或改写样本"`          // metric_relabel配置的YAML字符串，用于在写入前丢弃或改写样本
+	RefreshInterval                int                 `json:"refreshInterval,omitempty" gorm:"type:int;comment:刷新目标的时间间隔（针对服务树http类型，秒）"`                                        // 刷新目标的时间间隔（针对服务树http类型，秒）
+	Port                           int                 `json:"port,omitempty" gorm:"type:int;comment:端口号（针对服务树服务发现接口）"`                                                           // 端口号（针对服务树服务发现接口）
+	TreeNodeIDs                    StringList          `json:"treeNodeIds,omitempty" gorm:"type:text;comment:服务树接口绑定的树节点ID列表，用于获取IP列表"`                                           // 服务树接口绑定的树节点ID列表，用于获取IP列表
+	KubeConfigFilePath             string              `json:"kubeConfigFilePath,omitempty" gorm:"size:255;comment:连接apiServer的Kubernetes配置文件路径"`                                 // 连接apiServer的Kubernetes配置文件路径
+	TlsCaFilePath                  string              `json:"tlsCaFilePath,omitempty" gorm:"size:255;comment:TLS CA证书文件路径"`                                                      // TLS CA证书文件路径
+	TlsCaContent                   string              `json:"tlsCaContent,omitempty" gorm:"type:text;comment:TLS CA证书内容"`                                                        // TLS CA证书内容
+	BearerToken                    string              `json:"bearerToken,omitempty" gorm:"type:text;comment:鉴权Token内容"`                                                          // 鉴权Token内容
+	BearerTokenFile                string              `json:"bearerTokenFile,omitempty" gorm:"size:255;comment:鉴权Token文件路径"`                                                     // 鉴权Token文件路径
+	KubernetesSdRole               string              `json:"kubernetesSdRole,omitempty" gorm:"size:50;comment:Kubernetes服务发现角色"`                                                // Kubernetes服务发现角色
+	StaticTargetGroups             []ScrapeTargetGroup `json:"staticTargetGroups,omitempty" gorm:"type:text;serializer:json;comment:静态配置的采集目标组（针对static类型）"`                      // 静态配置的采集目标组（针对static类型）
+	FileSdTargetGroups             []ScrapeTargetGroup `json:"fileSdTargetGroups,omitempty" gorm:"type:text;serializer:json;comment:平台管理的采集目标组，由config-agent写入目标文件（针对file_sd类型）"` // 平台管理的采集目标组，由 config-agent 写入 Prometheus 主机上的目标文件（针对file_sd类型）
+	FileSdPaths                    StringList          `json:"fileSdPaths,omitempty" gorm:"type:text;comment:Prometheus主机上已有的目标文件路径，支持通配符（针对file_sd类型）"`                          // Prometheus主机上已有的目标文件路径，支持通配符（针对file_sd类型）
+	DnsSdNames                     StringList          `json:"dnsSdNames,omitempty" gorm:"type:text;comment:DNS服务发现查询的域名列表（针对dns类型）"`                                             // DNS服务发现查询的域名列表（针对dns类型）
+	DnsSdType                      string              `json:"dnsSdType,omitempty" gorm:"size:10;comment:DNS记录类型：SRV、A、AAAA、MX、NS，默认为SRV"`                                        // DNS记录类型：SRV、A、AAAA、MX、NS，默认为SRV
+	DnsSdPort                      int                 `json:"dnsSdPort,omitempty" gorm:"type:int;comment:采集端口，SRV以外的记录类型必填"`                                                     // 采集端口，SRV以外的记录类型必填
+	ConsulServer                   string              `json:"consulServer,omitempty" gorm:"size:255;comment:Consul地址，例如 127.0.0.1:8500（针对consul类型）"`                             // Consul地址，例如 127.0.0.1:8500（针对consul类型）
+	ConsulScheme                   string              `json:"consulScheme,omitempty" gorm:"size:10;comment:访问Consul的协议，http或https"`                                              // 访问Consul的协议，http或https
+	ConsulDatacenter               string              `json:"consulDatacenter,omitempty" gorm:"size:100;comment:Consul数据中心"`                                                     // Consul数据中心
+	ConsulToken                    string              `json:"consulToken,omitempty" gorm:"type:text;comment:访问Consul的Token"`                                                     // 访问Consul的Token
+	ConsulServices                 StringList          `json:"consulServices,omitempty" gorm:"type:text;comment:需要发现的Consul服务列表，为空表示所有服务"`                                        // 需要发现的Consul服务列表，为空表示所有服务
+	ConsulTags                     StringList          `json:"consulTags,omitempty" gorm:"type:text;comment:服务实例必须包含的Consul标签"`                                                   // 服务实例必须包含的Consul标签
+	BasicAuthUsername              string              `json:"basicAuthUsername,omitempty" gorm:"size:100;comment:Basic Auth用户名"`                                                 // Basic Auth用户名
+	BasicAuthPassword              string              `json:"basicAuthPassword,omitempty" gorm:"type:text;comment:Basic Auth密码"`                                                 // Basic Auth密码
+	TlsCertFilePath                string              `json:"tlsCertFilePath,omitempty" gorm:"size:255;comment:客户端证书文件路径"`                                                       // 客户端证书文件路径
+	TlsKeyFilePath                 string              `json:"tlsKeyFilePath,omitempty" gorm:"size:255;comment:客户端私钥文件路径"`                                                        // 客户端私钥文件路径
+	TlsCertContent                 string              `json:"tlsCertContent,omitempty" gorm:"type:text;comment:客户端证书内容"`                                                         // 客户端证书内容
+	TlsKeyContent                  string              `json:"tlsKeyContent,omitempty" gorm:"type:text;comment:客户端私钥内容"`                                                          // 客户端私钥内容
+	TlsServerName                  string              `json:"tlsServerName,omitempty" gorm:"size:255;comment:校验服务端证书时使用的服务名"`                                                    // 校验服务端证书时使用的服务名
+	TlsInsecureSkipVerify          int                 `json:"tlsInsecureSkipVerify,omitempty" gorm:"type:int;comment:是否跳过服务端证书校验：1跳过，2校验，未设置时k8s类型跳过、其他类型校验"`                    // 是否跳过服务端证书校验：1跳过，2校验，未设置时k8s类型跳过、其他类型校验
+	ProxyUrl                       string              `json:"proxyUrl,omitempty" gorm:"size:255;comment:采集使用的代理地址"`                                                              // 采集使用的代理地址
+	HonorLabels                    int                 `json:"honorLabels,omitempty" gorm:"type:int;comment:标签冲突时是否保留目标暴露的标签：1保留，2不保留（默认）"`                                       // 标签冲突时是否保留目标暴露的标签：1保留，2不保留（默认）
+	HonorTimestamps                int                 `json:"honorTimestamps,omitempty" gorm:"type:int;comment:是否使用目标暴露的时间戳：1使用（默认），2不使用"`                                       // 是否使用目标暴露的时间戳：1使用（默认），2不使用
+	SampleLimit                    int                 `json:"sampleLimit,omitempty" gorm:"type:int;comment:单次采集的样本数上限，0表示不限制"`                                                   // 单次采集的样本数上限，0表示不限制
+	TargetLimit                    int                 `json:"targetLimit,omitempty" gorm:"type:int;comment:采集目标数量上限，0表示不限制"`                                                     // 采集目标数量上限，0表示不限制
+	LabelLimit                     int                 `json:"labelLimit,omitempty" gorm:"type:int;comment:单个样本的标签数量上限，0表示不限制"`                                                   // 单个样本的标签数量上限，0表示不限制
+	Params                         StringList          `json:"params,omitempty" gorm:"type:text;comment:采集请求的URL参数，格式为 key=value，同一个key可以出现多次"`                                   // 采集请求的URL参数，格式为 key=value，同一个key可以出现多次
 
 	// 前端使用字段
 	TreeNodeIDIns  []int  `json:"treeNodeIdIns,omitempty" gorm:"-"`  // 树节点ID的整数列表
@@ -254,6 +489,542 @@ type MonitorScrapeJob struct {
 	CreateUserName string `json:"createUserName,omitempty" gorm:"-"` // 创建用户的名称，用于前端展示
 }
 
+// 采集任务的服务发现类型
+const (
+	ServiceDiscoveryHttp   = "http"    // 服务树接口
+	ServiceDiscoveryK8s    = "k8s"     // Kubernetes
+	ServiceDiscoveryStatic = "static"  // 静态配置
+	ServiceDiscoveryFile   = "file_sd" // 目标文件
+	ServiceDiscoveryDns    = "dns"     // DNS
+	ServiceDiscoveryConsul = "consul"  // Consul
+)
+
+// ScrapeTargetGroup 一组采集目标及其公共标签，与 Prometheus 的 static_configs 和 file_sd 目标文件格式一致
+type ScrapeTargetGroup struct {
+	Targets []string          `json:"targets"`          // 采集目标地址，格式为 host:port
+	Labels  map[string]string `json:"labels,omitempty"` // 附加到组内所有目标上的标签
+}
+
+// RelabelSimulateReq 模拟采集任务对服务发现得到的目标执行 relabel
+type RelabelSimulateReq struct {
+	JobID  int               `json:"jobId"`                     // 已保存的采集任务ID，未传 Job 时使用
+	Job    *MonitorScrapeJob `json:"job"`                       // 尚未保存的采集任务，优先于 JobID
+	Labels map[string]string `json:"labels" binding:"required"` // 服务发现得到的目标标签，需要包含 __address__
+}
+
+// RelabelSimulateResp relabel 模拟结果
+type RelabelSimulateResp struct {
+	Kept          bool                   `json:"kept"`          // 目标是否会被采集
+	DropReason    string                 `json:"dropReason"`    // 目标被丢弃的原因
+	InitialLabels map[string]string      `json:"initialLabels"` // 补充默认标签后、relabel 之前的标签
+	FinalLabels   map[string]string      `json:"finalLabels"`   // 最终附加到采集样本上的标签
+	InstanceIndex int                    `json:"instanceIndex"` // 按哈希分片后负责采集该目标的Prometheus实例序号，-1表示没有实例采集
+	InstanceIP    string                 `json:"instanceIp"`    // 负责采集该目标的Prometheus实例
+	Steps         []*RelabelSimulateStep `json:"steps"`         // 每条 relabel 规则执行后的标签
+}
+
+// RelabelSimulateStep 单条 relabel 规则的执行结果
+type RelabelSimulateStep struct {
+	Index  int               `json:"index"`  // 规则序号，从1开始
+	Action string            `json:"action"` // 规则动作
+	Kept   bool              `json:"kept"`   // 执行后目标是否保留
+	Labels map[string]string `json:"labels"` // 执行后的标签
+}
+
+// MonitorConfigVersion 生成的 Prometheus/AlertManager 配置的历史版本
+type MonitorConfigVersion struct {
+	NoUniqueIndexModel
+	ConfigType string `json:"configType" gorm:"index:idx_type_ip;size:50;comment:配置类型：prometheus、prometheus_alert、prometheus_record、alertManager"` // 配置类型
+	InstanceIP string `json:"instanceIp" gorm:"index:idx_type_ip;size:100;comment:实例IP"`                                                           // 实例IP
+	PoolID     int    `json:"poolId" gorm:"comment:生成该配置的池ID"`                                                                                     // 生成该配置的池ID
+	Version    int    `json:"version" gorm:"comment:版本号，同一实例同一配置类型内递增"`                                                                            // 版本号，同一实例同一配置类型内递增
+	Hash       string `json:"hash" gorm:"size:64;comment:配置内容的SHA256哈希"`                                                                           // 配置内容的SHA256哈希
+	Content    string `json:"content,omitempty" gorm:"type:longtext;comment:配置内容"`                                                                 // 配置内容
+	UserID     int    `json:"userId" gorm:"comment:触发本次变更的用户ID，0表示系统定时生成"`                                                                         // 触发本次变更的用户ID，0表示系统定时生成
+	Trigger    string `json:"trigger" gorm:"size:255;comment:触发本次变更的操作"`                                                                           // 触发本次变更的操作
+
+	// 前端使用字段
+	Pinned         bool   `json:"pinned" gorm:"-"`                   // 是否为实例当前固定的版本
+	CreateUserName string `json:"createUserName,omitempty" gorm:"-"` // 前端表格显示的变更者用户名
+}
+
+// MonitorConfigPin 实例固定使用的配置版本，存在时不再下发新生成的配置
+type MonitorConfigPin struct {
+	Model
+	ConfigType string `json:"configType" gorm:"uniqueIndex:udx_name;size:50;comment:配置类型"`  // 配置类型
+	InstanceIP string `json:"instanceIp" gorm:"uniqueIndex:udx_name;size:100;comment:实例IP"` // 实例IP
+	VersionID  int    `json:"versionId" gorm:"comment:固定的配置版本ID"`                           // 固定的配置版本ID
+	UserID     int    `json:"userId" gorm:"comment:执行固定操作的用户ID"`                            // 执行固定操作的用户ID
+	Reason     string `json:"reason,omitempty" gorm:"size:255;comment:固定或回滚的原因"`            // 固定或回滚的原因
+
+	// 前端使用字段
+	Version        int    `json:"version" gorm:"-"`                  // 固定的版本号
+	CreateUserName string `json:"createUserName,omitempty" gorm:"-"` // 前端表格显示的操作者用户名
+}
+
+// MonitorConfigApplied config-agent 上报的实例实际应用的配置
+type MonitorConfigApplied struct {
+	Model
+	ConfigType string `json:"configType" gorm:"uniqueIndex:udx_name;size:50;comment:配置类型"`  // 配置类型
+	InstanceIP string `json:"instanceIp" gorm:"uniqueIndex:udx_name;size:100;comment:实例IP"` // 实例IP
+	Hash       string `json:"hash" gorm:"size:64;comment:实例应用的配置哈希"`                        // 实例应用的配置哈希
+	VersionID  int    `json:"versionId" gorm:"comment:哈希对应的配置版本ID，0表示未找到对应版本"`              // 哈希对应的配置版本ID，0表示未找到对应版本
+	Version    int    `json:"version" gorm:"comment:哈希对应的配置版本号"`                            // 哈希对应的配置版本号
+	AppliedAt  int64  `json:"appliedAt" gorm:"comment:实例应用配置的时间"`                           // 实例应用配置的时间
+	Success    bool   `json:"success" gorm:"comment:写入配置并重载是否成功"`                           // 写入配置并重载是否成功
+	Error      string `json:"error,omitempty" gorm:"type:text;comment:应用失败的错误信息"`           // 应用失败的错误信息
+}
+
+// ConfigAppliedReportReq config-agent 上报已应用配置的请求
+type ConfigAppliedReportReq struct {
+	IP      string              `json:"ip"`                       // 实例IP，不作为身份依据，实际IP由 Token 确定
+	Token   string              `json:"token"`                    // 实例Token，也可以通过 X-Agent-Token 请求头传递
+	Items   []ConfigAppliedItem `json:"items" binding:"required"` // 应用的各类配置
+	Success bool                `json:"success"`                  // 写入配置并重载是否成功
+	Error   string              `json:"error"`                    // 应用失败的错误信息
+}
+
+// ConfigAppliedItem 实例应用的单类配置
+type ConfigAppliedItem struct {
+	ConfigType string `json:"configType" binding:"required"` // 配置类型
+	Hash       string `json:"hash" binding:"required"`       // 配置内容的SHA256哈希
+}
+
+// MonitorConfigFile 配置引用的平台管理文件，由 config-agent 拉取后写入实例主机
+type MonitorConfigFile struct {
+	Path    string `json:"path"`    // 实例主机上的绝对路径，位于 config-agent 的 files_dir 下
+	Content string `json:"content"` // 文件内容
+}
+
+// ConfigAppliedListReq 查询实例已应用配置的请求
+type ConfigAppliedListReq struct {
+	IP string `form:"ip"` // 实例IP，为空表示所有实例
+}
+
+// MonitorConfigSyncState 实例配置同步状态，记录最近一次下发并调用 /-/reload 的结果
+type MonitorConfigSyncState struct {
+	Model
+	InstanceType      string `json:"instanceType" gorm:"uniqueIndex:udx_name;size:50;comment:实例类型：prometheus、alertmanager"` // 实例类型
+	InstanceIP        string `json:"instanceIp" gorm:"uniqueIndex:udx_name;size:100;comment:实例IP"`                          // 实例IP
+	PoolID            int    `json:"poolId" gorm:"comment:实例所属的池ID"`                                                        // 实例所属的池ID
+	DesiredHash       string `json:"desiredHash" gorm:"size:64;comment:期望下发的配置哈希"`                                          // 期望下发的配置哈希
+	PushedHash        string `json:"pushedHash" gorm:"size:64;comment:config-agent 确认实例已加载的配置哈希"`                           // config-agent 确认实例已加载的配置哈希，平台重载成功不会更新该值
+	LastReloadAt      int64  `json:"lastReloadAt" gorm:"comment:最近一次调用重载的时间"`                                               // 最近一次调用重载的时间
+	LastReloadSuccess bool   `json:"lastReloadSuccess" gorm:"comment:最近一次重载是否成功"`                                           // 最近一次重载是否成功
+	LastError         string `json:"lastError,omitempty" gorm:"type:text;comment:最近一次重载失败的错误信息"`                            // 最近一次重载失败的错误信息
+	ReloadAttempts    int    `json:"reloadAttempts" gorm:"comment:最近一次重载的尝试次数"`                                             // 最近一次重载的尝试次数
+
+	// 前端使用字段
+	PoolName    string `json:"poolName,omitempty" gorm:"-"`    // 实例所属的池名称
+	AppliedHash string `json:"appliedHash,omitempty" gorm:"-"` // config-agent 确认已应用的期望配置哈希，未确认时为空
+	OutOfSync   bool   `json:"outOfSync" gorm:"-"`             // 实例是否未同步到期望的配置，以 config-agent 上报的已应用配置为准
+}
+
+// ConfigSyncListReq 查询实例配置同步状态的请求
+type ConfigSyncListReq struct {
+	OutOfSync bool `form:"outOfSync"` // 是否只返回未同步的实例
+}
+
+// ConfigSyncReloadReq 手动触发实例重载的请求
+type ConfigSyncReloadReq struct {
+	IP string `json:"ip"` // 实例IP，为空表示所有实例
+}
+
+// ConfigVersionListReq 查询配置版本列表的请求
+type ConfigVersionListReq struct {
+	ConfigType string `form:"configType" binding:"required"` // 配置类型
+	IP         string `form:"ip" binding:"required"`         // 实例IP
+}
+
+// ConfigVersionDiffReq 对比两个配置版本的请求
+type ConfigVersionDiffReq struct {
+	FromID int `form:"fromId" binding:"required"` // 旧版本ID
+	ToID   int `form:"toId" binding:"required"`   // 新版本ID
+}
+
+// ConfigVersionDiffResp 配置版本对比结果
+type ConfigVersionDiffResp struct {
+	From *MonitorConfigVersion `json:"from"` // 旧版本
+	To   *MonitorConfigVersion `json:"to"`   // 新版本
+	Diff string                `json:"diff"` // unified 格式的差异内容
+}
+
+// ConfigVersionPinReq 固定或回滚实例配置版本的请求
+type ConfigVersionPinReq struct {
+	ConfigType string `json:"configType" binding:"required"` // 配置类型
+	IP         string `json:"ip" binding:"required"`         // 实例IP
+	VersionID  int    `json:"versionId"`                     // 目标版本ID，固定时为空表示固定当前最新版本
+	Reason     string `json:"reason"`                        // 固定或回滚的原因
+}
+
+// MonitorConfigError 生成配置时的校验错误，只保存在内存中，每次重新生成后刷新
+type MonitorConfigError struct {
+	ConfigType string `json:"configType"` // 配置类型
+	PoolID     int    `json:"poolId"`     // 所属池ID，采集池或AlertManager池
+	PoolName   string `json:"poolName"`   // 所属池名称
+	InstanceIP string `json:"ip"`         // 实例IP，为空表示单个采集任务或规则的错误
+	Object     string `json:"object"`     // 出错的采集任务或规则名称，为空表示整个配置文件
+	Message    string `json:"message"`    // 错误信息
+	Fallback   bool   `json:"fallback"`   // 实例是否回退到了最近一次校验通过的版本
+	CheckedAt  int64  `json:"checkedAt"`  // 校验时间
+}
+
+// ConfigErrorListReq 查询配置校验错误的请求
+type ConfigErrorListReq struct {
+	ConfigType string `form:"configType"` // 配置类型，为空表示所有类型
+	PoolID     int    `form:"poolId"`     // 池ID，为空表示所有池
+}
+
+// MonitorRuleGroup 规则组，组内的预聚合规则和告警规则在同一个评估周期内按顺序评估
+type MonitorRuleGroup struct {
+	Model
+	Name        string `json:"name" binding:"required,min=1,max=50" gorm:"uniqueIndex:udx_name;size:100;comment:规则组名称，支持通配符*进行模糊搜索"` // 规则组名称，支持通配符*进行模糊搜索
+	UserID      int    `json:"userId" gorm:"comment:创建该规则组的用户ID"`                                                                    // 创建该规则组的用户ID
+	PoolID      int    `json:"poolId" binding:"required" gorm:"comment:关联的Prometheus实例池ID，组内规则都属于该池"`                                // 关联的Prometheus实例池ID，组内规则都属于该池
+	Interval    string `json:"interval,omitempty" gorm:"size:50;comment:规则组的评估间隔，为空时使用全局评估间隔"`                                       // 规则组的评估间隔，为空时使用全局评估间隔
+	RuleLimit   int    `json:"limit,omitempty" gorm:"comment:组内每条规则产生的告警或序列数量上限，0表示不限制"`                                             // 组内每条规则产生的告警或序列数量上限，0表示不限制
+	Description string `json:"description,omitempty" gorm:"type:text;comment:规则组描述"`                                                 // 规则组描述
+
+	// 前端使用字段
+	Key             string `json:"key" gorm:"-"`                      // 前端表格的Key
+	PoolName        string `json:"poolName,omitempty" gorm:"-"`       // 前端表格显示的Prometheus实例池名称
+	CreateUserName  string `json:"createUserName,omitempty" gorm:"-"` // 前端表格显示的创建者用户名
+	AlertRuleCount  int    `json:"alertRuleCount" gorm:"-"`           // 组内告警规则数量
+	RecordRuleCount int    `json:"recordRuleCount" gorm:"-"`          // 组内预聚合规则数量
+}
+
+// RuleGroupDetailResp 规则组及组内按评估顺序排列的规则
+type RuleGroupDetailResp struct {
+	Group       *MonitorRuleGroup    `json:"group"`       // 规则组
+	AlertRules  []*MonitorAlertRule  `json:"alertRules"`  // 组内的告警规则
+	RecordRules []*MonitorRecordRule `json:"recordRules"` // 组内的预聚合规则
+}
+
+// RuleShardPreviewReq 预览规则组在拟定实例列表下的分片结果
+type RuleShardPreviewReq struct {
+	PoolID              int      `json:"poolId" binding:"required"`              // 采集池ID
+	PrometheusInstances []string `json:"prometheusInstances" binding:"required"` // 拟定的Prometheus实例列表
+	RuleReplicas        int      `json:"ruleReplicas"`                           // 拟定的副本数，为0时使用采集池当前的设置
+}
+
+// RuleShardPreviewResp 规则组分片预览结果
+type RuleShardPreviewResp struct {
+	Total              int                    `json:"total"`              // 规则组总数
+	Moved              int                    `json:"moved"`              // 分配的实例发生变化的规则组数量
+	InstanceGroupCount map[string]int         `json:"instanceGroupCount"` // 拟定分配下每个实例评估的规则组数量
+	Groups             []*RuleShardAssignment `json:"groups"`             // 每个规则组的分配情况
+}
+
+// RuleShardAssignment 单个规则组在当前和拟定实例列表下分配的实例
+type RuleShardAssignment struct {
+	Name       string   `json:"name"`       // 规则组名称
+	ConfigType string   `json:"configType"` // 所在的规则文件类型，prometheus_alert 或 prometheus_record
+	Current    []string `json:"current"`    // 当前分配的实例
+	Proposed   []string `json:"proposed"`   // 拟定分配的实例
+	Moved      bool     `json:"moved"`      // 分配的实例是否发生变化
+}
+
+// 规则导入报告中的条目类型，告警规则和预聚合规则使用 RuleTypeAlert 和 RuleTypeRecord
+const RuleImportTypeGroup = "group"
+
+// 规则导入报告中条目的处理方式
+const (
+	RuleImportActionCreate   = "create"   // 新建
+	RuleImportActionUpdate   = "update"   // 更新同一采集池中的同名规则
+	RuleImportActionConflict = "conflict" // 名称与其他采集池的规则或文件中的其他规则冲突
+	RuleImportActionInvalid  = "invalid"  // 规则无效或包含平台不支持的字段
+)
+
+// RuleImportReq 导入 Prometheus 规则文件，内容可以通过 content 字段或上传的 file 文件提供
+type RuleImportReq struct {
+	Content     string `json:"content" form:"content"`                             // rulefmt 格式的规则文件内容
+	PoolID      int    `json:"poolId" form:"poolId" binding:"required"`            // 导入到的采集池ID
+	SendGroupID int    `json:"sendGroupId" form:"sendGroupId"`                     // 告警规则关联的发送组ID
+	TreeNodeID  int    `json:"treeNodeId" form:"treeNodeId"`                       // 规则绑定的树节点ID
+	Enable      int    `json:"enable" form:"enable" binding:"omitempty,oneof=1 2"` // 新建规则是否启用：1启用，2禁用，默认启用
+	DryRun      bool   `json:"dryRun" form:"dryRun"`                               // 只返回导入报告，不写入数据库
+}
+
+// RuleImportResp 规则导入报告
+type RuleImportResp struct {
+	DryRun    bool              `json:"dryRun"`    // 是否只是预览
+	Created   int               `json:"created"`   // 新建的规则组和规则数量
+	Updated   int               `json:"updated"`   // 更新的规则组和规则数量
+	Conflicts int               `json:"conflicts"` // 冲突的数量
+	Invalid   int               `json:"invalid"`   // 无效的数量
+	Items     []*RuleImportItem `json:"items"`     // 每个规则组和规则的处理方式，按文件中的顺序排列
+}
+
+// RuleImportItem 规则文件中一个规则组或规则的处理方式
+type RuleImportItem struct {
+	Type       string `json:"type"`                 // group、alert 或 record
+	Group      string `json:"group"`                // 所属的规则组名称
+	Name       string `json:"name"`                 // 规则组名称、告警名称或记录名称
+	Action     string `json:"action"`               // create、update、conflict 或 invalid
+	Reason     string `json:"reason,omitempty"`     // 冲突或无效的原因
+	ExistingID int    `json:"existingId,omitempty"` // 同名的已有规则组或规则ID
+}
+
+// PromQueryReq 在采集池的某个Prometheus实例上执行 PromQL 查询
+type PromQueryReq struct {
+	PoolID         int    `json:"poolId" binding:"required"` // 采集池ID
+	Instance       string `json:"instance"`                  // 执行查询的Prometheus实例，为空时使用采集池的第一个实例
+	Query          string `json:"query" binding:"required"`  // PromQL 表达式
+	Time           int64  `json:"time"`                      // 即时查询的时间点（秒级时间戳），为0时使用当前时间
+	Start          int64  `json:"start"`                     // 范围查询的开始时间（秒级时间戳）
+	End            int64  `json:"end"`                       // 范围查询的结束时间（秒级时间戳），为0时使用当前时间
+	Step           int    `json:"step"`                      // 范围查询的步长（秒），为0时使用采集池的采集间隔
+	TimeoutSeconds int    `json:"timeoutSeconds"`            // 查询超时时间（秒），为0时使用默认值
+}
+
+// PromQueryResp PromQL 查询结果，Result 与 Prometheus HTTP API 返回的 data.result 格式一致
+type PromQueryResp struct {
+	Instance   string      `json:"instance"`           // 执行查询的Prometheus实例
+	ResultType string      `json:"resultType"`         // 结果类型：vector、matrix、scalar、string
+	Result     interface{} `json:"result"`             // 查询结果
+	Warnings   []string    `json:"warnings,omitempty"` // Prometheus 返回的警告信息
+}
+
+// AlertRulePreviewReq 预览尚未保存的告警规则当前的触发情况
+type AlertRulePreviewReq struct {
+	PoolID         int    `json:"poolId" binding:"required"` // 采集池ID
+	Instance       string `json:"instance"`                  // 执行查询的Prometheus实例，为空时使用采集池的第一个实例
+	Expr           string `json:"expr" binding:"required"`   // 告警规则表达式
+	ForTime        string `json:"forTime"`                   // 持续时间，为空时表达式有结果即触发
+	TimeoutSeconds int    `json:"timeoutSeconds"`            // 查询超时时间（秒），为0时使用默认值
+}
+
+// AlertRulePreviewResp 告警规则预览结果
+type AlertRulePreviewResp struct {
+	Instance     string                    `json:"instance"`     // 执行查询的Prometheus实例
+	FiringCount  int                       `json:"firingCount"`  // 当前会触发的告警数量
+	PendingCount int                       `json:"pendingCount"` // 当前满足表达式但持续时间不足的告警数量
+	Series       []*AlertRulePreviewSeries `json:"series"`       // 当前满足表达式的序列
+}
+
+// AlertRulePreviewSeries 单个满足告警表达式的序列
+type AlertRulePreviewSeries struct {
+	Labels      map[string]string `json:"labels"`      // 序列标签
+	Value       float64           `json:"value"`       // 当前值
+	ActiveSince int64             `json:"activeSince"` // 连续满足表达式的起始时间（秒级时间戳），受查询窗口限制
+	State       string            `json:"state"`       // firing 或 pending
+}
+
+// AlertRuleBacktestReq 使用历史数据回测告警规则
+type AlertRuleBacktestReq struct {
+	RuleID         int               `json:"ruleId"`         // 已保存的告警规则ID，未传 Rule 时使用
+	Rule           *MonitorAlertRule `json:"rule"`           // 尚未保存的告警规则，优先于 RuleID
+	Instance       string            `json:"instance"`       // 执行查询的Prometheus实例，为空时使用采集池的第一个实例
+	Start          int64             `json:"start"`          // 回测开始时间（秒级时间戳），为0时从结束时间往前7天
+	End            int64             `json:"end"`            // 回测结束时间（秒级时间戳），为0时使用当前时间
+	TimeoutSeconds int               `json:"timeoutSeconds"` // 查询超时时间（秒），为0时使用默认值
+}
+
+// AlertRuleBacktestResp 告警规则回测结果
+type AlertRuleBacktestResp struct {
+	Instance    string                     `json:"instance"`    // 执行查询的Prometheus实例
+	Start       int64                      `json:"start"`       // 回测开始时间（秒级时间戳）
+	End         int64                      `json:"end"`         // 回测结束时间（秒级时间戳）
+	Step        int                        `json:"step"`        // 评估间隔（秒）
+	ForTime     string                     `json:"forTime"`     // 回测使用的持续时间
+	FiringCount int                        `json:"firingCount"` // 回测时间内触发告警的总次数
+	Series      []*AlertRuleBacktestSeries `json:"series"`      // 每组标签的触发情况，按触发次数从多到少排列
+}
+
+// AlertRuleBacktestSeries 同一组标签的告警在回测时间内的触发情况
+type AlertRuleBacktestSeries struct {
+	Labels    map[string]string            `json:"labels"`    // 告警标签
+	Count     int                          `json:"count"`     // 触发次数
+	Intervals []*AlertRuleBacktestInterval `json:"intervals"` // 每次触发的时间段
+}
+
+// AlertRuleBacktestInterval 一次告警从 pending 到恢复的时间段
+type AlertRuleBacktestInterval struct {
+	ActiveSince int64 `json:"activeSince"` // 进入 pending 的时间（秒级时间戳），早于查询范围时为查询开始时间
+	FiredAt     int64 `json:"firedAt"`     // 开始触发的时间（秒级时间戳）
+	LastFiring  int64 `json:"lastFiring"`  // 最后一次评估为触发的时间（秒级时间戳）
+	Resolved    bool  `json:"resolved"`    // 回测结束前是否已恢复
+}
+
+// 告警规则模板参数的类型
+const (
+	RuleTemplateParamString   = "string"   // 任意字符串
+	RuleTemplateParamNumber   = "number"   // 数字，如阈值 80 或 0.95
+	RuleTemplateParamDuration = "duration" // Prometheus 时间长度，如 5m
+)
+
+// 告警规则模板的内置参数，实例化时根据树节点自动填充
+const (
+	RuleTemplateParamTreeNodeID    = "tree_node_id"    // 树节点ID
+	RuleTemplateParamTreeNodeTitle = "tree_node_title" // 树节点名称
+)
+
+// MonitorAlertRuleTemplate 告警规则模板，表达式、持续时间、级别、标签和注解中可以使用 ${参数名} 引用参数，
+// 针对多个树节点实例化后生成关联的告警规则
+type MonitorAlertRuleTemplate struct {
+	Model
+	Name        string              `json:"name" binding:"required,min=1,max=40" gorm:"uniqueIndex:udx_name;size:100;comment:模板名称，实例化的告警规则名称为 模板名称_树节点ID"` // 模板名称，实例化的告警规则名称为 模板名称_树节点ID
+	UserID      int                 `json:"userId" gorm:"comment:创建该模板的用户ID"`                                                                              // 创建该模板的用户ID
+	PoolID      int                 `json:"poolId" binding:"required" gorm:"comment:实例化的告警规则关联的Prometheus实例池ID"`                                           // 实例化的告警规则关联的Prometheus实例池ID
+	SendGroupID int                 `json:"sendGroupId" gorm:"comment:实例化的告警规则关联的发送组ID"`                                                                   // 实例化的告警规则关联的发送组ID
+	Expr        string              `json:"expr" binding:"required" gorm:"type:text;comment:告警规则表达式模板"`                                                    // 告警规则表达式模板
+	ForTime     string              `json:"forTime,omitempty" gorm:"size:50;comment:持续时间模板"`                                                               // 持续时间模板
+	Severity    string              `json:"severity,omitempty" gorm:"size:50;comment:告警级别模板"`                                                              // 告警级别模板
+	Labels      StringList          `json:"labels,omitempty" gorm:"type:text;comment:标签组模板，格式为 key=v"`                                                     // 标签组模板，格式为 key=v
+	Annotations StringList          `json:"annotations,omitempty" gorm:"type:text;comment:注解模板，格式为 key=v"`                                                 // 注解模板，格式为 key=v
+	Parameters  []RuleTemplateParam `json:"parameters,omitempty" gorm:"type:text;serializer:json;comment:模板参数定义"`                                          // 模板参数定义
+	Description string              `json:"description,omitempty" gorm:"type:text;comment:模板描述"`                                                           // 模板描述
+
+	// 前端使用字段
+	Key            string `json:"key" gorm:"-"`                      // 前端表格的Key
+	PoolName       string `json:"poolName,omitempty" gorm:"-"`       // 前端表格显示的Prometheus实例池名称
+	CreateUserName string `json:"createUserName,omitempty" gorm:"-"` // 前端表格显示的创建者用户名
+	InstanceCount  int    `json:"instanceCount" gorm:"-"`            // 由模板生成的告警规则数量
+}
+
+// RuleTemplateParam 告警规则模板的参数定义
+type RuleTemplateParam struct {
+	Name        string `json:"name"`                  // 参数名，在模板中以 ${参数名} 引用
+	Type        string `json:"type"`                  // 参数类型：string、number、duration
+	Default     string `json:"default,omitempty"`     // 默认值，为空且 Required 时实例化必须提供
+	Required    bool   `json:"required"`              // 是否必须提供
+	Description string `json:"description,omitempty"` // 参数说明
+}
+
+// RuleTemplateDetailResp 告警规则模板详情
+type RuleTemplateDetailResp struct {
+	Template  *MonitorAlertRuleTemplate `json:"template"`  // 模板
+	Instances []*MonitorAlertRule       `json:"instances"` // 由模板生成的告警规则
+}
+
+// RuleTemplateInstantiateReq 针对一组树节点实例化告警规则模板
+type RuleTemplateInstantiateReq struct {
+	TemplateID int                          `json:"templateId" binding:"required"`      // 模板ID
+	Instances  []*RuleTemplateInstantiation `json:"instances" binding:"required,min=1"` // 每个树节点及其覆盖的参数
+}
+
+// RuleTemplateInstantiation 一个树节点的实例化参数
+type RuleTemplateInstantiation struct {
+	TreeNodeID int               `json:"treeNodeId" binding:"required"` // 树节点ID
+	Params     map[string]string `json:"params,omitempty"`              // 覆盖模板默认值的参数
+}
+
+// RuleTemplateRenderResp 实例化或修改模板后重新生成告警规则的结果
+type RuleTemplateRenderResp struct {
+	Created []string             `json:"created"` // 新建的告警规则名称
+	Updated []string             `json:"updated"` // 重新生成的告警规则名称
+	Drifted []*RuleTemplateDrift `json:"drifted"` // 重新生成前已经偏离模板的告警规则，其修改已被覆盖
+}
+
+// RuleTemplateDrift 由模板生成的告警规则与模板当前渲染结果的差异
+type RuleTemplateDrift struct {
+	RuleID     int                       `json:"ruleId"`          // 告警规则ID
+	RuleName   string                    `json:"ruleName"`        // 告警规则名称
+	TreeNodeID int                       `json:"treeNodeId"`      // 树节点ID
+	Error      string                    `json:"error,omitempty"` // 无法按模板渲染时的错误信息
+	Fields     []*RuleTemplateDriftField `json:"fields"`          // 不一致的字段
+}
+
+// RuleTemplateDriftField 一个不一致的字段
+type RuleTemplateDriftField struct {
+	Field    string `json:"field"`    // 字段名
+	Expected string `json:"expected"` // 按模板渲染的值
+	Actual   string `json:"actual"`   // 告警规则当前的值
+}
+
+// MonitorRuleExport 将采集池的告警规则和预聚合规则以 PrometheusRule 资源同步到 K8s 集群，供集群内的 prometheus-operator 使用
+type MonitorRuleExport struct {
+	Model
+	PoolID         int        `json:"poolId" binding:"required" gorm:"uniqueIndex:udx_name;comment:导出规则的采集池ID"`                      // 导出规则的采集池ID
+	ClusterID      int        `json:"clusterId" binding:"required" gorm:"uniqueIndex:udx_name;comment:目标K8s集群ID"`                    // 目标K8s集群ID
+	Namespace      string     `json:"namespace" binding:"required,min=1,max=63" gorm:"uniqueIndex:udx_name;size:100;comment:目标命名空间"` // 目标命名空间
+	Labels         StringList `json:"labels,omitempty" gorm:"type:text;comment:附加到 PrometheusRule 上的标签，格式为 key=v，用于匹配 ruleSelector"` // 附加到 PrometheusRule 上的标签，格式为 key=v，用于匹配 ruleSelector
+	Enable         int        `json:"enable" gorm:"type:int;default:1;comment:是否启用同步：1启用，2禁用，禁用后清理集群中已同步的资源"`                        // 是否启用同步：1启用，2禁用，禁用后清理集群中已同步的资源
+	UserID         int        `json:"userId" gorm:"comment:创建者用户ID"`                                                                 // 创建者用户ID
+	LastSyncAt     int64      `json:"lastSyncAt" gorm:"comment:最近一次同步的时间"`                                                           // 最近一次同步的时间
+	LastSyncError  string     `json:"lastSyncError,omitempty" gorm:"type:text;comment:最近一次同步的错误信息"`                                  // 最近一次同步的错误信息
+	LastSyncObject int        `json:"lastSyncObject" gorm:"comment:最近一次同步后集群中的 PrometheusRule 数量"`                                   // 最近一次同步后集群中的 PrometheusRule 数量
+
+	// 前端使用字段
+	PoolName       string `json:"poolName,omitempty" gorm:"-"`       // 前端表格显示的采集池名称
+	ClusterName    string `json:"clusterName,omitempty" gorm:"-"`    // 前端表格显示的集群名称
+	CreateUserName string `json:"createUserName,omitempty" gorm:"-"` // 前端表格显示的创建者用户名
+}
+
+// 单元测试关联的规则类型
+const (
+	RuleTypeAlert  = "alert"  // 告警规则
+	RuleTypeRecord = "record" // 预聚合规则
+)
+
+// MonitorRuleTest 告警规则或预聚合规则的单元测试用例，写法与 promtool test rules 一致
+type MonitorRuleTest struct {
+	Model
+	RuleType    string           `json:"ruleType" binding:"required,oneof=alert record" gorm:"uniqueIndex:udx_name;size:50;comment:规则类型：alert告警规则，record预聚合规则"` // 规则类型：alert告警规则，record预聚合规则
+	RuleID      int              `json:"ruleId" binding:"required" gorm:"uniqueIndex:udx_name;comment:关联的规则ID"`                                                 // 关联的规则ID
+	Name        string           `json:"name" binding:"required,min=1,max=50" gorm:"uniqueIndex:udx_name;size:100;comment:测试用例名称"`                              // 测试用例名称
+	UserID      int              `json:"userId" gorm:"comment:创建该测试用例的用户ID"`                                                                                    // 创建该测试用例的用户ID
+	Interval    string           `json:"interval,omitempty" gorm:"size:50;comment:输入序列的采样间隔，同时作为规则的评估间隔，默认1m"`                                                  // 输入序列的采样间隔，同时作为规则的评估间隔，默认1m
+	InputSeries []RuleTestSeries `json:"inputSeries" binding:"required,min=1,dive" gorm:"type:text;serializer:json;comment:输入序列"`                               // 输入序列
+	EvalTime    string           `json:"evalTime" binding:"required" gorm:"size:50;comment:评估时间，相对于输入序列的0时刻，如 5m"`                                              // 评估时间，相对于输入序列的0时刻，如 5m
+	Expr        string           `json:"expr,omitempty" gorm:"type:text;comment:预聚合规则测试的查询表达式，为空时查询记录的指标"`                                                      // 预聚合规则测试的查询表达式，为空时查询记录的指标
+	ExpAlerts   []RuleTestAlert  `json:"expAlerts,omitempty" gorm:"type:text;serializer:json;comment:告警规则测试期望在评估时间触发的告警"`                                       // 告警规则测试期望在评估时间触发的告警
+	ExpSamples  []RuleTestSample `json:"expSamples,omitempty" binding:"dive" gorm:"type:text;serializer:json;comment:预聚合规则测试期望查询到的样本"`                          // 预聚合规则测试期望查询到的样本
+	LastRunAt   int64            `json:"lastRunAt" gorm:"comment:最近一次运行的时间"`                                                                                    // 最近一次运行的时间
+	LastPassed  bool             `json:"lastPassed" gorm:"comment:最近一次运行是否通过"`                                                                                  // 最近一次运行是否通过
+
+	// 前端使用字段
+	CreateUserName string `json:"createUserName,omitempty" gorm:"-"` // 前端表格显示的创建者用户名
+}
+
+// RuleTestSeries 单元测试的输入序列，使用 promtool 的序列写法
+type RuleTestSeries struct {
+	Series string `json:"series" binding:"required"` // 序列标签，如 up{job="node", instance="a"}
+	Values string `json:"values" binding:"required"` // 序列取值，如 1 1 0x5 _ stale
+}
+
+// RuleTestAlert 期望触发的告警，标签需包含序列本身的标签和规则附加的标签，alertname 无需填写
+type RuleTestAlert struct {
+	ExpLabels      map[string]string `json:"expLabels"`      // 期望的告警标签
+	ExpAnnotations map[string]string `json:"expAnnotations"` // 期望的告警注解
+}
+
+// RuleTestSample 期望查询到的样本
+type RuleTestSample struct {
+	Labels string  `json:"labels" binding:"required"` // 样本标签，使用序列写法，如 job:up:sum{job="node"}
+	Value  float64 `json:"value"`                     // 样本值
+}
+
+// RuleTestListReq 查询规则单元测试用例的请求
+type RuleTestListReq struct {
+	RuleType string `form:"ruleType" binding:"required,oneof=alert record"` // 规则类型
+	RuleID   int    `form:"ruleId" binding:"required"`                      // 规则ID
+}
+
+// RuleTestRunReq 运行规则单元测试的请求
+type RuleTestRunReq struct {
+	RuleType string `json:"ruleType" binding:"required,oneof=alert record"` // 规则类型
+	RuleID   int    `json:"ruleId" binding:"required"`                      // 规则ID
+	IDs      []int  `json:"ids"`                                            // 要运行的测试用例ID，为空表示运行规则的所有用例
+}
+
+// RuleTestRunResp 规则单元测试的运行结果
+type RuleTestRunResp struct {
+	Passed  bool              `json:"passed"`  // 是否全部通过
+	Results []*RuleTestResult `json:"results"` // 每个测试用例的结果
+}
+
+// RuleTestResult 单个测试用例的运行结果
+type RuleTestResult struct {
+	TestID   int    `json:"testId"`          // 测试用例ID
+	Name     string `json:"name"`            // 测试用例名称
+	Passed   bool   `json:"passed"`          // 是否通过
+	Error    string `json:"error,omitempty"` // 用例无法运行时的错误信息
+	Expected string `json:"expected"`        // 期望的结果
+	Got      string `json:"got"`             // 实际的结果
+	Diff     string `json:"diff,omitempty"`  // 期望与实际结果的差异，unified 格式
+}
+
 type AlertEventSilenceRequest struct {
 	UseName bool   `json:"useName"` // 是否启用名称静默
 	Time    string `json:"time"`
@@ -0,0 +1,626 @@
+package agent
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 实例角色，决定拉取哪些配置
+const (
+	RolePrometheus   = "prometheus"
+	RoleAlertManager = "alertmanager"
+)
+
+// 配置类型，与服务端 /api/monitor/prometheus_configs 下的拉取接口一一对应
+const (
+	configTypePrometheus        = "prometheus"
+	configTypePrometheusAlert   = "prometheus_alert"
+	configTypePrometheusRecord  = "prometheus_record"
+	configTypeAlertManager      = "alertManager"
+	configTypePrometheusFiles   = "prometheus_files"
+	configTypeAlertManagerFiles = "alertManager_files"
+)
+
+const (
+	configsPath     = "/api/monitor/prometheus_configs"
+	tokenHeader     = "X-Agent-Token"
+	requestTimeout  = 30 * time.Second
+	defaultInterval = 15 * time.Second
+)
+
+// errNoConfig 服务端没有为该实例生成此类配置
+var errNoConfig = errors.New("服务端没有可用的配置")
+
+type Config struct {
+	Server         string        // 平台地址，例如 http://127.0.0.1:8888
+	IP             string        // 实例IP，与 Token 都为空时自动探测本机IP
+	Token          string        // 实例Token，对应服务端 prometheus.agent_tokens 中的配置
+	Role           string        // 实例角色：prometheus 或 alertmanager
+	Interval       time.Duration // 拉取间隔
+	MainConfigPath string        // 主配置文件路径
+	RuleFilePath   string        // 告警规则文件路径，为空时从主配置的 rule_files 中获取
+	RecordFilePath string        // 预聚合规则文件路径，为空时从主配置的 rule_files 中获取
+	ReloadURL      string        // 本地实例的重载地址
+	FilesDir       string        // 平台管理文件的写入目录，需与服务端生成配置时使用的目录一致
+}
+
+// NewConfigFromViper 从 agent 配置段读取配置并补充默认值
+func NewConfigFromViper() Config {
+	cfg := Config{
+		Server:         strings.TrimRight(viper.GetString("agent.server"), "/"),
+		IP:             viper.GetString("agent.ip"),
+		Token:          viper.GetString("agent.token"),
+		Role:           viper.GetString("agent.role"),
+		Interval:       time.Duration(viper.GetInt("agent.interval_seconds")) * time.Second,
+		MainConfigPath: viper.GetString("agent.main_config_path"),
+		RuleFilePath:   viper.GetString("agent.rule_file_path"),
+		RecordFilePath: viper.GetString("agent.record_file_path"),
+		ReloadURL:      viper.GetString("agent.reload_url"),
+		FilesDir:       viper.GetString("agent.files_dir"),
+	}
+
+	if cfg.Role == "" {
+		cfg.Role = RolePrometheus
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultInterval
+	}
+	if cfg.ReloadURL == "" {
+		cfg.ReloadURL = "http://127.0.0.1:9090/-/reload"
+		if cfg.Role == RoleAlertManager {
+			cfg.ReloadURL = "http://127.0.0.1:9093/-/reload"
+		}
+	}
+	if cfg.FilesDir == "" {
+		cfg.FilesDir = "/etc/prometheus/platform_files"
+		if cfg.Role == RoleAlertManager {
+			cfg.FilesDir = "/etc/alertmanager/platform_files"
+		}
+	}
+
+	return cfg
+}
+
+// configFile 一类配置及其写入路径
+type configFile struct {
+	configType string
+	path       string
+	content    []byte
+}
+
+// platformFile 配置引用的平台管理文件，例如 file_sd 的目标文件、第三方 Webhook 地址和 SMTP 密码文件
+type platformFile struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// apiResponse 服务端的通用响应，拉取不到配置时返回
+type apiResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type appliedItem struct {
+	ConfigType string `json:"configType"`
+	Hash       string `json:"hash"`
+}
+
+type reportRequest struct {
+	IP      string        `json:"ip"`
+	Token   string        `json:"token"`
+	Items   []appliedItem `json:"items"`
+	Success bool          `json:"success"`
+	Error   string        `json:"error"`
+}
+
+type Agent struct {
+	cfg          Config
+	l            *zap.Logger
+	client       *http.Client
+	lastReported string // 最近一次成功上报内容的摘要，未变化时不重复上报
+	applied      string // 最近一次重载成功时各配置哈希的摘要，与已写入的配置不一致时需要重载
+	fetched      map[string]fetchedConfig
+}
+
+// fetchedConfig 最近一次拉取到的配置及其 ETag，用于条件请求
+type fetchedConfig struct {
+	etag    string
+	content []byte
+}
+
+func NewAgent(cfg Config, l *zap.Logger) (*Agent, error) {
+	if cfg.Server == "" {
+		return nil, errors.New("agent.server 不能为空")
+	}
+	if cfg.MainConfigPath == "" {
+		return nil, errors.New("agent.main_config_path 不能为空")
+	}
+	if cfg.Role != RolePrometheus && cfg.Role != RoleAlertManager {
+		return nil, fmt.Errorf("不支持的实例角色: %s", cfg.Role)
+	}
+
+	if cfg.IP == "" && cfg.Token == "" {
+		ip, err := detectIP(cfg.Server)
+		if err != nil {
+			return nil, fmt.Errorf("探测本机IP失败: %w", err)
+		}
+		cfg.IP = ip
+	}
+	if cfg.Token == "" {
+		l.Warn("未配置 agent.token，不会向平台上报已应用的配置")
+	}
+
+	return &Agent{
+		cfg:     cfg,
+		l:       l,
+		client:  &http.Client{Timeout: requestTimeout},
+		fetched: make(map[string]fetchedConfig),
+	}, nil
+}
+
+// Run 按间隔同步配置，直到 ctx 结束
+func (a *Agent) Run(ctx context.Context) error {
+	a.l.Info("config-agent 启动",
+		zap.String("平台地址", a.cfg.Server),
+		zap.String("IP", a.cfg.IP),
+		zap.String("角色", a.cfg.Role),
+		zap.Duration("间隔", a.cfg.Interval),
+	)
+
+	ticker := time.NewTicker(a.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := a.SyncOnce(ctx); err != nil {
+			a.l.Error("同步配置失败", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			a.l.Info("config-agent 退出")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// SyncOnce 拉取配置，内容变化时原子写入文件，已写入的配置与最近一次成功重载的不一致时重载本地实例，然后上报已应用的版本
+// 重载失败时文件已经写入，下个周期会继续重载，直到成功前都上报失败
+func (a *Agent) SyncOnce(ctx context.Context) error {
+	files, err := a.fetchConfigs(ctx)
+	if err != nil {
+		return err
+	}
+
+	// 平台管理文件先于引用它们的配置写入，文件变化时实例会自动重新读取，不参与重载判断和上报
+	platformFiles, err := a.fetchPlatformFiles(ctx)
+	if err != nil {
+		return err
+	}
+	for _, file := range platformFiles {
+		written, err := writeIfChanged(file.path, file.content, platformFilePerm(file.configType))
+		if err != nil {
+			return a.report(ctx, files, fmt.Errorf("写入 %s 失败: %w", file.path, err))
+		}
+		if written {
+			a.l.Info("平台管理文件已更新", zap.String("文件路径", file.path))
+		}
+	}
+
+	for _, file := range files {
+		written, err := writeIfChanged(file.path, file.content, 0644)
+		if err != nil {
+			return a.report(ctx, files, fmt.Errorf("写入 %s 失败: %w", file.path, err))
+		}
+		if written {
+			a.l.Info("配置已更新", zap.String("配置类型", file.configType), zap.String("文件路径", file.path))
+		}
+	}
+
+	digest := filesDigest(files)
+	if digest == a.applied {
+		return a.report(ctx, files, nil)
+	}
+
+	applyErr := a.reload(ctx)
+	if applyErr == nil {
+		a.applied = digest
+		a.l.Info("本地实例重载成功", zap.String("重载地址", a.cfg.ReloadURL))
+	}
+
+	return a.report(ctx, files, applyErr)
+}
+
+// fetchConfigs 拉取实例角色对应的所有配置，并确定每类配置的写入路径
+func (a *Agent) fetchConfigs(ctx context.Context) ([]configFile, error) {
+	if a.cfg.Role == RoleAlertManager {
+		content, err := a.fetch(ctx, configTypeAlertManager)
+		if err != nil {
+			return nil, err
+		}
+		return []configFile{{configType: configTypeAlertManager, path: a.cfg.MainConfigPath, content: content}}, nil
+	}
+
+	mainConfig, err := a.fetch(ctx, configTypePrometheus)
+	if err != nil {
+		return nil, err
+	}
+	files := []configFile{{configType: configTypePrometheus, path: a.cfg.MainConfigPath, content: mainConfig}}
+
+	alertRules, err := a.fetchOptional(ctx, configTypePrometheusAlert)
+	if err != nil {
+		return nil, err
+	}
+	recordRules, err := a.fetchOptional(ctx, configTypePrometheusRecord)
+	if err != nil {
+		return nil, err
+	}
+
+	rulePath, recordPath, err := a.ruleFilePaths(mainConfig, alertRules != nil, recordRules != nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if alertRules != nil {
+		files = append(files, configFile{configType: configTypePrometheusAlert, path: rulePath, content: alertRules})
+	}
+	if recordRules != nil {
+		files = append(files, configFile{configType: configTypePrometheusRecord, path: recordPath, content: recordRules})
+	}
+
+	return files, nil
+}
+
+// fetchPlatformFiles 拉取配置引用的平台管理文件，文件路径必须位于 FilesDir 下
+// 旧的文件不会被删除，不再被配置引用后不影响实例
+func (a *Agent) fetchPlatformFiles(ctx context.Context) ([]configFile, error) {
+	configType := configTypePrometheusFiles
+	if a.cfg.Role == RoleAlertManager {
+		configType = configTypeAlertManagerFiles
+	}
+
+	content, err := a.fetchOptional(ctx, configType)
+	if err != nil || content == nil {
+		return nil, err
+	}
+
+	var list []platformFile
+	if err := json.Unmarshal(content, &list); err != nil {
+		return nil, fmt.Errorf("解析平台管理文件失败: %w", err)
+	}
+
+	files := make([]configFile, 0, len(list))
+	for _, item := range list {
+		rel, err := filepath.Rel(a.cfg.FilesDir, item.Path)
+		if !filepath.IsAbs(item.Path) || err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("平台管理文件 %s 不在 %s 下，请确认服务端的文件目录与 agent.files_dir 一致", item.Path, a.cfg.FilesDir)
+		}
+		files = append(files, configFile{configType: configType, path: item.Path, content: []byte(item.Content)})
+	}
+
+	return files, nil
+}
+
+// ruleFilePaths 确定规则文件的写入路径
+// 未单独配置时从主配置的 rule_files 中获取，服务端按告警规则、预聚合规则的顺序生成 rule_files
+func (a *Agent) ruleFilePaths(mainConfig []byte, hasAlert, hasRecord bool) (string, string, error) {
+	rulePath, recordPath := a.cfg.RuleFilePath, a.cfg.RecordFilePath
+	if (!hasAlert || rulePath != "") && (!hasRecord || recordPath != "") {
+		return rulePath, recordPath, nil
+	}
+
+	var promConfig struct {
+		RuleFiles []string `yaml:"rule_files"`
+	}
+	if err := yaml.Unmarshal(mainConfig, &promConfig); err != nil {
+		return "", "", fmt.Errorf("解析主配置的 rule_files 失败: %w", err)
+	}
+
+	next := func() (string, error) {
+		if len(promConfig.RuleFiles) == 0 {
+			return "", errors.New("主配置的 rule_files 中缺少规则文件路径，请配置 agent.rule_file_path/agent.record_file_path")
+		}
+		path := promConfig.RuleFiles[0]
+		promConfig.RuleFiles = promConfig.RuleFiles[1:]
+		if strings.ContainsAny(path, "*?[") {
+			return "", fmt.Errorf("rule_files 中的 %s 是通配符路径，请单独配置规则文件路径", path)
+		}
+		// 相对路径相对于主配置文件所在目录，与 Prometheus 的处理方式一致
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(a.cfg.MainConfigPath), path)
+		}
+		return path, nil
+	}
+
+	var err error
+	if hasAlert {
+		path, nextErr := next()
+		if rulePath == "" {
+			rulePath, err = path, nextErr
+		}
+	}
+	if err == nil && hasRecord && recordPath == "" {
+		recordPath, err = next()
+	}
+
+	return rulePath, recordPath, err
+}
+
+func (a *Agent) fetchOptional(ctx context.Context, configType string) ([]byte, error) {
+	content, err := a.fetch(ctx, configType)
+	if errors.Is(err, errNoConfig) {
+		return nil, nil
+	}
+
+	return content, err
+}
+
+func (a *Agent) fetch(ctx context.Context, configType string) ([]byte, error) {
+	query := url.Values{}
+	if a.cfg.IP != "" {
+		query.Set("ip", a.cfg.IP)
+	}
+	reqURL := fmt.Sprintf("%s%s/%s?%s", a.cfg.Server, configsPath, configType, query.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if a.cfg.Token != "" {
+		req.Header.Set(tokenHeader, a.cfg.Token)
+	}
+	cached, hasCached := a.fetched[configType]
+	if hasCached {
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("拉取 %s 配置失败: %w", configType, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 配置失败: %w", configType, err)
+	}
+
+	// 配置未变化，沿用上次拉取的内容
+	if resp.StatusCode == http.StatusNotModified && hasCached {
+		return cached.content, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("拉取 %s 配置返回状态码 %d", configType, resp.StatusCode)
+	}
+
+	// 成功时服务端直接返回配置文本，失败时返回 JSON 格式的通用响应
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		var apiResp apiResponse
+		if err := json.Unmarshal(body, &apiResp); err != nil {
+			return nil, fmt.Errorf("解析 %s 配置响应失败: %w", configType, err)
+		}
+		delete(a.fetched, configType)
+		return nil, fmt.Errorf("%w: %s %s", errNoConfig, configType, apiResp.Message)
+	}
+
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		a.fetched[configType] = fetchedConfig{etag: etag, content: body}
+	}
+
+	return body, nil
+}
+
+func (a *Agent) reload(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.cfg.ReloadURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("重载本地实例失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("重载本地实例返回状态码 %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
+
+// report 向服务端上报已应用的配置，内容与上次成功上报相同时跳过
+// 服务端通过 Token 确认实例身份，未配置 Token 时不上报
+func (a *Agent) report(ctx context.Context, files []configFile, applyErr error) error {
+	if a.cfg.Token == "" {
+		return applyErr
+	}
+
+	reportReq := reportRequest{
+		IP:      a.cfg.IP,
+		Token:   a.cfg.Token,
+		Success: applyErr == nil,
+	}
+	if applyErr != nil {
+		reportReq.Error = applyErr.Error()
+	}
+	for _, file := range files {
+		reportReq.Items = append(reportReq.Items, appliedItem{ConfigType: file.configType, Hash: hashContent(file.content)})
+	}
+	sort.Slice(reportReq.Items, func(i, j int) bool {
+		return reportReq.Items[i].ConfigType < reportReq.Items[j].ConfigType
+	})
+
+	body, err := json.Marshal(reportReq)
+	if err != nil {
+		return err
+	}
+
+	digest := hashContent(body)
+	if digest == a.lastReported {
+		return applyErr
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.cfg.Server+configsPath+"/report", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(tokenHeader, a.cfg.Token)
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return errors.Join(applyErr, fmt.Errorf("上报已应用配置失败: %w", err))
+	}
+	defer resp.Body.Close()
+
+	var apiResp apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return errors.Join(applyErr, fmt.Errorf("解析上报响应失败: %w", err))
+	}
+	if apiResp.Code != 0 {
+		return errors.Join(applyErr, fmt.Errorf("上报已应用配置失败: %s", apiResp.Message))
+	}
+
+	a.lastReported = digest
+	return applyErr
+}
+
+// platformFilePerm 平台管理文件的权限，AlertManager 的文件包含 SMTP 密码等敏感信息，只允许属主读取
+func platformFilePerm(configType string) os.FileMode {
+	if configType == configTypeAlertManagerFiles {
+		return 0600
+	}
+
+	return 0644
+}
+
+// writeIfChanged 内容与现有文件不同时原子写入，返回是否写入
+func writeIfChanged(path string, content []byte, perm os.FileMode) (bool, error) {
+	existing, err := os.ReadFile(path)
+	if err == nil && bytes.Equal(existing, content) {
+		return false, nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+
+	return true, writeFileAtomic(path, content, perm)
+}
+
+// writeFileAtomic 先写入同目录下的临时文件再重命名，避免实例读取到写了一半的配置
+func writeFileAtomic(path string, content []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+// filesDigest 计算各配置的类型、路径与内容哈希的摘要，用于判断已写入的配置是否已经重载
+func filesDigest(files []configFile) string {
+	parts := make([]string, 0, len(files))
+	for _, file := range files {
+		parts = append(parts, file.configType+"|"+file.path+"|"+hashContent(file.content))
+	}
+	sort.Strings(parts)
+
+	return hashContent([]byte(strings.Join(parts, "\n")))
+}
+
+// hashContent 与服务端配置版本使用相同的 SHA256 哈希
+func hashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// detectIP 通过连接平台地址获取本机出口IP
+func detectIP(server string) (string, error) {
+	u, err := url.Parse(server)
+	if err != nil {
+		return "", err
+	}
+
+	host := u.Host
+	if u.Port() == "" {
+		port := "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	conn, err := net.Dial("udp", host)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	return conn.LocalAddr().(*net.UDPAddr).IP.String(), nil
+}
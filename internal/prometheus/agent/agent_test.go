@@ -0,0 +1,267 @@
+package agent
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// fakeServer 模拟平台的配置接口和本地实例的重载接口
+type fakeServer struct {
+	mu          sync.Mutex
+	config      string
+	files       string // 平台管理文件列表，为空时返回没有配置
+	reloadFails int    // 前几次重载返回失败
+	reloads     int
+	reports     []reportRequest
+}
+
+func (f *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.URL.Path {
+	case configsPath + "/" + configTypeAlertManager, configsPath + "/" + configTypePrometheus:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte(f.config))
+	case configsPath + "/" + configTypePrometheusFiles, configsPath + "/" + configTypeAlertManagerFiles:
+		if f.files == "" {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"code":1,"message":"没有配置"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte(f.files))
+	case configsPath + "/" + configTypePrometheusAlert, configsPath + "/" + configTypePrometheusRecord:
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":1,"message":"没有配置"}`))
+	case configsPath + "/report":
+		var req reportRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		req.Token = r.Header.Get(tokenHeader)
+		f.reports = append(f.reports, req)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":0,"message":"ok"}`))
+	case "/-/reload":
+		f.reloads++
+		if f.reloads <= f.reloadFails {
+			http.Error(w, "reload failed", http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newTestAgent(t *testing.T, fake *fakeServer) (*Agent, string) {
+	t.Helper()
+
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	path := filepath.Join(t.TempDir(), "alertmanager.yml")
+	a, err := NewAgent(Config{
+		Server:         srv.URL,
+		Token:          "token-a",
+		Role:           RoleAlertManager,
+		Interval:       time.Second,
+		MainConfigPath: path,
+		ReloadURL:      srv.URL + "/-/reload",
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewAgent: %v", err)
+	}
+
+	return a, path
+}
+
+func TestSyncOnceRetriesFailedReload(t *testing.T) {
+	fake := &fakeServer{config: "route:\n  receiver: a\n", reloadFails: 1}
+	a, path := newTestAgent(t, fake)
+	ctx := context.Background()
+
+	if err := a.SyncOnce(ctx); err == nil {
+		t.Fatal("第一次重载失败时 SyncOnce 应该返回错误")
+	}
+	if content, _ := os.ReadFile(path); string(content) != fake.config {
+		t.Fatalf("配置文件内容 = %q, 期望 %q", content, fake.config)
+	}
+
+	// 文件已经写入，第二次同步内容未变化，仍然需要重载
+	if err := a.SyncOnce(ctx); err != nil {
+		t.Fatalf("第二次同步: %v", err)
+	}
+	// 重载成功后不再重复重载
+	if err := a.SyncOnce(ctx); err != nil {
+		t.Fatalf("第三次同步: %v", err)
+	}
+
+	if fake.reloads != 2 {
+		t.Errorf("重载次数 = %d, 期望 2", fake.reloads)
+	}
+	if len(fake.reports) != 2 {
+		t.Fatalf("上报次数 = %d, 期望 2", len(fake.reports))
+	}
+	if fake.reports[0].Success || fake.reports[0].Error == "" {
+		t.Errorf("重载失败时应上报失败: %+v", fake.reports[0])
+	}
+	if !fake.reports[1].Success {
+		t.Errorf("重载成功后应上报成功: %+v", fake.reports[1])
+	}
+	for _, report := range fake.reports {
+		if report.Token != "token-a" {
+			t.Errorf("上报请求头的 Token = %q, 期望 token-a", report.Token)
+		}
+	}
+}
+
+func TestSyncOnceReloadsOnlyWhenChanged(t *testing.T) {
+	fake := &fakeServer{config: "route:\n  receiver: a\n"}
+	a, _ := newTestAgent(t, fake)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if err := a.SyncOnce(ctx); err != nil {
+			t.Fatalf("同步: %v", err)
+		}
+	}
+	if fake.reloads != 1 {
+		t.Fatalf("配置未变化时重载次数 = %d, 期望 1", fake.reloads)
+	}
+
+	fake.mu.Lock()
+	fake.config = "route:\n  receiver: b\n"
+	fake.mu.Unlock()
+
+	if err := a.SyncOnce(ctx); err != nil {
+		t.Fatalf("同步: %v", err)
+	}
+	if fake.reloads != 2 {
+		t.Errorf("配置变化后重载次数 = %d, 期望 2", fake.reloads)
+	}
+}
+
+func TestReportSkippedWithoutToken(t *testing.T) {
+	fake := &fakeServer{config: "route:\n  receiver: a\n"}
+	a, _ := newTestAgent(t, fake)
+	a.cfg.Token = ""
+	a.cfg.IP = "127.0.0.1"
+
+	if err := a.SyncOnce(context.Background()); err != nil {
+		t.Fatalf("同步: %v", err)
+	}
+	if len(fake.reports) != 0 {
+		t.Errorf("未配置 Token 时不应上报，实际上报 %d 次", len(fake.reports))
+	}
+}
+
+func TestSyncOnceWritesPlatformFiles(t *testing.T) {
+	fake := &fakeServer{config: "global:\n  scrape_interval: 15s\n"}
+	a, _ := newTestAgent(t, fake)
+	a.cfg.Role = RolePrometheus
+	a.cfg.FilesDir = t.TempDir()
+	ctx := context.Background()
+
+	targetPath := filepath.Join(a.cfg.FilesDir, "file_sd_1.json")
+	setFiles := func(files []platformFile) {
+		data, _ := json.Marshal(files)
+		fake.mu.Lock()
+		fake.files = string(data)
+		fake.mu.Unlock()
+	}
+
+	setFiles([]platformFile{{Path: targetPath, Content: `[{"targets":["10.0.0.1:9100"]}]`}})
+	if err := a.SyncOnce(ctx); err != nil {
+		t.Fatalf("同步: %v", err)
+	}
+	if content, _ := os.ReadFile(targetPath); string(content) != `[{"targets":["10.0.0.1:9100"]}]` {
+		t.Fatalf("目标文件内容 = %q", content)
+	}
+
+	// 只有目标文件变化时 Prometheus 会自动重新读取，不需要重载，也不上报
+	setFiles([]platformFile{{Path: targetPath, Content: `[{"targets":["10.0.0.2:9100"]}]`}})
+	if err := a.SyncOnce(ctx); err != nil {
+		t.Fatalf("同步: %v", err)
+	}
+	if content, _ := os.ReadFile(targetPath); string(content) != `[{"targets":["10.0.0.2:9100"]}]` {
+		t.Errorf("目标文件未更新: %q", content)
+	}
+	if fake.reloads != 1 {
+		t.Errorf("重载次数 = %d, 期望 1", fake.reloads)
+	}
+	for _, report := range fake.reports {
+		for _, item := range report.Items {
+			if item.ConfigType == configTypePrometheusFiles {
+				t.Errorf("平台管理文件不应上报: %+v", report)
+			}
+		}
+	}
+
+	// 不在 files_dir 下的路径不写入
+	outside := filepath.Join(filepath.Dir(a.cfg.FilesDir), "outside.json")
+	setFiles([]platformFile{{Path: filepath.Join(a.cfg.FilesDir, "..", "outside.json"), Content: "[]"}})
+	if err := a.SyncOnce(ctx); err == nil {
+		t.Error("路径不在 files_dir 下时 SyncOnce 应该返回错误")
+	}
+	if _, err := os.Stat(outside); !os.IsNotExist(err) {
+		t.Errorf("不应写入 %s", outside)
+	}
+}
+
+func TestSyncOnceWritesAlertManagerFilesPrivately(t *testing.T) {
+	fake := &fakeServer{config: "route:\n  receiver: a\n"}
+	a, _ := newTestAgent(t, fake)
+	a.cfg.FilesDir = t.TempDir()
+
+	passwordPath := filepath.Join(a.cfg.FilesDir, "smtp_password_1.txt")
+	data, _ := json.Marshal([]platformFile{{Path: passwordPath, Content: "smtp-secret"}})
+	fake.files = string(data)
+
+	if err := a.SyncOnce(context.Background()); err != nil {
+		t.Fatalf("同步: %v", err)
+	}
+
+	info, err := os.Stat(passwordPath)
+	if err != nil {
+		t.Fatalf("SMTP 密码文件未写入: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("SMTP 密码文件权限 = %o, 期望 600", perm)
+	}
+	if content, _ := os.ReadFile(passwordPath); string(content) != "smtp-secret" {
+		t.Errorf("SMTP 密码文件内容 = %q", content)
+	}
+}
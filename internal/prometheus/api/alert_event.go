@@ -92,7 +92,7 @@ func (a *AlertEventHandler) EventAlertSilence(ctx *gin.Context) {
 	}
 
 	if err := a.alertEventService.EventAlertSilence(ctx, intId, &silence, uc.Uid); err != nil {
-		apiresponse.ErrorWithMessage(ctx, "服务器内部错误")
+		apiresponse.ErrorWithMessage(ctx, err.Error())
 		return
 	}
 
@@ -120,8 +120,6 @@ func (a *AlertEventHandler) EventAlertClaim(ctx *gin.Context) {
 
 // EventAlertUnSilence 取消指定告警事件的静默状态
 func (a *AlertEventHandler) EventAlertUnSilence(ctx *gin.Context) {
-	uc := ctx.MustGet("user").(ijwt.UserClaims)
-
 	id := ctx.Param("id")
 	intId, err := strconv.Atoi(id)
 	if err != nil {
@@ -129,8 +127,8 @@ func (a *AlertEventHandler) EventAlertUnSilence(ctx *gin.Context) {
 		return
 	}
 
-	if err := a.alertEventService.EventAlertClaim(ctx, intId, uc.Uid); err != nil {
-		apiresponse.ErrorWithMessage(ctx, "服务器内部错误")
+	if err := a.alertEventService.EventAlertUnSilence(ctx, intId); err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
 		return
 	}
 
@@ -149,7 +147,7 @@ func (a *AlertEventHandler) BatchEventAlertSilence(ctx *gin.Context) {
 	}
 
 	if err := a.alertEventService.BatchEventAlertSilence(ctx, &req, uc.Uid); err != nil {
-		apiresponse.ErrorWithMessage(ctx, "服务器内部错误")
+		apiresponse.ErrorWithMessage(ctx, err.Error())
 		return
 	}
 
@@ -77,7 +77,7 @@ func (a *AlertRuleHandler) CreateMonitorAlertRule(ctx *gin.Context) {
 	alertRule.UserID = uc.Uid
 
 	if err := a.alertRuleService.CreateMonitorAlertRule(ctx, &alertRule); err != nil {
-		apiresponse.ErrorWithMessage(ctx, "服务器内部错误")
+		apiresponse.ErrorWithMessage(ctx, err.Error())
 		return
 	}
 
@@ -94,7 +94,7 @@ func (a *AlertRuleHandler) UpdateMonitorAlertRule(ctx *gin.Context) {
 	}
 
 	if err := a.alertRuleService.UpdateMonitorAlertRule(ctx, &alertRule); err != nil {
-		apiresponse.ErrorWithMessage(ctx, "服务器内部错误")
+		apiresponse.ErrorWithMessage(ctx, err.Error())
 		return
 	}
 
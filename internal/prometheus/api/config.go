@@ -26,13 +26,22 @@ package api
  */
 
 import (
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/cache"
 	yamlService "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/service/yaml"
 	"github.com/GoSimplicity/AI-CloudOps/pkg/utils/apiresponse"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// maxConfigWait 配置长轮询的最长等待时间
+const maxConfigWait = 5 * time.Minute
+
 type ConfigYamlHandler struct {
 	yamlService yamlService.ConfigYamlService
 	l           *zap.Logger
@@ -53,57 +62,161 @@ func (c *ConfigYamlHandler) RegisterRouters(server *gin.Engine) {
 		prometheusConfigs.GET("/prometheus", c.GetMonitorPrometheusYaml)                // 获取单个 Prometheus 配置文件
 		prometheusConfigs.GET("/prometheus_alert", c.GetMonitorPrometheusAlertRuleYaml) // 获取单个 Prometheus 告警配置文件
 		prometheusConfigs.GET("/prometheus_record", c.GetMonitorPrometheusRecordYaml)   // 获取单个 Prometheus 记录配置文件
+		prometheusConfigs.GET("/prometheus_files", c.GetMonitorPrometheusFiles)         // 获取 Prometheus 配置引用的平台管理文件
 		prometheusConfigs.GET("/alertManager", c.GetMonitorAlertManagerYaml)            // 获取单个 AlertManager 配置文件
+		prometheusConfigs.GET("/alertManager_files", c.GetMonitorAlertManagerFiles)     // 获取 AlertManager 配置引用的平台管理文件
+		prometheusConfigs.GET("/errors", c.GetConfigErrors)                             // 获取各池配置的校验错误
 	}
 }
 
 // GetMonitorPrometheusYaml 获取单个 Prometheus 配置文件
 func (c *ConfigYamlHandler) GetMonitorPrometheusYaml(ctx *gin.Context) {
-	ip := ctx.Query("ip")
+	c.serveConfig(ctx, cache.ConfigTypePrometheus, "获取 Prometheus 配置文件失败")
+}
 
-	yaml := c.yamlService.GetMonitorPrometheusYaml(ctx, ip)
-	if yaml == "" {
-		apiresponse.ErrorWithMessage(ctx, "获取 Prometheus 配置文件失败")
+// GetMonitorPrometheusAlertRuleYaml 获取单个 Prometheus 告警配置规则文件
+func (c *ConfigYamlHandler) GetMonitorPrometheusAlertRuleYaml(ctx *gin.Context) {
+	c.serveConfig(ctx, cache.ConfigTypePrometheusAlert, "获取 Prometheus 告警配置文件失败")
+}
+
+// GetMonitorPrometheusRecordYaml 获取单个 Prometheus 记录配置文件
+func (c *ConfigYamlHandler) GetMonitorPrometheusRecordYaml(ctx *gin.Context) {
+	c.serveConfig(ctx, cache.ConfigTypePrometheusRecord, "获取 Prometheus 记录配置文件失败")
+}
+
+// GetMonitorPrometheusFiles 获取 Prometheus 配置引用的平台管理文件，例如 file_sd 的目标文件
+func (c *ConfigYamlHandler) GetMonitorPrometheusFiles(ctx *gin.Context) {
+	c.serveConfig(ctx, cache.ConfigTypePrometheusFiles, "获取 Prometheus 平台管理文件失败")
+}
+
+// GetMonitorAlertManagerYaml 获取单个 AlertManager 配置文件
+func (c *ConfigYamlHandler) GetMonitorAlertManagerYaml(ctx *gin.Context) {
+	c.serveConfig(ctx, cache.ConfigTypeAlertManager, "获取 AlertManager 配置文件失败")
+}
+
+// GetMonitorAlertManagerFiles 获取 AlertManager 配置引用的平台管理文件，例如第三方 Webhook 地址和 SMTP 密码文件
+func (c *ConfigYamlHandler) GetMonitorAlertManagerFiles(ctx *gin.Context) {
+	// 文件中包含 SMTP 密码等敏感信息，只允许携带实例 Token 的 config-agent 拉取
+	if ctx.GetHeader("X-Agent-Token") == "" && ctx.Query("token") == "" {
+		apiresponse.ErrorWithMessage(ctx, "拉取 AlertManager 平台管理文件必须携带实例Token")
 		return
 	}
 
-	ctx.String(http.StatusOK, yaml)
+	c.serveConfig(ctx, cache.ConfigTypeAlertManagerFiles, "获取 AlertManager 平台管理文件失败")
 }
 
-// GetMonitorPrometheusAlertRuleYaml 获取单个 Prometheus 告警配置规则文件
-func (c *ConfigYamlHandler) GetMonitorPrometheusAlertRuleYaml(ctx *gin.Context) {
-	ip := ctx.Query("ip")
+// serveConfig 返回实例的配置文件，支持 If-None-Match 条件请求和 wait 参数长轮询
+// 配置未变化时返回 304，指定 wait 时会等待配置变化或超时后再返回
+func (c *ConfigYamlHandler) serveConfig(ctx *gin.Context, configType, errMsg string) {
+	ip, ok := c.instanceIP(ctx)
+	if !ok {
+		return
+	}
 
-	yaml := c.yamlService.GetMonitorPrometheusAlertRuleYaml(ctx, ip)
+	wait, err := parseWait(ctx.Query("wait"))
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, "参数错误: wait 格式不正确")
+		return
+	}
+
+	etags := parseETags(ctx.GetHeader("If-None-Match"))
+	// 长轮询只针对客户端当前持有的版本，"*" 没有具体版本，不做等待
+	waitHash := ""
+	if len(etags) > 0 && etags[0] != "*" {
+		waitHash = etags[0]
+	}
+
+	yaml, hash, err := c.yamlService.GetMonitorConfig(ctx.Request.Context(), configType, ip, waitHash, wait)
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
 	if yaml == "" {
-		apiresponse.ErrorWithMessage(ctx, "获取 Prometheus 告警配置文件失败")
+		apiresponse.ErrorWithMessage(ctx, errMsg)
 		return
 	}
 
+	ctx.Header("ETag", fmt.Sprintf("%q", hash))
+	for _, etag := range etags {
+		if etag == "*" || etag == hash {
+			ctx.Status(http.StatusNotModified)
+			return
+		}
+	}
+
 	ctx.String(http.StatusOK, yaml)
 }
 
-// GetMonitorPrometheusRecordYaml 获取单个 Prometheus 记录配置文件
-func (c *ConfigYamlHandler) GetMonitorPrometheusRecordYaml(ctx *gin.Context) {
-	ip := ctx.Query("ip")
+// parseETags 解析 If-None-Match 请求头，去掉弱校验前缀和引号
+func parseETags(header string) []string {
+	var etags []string
+	for _, etag := range strings.Split(header, ",") {
+		etag = strings.TrimSpace(etag)
+		etag = strings.TrimPrefix(etag, "W/")
+		etag = strings.Trim(etag, `"`)
+		if etag != "" {
+			etags = append(etags, etag)
+		}
+	}
 
-	yaml := c.yamlService.GetMonitorPrometheusRecordYaml(ctx, ip)
-	if yaml == "" {
-		apiresponse.ErrorWithMessage(ctx, "获取 Prometheus 记录配置文件失败")
-		return
+	return etags
+}
+
+// parseWait 解析长轮询等待时间，支持秒数或 Go duration 格式，最长等待 maxConfigWait
+func parseWait(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
 	}
-	ctx.String(http.StatusOK, yaml)
+
+	wait, err := time.ParseDuration(value)
+	if err != nil {
+		seconds, convErr := strconv.Atoi(value)
+		if convErr != nil {
+			return 0, err
+		}
+		wait = time.Duration(seconds) * time.Second
+	}
+
+	if wait < 0 {
+		return 0, fmt.Errorf("wait 不能为负数")
+	}
+	if wait > maxConfigWait {
+		wait = maxConfigWait
+	}
+
+	return wait, nil
 }
 
-// GetMonitorAlertManagerYaml 获取单个 AlertManager 配置文件
-func (c *ConfigYamlHandler) GetMonitorAlertManagerYaml(ctx *gin.Context) {
-	ip := ctx.Query("ip")
+// instanceIP 获取拉取配置的实例IP，实例可以通过 ip 参数或 X-Agent-Token 请求头、token 参数标识
+func (c *ConfigYamlHandler) instanceIP(ctx *gin.Context) (string, bool) {
+	token := ctx.GetHeader("X-Agent-Token")
+	if token == "" {
+		token = ctx.Query("token")
+	}
 
-	yaml := c.yamlService.GetMonitorAlertManagerYaml(ctx, ip)
-	if yaml == "" {
-		apiresponse.ErrorWithMessage(ctx, "获取 AlertManager 配置文件失败")
+	ip, err := c.yamlService.ResolveInstanceIP(ctx.Query("ip"), token)
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return "", false
+	}
+
+	return ip, true
+}
+
+// GetConfigErrors 获取各池最近一次生成配置时的校验错误
+func (c *ConfigYamlHandler) GetConfigErrors(ctx *gin.Context) {
+	var req model.ConfigErrorListReq
+
+	if err := ctx.ShouldBind(&req); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
 		return
 	}
 
-	ctx.String(http.StatusOK, yaml)
+	list, err := c.yamlService.GetConfigErrors(ctx, &req)
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.SuccessWithData(ctx, list)
 }
@@ -0,0 +1,90 @@
+package api
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+import (
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	yamlService "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/service/yaml"
+	"github.com/GoSimplicity/AI-CloudOps/pkg/utils/apiresponse"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ConfigSyncHandler struct {
+	syncService yamlService.ConfigSyncService
+	l           *zap.Logger
+}
+
+func NewConfigSyncHandler(l *zap.Logger, syncService yamlService.ConfigSyncService) *ConfigSyncHandler {
+	return &ConfigSyncHandler{
+		l:           l,
+		syncService: syncService,
+	}
+}
+
+func (c *ConfigSyncHandler) RegisterRouters(server *gin.Engine) {
+	monitorGroup := server.Group("/api/monitor")
+
+	configSync := monitorGroup.Group("/config_sync")
+	{
+		configSync.GET("/", c.GetConfigSyncStates)   // 获取实例的配置同步状态
+		configSync.POST("/reload", c.ReloadInstance) // 手动触发实例重载配置
+	}
+}
+
+// GetConfigSyncStates 获取实例的配置同步状态，可只返回未同步的实例
+func (c *ConfigSyncHandler) GetConfigSyncStates(ctx *gin.Context) {
+	var req model.ConfigSyncListReq
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
+		return
+	}
+
+	list, err := c.syncService.GetConfigSyncStates(ctx, req.OutOfSync)
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, "服务器内部错误")
+		return
+	}
+
+	apiresponse.SuccessWithData(ctx, list)
+}
+
+// ReloadInstance 手动触发实例重载配置
+func (c *ConfigSyncHandler) ReloadInstance(ctx *gin.Context) {
+	var req model.ConfigSyncReloadReq
+
+	if err := ctx.ShouldBind(&req); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
+		return
+	}
+
+	if err := c.syncService.ReloadInstances(ctx, req.IP); err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.Success(ctx)
+}
@@ -0,0 +1,85 @@
+package api
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseETags(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   []string
+	}{
+		{name: "空请求头", header: "", want: nil},
+		{name: "单个ETag", header: `"abc"`, want: []string{"abc"}},
+		{name: "弱ETag", header: `W/"abc"`, want: []string{"abc"}},
+		{name: "多个ETag", header: `"abc", W/"def" ,"ghi"`, want: []string{"abc", "def", "ghi"}},
+		{name: "忽略空值", header: `"abc",,""`, want: []string{"abc"}},
+		{name: "通配符", header: "*", want: []string{"*"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseETags(tt.header); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseETags(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWait(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "不等待", value: "", want: 0},
+		{name: "秒数", value: "30", want: 30 * time.Second},
+		{name: "Go duration 格式", value: "1m30s", want: 90 * time.Second},
+		{name: "超过最长等待时间", value: "1h", want: maxConfigWait},
+		{name: "秒数超过最长等待时间", value: "100000", want: maxConfigWait},
+		{name: "负数", value: "-5s", wantErr: true},
+		{name: "负的秒数", value: "-5", wantErr: true},
+		{name: "无法解析", value: "soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseWait(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseWait(%q) err = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseWait(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
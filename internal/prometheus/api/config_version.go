@@ -0,0 +1,226 @@
+package api
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	yamlService "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/service/yaml"
+	"github.com/GoSimplicity/AI-CloudOps/pkg/utils/apiresponse"
+	ijwt "github.com/GoSimplicity/AI-CloudOps/pkg/utils/jwt"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"strconv"
+)
+
+type ConfigVersionHandler struct {
+	versionService yamlService.ConfigVersionService
+	l              *zap.Logger
+}
+
+func NewConfigVersionHandler(l *zap.Logger, versionService yamlService.ConfigVersionService) *ConfigVersionHandler {
+	return &ConfigVersionHandler{
+		l:              l,
+		versionService: versionService,
+	}
+}
+
+func (c *ConfigVersionHandler) RegisterRouters(server *gin.Engine) {
+	monitorGroup := server.Group("/api/monitor")
+
+	configVersions := monitorGroup.Group("/config_versions")
+	{
+		configVersions.GET("/", c.GetConfigVersionList)           // 获取实例某类配置的版本列表
+		configVersions.GET("/:id", c.GetConfigVersion)            // 获取单个配置版本的内容
+		configVersions.GET("/diff", c.DiffConfigVersions)         // 对比两个配置版本
+		configVersions.GET("/pins", c.GetConfigPinList)           // 获取所有固定的配置版本
+		configVersions.POST("/pin", c.PinConfigVersion)           // 固定实例的配置版本
+		configVersions.POST("/rollback", c.RollbackConfigVersion) // 回滚实例的配置到历史版本
+		configVersions.POST("/unpin", c.UnpinConfigVersion)       // 取消固定实例的配置版本
+		configVersions.GET("/applied", c.GetAppliedVersionList)   // 获取实例上报的已应用配置
+	}
+
+	// config-agent 上报已应用的配置，与配置拉取接口一样不需要登录
+	monitorGroup.POST("/prometheus_configs/report", c.ReportAppliedVersion)
+}
+
+// GetConfigVersionList 获取实例某类配置的版本列表
+func (c *ConfigVersionHandler) GetConfigVersionList(ctx *gin.Context) {
+	var req model.ConfigVersionListReq
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
+		return
+	}
+
+	list, err := c.versionService.GetConfigVersionList(ctx, req.ConfigType, req.IP)
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.SuccessWithData(ctx, list)
+}
+
+// GetConfigVersion 获取单个配置版本的内容
+func (c *ConfigVersionHandler) GetConfigVersion(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, "参数错误")
+		return
+	}
+
+	version, err := c.versionService.GetConfigVersionById(ctx, id)
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, "服务器内部错误")
+		return
+	}
+
+	apiresponse.SuccessWithData(ctx, version)
+}
+
+// DiffConfigVersions 对比两个配置版本
+func (c *ConfigVersionHandler) DiffConfigVersions(ctx *gin.Context) {
+	var req model.ConfigVersionDiffReq
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
+		return
+	}
+
+	diff, err := c.versionService.DiffConfigVersions(ctx, req.FromID, req.ToID)
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.SuccessWithData(ctx, diff)
+}
+
+// GetConfigPinList 获取所有固定的配置版本
+func (c *ConfigVersionHandler) GetConfigPinList(ctx *gin.Context) {
+	list, err := c.versionService.GetConfigPinList(ctx)
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, "服务器内部错误")
+		return
+	}
+
+	apiresponse.SuccessWithData(ctx, list)
+}
+
+// PinConfigVersion 固定实例的配置版本
+func (c *ConfigVersionHandler) PinConfigVersion(ctx *gin.Context) {
+	var req model.ConfigVersionPinReq
+
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+
+	if err := ctx.ShouldBind(&req); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
+		return
+	}
+
+	if err := c.versionService.PinConfigVersion(ctx, &req, uc.Uid); err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.Success(ctx)
+}
+
+// RollbackConfigVersion 回滚实例的配置到历史版本
+func (c *ConfigVersionHandler) RollbackConfigVersion(ctx *gin.Context) {
+	var req model.ConfigVersionPinReq
+
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+
+	if err := ctx.ShouldBind(&req); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
+		return
+	}
+
+	if err := c.versionService.RollbackConfigVersion(ctx, &req, uc.Uid); err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.Success(ctx)
+}
+
+// UnpinConfigVersion 取消固定实例的配置版本
+func (c *ConfigVersionHandler) UnpinConfigVersion(ctx *gin.Context) {
+	var req model.ConfigVersionPinReq
+
+	if err := ctx.ShouldBind(&req); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
+		return
+	}
+
+	if err := c.versionService.UnpinConfigVersion(ctx, req.ConfigType, req.IP); err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.Success(ctx)
+}
+
+// ReportAppliedVersion 接收 config-agent 上报的已应用配置
+func (c *ConfigVersionHandler) ReportAppliedVersion(ctx *gin.Context) {
+	var req model.ConfigAppliedReportReq
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
+		return
+	}
+
+	// 与拉取配置一致，Token 优先从 X-Agent-Token 请求头获取
+	if token := ctx.GetHeader("X-Agent-Token"); token != "" {
+		req.Token = token
+	}
+
+	if err := c.versionService.ReportAppliedVersion(ctx, &req); err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.Success(ctx)
+}
+
+// GetAppliedVersionList 获取实例上报的已应用配置
+func (c *ConfigVersionHandler) GetAppliedVersionList(ctx *gin.Context) {
+	var req model.ConfigAppliedListReq
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
+		return
+	}
+
+	list, err := c.versionService.GetAppliedVersionList(ctx, req.IP)
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, "服务器内部错误")
+		return
+	}
+
+	apiresponse.SuccessWithData(ctx, list)
+}
@@ -0,0 +1,126 @@
+package api
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	alertService "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/service/alert"
+	"github.com/GoSimplicity/AI-CloudOps/pkg/utils/apiresponse"
+	ijwt "github.com/GoSimplicity/AI-CloudOps/pkg/utils/jwt"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"strconv"
+)
+
+type InhibitRuleHandler struct {
+	inhibitService alertService.AlertManagerInhibitService
+	l              *zap.Logger
+}
+
+func NewInhibitRuleHandler(l *zap.Logger, inhibitService alertService.AlertManagerInhibitService) *InhibitRuleHandler {
+	return &InhibitRuleHandler{
+		l:              l,
+		inhibitService: inhibitService,
+	}
+}
+
+func (i *InhibitRuleHandler) RegisterRouters(server *gin.Engine) {
+	monitorGroup := server.Group("/api/monitor")
+
+	inhibitRules := monitorGroup.Group("/inhibit_rules")
+	{
+		inhibitRules.GET("/list", i.GetMonitorInhibitRuleList)   // 获取抑制规则列表
+		inhibitRules.POST("/create", i.CreateMonitorInhibitRule) // 创建新的抑制规则
+		inhibitRules.POST("/update", i.UpdateMonitorInhibitRule) // 更新现有的抑制规则
+		inhibitRules.DELETE("/:id", i.DeleteMonitorInhibitRule)  // 删除指定的抑制规则
+	}
+}
+
+// GetMonitorInhibitRuleList 获取抑制规则列表
+func (i *InhibitRuleHandler) GetMonitorInhibitRuleList(ctx *gin.Context) {
+	searchName := ctx.Query("name")
+
+	list, err := i.inhibitService.GetMonitorInhibitRuleList(ctx, &searchName)
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, "服务器内部错误")
+		return
+	}
+
+	apiresponse.SuccessWithData(ctx, list)
+}
+
+// CreateMonitorInhibitRule 创建新的抑制规则
+func (i *InhibitRuleHandler) CreateMonitorInhibitRule(ctx *gin.Context) {
+	var inhibitRule model.MonitorInhibitRule
+
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	if err := ctx.ShouldBindJSON(&inhibitRule); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
+		return
+	}
+
+	inhibitRule.UserID = uc.Uid
+
+	if err := i.inhibitService.CreateMonitorInhibitRule(ctx, &inhibitRule); err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.Success(ctx)
+}
+
+// UpdateMonitorInhibitRule 更新现有的抑制规则
+func (i *InhibitRuleHandler) UpdateMonitorInhibitRule(ctx *gin.Context) {
+	var inhibitRule model.MonitorInhibitRule
+
+	if err := ctx.ShouldBindJSON(&inhibitRule); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
+		return
+	}
+
+	if err := i.inhibitService.UpdateMonitorInhibitRule(ctx, &inhibitRule); err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.Success(ctx)
+}
+
+// DeleteMonitorInhibitRule 删除指定的抑制规则
+func (i *InhibitRuleHandler) DeleteMonitorInhibitRule(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, "参数错误")
+		return
+	}
+
+	if err := i.inhibitService.DeleteMonitorInhibitRule(ctx, id); err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.Success(ctx)
+}
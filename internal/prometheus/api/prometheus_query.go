@@ -0,0 +1,139 @@
+package api
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	queryService "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/service/query"
+	"github.com/GoSimplicity/AI-CloudOps/pkg/utils/apiresponse"
+	ijwt "github.com/GoSimplicity/AI-CloudOps/pkg/utils/jwt"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type PromQueryHandler struct {
+	queryService queryService.PromQueryService
+	l            *zap.Logger
+}
+
+func NewPromQueryHandler(l *zap.Logger, queryService queryService.PromQueryService) *PromQueryHandler {
+	return &PromQueryHandler{
+		l:            l,
+		queryService: queryService,
+	}
+}
+
+func (p *PromQueryHandler) RegisterRouters(server *gin.Engine) {
+	monitorGroup := server.Group("/api/monitor")
+
+	promQuery := monitorGroup.Group("/prometheus_query")
+	{
+		promQuery.POST("/query", p.Query)                     // 在采集池的Prometheus实例上执行即时查询
+		promQuery.POST("/query_range", p.QueryRange)          // 在采集池的Prometheus实例上执行范围查询
+		promQuery.POST("/rule_preview", p.PreviewAlertRule)   // 预览告警规则当前会触发的告警
+		promQuery.POST("/rule_backtest", p.BacktestAlertRule) // 使用历史数据回测告警规则
+	}
+}
+
+// Query 在采集池的Prometheus实例上执行即时查询
+func (p *PromQueryHandler) Query(ctx *gin.Context) {
+	var req model.PromQueryReq
+
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
+		return
+	}
+
+	resp, err := p.queryService.Query(ctx, &req, uc.Uid)
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.SuccessWithData(ctx, resp)
+}
+
+// QueryRange 在采集池的Prometheus实例上执行范围查询
+func (p *PromQueryHandler) QueryRange(ctx *gin.Context) {
+	var req model.PromQueryReq
+
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
+		return
+	}
+
+	resp, err := p.queryService.QueryRange(ctx, &req, uc.Uid)
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.SuccessWithData(ctx, resp)
+}
+
+// PreviewAlertRule 预览尚未保存的告警规则当前会触发的告警
+func (p *PromQueryHandler) PreviewAlertRule(ctx *gin.Context) {
+	var req model.AlertRulePreviewReq
+
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
+		return
+	}
+
+	resp, err := p.queryService.PreviewAlertRule(ctx, &req, uc.Uid)
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.SuccessWithData(ctx, resp)
+}
+
+// BacktestAlertRule 使用历史数据回测已保存或尚未保存的告警规则
+func (p *PromQueryHandler) BacktestAlertRule(ctx *gin.Context) {
+	var req model.AlertRuleBacktestReq
+
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
+		return
+	}
+
+	resp, err := p.queryService.BacktestAlertRule(ctx, &req, uc.Uid)
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.SuccessWithData(ctx, resp)
+}
@@ -88,7 +88,7 @@ func (r *RecordRuleHandler) CreateMonitorRecordRule(ctx *gin.Context) {
 	recordRule.UserID = uc.Uid
 
 	if err := r.alertRecordService.CreateMonitorRecordRule(ctx, &recordRule); err != nil {
-		apiresponse.ErrorWithMessage(ctx, "服务器内部错误")
+		apiresponse.ErrorWithMessage(ctx, err.Error())
 		return
 	}
 
@@ -105,7 +105,7 @@ func (r *RecordRuleHandler) UpdateMonitorRecordRule(ctx *gin.Context) {
 	}
 
 	if err := r.alertRecordService.UpdateMonitorRecordRule(ctx, &recordRule); err != nil {
-		apiresponse.ErrorWithMessage(ctx, "服务器内部错误")
+		apiresponse.ErrorWithMessage(ctx, err.Error())
 		return
 	}
 
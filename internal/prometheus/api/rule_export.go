@@ -0,0 +1,142 @@
+package api
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	alertService "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/service/alert"
+	"github.com/GoSimplicity/AI-CloudOps/pkg/utils/apiresponse"
+	ijwt "github.com/GoSimplicity/AI-CloudOps/pkg/utils/jwt"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"strconv"
+)
+
+type RuleExportHandler struct {
+	ruleExportService alertService.AlertManagerRuleExportService
+	l                 *zap.Logger
+}
+
+func NewRuleExportHandler(l *zap.Logger, ruleExportService alertService.AlertManagerRuleExportService) *RuleExportHandler {
+	return &RuleExportHandler{
+		l:                 l,
+		ruleExportService: ruleExportService,
+	}
+}
+
+func (r *RuleExportHandler) RegisterRouters(server *gin.Engine) {
+	monitorGroup := server.Group("/api/monitor")
+
+	ruleExports := monitorGroup.Group("/rule_exports")
+	{
+		ruleExports.GET("/", r.GetMonitorRuleExportList)       // 获取 PrometheusRule 导出配置列表
+		ruleExports.POST("/create", r.CreateMonitorRuleExport) // 创建导出配置并立即同步
+		ruleExports.POST("/update", r.UpdateMonitorRuleExport) // 更新导出配置的标签和启用状态
+		ruleExports.DELETE("/:id", r.DeleteMonitorRuleExport)  // 删除导出配置并清理集群中的资源
+		ruleExports.POST("/sync/:id", r.SyncMonitorRuleExport) // 立即同步规则到集群
+	}
+}
+
+// GetMonitorRuleExportList 获取 PrometheusRule 导出配置列表
+func (r *RuleExportHandler) GetMonitorRuleExportList(ctx *gin.Context) {
+	list, err := r.ruleExportService.GetMonitorRuleExportList(ctx)
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, "服务器内部错误")
+		return
+	}
+
+	apiresponse.SuccessWithData(ctx, list)
+}
+
+// CreateMonitorRuleExport 创建导出配置
+func (r *RuleExportHandler) CreateMonitorRuleExport(ctx *gin.Context) {
+	var ruleExport model.MonitorRuleExport
+
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	if err := ctx.ShouldBind(&ruleExport); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
+		return
+	}
+
+	ruleExport.UserID = uc.Uid
+
+	if err := r.ruleExportService.CreateMonitorRuleExport(ctx, &ruleExport); err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.Success(ctx)
+}
+
+// UpdateMonitorRuleExport 更新导出配置
+func (r *RuleExportHandler) UpdateMonitorRuleExport(ctx *gin.Context) {
+	var ruleExport model.MonitorRuleExport
+
+	if err := ctx.ShouldBind(&ruleExport); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
+		return
+	}
+
+	if err := r.ruleExportService.UpdateMonitorRuleExport(ctx, &ruleExport); err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.Success(ctx)
+}
+
+// DeleteMonitorRuleExport 删除导出配置
+func (r *RuleExportHandler) DeleteMonitorRuleExport(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, "参数错误")
+		return
+	}
+
+	if err := r.ruleExportService.DeleteMonitorRuleExport(ctx, id); err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.Success(ctx)
+}
+
+// SyncMonitorRuleExport 立即同步规则到集群，返回同步后的导出配置
+func (r *RuleExportHandler) SyncMonitorRuleExport(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, "参数错误")
+		return
+	}
+
+	ruleExport, err := r.ruleExportService.SyncMonitorRuleExport(ctx, id)
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.SuccessWithData(ctx, ruleExport)
+}
@@ -0,0 +1,163 @@
+package api
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	alertService "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/service/alert"
+	"github.com/GoSimplicity/AI-CloudOps/pkg/utils/apiresponse"
+	ijwt "github.com/GoSimplicity/AI-CloudOps/pkg/utils/jwt"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"strconv"
+)
+
+type RuleGroupHandler struct {
+	ruleGroupService alertService.AlertManagerRuleGroupService
+	l                *zap.Logger
+}
+
+func NewRuleGroupHandler(l *zap.Logger, ruleGroupService alertService.AlertManagerRuleGroupService) *RuleGroupHandler {
+	return &RuleGroupHandler{
+		l:                l,
+		ruleGroupService: ruleGroupService,
+	}
+}
+
+func (r *RuleGroupHandler) RegisterRouters(server *gin.Engine) {
+	monitorGroup := server.Group("/api/monitor")
+
+	ruleGroups := monitorGroup.Group("/rule_groups")
+	{
+		ruleGroups.GET("/", r.GetMonitorRuleGroupList)         // 获取规则组列表
+		ruleGroups.GET("/:id", r.GetMonitorRuleGroupDetail)    // 获取规则组详情及组内规则
+		ruleGroups.POST("/create", r.CreateMonitorRuleGroup)   // 创建规则组
+		ruleGroups.POST("/update", r.UpdateMonitorRuleGroup)   // 更新规则组
+		ruleGroups.DELETE("/:id", r.DeleteMonitorRuleGroup)    // 删除规则组
+		ruleGroups.POST("/shard_preview", r.PreviewRuleShards) // 预览更换实例列表后规则组的分片变化
+	}
+}
+
+// GetMonitorRuleGroupList 获取规则组列表
+func (r *RuleGroupHandler) GetMonitorRuleGroupList(ctx *gin.Context) {
+	searchName := ctx.Query("name")
+
+	list, err := r.ruleGroupService.GetMonitorRuleGroupList(ctx, &searchName)
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, "服务器内部错误")
+		return
+	}
+
+	apiresponse.SuccessWithData(ctx, list)
+}
+
+// GetMonitorRuleGroupDetail 获取规则组详情及组内规则
+func (r *RuleGroupHandler) GetMonitorRuleGroupDetail(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, "参数错误")
+		return
+	}
+
+	detail, err := r.ruleGroupService.GetMonitorRuleGroupDetail(ctx, id)
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, "服务器内部错误")
+		return
+	}
+
+	apiresponse.SuccessWithData(ctx, detail)
+}
+
+// CreateMonitorRuleGroup 创建规则组
+func (r *RuleGroupHandler) CreateMonitorRuleGroup(ctx *gin.Context) {
+	var ruleGroup model.MonitorRuleGroup
+
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	if err := ctx.ShouldBind(&ruleGroup); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
+		return
+	}
+
+	ruleGroup.UserID = uc.Uid
+
+	if err := r.ruleGroupService.CreateMonitorRuleGroup(ctx, &ruleGroup); err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.Success(ctx)
+}
+
+// UpdateMonitorRuleGroup 更新规则组
+func (r *RuleGroupHandler) UpdateMonitorRuleGroup(ctx *gin.Context) {
+	var ruleGroup model.MonitorRuleGroup
+
+	if err := ctx.ShouldBind(&ruleGroup); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
+		return
+	}
+
+	if err := r.ruleGroupService.UpdateMonitorRuleGroup(ctx, &ruleGroup); err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.Success(ctx)
+}
+
+// DeleteMonitorRuleGroup 删除规则组，组内规则保留
+func (r *RuleGroupHandler) DeleteMonitorRuleGroup(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, "参数错误")
+		return
+	}
+
+	if err := r.ruleGroupService.DeleteMonitorRuleGroup(ctx, id); err != nil {
+		apiresponse.ErrorWithMessage(ctx, "服务器内部错误")
+		return
+	}
+
+	apiresponse.Success(ctx)
+}
+
+// PreviewRuleShards 预览更换实例列表或副本数后规则组的分片变化
+func (r *RuleGroupHandler) PreviewRuleShards(ctx *gin.Context) {
+	var req model.RuleShardPreviewReq
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
+		return
+	}
+
+	resp, err := r.ruleGroupService.PreviewRuleShards(ctx, &req)
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.SuccessWithData(ctx, resp)
+}
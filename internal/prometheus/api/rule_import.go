@@ -0,0 +1,110 @@
+package api
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	alertService "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/service/alert"
+	"github.com/GoSimplicity/AI-CloudOps/pkg/utils/apiresponse"
+	ijwt "github.com/GoSimplicity/AI-CloudOps/pkg/utils/jwt"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+)
+
+// maxRuleFileSize 上传的规则文件大小上限
+const maxRuleFileSize = 10 << 20
+
+type RuleImportHandler struct {
+	ruleImportService alertService.AlertManagerRuleImportService
+	l                 *zap.Logger
+}
+
+func NewRuleImportHandler(l *zap.Logger, ruleImportService alertService.AlertManagerRuleImportService) *RuleImportHandler {
+	return &RuleImportHandler{
+		l:                 l,
+		ruleImportService: ruleImportService,
+	}
+}
+
+func (r *RuleImportHandler) RegisterRouters(server *gin.Engine) {
+	monitorGroup := server.Group("/api/monitor")
+
+	ruleImports := monitorGroup.Group("/rule_imports")
+	{
+		ruleImports.POST("/", r.ImportRules) // 导入 Prometheus 规则文件，dryRun 为 true 时只返回导入报告
+	}
+}
+
+// ImportRules 导入 Prometheus 规则文件，支持 JSON 的 content 字段或 multipart 上传的 file 文件
+func (r *RuleImportHandler) ImportRules(ctx *gin.Context) {
+	var req model.RuleImportReq
+
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+
+	if err := ctx.ShouldBind(&req); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
+		return
+	}
+
+	if file, err := ctx.FormFile("file"); err == nil {
+		if file.Size > maxRuleFileSize {
+			apiresponse.ErrorWithMessage(ctx, "规则文件不能超过10MB")
+			return
+		}
+
+		f, err := file.Open()
+		if err != nil {
+			apiresponse.ErrorWithMessage(ctx, "读取规则文件失败")
+			return
+		}
+		defer f.Close()
+
+		content, err := io.ReadAll(f)
+		if err != nil {
+			apiresponse.ErrorWithMessage(ctx, "读取规则文件失败")
+			return
+		}
+		req.Content = string(content)
+	} else if err != http.ErrNotMultipart && err != http.ErrMissingFile {
+		apiresponse.ErrorWithMessage(ctx, "读取规则文件失败")
+		return
+	}
+
+	resp, err := r.ruleImportService.ImportRules(ctx, &req, uc.Uid)
+	if err != nil {
+		// 存在冲突时同时返回导入报告，便于前端展示
+		if resp != nil {
+			apiresponse.ErrorWithDetails(ctx, resp, err.Error())
+			return
+		}
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.SuccessWithData(ctx, resp)
+}
@@ -0,0 +1,183 @@
+package api
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	alertService "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/service/alert"
+	"github.com/GoSimplicity/AI-CloudOps/pkg/utils/apiresponse"
+	ijwt "github.com/GoSimplicity/AI-CloudOps/pkg/utils/jwt"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"strconv"
+)
+
+type RuleTemplateHandler struct {
+	ruleTemplateService alertService.AlertManagerRuleTemplateService
+	l                   *zap.Logger
+}
+
+func NewRuleTemplateHandler(l *zap.Logger, ruleTemplateService alertService.AlertManagerRuleTemplateService) *RuleTemplateHandler {
+	return &RuleTemplateHandler{
+		l:                   l,
+		ruleTemplateService: ruleTemplateService,
+	}
+}
+
+func (r *RuleTemplateHandler) RegisterRouters(server *gin.Engine) {
+	monitorGroup := server.Group("/api/monitor")
+
+	ruleTemplates := monitorGroup.Group("/rule_templates")
+	{
+		ruleTemplates.GET("/", r.GetMonitorAlertRuleTemplateList)                 // 获取告警规则模板列表
+		ruleTemplates.GET("/:id", r.GetMonitorAlertRuleTemplateDetail)            // 获取模板详情及由模板生成的告警规则
+		ruleTemplates.POST("/create", r.CreateMonitorAlertRuleTemplate)           // 创建告警规则模板
+		ruleTemplates.POST("/update", r.UpdateMonitorAlertRuleTemplate)           // 更新模板并重新生成所有告警规则
+		ruleTemplates.DELETE("/:id", r.DeleteMonitorAlertRuleTemplate)            // 删除模板，已生成的告警规则保留
+		ruleTemplates.POST("/instantiate", r.InstantiateMonitorAlertRuleTemplate) // 针对树节点实例化模板
+		ruleTemplates.GET("/drift/:id", r.GetMonitorAlertRuleTemplateDrift)       // 获取偏离模板的告警规则
+	}
+}
+
+// GetMonitorAlertRuleTemplateList 获取告警规则模板列表
+func (r *RuleTemplateHandler) GetMonitorAlertRuleTemplateList(ctx *gin.Context) {
+	searchName := ctx.Query("name")
+
+	list, err := r.ruleTemplateService.GetMonitorAlertRuleTemplateList(ctx, &searchName)
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, "服务器内部错误")
+		return
+	}
+
+	apiresponse.SuccessWithData(ctx, list)
+}
+
+// GetMonitorAlertRuleTemplateDetail 获取模板详情及由模板生成的告警规则
+func (r *RuleTemplateHandler) GetMonitorAlertRuleTemplateDetail(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, "参数错误")
+		return
+	}
+
+	detail, err := r.ruleTemplateService.GetMonitorAlertRuleTemplateDetail(ctx, id)
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.SuccessWithData(ctx, detail)
+}
+
+// CreateMonitorAlertRuleTemplate 创建告警规则模板
+func (r *RuleTemplateHandler) CreateMonitorAlertRuleTemplate(ctx *gin.Context) {
+	var template model.MonitorAlertRuleTemplate
+
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	if err := ctx.ShouldBindJSON(&template); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
+		return
+	}
+
+	template.UserID = uc.Uid
+
+	if err := r.ruleTemplateService.CreateMonitorAlertRuleTemplate(ctx, &template); err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.Success(ctx)
+}
+
+// UpdateMonitorAlertRuleTemplate 更新模板并重新生成所有告警规则，返回被覆盖的偏离
+func (r *RuleTemplateHandler) UpdateMonitorAlertRuleTemplate(ctx *gin.Context) {
+	var template model.MonitorAlertRuleTemplate
+
+	if err := ctx.ShouldBindJSON(&template); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
+		return
+	}
+
+	resp, err := r.ruleTemplateService.UpdateMonitorAlertRuleTemplate(ctx, &template)
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.SuccessWithData(ctx, resp)
+}
+
+// DeleteMonitorAlertRuleTemplate 删除告警规则模板
+func (r *RuleTemplateHandler) DeleteMonitorAlertRuleTemplate(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, "参数错误")
+		return
+	}
+
+	if err := r.ruleTemplateService.DeleteMonitorAlertRuleTemplate(ctx, id); err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.Success(ctx)
+}
+
+// InstantiateMonitorAlertRuleTemplate 针对树节点实例化模板
+func (r *RuleTemplateHandler) InstantiateMonitorAlertRuleTemplate(ctx *gin.Context) {
+	var req model.RuleTemplateInstantiateReq
+
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
+		return
+	}
+
+	resp, err := r.ruleTemplateService.InstantiateMonitorAlertRuleTemplate(ctx, &req, uc.Uid)
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.SuccessWithData(ctx, resp)
+}
+
+// GetMonitorAlertRuleTemplateDrift 获取偏离模板的告警规则
+func (r *RuleTemplateHandler) GetMonitorAlertRuleTemplateDrift(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, "参数错误")
+		return
+	}
+
+	drifts, err := r.ruleTemplateService.GetMonitorAlertRuleTemplateDrift(ctx, id)
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.SuccessWithData(ctx, drifts)
+}
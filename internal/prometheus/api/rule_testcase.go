@@ -0,0 +1,150 @@
+package api
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	alertService "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/service/alert"
+	"github.com/GoSimplicity/AI-CloudOps/pkg/utils/apiresponse"
+	ijwt "github.com/GoSimplicity/AI-CloudOps/pkg/utils/jwt"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"strconv"
+)
+
+type RuleTestHandler struct {
+	ruleTestService alertService.AlertManagerRuleTestService
+	l               *zap.Logger
+}
+
+func NewRuleTestHandler(l *zap.Logger, ruleTestService alertService.AlertManagerRuleTestService) *RuleTestHandler {
+	return &RuleTestHandler{
+		l:               l,
+		ruleTestService: ruleTestService,
+	}
+}
+
+func (r *RuleTestHandler) RegisterRouters(server *gin.Engine) {
+	monitorGroup := server.Group("/api/monitor")
+
+	ruleTests := monitorGroup.Group("/rule_tests")
+	{
+		ruleTests.GET("/", r.GetRuleTestList)       // 获取规则的单元测试用例列表
+		ruleTests.POST("/create", r.CreateRuleTest) // 创建规则单元测试用例
+		ruleTests.POST("/update", r.UpdateRuleTest) // 更新规则单元测试用例
+		ruleTests.DELETE("/:id", r.DeleteRuleTest)  // 删除规则单元测试用例
+		ruleTests.POST("/run", r.RunRuleTests)      // 运行规则的单元测试
+	}
+}
+
+// GetRuleTestList 获取规则的单元测试用例列表
+func (r *RuleTestHandler) GetRuleTestList(ctx *gin.Context) {
+	var req model.RuleTestListReq
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
+		return
+	}
+
+	list, err := r.ruleTestService.GetRuleTestList(ctx, req.RuleType, req.RuleID)
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, "服务器内部错误")
+		return
+	}
+
+	apiresponse.SuccessWithData(ctx, list)
+}
+
+// CreateRuleTest 创建规则单元测试用例
+func (r *RuleTestHandler) CreateRuleTest(ctx *gin.Context) {
+	var ruleTest model.MonitorRuleTest
+
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	if err := ctx.ShouldBindJSON(&ruleTest); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
+		return
+	}
+
+	ruleTest.UserID = uc.Uid
+
+	if err := r.ruleTestService.CreateRuleTest(ctx, &ruleTest); err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.Success(ctx)
+}
+
+// UpdateRuleTest 更新规则单元测试用例
+func (r *RuleTestHandler) UpdateRuleTest(ctx *gin.Context) {
+	var ruleTest model.MonitorRuleTest
+
+	if err := ctx.ShouldBindJSON(&ruleTest); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
+		return
+	}
+
+	if err := r.ruleTestService.UpdateRuleTest(ctx, &ruleTest); err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.Success(ctx)
+}
+
+// DeleteRuleTest 删除规则单元测试用例
+func (r *RuleTestHandler) DeleteRuleTest(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, "参数错误")
+		return
+	}
+
+	if err := r.ruleTestService.DeleteRuleTest(ctx, id); err != nil {
+		apiresponse.ErrorWithMessage(ctx, "服务器内部错误")
+		return
+	}
+
+	apiresponse.Success(ctx)
+}
+
+// RunRuleTests 使用规则当前的定义运行单元测试，返回每个用例的通过情况和差异
+func (r *RuleTestHandler) RunRuleTests(ctx *gin.Context) {
+	var req model.RuleTestRunReq
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
+		return
+	}
+
+	resp, err := r.ruleTestService.RunRuleTests(ctx, &req)
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.SuccessWithData(ctx, resp)
+}
@@ -26,10 +26,12 @@ package api
  */
 
 import (
+	"errors"
 	"github.com/GoSimplicity/AI-CloudOps/internal/model"
 	scrapeJobService "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/service/scrape"
 	"github.com/GoSimplicity/AI-CloudOps/pkg/utils/apiresponse"
 	ijwt "github.com/GoSimplicity/AI-CloudOps/pkg/utils/jwt"
+	pkg "github.com/GoSimplicity/AI-CloudOps/pkg/utils/prometheus"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"strconv"
@@ -52,10 +54,11 @@ func (s *ScrapeJobHandler) RegisterRouters(server *gin.Engine) {
 
 	scrapeJobs := monitorGroup.Group("/scrape_jobs")
 	{
-		scrapeJobs.GET("/", s.GetMonitorScrapeJobList)       // 获取监控采集 Job 列表
-		scrapeJobs.POST("/create", s.CreateMonitorScrapeJob) // 创建监控采集 Job
-		scrapeJobs.POST("/update", s.UpdateMonitorScrapeJob) // 更新监控采集 Job
-		scrapeJobs.DELETE("/:id", s.DeleteMonitorScrapeJob)  // 删除监控采集 Job
+		scrapeJobs.GET("/", s.GetMonitorScrapeJobList)          // 获取监控采集 Job 列表
+		scrapeJobs.POST("/create", s.CreateMonitorScrapeJob)    // 创建监控采集 Job
+		scrapeJobs.POST("/update", s.UpdateMonitorScrapeJob)    // 更新监控采集 Job
+		scrapeJobs.DELETE("/:id", s.DeleteMonitorScrapeJob)     // 删除监控采集 Job
+		scrapeJobs.POST("/relabel_simulate", s.SimulateRelabel) // 模拟目标的 relabel 结果和分片实例
 	}
 }
 
@@ -84,7 +87,7 @@ func (s *ScrapeJobHandler) CreateMonitorScrapeJob(ctx *gin.Context) {
 	monitorScrapeJob.UserID = uc.Uid
 
 	if err := s.scrapeJobService.CreateMonitorScrapeJob(ctx, &monitorScrapeJob); err != nil {
-		apiresponse.ErrorWithMessage(ctx, "服务器内部错误")
+		responseScrapeJobError(ctx, err)
 		return
 	}
 
@@ -101,7 +104,7 @@ func (s *ScrapeJobHandler) UpdateMonitorScrapeJob(ctx *gin.Context) {
 	}
 
 	if err := s.scrapeJobService.UpdateMonitorScrapeJob(ctx, &monitorScrapeJob); err != nil {
-		apiresponse.ErrorWithMessage(ctx, "服务器内部错误")
+		responseScrapeJobError(ctx, err)
 		return
 	}
 
@@ -125,3 +128,32 @@ func (s *ScrapeJobHandler) DeleteMonitorScrapeJob(ctx *gin.Context) {
 
 	apiresponse.Success(ctx)
 }
+
+// SimulateRelabel 模拟采集任务对目标执行 relabel，返回最终标签和负责采集的实例
+func (s *ScrapeJobHandler) SimulateRelabel(ctx *gin.Context) {
+	var req model.RelabelSimulateReq
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
+		return
+	}
+
+	resp, err := s.scrapeJobService.SimulateRelabel(ctx, &req)
+	if err != nil {
+		responseScrapeJobError(ctx, err)
+		return
+	}
+
+	apiresponse.SuccessWithData(ctx, resp)
+}
+
+// responseScrapeJobError 返回采集任务的保存错误，relabel 配置错误附带出错的字段和规则位置
+func responseScrapeJobError(ctx *gin.Context, err error) {
+	var relabelErr *pkg.RelabelConfigError
+	if errors.As(err, &relabelErr) {
+		apiresponse.ErrorWithDetails(ctx, relabelErr, relabelErr.Error())
+		return
+	}
+
+	apiresponse.ErrorWithMessage(ctx, err.Error())
+}
@@ -84,7 +84,7 @@ func (s *ScrapePoolHandler) CreateMonitorScrapePool(ctx *gin.Context) {
 
 	monitorScrapePool.UserID = uc.Uid
 	if err := s.scrapePoolService.CreateMonitorScrapePool(ctx, &monitorScrapePool); err != nil {
-		apiresponse.ErrorWithMessage(ctx, "服务器内部错误")
+		apiresponse.ErrorWithMessage(ctx, err.Error())
 		return
 	}
 
@@ -101,7 +101,7 @@ func (s *ScrapePoolHandler) UpdateMonitorScrapePool(ctx *gin.Context) {
 	}
 
 	if err := s.scrapePoolService.UpdateMonitorScrapePool(ctx, &monitorScrapePool); err != nil {
-		apiresponse.ErrorWithMessage(ctx, "服务器内部错误")
+		apiresponse.ErrorWithMessage(ctx, err.Error())
 		return
 	}
 
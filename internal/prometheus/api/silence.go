@@ -0,0 +1,156 @@
+package api
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	alertService "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/service/alert"
+	"github.com/GoSimplicity/AI-CloudOps/pkg/utils/apiresponse"
+	ijwt "github.com/GoSimplicity/AI-CloudOps/pkg/utils/jwt"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"strconv"
+)
+
+type SilenceHandler struct {
+	silenceService alertService.AlertManagerSilenceService
+	l              *zap.Logger
+}
+
+func NewSilenceHandler(l *zap.Logger, silenceService alertService.AlertManagerSilenceService) *SilenceHandler {
+	return &SilenceHandler{
+		l:              l,
+		silenceService: silenceService,
+	}
+}
+
+func (s *SilenceHandler) RegisterRouters(server *gin.Engine) {
+	monitorGroup := server.Group("/api/monitor")
+
+	silences := monitorGroup.Group("/silences")
+	{
+		silences.GET("/list", s.GetMonitorSilenceList)   // 获取静默列表
+		silences.GET("/:id", s.GetMonitorSilence)        // 获取单个静默
+		silences.POST("/create", s.CreateMonitorSilence) // 创建静默
+		silences.POST("/update", s.UpdateMonitorSilence) // 修改未过期的静默
+		silences.DELETE("/:id", s.ExpireMonitorSilence)  // 使静默立即过期
+	}
+}
+
+// GetMonitorSilenceList 获取静默列表
+func (s *SilenceHandler) GetMonitorSilenceList(ctx *gin.Context) {
+	var req model.SilenceListReq
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
+		return
+	}
+
+	list, err := s.silenceService.GetMonitorSilenceList(ctx, &req)
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, "服务器内部错误")
+		return
+	}
+
+	apiresponse.SuccessWithData(ctx, list)
+}
+
+// GetMonitorSilence 获取单个静默
+func (s *SilenceHandler) GetMonitorSilence(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, "参数错误")
+		return
+	}
+
+	silence, err := s.silenceService.GetMonitorSilenceById(ctx, id)
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, "服务器内部错误")
+		return
+	}
+
+	apiresponse.SuccessWithData(ctx, silence)
+}
+
+// CreateMonitorSilence 创建静默，创建者为当前登录用户
+func (s *SilenceHandler) CreateMonitorSilence(ctx *gin.Context) {
+	var req model.SilenceCreateReq
+
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
+		return
+	}
+
+	silence, err := s.silenceService.CreateMonitorSilence(ctx, &req, uc.Uid)
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.SuccessWithData(ctx, silence)
+}
+
+// UpdateMonitorSilence 修改未过期的静默
+func (s *SilenceHandler) UpdateMonitorSilence(ctx *gin.Context) {
+	var req model.SilenceUpdateReq
+
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
+		return
+	}
+
+	silence, err := s.silenceService.UpdateMonitorSilence(ctx, &req, uc.Uid)
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.SuccessWithData(ctx, silence)
+}
+
+// ExpireMonitorSilence 使静默立即过期
+func (s *SilenceHandler) ExpireMonitorSilence(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, "参数错误")
+		return
+	}
+
+	silence, err := s.silenceService.ExpireMonitorSilence(ctx, id)
+	if err != nil {
+		// 部分实例失败时返回静默记录，前端根据 instanceErrors 提示重试
+		if silence != nil {
+			apiresponse.ErrorWithDetails(ctx, silence, err.Error())
+			return
+		}
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.SuccessWithData(ctx, silence)
+}
@@ -0,0 +1,126 @@
+package api
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	alertService "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/service/alert"
+	"github.com/GoSimplicity/AI-CloudOps/pkg/utils/apiresponse"
+	ijwt "github.com/GoSimplicity/AI-CloudOps/pkg/utils/jwt"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"strconv"
+)
+
+type TimeIntervalHandler struct {
+	timeIntervalService alertService.AlertManagerTimeIntervalService
+	l                   *zap.Logger
+}
+
+func NewTimeIntervalHandler(l *zap.Logger, timeIntervalService alertService.AlertManagerTimeIntervalService) *TimeIntervalHandler {
+	return &TimeIntervalHandler{
+		l:                   l,
+		timeIntervalService: timeIntervalService,
+	}
+}
+
+func (t *TimeIntervalHandler) RegisterRouters(server *gin.Engine) {
+	monitorGroup := server.Group("/api/monitor")
+
+	timeIntervals := monitorGroup.Group("/time_intervals")
+	{
+		timeIntervals.GET("/list", t.GetMonitorTimeIntervalList)   // 获取时间段列表
+		timeIntervals.POST("/create", t.CreateMonitorTimeInterval) // 创建新的时间段
+		timeIntervals.POST("/update", t.UpdateMonitorTimeInterval) // 更新现有的时间段
+		timeIntervals.DELETE("/:id", t.DeleteMonitorTimeInterval)  // 删除指定的时间段
+	}
+}
+
+// GetMonitorTimeIntervalList 获取时间段列表
+func (t *TimeIntervalHandler) GetMonitorTimeIntervalList(ctx *gin.Context) {
+	searchName := ctx.Query("name")
+
+	list, err := t.timeIntervalService.GetMonitorTimeIntervalList(ctx, &searchName)
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, "服务器内部错误")
+		return
+	}
+
+	apiresponse.SuccessWithData(ctx, list)
+}
+
+// CreateMonitorTimeInterval 创建新的时间段
+func (t *TimeIntervalHandler) CreateMonitorTimeInterval(ctx *gin.Context) {
+	var timeInterval model.MonitorTimeInterval
+
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	if err := ctx.ShouldBindJSON(&timeInterval); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
+		return
+	}
+
+	timeInterval.UserID = uc.Uid
+
+	if err := t.timeIntervalService.CreateMonitorTimeInterval(ctx, &timeInterval); err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.Success(ctx)
+}
+
+// UpdateMonitorTimeInterval 更新现有的时间段
+func (t *TimeIntervalHandler) UpdateMonitorTimeInterval(ctx *gin.Context) {
+	var timeInterval model.MonitorTimeInterval
+
+	if err := ctx.ShouldBindJSON(&timeInterval); err != nil {
+		apiresponse.ErrorWithDetails(ctx, err, "参数错误")
+		return
+	}
+
+	if err := t.timeIntervalService.UpdateMonitorTimeInterval(ctx, &timeInterval); err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.Success(ctx)
+}
+
+// DeleteMonitorTimeInterval 删除指定的时间段
+func (t *TimeIntervalHandler) DeleteMonitorTimeInterval(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, "参数错误")
+		return
+	}
+
+	if err := t.timeIntervalService.DeleteMonitorTimeInterval(ctx, id); err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+
+	apiresponse.Success(ctx)
+}
@@ -30,13 +30,17 @@ import (
 	"fmt"
 	"github.com/GoSimplicity/AI-CloudOps/internal/model"
 	alertPoolDao "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/alert"
+	pkg "github.com/GoSimplicity/AI-CloudOps/pkg/utils/prometheus"
 	altconfig "github.com/prometheus/alertmanager/config"
 	al "github.com/prometheus/alertmanager/pkg/labels"
 	pm "github.com/prometheus/common/model"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v3"
+	"net"
 	"os"
+	"sort"
+	"strings"
 	"sync"
 )
 
@@ -45,33 +49,49 @@ const alertSendGroupKey = "alert_send_group"
 type AlertConfigCache interface {
 	// GetAlertManagerMainConfigYamlByIP 根据IP地址获取AlertManager的主配置内容
 	GetAlertManagerMainConfigYamlByIP(ip string) string
+	// GetAlertManagerFilesByIP 根据IP地址获取主配置引用的平台管理文件，由 config-agent 写入 AlertManager 主机
+	GetAlertManagerFilesByIP(ip string) string
 	// GenerateAlertManagerMainConfig 生成所有AlertManager主配置文件
 	GenerateAlertManagerMainConfig(ctx context.Context) error
-	// GenerateAlertManagerMainConfigOnePool 生成单个AlertManager池的主配置
-	GenerateAlertManagerMainConfigOnePool(pool *model.MonitorAlertManagerPool) *altconfig.Config
-	// GenerateAlertManagerRouteConfigOnePool 生成单个AlertManager池的routes和receivers配置
-	GenerateAlertManagerRouteConfigOnePool(ctx context.Context, pool *model.MonitorAlertManagerPool) ([]*altconfig.Route, []altconfig.Receiver)
+	// GenerateAlertManagerMainConfigOnePool 生成单个AlertManager池的主配置，SMTP 密码文件记录到 files 中
+	GenerateAlertManagerMainConfigOnePool(pool *model.MonitorAlertManagerPool, files map[string]string) *altconfig.Config
+	// GenerateAlertManagerRouteConfigOnePool 生成单个AlertManager池的routes、receivers以及发送组引用的time_intervals配置，第三方 Webhook 的地址文件记录到 files 中
+	GenerateAlertManagerRouteConfigOnePool(ctx context.Context, pool *model.MonitorAlertManagerPool, files map[string]string) ([]*altconfig.Route, []altconfig.Receiver, []altconfig.TimeInterval)
+	// GenerateAlertManagerInhibitRulesOnePool 生成单个AlertManager池的inhibit_rules配置
+	GenerateAlertManagerInhibitRulesOnePool(ctx context.Context, pool *model.MonitorAlertManagerPool) []altconfig.InhibitRule
 }
 
 type alertConfigCache struct {
 	AlertManagerMainConfigMap map[string]string // 存储AlertManager主配置
+	AlertManagerFilesMap      map[string]string // 存储主配置引用的平台管理文件，键为IP地址
 	l                         *zap.Logger
 	mu                        sync.RWMutex // 读写锁，保护缓存数据
 	localYamlDir              string       // 本地YAML目录
+	filesDir                  string       // AlertManager 主机上 config-agent 写入平台管理文件的目录
 	alertWebhookAddr          string       // Alertmanager Webhook地址
 	alertPoolDao              alertPoolDao.AlertManagerPoolDAO
 	alertSendDao              alertPoolDao.AlertManagerSendDAO
+	alertInhibitDao           alertPoolDao.AlertManagerInhibitDAO
+	alertTimeDao              alertPoolDao.AlertManagerTimeIntervalDAO
+	versionCache              ConfigVersionCache
+	errorCache                ConfigErrorCache
 }
 
-func NewAlertConfigCache(l *zap.Logger, alertPoolDao alertPoolDao.AlertManagerPoolDAO, alertSendDao alertPoolDao.AlertManagerSendDAO) AlertConfigCache {
+func NewAlertConfigCache(l *zap.Logger, alertPoolDao alertPoolDao.AlertManagerPoolDAO, alertSendDao alertPoolDao.AlertManagerSendDAO, alertInhibitDao alertPoolDao.AlertManagerInhibitDAO, alertTimeDao alertPoolDao.AlertManagerTimeIntervalDAO, versionCache ConfigVersionCache, errorCache ConfigErrorCache) AlertConfigCache {
 	return &alertConfigCache{
 		AlertManagerMainConfigMap: make(map[string]string),
+		AlertManagerFilesMap:      make(map[string]string),
 		l:                         l,
 		localYamlDir:              viper.GetString("prometheus.local_yaml_dir"),
+		filesDir:                  configFilesDir("prometheus.alertmanager_files_dir", defaultAlertManagerFilesDir),
 		alertWebhookAddr:          viper.GetString("prometheus.alert_webhook_addr"),
 		mu:                        sync.RWMutex{},
 		alertPoolDao:              alertPoolDao,
 		alertSendDao:              alertSendDao,
+		alertInhibitDao:           alertInhibitDao,
+		alertTimeDao:              alertTimeDao,
+		versionCache:              versionCache,
+		errorCache:                errorCache,
 	}
 }
 
@@ -81,6 +101,12 @@ func (a *alertConfigCache) GetAlertManagerMainConfigYamlByIP(ip string) string {
 	return a.AlertManagerMainConfigMap[ip]
 }
 
+func (a *alertConfigCache) GetAlertManagerFilesByIP(ip string) string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.AlertManagerFilesMap[ip]
+}
+
 func (a *alertConfigCache) GenerateAlertManagerMainConfig(ctx context.Context) error {
 	// 从数据库中获取所有AlertManager采集池
 	pools, err := a.alertPoolDao.GetAllAlertManagerPools(ctx)
@@ -91,20 +117,25 @@ func (a *alertConfigCache) GenerateAlertManagerMainConfig(ctx context.Context) e
 
 	if len(pools) == 0 {
 		a.l.Info("[监控模块]没有找到任何AlertManager采集池")
+		a.errorCache.ReplaceConfigErrors(ConfigTypeAlertManager, nil)
 		return err
 	}
 
 	mainConfigMap := make(map[string]string)
+	filesMap := make(map[string]string)
+	var configErrs []*model.MonitorConfigError
 
 	for _, pool := range pools {
-		// 生成单个AlertManager池的主配置
-		oneConfig := a.GenerateAlertManagerMainConfigOnePool(pool)
+		// 生成单个AlertManager池的主配置，池内所有实例使用相同的平台管理文件
+		files := make(map[string]string)
+		oneConfig := a.GenerateAlertManagerMainConfigOnePool(pool, files)
 
-		// 生成对应的routes和receivers配置
-		routes, receivers := a.GenerateAlertManagerRouteConfigOnePool(ctx, pool)
+		// 生成对应的routes、receivers和time_intervals配置
+		routes, receivers, timeIntervals := a.GenerateAlertManagerRouteConfigOnePool(ctx, pool, files)
 		if len(routes) > 0 {
 			oneConfig.Route.Routes = routes
 		}
+		oneConfig.TimeIntervals = timeIntervals
 
 		if len(receivers) > 0 {
 			if oneConfig.Receivers == nil {
@@ -113,6 +144,15 @@ func (a *alertConfigCache) GenerateAlertManagerMainConfig(ctx context.Context) e
 				oneConfig.Receivers = append(oneConfig.Receivers, receivers...)
 			}
 		}
+
+		// 生成抑制规则
+		oneConfig.InhibitRules = a.GenerateAlertManagerInhibitRulesOnePool(ctx, pool)
+
+		// 默认接收者不是任何发送组时补充一个空接收者，否则 AlertManager 会因未定义接收者拒绝加载
+		if oneConfig.Route.Receiver != "" && !hasReceiver(oneConfig.Receivers, oneConfig.Route.Receiver) {
+			oneConfig.Receivers = append(oneConfig.Receivers, altconfig.Receiver{Name: oneConfig.Route.Receiver})
+		}
+
 		// 序列化配置为YAML格式
 		config, err := yaml.Marshal(oneConfig)
 		if err != nil {
@@ -127,6 +167,15 @@ func (a *alertConfigCache) GenerateAlertManagerMainConfig(ctx context.Context) e
 			zap.ByteString("配置", config),
 		)
 
+		filesContent, err := encodeConfigFiles(files)
+		if err != nil {
+			a.l.Error("[监控模块]序列化平台管理文件失败",
+				zap.Error(err),
+				zap.String("池子", pool.Name),
+			)
+			continue
+		}
+
 		// 写入配置文件并更新缓存
 		for index, ip := range pool.AlertManagerInstances {
 			fileName := fmt.Sprintf("%s/alertmanager_pool_%s_%s_%d.yaml",
@@ -136,7 +185,16 @@ func (a *alertConfigCache) GenerateAlertManagerMainConfig(ctx context.Context) e
 				index,
 			)
 
-			if err := os.WriteFile(fileName, config, 0644); err != nil {
+			// 校验并记录配置版本，校验失败时回退到最近一次校验通过的版本
+			content, fallback, err := validateAndApply(ctx, a.versionCache, ConfigTypeAlertManager, pool.ID, ip, string(config))
+			if err != nil {
+				configErrs = append(configErrs, newConfigError(ConfigTypeAlertManager, pool.ID, pool.Name, ip, "", err, fallback))
+				if !fallback {
+					continue
+				}
+			}
+
+			if err := os.WriteFile(fileName, []byte(content), 0644); err != nil {
 				a.l.Error("[监控模块]写入AlertManager配置文件失败",
 					zap.Error(err),
 					zap.String("文件路径", fileName),
@@ -145,18 +203,22 @@ func (a *alertConfigCache) GenerateAlertManagerMainConfig(ctx context.Context) e
 			}
 
 			// 配置存入map中
-			mainConfigMap[ip] = string(config)
+			mainConfigMap[ip] = content
+			filesMap[ip] = filesContent
 		}
 	}
 
+	a.errorCache.ReplaceConfigErrors(ConfigTypeAlertManager, configErrs)
+
 	a.mu.Lock()
 	a.AlertManagerMainConfigMap = mainConfigMap
+	a.AlertManagerFilesMap = filesMap
 	a.mu.Unlock()
 
 	return nil
 }
 
-func (a *alertConfigCache) GenerateAlertManagerMainConfigOnePool(pool *model.MonitorAlertManagerPool) *altconfig.Config {
+func (a *alertConfigCache) GenerateAlertManagerMainConfigOnePool(pool *model.MonitorAlertManagerPool, files map[string]string) *altconfig.Config {
 	// 解析默认恢复时间
 	resolveTimeout, err := pm.ParseDuration(pool.ResolveTimeout)
 	if err != nil {
@@ -200,7 +262,8 @@ func (a *alertConfigCache) GenerateAlertManagerMainConfigOnePool(pool *model.Mon
 	// 生成 Alertmanager 默认配置
 	config := &altconfig.Config{
 		Global: &altconfig.GlobalConfig{
-			ResolveTimeout: resolveTimeout, // 设置恢复超时时间
+			ResolveTimeout: resolveTimeout,           // 设置恢复超时时间
+			SMTPRequireTLS: pool.SmtpRequireTLS != 2, // 邮件通知是否要求STARTTLS
 		},
 		Route: &altconfig.Route{ // 设置默认路由
 			Receiver:       pool.Receiver,   // 设置默认接收者
@@ -211,6 +274,9 @@ func (a *alertConfigCache) GenerateAlertManagerMainConfigOnePool(pool *model.Mon
 		},
 	}
 
+	// 设置邮件通知使用的 SMTP 服务器
+	a.setSmtpGlobalConfig(pool, config.Global, files)
+
 	//// 如果有默认rs列表中Receiver，则添加到Receive
 	//if config.Route.Receiver != "" {
 	//	config.Receivers = []altconfig.Receiver{
@@ -223,7 +289,7 @@ func (a *alertConfigCache) GenerateAlertManagerMainConfigOnePool(pool *model.Mon
 	return config
 }
 
-func (a *alertConfigCache) GenerateAlertManagerRouteConfigOnePool(ctx context.Context, pool *model.MonitorAlertManagerPool) ([]*altconfig.Route, []altconfig.Receiver) {
+func (a *alertConfigCache) GenerateAlertManagerRouteConfigOnePool(ctx context.Context, pool *model.MonitorAlertManagerPool, files map[string]string) ([]*altconfig.Route, []altconfig.Receiver, []altconfig.TimeInterval) {
 	// 从数据库中查找该AlertManager池的所有发送组
 	sendGroups, err := a.alertSendDao.GetMonitorSendGroupByPoolId(ctx, pool.ID)
 	if err != nil {
@@ -231,11 +297,21 @@ func (a *alertConfigCache) GenerateAlertManagerRouteConfigOnePool(ctx context.Co
 			zap.Error(err),
 			zap.String("池子", pool.Name),
 		)
-		return nil, nil
+		return nil, nil, nil
 	}
 	if len(sendGroups) == 0 {
 		a.l.Info("[监控模块]没有找到发送组", zap.String("池子", pool.Name))
-		return nil, nil
+		return nil, nil, nil
+	}
+
+	// 该池的所有时间段，只输出被发送组引用的时间段
+	poolTimeIntervals := a.generateTimeIntervalsOnePool(ctx, pool)
+	usedTimeIntervals := make(map[int]bool)
+
+	// 发送组ID到接收者名称的映射，子路由可以将告警交给同一实例下的其他发送组
+	receiverNames := make(map[int]string, len(sendGroups))
+	for _, sendGroup := range sendGroups {
+		receiverNames[sendGroup.ID] = sendGroup.Name
 	}
 
 	var routes []*altconfig.Route
@@ -271,6 +347,68 @@ func (a *alertConfigCache) GenerateAlertManagerRouteConfigOnePool(ctx context.Co
 			RepeatInterval: &repeatInterval,        // 设置重复发送时间
 		}
 
+		// 设置发送组自定义的分组、等待时间、额外匹配条件和子路由
+		if err := pkg.ApplySendGroupRouting(route, sendGroup, receiverNames); err != nil {
+			a.l.Error("[监控模块]生成发送组路由失败",
+				zap.Error(err),
+				zap.String("发送组", sendGroup.Name),
+			)
+			continue
+		}
+
+		// 设置静默和生效时间段，引用的时间段不存在或无效时忽略
+		for _, id := range sendGroup.MuteTimeIntervalIDs {
+			if ti, ok := poolTimeIntervals[id]; ok {
+				route.MuteTimeIntervals = append(route.MuteTimeIntervals, ti.Name)
+				usedTimeIntervals[id] = true
+			}
+		}
+		for _, id := range sendGroup.ActiveTimeIntervalIDs {
+			if ti, ok := poolTimeIntervals[id]; ok {
+				route.ActiveTimeIntervals = append(route.ActiveTimeIntervals, ti.Name)
+				usedTimeIntervals[id] = true
+			}
+		}
+
+		// 根据通知方式创建Receiver
+		receiver, err := a.generateSendGroupReceiver(pool, sendGroup, files)
+		if err != nil {
+			a.l.Error("[监控模块]生成发送组接收者失败",
+				zap.Error(err),
+				zap.String("发送组", sendGroup.Name),
+			)
+			continue
+		}
+		// 添加到routes和receivers中
+		routes = append(routes, route)
+		receivers = append(receivers, receiver)
+	}
+
+	var timeIntervals []altconfig.TimeInterval
+	for id, ti := range poolTimeIntervals {
+		if usedTimeIntervals[id] {
+			timeIntervals = append(timeIntervals, ti)
+		}
+	}
+	sort.Slice(timeIntervals, func(i, j int) bool {
+		return timeIntervals[i].Name < timeIntervals[j].Name
+	})
+
+	return routes, receivers, timeIntervals
+}
+
+// generateSendGroupReceiver 根据发送组的通知方式生成接收者，包括平台 Webhook、邮件和第三方 Webhook
+func (a *alertConfigCache) generateSendGroupReceiver(pool *model.MonitorAlertManagerPool, sendGroup *model.MonitorSendGroup, files map[string]string) (altconfig.Receiver, error) {
+	receiver := altconfig.Receiver{
+		Name: sendGroup.Name, // 接收者名称
+	}
+	notifierConfig := altconfig.NotifierConfig{ // Notifier配置 用于告警通知
+		VSendResolved: sendGroup.SendResolved == 1, // 在告警解决时是否发送通知
+	}
+
+	platform, email, webhook := pkg.SendGroupNotifyTargets(sendGroup)
+
+	if platform {
 		// 拼接Webhook URL
 		webHookURL := fmt.Sprintf("%s?%s=%d",
 			a.alertWebhookAddr,
@@ -280,32 +418,142 @@ func (a *alertConfigCache) GenerateAlertManagerRouteConfigOnePool(ctx context.Co
 
 		// 将 URL 写入到 .txt 文件
 		urlFilePath := fmt.Sprintf("%s/webhook_url_%d.txt", a.localYamlDir, sendGroup.ID)
-		err = os.WriteFile(urlFilePath, []byte(webHookURL), 0644)
+		if err := os.WriteFile(urlFilePath, []byte(webHookURL), 0644); err != nil {
+			return receiver, fmt.Errorf("写入Webhook URL文件失败: %w", err)
+		}
+
+		receiver.WebhookConfigs = append(receiver.WebhookConfigs, &altconfig.WebhookConfig{
+			NotifierConfig: notifierConfig,
+			URLFile:        urlFilePath,
+		})
+	}
+
+	if email && len(sendGroup.EmailTo) > 0 {
+		// 没有 SMTP 服务器时 AlertManager 会拒绝加载配置，只忽略邮件通知
+		if pool.SmtpSmarthost == "" {
+			a.l.Warn("[监控模块]AlertManager池没有设置SMTP服务器，忽略发送组的邮件通知",
+				zap.String("池子", pool.Name),
+				zap.String("发送组", sendGroup.Name),
+			)
+		} else {
+			receiver.EmailConfigs = append(receiver.EmailConfigs, &altconfig.EmailConfig{
+				NotifierConfig: notifierConfig,
+				To:             strings.Join(sendGroup.EmailTo, ", "),
+			})
+		}
+	}
+
+	if webhook {
+		for i, w := range sendGroup.Webhooks {
+			httpConfig, err := pkg.BuildWebhookHTTPConfig(w)
+			if err != nil {
+				return receiver, fmt.Errorf("第 %d 个Webhook设置无效: %w", i+1, err)
+			}
+
+			// 第三方地址可能包含 Token，由 config-agent 写入 AlertManager 主机上的文件，避免序列化为 <secret>
+			urlFilePath := addConfigFile(files, a.filesDir, fmt.Sprintf("webhook_url_%d_%d.txt", sendGroup.ID, i), w.Url)
+
+			receiver.WebhookConfigs = append(receiver.WebhookConfigs, &altconfig.WebhookConfig{
+				NotifierConfig: notifierConfig,
+				HTTPConfig:     httpConfig,
+				URLFile:        urlFilePath,
+				MaxAlerts:      w.MaxAlerts,
+			})
+		}
+	}
+
+	return receiver, nil
+}
+
+// setSmtpGlobalConfig 将 AlertManager 池的 SMTP 设置写入全局配置，密码由 config-agent 写入 AlertManager 主机上的文件，避免序列化为 <secret>
+func (a *alertConfigCache) setSmtpGlobalConfig(pool *model.MonitorAlertManagerPool, global *altconfig.GlobalConfig, files map[string]string) {
+	if pool.SmtpSmarthost == "" {
+		return
+	}
+
+	host, port, err := net.SplitHostPort(pool.SmtpSmarthost)
+	if err != nil {
+		a.l.Error("[监控模块]解析SMTP服务器地址失败",
+			zap.Error(err),
+			zap.String("池子", pool.Name),
+		)
+		return
+	}
+
+	global.SMTPSmarthost = altconfig.HostPort{Host: host, Port: port}
+	global.SMTPFrom = pool.SmtpFrom
+	global.SMTPAuthUsername = pool.SmtpAuthUsername
+
+	if pool.SmtpAuthPassword != "" {
+		global.SMTPAuthPasswordFile = addConfigFile(files, a.filesDir, fmt.Sprintf("smtp_password_%d.txt", pool.ID), pool.SmtpAuthPassword)
+	}
+}
+
+// generateTimeIntervalsOnePool 生成单个AlertManager池的所有时间段，按ID索引
+func (a *alertConfigCache) generateTimeIntervalsOnePool(ctx context.Context, pool *model.MonitorAlertManagerPool) map[int]altconfig.TimeInterval {
+	timeIntervals, err := a.alertTimeDao.GetMonitorTimeIntervalsByPoolId(ctx, pool.ID)
+	if err != nil {
+		a.l.Error("[监控模块]根据AlertManager池ID查找时间段错误",
+			zap.Error(err),
+			zap.String("池子", pool.Name),
+		)
+		return nil
+	}
+
+	result := make(map[int]altconfig.TimeInterval, len(timeIntervals))
+	for _, timeInterval := range timeIntervals {
+		// 保存时已经校验过时间段条件，这里解析失败只忽略该时间段，不影响整个配置
+		ti, err := pkg.BuildTimeInterval(timeInterval)
 		if err != nil {
-			a.l.Error("[监控模块]写入Webhook URL文件失败",
+			a.l.Error("[监控模块]生成时间段失败",
 				zap.Error(err),
-				zap.String("Webhook URL", webHookURL),
-				zap.String("发送组", sendGroup.Name),
+				zap.String("时间段", timeInterval.Name),
+				zap.String("池子", pool.Name),
+			)
+			continue
+		}
+		result[timeInterval.ID] = ti
+	}
+
+	return result
+}
+
+func (a *alertConfigCache) GenerateAlertManagerInhibitRulesOnePool(ctx context.Context, pool *model.MonitorAlertManagerPool) []altconfig.InhibitRule {
+	// 从数据库中查找该AlertManager池启用的抑制规则
+	inhibitRules, err := a.alertInhibitDao.GetEnabledMonitorInhibitRulesByPoolId(ctx, pool.ID)
+	if err != nil {
+		a.l.Error("[监控模块]根据AlertManager池ID查找抑制规则错误",
+			zap.Error(err),
+			zap.String("池子", pool.Name),
+		)
+		return nil
+	}
+
+	var rules []altconfig.InhibitRule
+	for _, inhibitRule := range inhibitRules {
+		// 保存时已经校验过匹配条件，这里解析失败只跳过该规则，不影响整个配置
+		rule, err := pkg.BuildInhibitRule(inhibitRule)
+		if err != nil {
+			a.l.Error("[监控模块]生成抑制规则失败",
+				zap.Error(err),
+				zap.String("抑制规则", inhibitRule.Name),
+				zap.String("池子", pool.Name),
 			)
 			continue
 		}
+		rules = append(rules, rule)
+	}
+
+	return rules
+}
 
-		// 创建Receiver
-		receiver := altconfig.Receiver{
-			Name: sendGroup.Name, // 接收者名称
-			WebhookConfigs: []*altconfig.WebhookConfig{ // Webhook配置
-				{
-					NotifierConfig: altconfig.NotifierConfig{ // Notifier配置 用于告警通知
-						VSendResolved: sendGroup.SendResolved == 1, // 在告警解决时是否发送通知
-					},
-					URLFile: urlFilePath,
-				},
-			},
+// hasReceiver 判断接收者列表中是否已有指定名称的接收者
+func hasReceiver(receivers []altconfig.Receiver, name string) bool {
+	for _, receiver := range receivers {
+		if receiver.Name == name {
+			return true
 		}
-		// 添加到routes和receivers中
-		routes = append(routes, route)
-		receivers = append(receivers, receiver)
 	}
 
-	return routes, receivers
+	return false
 }
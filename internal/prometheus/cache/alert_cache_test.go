@@ -0,0 +1,109 @@
+package cache
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"os"
+	"testing"
+
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	altconfig "github.com/prometheus/alertmanager/config"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+func TestAlertManagerSecretFiles(t *testing.T) {
+	const filesDir = "/etc/alertmanager/platform_files"
+	localYamlDir := t.TempDir()
+	a := &alertConfigCache{
+		l:                zap.NewNop(),
+		localYamlDir:     localYamlDir,
+		filesDir:         filesDir,
+		alertWebhookAddr: "http://127.0.0.1:8889/api/v1/alerts/receive",
+	}
+
+	pool := &model.MonitorAlertManagerPool{
+		Model:            model.Model{ID: 2},
+		Name:             "am",
+		Receiver:         "sg",
+		SmtpSmarthost:    "smtp.example.com:587",
+		SmtpFrom:         "alert@example.com",
+		SmtpAuthUsername: "alert",
+		SmtpAuthPassword: "smtp-secret",
+	}
+	sendGroup := &model.MonitorSendGroup{
+		Model:         model.Model{ID: 5},
+		Name:          "sg",
+		NotifyMethods: model.StringList{model.NotifyMethodEmail, model.NotifyMethodWebhook},
+		EmailTo:       []string{"ops@example.com"},
+		Webhooks:      []model.SendGroupWebhook{{Url: "https://hook.example.com/send?token=hook-secret"}},
+	}
+
+	files := make(map[string]string)
+	config := a.GenerateAlertManagerMainConfigOnePool(pool, files)
+	receiver, err := a.generateSendGroupReceiver(pool, sendGroup, files)
+	if err != nil {
+		t.Fatalf("generateSendGroupReceiver() err = %v", err)
+	}
+	config.Receivers = []altconfig.Receiver{receiver}
+
+	passwordFile := filesDir + "/smtp_password_2.txt"
+	if config.Global.SMTPAuthPasswordFile != passwordFile {
+		t.Errorf("smtp_auth_password_file = %q, want %q", config.Global.SMTPAuthPasswordFile, passwordFile)
+	}
+	if files[passwordFile] != "smtp-secret" {
+		t.Errorf("SMTP 密码文件内容 = %q", files[passwordFile])
+	}
+
+	urlFile := filesDir + "/webhook_url_5_0.txt"
+	if len(receiver.WebhookConfigs) != 1 || receiver.WebhookConfigs[0].URLFile != urlFile {
+		t.Fatalf("第三方 Webhook 应引用 %s: %+v", urlFile, receiver.WebhookConfigs)
+	}
+	if files[urlFile] != sendGroup.Webhooks[0].Url {
+		t.Errorf("Webhook 地址文件内容 = %q", files[urlFile])
+	}
+	if len(receiver.EmailConfigs) != 1 {
+		t.Errorf("邮件通知数量 = %d, want 1", len(receiver.EmailConfigs))
+	}
+
+	// 敏感信息不写入平台的本地目录
+	entries, err := os.ReadDir(localYamlDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("本地目录中不应有文件，实际有 %d 个", len(entries))
+	}
+
+	// 生成的配置引用绝对路径，能够通过 AlertManager 的校验
+	content, err := yaml.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateConfig(ConfigTypeAlertManager, string(content)); err != nil {
+		t.Errorf("配置校验失败: %v", err)
+	}
+}
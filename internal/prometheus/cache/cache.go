@@ -44,15 +44,19 @@ type monitorCache struct {
 	AlertMangerMainConfig AlertConfigCache
 	AlertRuleConfig       RuleConfigCache
 	AlertRecordConfig     RecordConfigCache
+	ConfigSync            ConfigSyncCache
+	ConfigWatch           ConfigWatchCache
 	l                     *zap.Logger
 }
 
-func NewMonitorCache(PrometheusMainConfig PromConfigCache, AlertMangerMainConfig AlertConfigCache, AlertRuleConfig RuleConfigCache, AlertRecordConfig RecordConfigCache, l *zap.Logger) MonitorCache {
+func NewMonitorCache(PrometheusMainConfig PromConfigCache, AlertMangerMainConfig AlertConfigCache, AlertRuleConfig RuleConfigCache, AlertRecordConfig RecordConfigCache, ConfigSync ConfigSyncCache, ConfigWatch ConfigWatchCache, l *zap.Logger) MonitorCache {
 	return &monitorCache{
 		PrometheusMainConfig:  PrometheusMainConfig,
 		AlertMangerMainConfig: AlertMangerMainConfig,
 		AlertRuleConfig:       AlertRuleConfig,
 		AlertRecordConfig:     AlertRecordConfig,
+		ConfigSync:            ConfigSync,
+		ConfigWatch:           ConfigWatch,
 		l:                     l,
 	}
 }
@@ -91,6 +95,10 @@ func (mc *monitorCache) MonitorCacheManager(ctx context.Context) error {
 	wg.Wait()
 	close(errChan)
 
+	// 部分任务失败时其他配置仍可能已更新，始终唤醒长轮询请求并通知实例重载
+	mc.ConfigWatch.NotifyChanged()
+	mc.ConfigSync.TriggerSync()
+
 	// 收集所有错误
 	var aggregatedErrors []error
 	for err := range errChan {
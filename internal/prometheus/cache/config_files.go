@@ -0,0 +1,86 @@
+package cache
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"encoding/json"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"github.com/spf13/viper"
+	"path"
+	"sort"
+)
+
+// 配置引用的平台管理文件，由 config-agent 拉取后写入实例主机，文件变化不需要重载实例，也不参与已应用配置的上报
+const (
+	ConfigTypePrometheusFiles   = "prometheus_files"
+	ConfigTypeAlertManagerFiles = "alertManager_files"
+)
+
+// config-agent 写入平台管理文件的默认目录，需与实例上 agent.files_dir 的配置一致
+const (
+	defaultPrometheusFilesDir   = "/etc/prometheus/platform_files"
+	defaultAlertManagerFilesDir = "/etc/alertmanager/platform_files"
+)
+
+// configFilesDir 读取实例主机上平台管理文件的目录，未配置时使用默认目录
+func configFilesDir(key, defaultDir string) string {
+	if dir := viper.GetString(key); dir != "" {
+		return dir
+	}
+
+	return defaultDir
+}
+
+// addConfigFile 记录需要下发的文件，返回文件在实例主机上的路径
+// 实例主机不一定与平台使用相同的操作系统，路径统一使用 / 分隔
+func addConfigFile(files map[string]string, dir, name, content string) string {
+	filePath := path.Join(dir, name)
+	files[filePath] = content
+
+	return filePath
+}
+
+// encodeConfigFiles 将下发的文件按路径排序后序列化，没有文件时返回空字符串
+func encodeConfigFiles(files map[string]string) (string, error) {
+	if len(files) == 0 {
+		return "", nil
+	}
+
+	list := make([]model.MonitorConfigFile, 0, len(files))
+	for filePath, content := range files {
+		list = append(list, model.MonitorConfigFile{Path: filePath, Content: content})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Path < list[j].Path
+	})
+
+	data, err := json.Marshal(list)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
@@ -53,33 +53,44 @@ const hashTmpKey = "__tmp_hash"
 type PromConfigCache interface {
 	// GetPrometheusMainConfigByIP 根据IP地址获取Prometheus的主配置内容
 	GetPrometheusMainConfigByIP(ip string) string
+	// GetPrometheusFilesByIP 根据IP地址获取主配置引用的平台管理文件，由 config-agent 写入 Prometheus 主机
+	GetPrometheusFilesByIP(ip string) string
 	// GeneratePrometheusMainConfig 生成所有Prometheus主配置文件
 	GeneratePrometheusMainConfig(ctx context.Context) error
 	// CreateBasePrometheusConfig 创建基础Prometheus配置
 	CreateBasePrometheusConfig(pool *model.MonitorScrapePool) (pc.Config, error)
-	// GenerateScrapeConfigs 生成采集配置
-	GenerateScrapeConfigs(ctx context.Context, pool *model.MonitorScrapePool) []*pc.ScrapeConfig
+	// GenerateScrapeConfigs 生成采集配置，无法生成的采集任务会被跳过并返回对应的错误
+	// 采集配置引用的平台管理文件记录到 files 中，键为 Prometheus 主机上的路径
+	GenerateScrapeConfigs(ctx context.Context, pool *model.MonitorScrapePool, files map[string]string) ([]*pc.ScrapeConfig, []*model.MonitorConfigError)
 	// ApplyHashMod 应用HashMod和Keep Relabel配置进行分片
 	ApplyHashMod(scrapeConfigs []*pc.ScrapeConfig, modNum, index int) []*pc.ScrapeConfig
 }
 
 type promConfigCache struct {
 	PrometheusMainConfigMap map[string]string // 存储Prometheus主配置，键为IP地址
+	PrometheusFilesMap      map[string]string // 存储主配置引用的平台管理文件，键为IP地址
 	mu                      sync.RWMutex      // 读写锁，保护缓存数据
 	l                       *zap.Logger       // 日志记录器
 	localYamlDir            string            // 本地YAML目录
+	filesDir                string            // Prometheus 主机上 config-agent 写入平台管理文件的目录
 	scrapePoolDao           scrapeJobDao.ScrapePoolDAO
 	scrapeJobDao            scrapeJobDao.ScrapeJobDAO
+	versionCache            ConfigVersionCache
+	errorCache              ConfigErrorCache
 	httpSdAPI               string // HTTP服务发现API地址
 }
 
-func NewPromConfigCache(l *zap.Logger, scrapePoolDao scrapeJobDao.ScrapePoolDAO, scrapeJobDao scrapeJobDao.ScrapeJobDAO) PromConfigCache {
+func NewPromConfigCache(l *zap.Logger, scrapePoolDao scrapeJobDao.ScrapePoolDAO, scrapeJobDao scrapeJobDao.ScrapeJobDAO, versionCache ConfigVersionCache, errorCache ConfigErrorCache) PromConfigCache {
 	return &promConfigCache{
 		PrometheusMainConfigMap: make(map[string]string),
+		PrometheusFilesMap:      make(map[string]string),
 		localYamlDir:            viper.GetString("prometheus.local_yaml_dir"),
+		filesDir:                configFilesDir("prometheus.prometheus_files_dir", defaultPrometheusFilesDir),
 		httpSdAPI:               viper.GetString("prometheus.httpSdAPI"),
 		scrapePoolDao:           scrapePoolDao,
 		scrapeJobDao:            scrapeJobDao,
+		versionCache:            versionCache,
+		errorCache:              errorCache,
 		l:                       l,
 		mu:                      sync.RWMutex{},
 	}
@@ -91,6 +102,12 @@ func (p *promConfigCache) GetPrometheusMainConfigByIP(ip string) string {
 	return p.PrometheusMainConfigMap[ip]
 }
 
+func (p *promConfigCache) GetPrometheusFilesByIP(ip string) string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.PrometheusFilesMap[ip]
+}
+
 func (p *promConfigCache) GeneratePrometheusMainConfig(ctx context.Context) error {
 	// 获取所有采集池
 	pools, err := p.scrapePoolDao.GetAllMonitorScrapePool(ctx)
@@ -101,28 +118,52 @@ func (p *promConfigCache) GeneratePrometheusMainConfig(ctx context.Context) erro
 
 	if len(pools) == 0 {
 		p.l.Info("没有找到任何采集池")
+		p.errorCache.ReplaceConfigErrors(ConfigTypePrometheus, nil)
 		return nil
 	}
 
 	// 创建新的配置映射key为ip，val为配置
 	newConfigMap := make(map[string]string)
+	newFilesMap := make(map[string]string)
+	var configErrs []*model.MonitorConfigError
 
 	for _, pool := range pools {
 		// 创建基础配置
 		baseConfig, err := p.CreateBasePrometheusConfig(pool)
 		if err != nil {
 			p.l.Error("创建基础 Prometheus 配置失败", zap.Error(err), zap.String("池名", pool.Name))
+			// 池的基础配置有误时，所有实例回退到最近一次校验通过的版本
+			for _, ip := range pool.PrometheusInstances {
+				content, fallback := fallbackConfig(ctx, p.versionCache, ConfigTypePrometheus, ip)
+				configErrs = append(configErrs, newConfigError(ConfigTypePrometheus, pool.ID, pool.Name, ip, "", err, fallback))
+				if fallback && p.writeConfigFile(ip, content) == nil {
+					newConfigMap[ip] = content
+				}
+			}
 			continue
 		}
 
-		// 生成采集配置
-		scrapeConfigs := p.GenerateScrapeConfigs(ctx, pool)
+		// 生成采集配置，池内所有实例使用相同的平台管理文件
+		files := make(map[string]string)
+		scrapeConfigs, jobErrs := p.GenerateScrapeConfigs(ctx, pool, files)
+		configErrs = append(configErrs, jobErrs...)
+
+		// 逐个校验采集任务，剔除无法通过校验的任务，避免单个任务影响整个池
+		scrapeConfigs, jobErrs = p.filterInvalidScrapeConfigs(pool, baseConfig.GlobalConfig, scrapeConfigs)
+		configErrs = append(configErrs, jobErrs...)
+
 		if len(scrapeConfigs) == 0 {
 			p.l.Warn("没有找到任何采集任务", zap.String("池名", pool.Name))
 			continue
 		}
 		baseConfig.ScrapeConfigs = scrapeConfigs
 
+		filesContent, err := encodeConfigFiles(files)
+		if err != nil {
+			p.l.Error("序列化平台管理文件失败", zap.Error(err), zap.String("池名", pool.Name))
+			continue
+		}
+
 		for idx, ip := range pool.PrometheusInstances {
 			configCopy := baseConfig // 浅拷贝
 			// 如果有多个实例，应用哈希分片
@@ -137,34 +178,79 @@ func (p *promConfigCache) GeneratePrometheusMainConfig(ctx context.Context) erro
 				continue
 			}
 
-			// 写入配置文件
-			filePath := fmt.Sprintf("%s/prometheus_pool_%s.yaml", p.localYamlDir, ip)
-
-			// 创建目录
-			dir := filepath.Dir(filePath)
-			if err := os.MkdirAll(dir, 0755); err != nil {
-				p.l.Error("创建目录失败", zap.Error(err), zap.String("目录路径", dir))
-				continue
+			// 校验并记录配置版本，校验失败时回退到最近一次校验通过的版本
+			content, fallback, err := validateAndApply(ctx, p.versionCache, ConfigTypePrometheus, pool.ID, ip, string(yamlData))
+			if err != nil {
+				configErrs = append(configErrs, newConfigError(ConfigTypePrometheus, pool.ID, pool.Name, ip, "", err, fallback))
+				if !fallback {
+					continue
+				}
 			}
 
-			if err := os.WriteFile(filePath, yamlData, 0644); err != nil {
-				p.l.Error("写入 Prometheus 配置文件失败", zap.Error(err), zap.String("文件路径", filePath))
+			if err := p.writeConfigFile(ip, content); err != nil {
 				continue
 			}
 
-			newConfigMap[ip] = string(yamlData)
+			newConfigMap[ip] = content
+			newFilesMap[ip] = filesContent
 			p.l.Debug("成功生成 Prometheus 配置", zap.String("池名", pool.Name), zap.String("IP", ip))
 		}
 	}
 
+	p.errorCache.ReplaceConfigErrors(ConfigTypePrometheus, configErrs)
+
 	// 更新缓存
 	p.mu.Lock()
 	p.PrometheusMainConfigMap = newConfigMap
+	p.PrometheusFilesMap = newFilesMap
 	p.mu.Unlock()
 
 	return nil
 }
 
+// writeConfigFile 将实例的 Prometheus 配置写入本地文件
+func (p *promConfigCache) writeConfigFile(ip string, content string) error {
+	filePath := fmt.Sprintf("%s/prometheus_pool_%s.yaml", p.localYamlDir, ip)
+
+	// 创建目录
+	dir := filepath.Dir(filePath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		p.l.Error("创建目录失败", zap.Error(err), zap.String("目录路径", dir))
+		return err
+	}
+
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		p.l.Error("写入 Prometheus 配置文件失败", zap.Error(err), zap.String("文件路径", filePath))
+		return err
+	}
+
+	return nil
+}
+
+// filterInvalidScrapeConfigs 结合池的全局配置逐个校验采集任务，返回通过校验的任务
+func (p *promConfigCache) filterInvalidScrapeConfigs(pool *model.MonitorScrapePool, globalConfig pc.GlobalConfig, scrapeConfigs []*pc.ScrapeConfig) ([]*pc.ScrapeConfig, []*model.MonitorConfigError) {
+	var valid []*pc.ScrapeConfig
+	var configErrs []*model.MonitorConfigError
+
+	for _, sc := range scrapeConfigs {
+		yamlData, err := yaml.Marshal(pc.Config{
+			GlobalConfig:  globalConfig,
+			ScrapeConfigs: []*pc.ScrapeConfig{sc},
+		})
+		if err == nil {
+			err = ValidateConfig(ConfigTypePrometheus, string(yamlData))
+		}
+		if err != nil {
+			configErrs = append(configErrs, newConfigError(ConfigTypePrometheus, pool.ID, pool.Name, "", sc.JobName, err, false))
+			continue
+		}
+
+		valid = append(valid, sc)
+	}
+
+	return valid, configErrs
+}
+
 func (p *promConfigCache) CreateBasePrometheusConfig(pool *model.MonitorScrapePool) (pc.Config, error) {
 	// 创建prometheus global全局配置
 	globalConfig := pc.GlobalConfig{
@@ -178,41 +264,27 @@ func (p *promConfigCache) CreateBasePrometheusConfig(pool *model.MonitorScrapePo
 		globalConfig.ExternalLabels = labels.FromStrings(externalLabels...)
 	}
 
-	// 解析 RemoteWrite URL
-	remoteWriteURL, err := pkg.ParseURL(pool.RemoteWriteUrl)
+	// 生成远程写入和远程读取配置
+	remoteWriteConfigs, err := pkg.BuildRemoteWriteConfigs(pool)
 	if err != nil {
-		p.l.Error("解析 RemoteWriteUrl 失败", zap.Error(err))
-		return pc.Config{}, fmt.Errorf("解析 RemoteWriteUrl 失败: %w", err)
+		p.l.Error("生成 remote_write 配置失败", zap.Error(err))
+		return pc.Config{}, fmt.Errorf("生成 remote_write 配置失败: %w", err)
 	}
 
-	// 配置远程写入
-	remoteWrite := &pc.RemoteWriteConfig{
-		URL:           remoteWriteURL,
-		RemoteTimeout: pkg.GenPromDuration(pool.RemoteTimeoutSeconds),
+	remoteReadConfigs, err := pkg.BuildRemoteReadConfigs(pool)
+	if err != nil {
+		p.l.Error("生成 remote_read 配置失败", zap.Error(err))
+		return pc.Config{}, fmt.Errorf("生成 remote_read 配置失败: %w", err)
 	}
 
 	// 组装prometheus基础配置
 	config := pc.Config{
 		GlobalConfig:       globalConfig,
-		RemoteWriteConfigs: []*pc.RemoteWriteConfig{remoteWrite},
+		RemoteWriteConfigs: remoteWriteConfigs,
+		RemoteReadConfigs:  remoteReadConfigs,
 	}
 
 	if pool.SupportAlert == 1 { // 启用告警
-		// 解析 RemoteRead URL
-		remoteReadURL, err := pkg.ParseURL(pool.RemoteReadUrl)
-		if err != nil {
-			p.l.Error("解析 RemoteReadUrl 失败", zap.Error(err))
-			return pc.Config{}, fmt.Errorf("解析 RemoteReadUrl 失败: %w", err)
-		}
-
-		// 配置远程读取
-		config.RemoteReadConfigs = []*pc.RemoteReadConfig{
-			{
-				URL:           remoteReadURL,
-				RemoteTimeout: pkg.GenPromDuration(pool.RemoteTimeoutSeconds),
-			},
-		}
-
 		// 配置 Alertmanager
 		alertConfig := &pc.AlertmanagerConfig{
 			APIVersion: "v2",
@@ -246,19 +318,20 @@ func (p *promConfigCache) CreateBasePrometheusConfig(pool *model.MonitorScrapePo
 	return config, nil
 }
 
-func (p *promConfigCache) GenerateScrapeConfigs(ctx context.Context, pool *model.MonitorScrapePool) []*pc.ScrapeConfig {
+func (p *promConfigCache) GenerateScrapeConfigs(ctx context.Context, pool *model.MonitorScrapePool, files map[string]string) ([]*pc.ScrapeConfig, []*model.MonitorConfigError) {
 	// 获取与指定池相关的采集任务
 	scrapeJobs, err := p.scrapeJobDao.GetMonitorScrapeJobsByPoolId(ctx, pool.ID)
 	if err != nil {
 		p.l.Error("获取采集任务失败", zap.Error(err), zap.String("池名", pool.Name))
-		return nil
+		return nil, nil
 	}
 	if len(scrapeJobs) == 0 {
 		p.l.Info("没有找到任何采集任务", zap.String("池名", pool.Name))
-		return nil
+		return nil, nil
 	}
 
 	var scrapeConfigs []*pc.ScrapeConfig
+	var configErrs []*model.MonitorConfigError
 
 	for _, job := range scrapeJobs {
 		sc := &pc.ScrapeConfig{
@@ -269,17 +342,33 @@ func (p *promConfigCache) GenerateScrapeConfigs(ctx context.Context, pool *model
 			ScrapeTimeout:  pkg.GenPromDuration(job.ScrapeTimeout),
 		}
 
-		// 解析 Relabel 配置
-		if job.RelabelConfigsYamlString != "" {
-			if err := yaml.Unmarshal([]byte(job.RelabelConfigsYamlString), &sc.RelabelConfigs); err != nil {
-				p.l.Error("解析 Relabel 配置失败", zap.Error(err), zap.String("任务名", job.Name))
-				continue
-			}
+		// 解析 Relabel 和 Metric Relabel 配置
+		relabelConfigs, err := pkg.ParseRelabelConfigs(pkg.RelabelFieldRelabel, job.RelabelConfigsYamlString)
+		if err != nil {
+			p.l.Error("解析 Relabel 配置失败", zap.Error(err), zap.String("任务名", job.Name))
+			configErrs = append(configErrs, newConfigError(ConfigTypePrometheus, pool.ID, pool.Name, "", job.Name, err, false))
+			continue
+		}
+		metricRelabelConfigs, err := pkg.ParseRelabelConfigs(pkg.RelabelFieldMetricRelabel, job.MetricRelabelConfigsYamlString)
+		if err != nil {
+			p.l.Error("解析 Metric Relabel 配置失败", zap.Error(err), zap.String("任务名", job.Name))
+			configErrs = append(configErrs, newConfigError(ConfigTypePrometheus, pool.ID, pool.Name, "", job.Name, err, false))
+			continue
+		}
+		sc.RelabelConfigs = relabelConfigs
+		sc.MetricRelabelConfigs = metricRelabelConfigs
+
+		// 配置 HTTP 客户端、标签处理、限制和URL参数
+		if err := applyScrapeOptions(sc, job); err != nil {
+			p.l.Error("生成采集任务HTTP客户端配置失败", zap.Error(err), zap.String("任务名", job.Name))
+			configErrs = append(configErrs, newConfigError(ConfigTypePrometheus, pool.ID, pool.Name, "", job.Name, fmt.Errorf("生成HTTP客户端配置失败: %w", err), false))
+			continue
 		}
 
 		// 根据服务发现类型配置 ServiceDiscoveryConfigs
+		var sdErr error
 		switch job.ServiceDiscoveryType {
-		case "http":
+		case model.ServiceDiscoveryHttp:
 			if err != nil {
 				p.l.Error("获取 HTTP SD API 失败", zap.Error(err), zap.String("任务名", job.Name))
 				continue
@@ -294,15 +383,7 @@ func (p *promConfigCache) GenerateScrapeConfigs(ctx context.Context, pool *model
 					RefreshInterval: pkg.GenPromDuration(job.RefreshInterval),
 				},
 			}
-		case "k8s":
-			sc.HTTPClientConfig = pcc.HTTPClientConfig{ // 配置 HTTP 客户端配置
-				BearerTokenFile: job.BearerTokenFile, // 设置鉴权文件路径
-				TLSConfig: pcc.TLSConfig{ // 配置 TLS 配置
-					CAFile:             job.TlsCaFilePath, // 设置 CA 证书文件路径
-					InsecureSkipVerify: true,              // 跳过证书验证
-				},
-			}
-
+		case model.ServiceDiscoveryK8s:
 			sc.ServiceDiscoveryConfigs = discovery.Configs{
 				&kubernetes.SDConfig{
 					Role:             kubernetes.Role(job.KubernetesSdRole), // 设置k8s服务发现角色
@@ -310,15 +391,30 @@ func (p *promConfigCache) GenerateScrapeConfigs(ctx context.Context, pool *model
 					HTTPClientConfig: pcc.DefaultHTTPClientConfig,           // 使用默认的HTTP客户端配置
 				},
 			}
+		case model.ServiceDiscoveryStatic:
+			sc.ServiceDiscoveryConfigs, sdErr = staticSdConfigs(job)
+		case model.ServiceDiscoveryFile:
+			sc.ServiceDiscoveryConfigs, sdErr = fileSdConfigs(job, p.filesDir, files)
+		case model.ServiceDiscoveryDns:
+			sc.ServiceDiscoveryConfigs, sdErr = dnsSdConfigs(job)
+		case model.ServiceDiscoveryConsul:
+			sc.ServiceDiscoveryConfigs, sdErr = consulSdConfigs(job)
 		default:
 			p.l.Warn("未知的服务发现类型", zap.String("类型", job.ServiceDiscoveryType), zap.String("任务名", job.Name))
+			configErrs = append(configErrs, newConfigError(ConfigTypePrometheus, pool.ID, pool.Name, "", job.Name, fmt.Errorf("未知的服务发现类型: %s", job.ServiceDiscoveryType), false))
+			continue
+		}
+
+		if sdErr != nil {
+			p.l.Error("生成服务发现配置失败", zap.Error(sdErr), zap.String("任务名", job.Name))
+			configErrs = append(configErrs, newConfigError(ConfigTypePrometheus, pool.ID, pool.Name, "", job.Name, fmt.Errorf("生成服务发现配置失败: %w", sdErr), false))
 			continue
 		}
 
 		scrapeConfigs = append(scrapeConfigs, sc)
 	}
 
-	return scrapeConfigs
+	return scrapeConfigs, configErrs
 }
 
 func (p *promConfigCache) ApplyHashMod(scrapeConfigs []*pc.ScrapeConfig, modNum, index int) []*pc.ScrapeConfig {
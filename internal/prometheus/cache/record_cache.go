@@ -31,8 +31,6 @@ import (
 	"github.com/GoSimplicity/AI-CloudOps/internal/model"
 	alertRecordDao "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/alert"
 	scrapePoolDao "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/scrape"
-	pm "github.com/prometheus/common/model"
-	"github.com/prometheus/prometheus/model/rulefmt"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v3"
@@ -45,38 +43,36 @@ type RecordConfigCache interface {
 	GetPrometheusRecordRuleConfigYamlByIp(ip string) string
 	// GenerateRecordRuleConfigYaml 生成并更新所有Prometheus的预聚合规则配置YAML
 	GenerateRecordRuleConfigYaml(ctx context.Context) error
-	// GeneratePrometheusRecordRuleConfigYamlOnePool 根据单个采集池生成Prometheus的预聚合规则配置YAML
-	GeneratePrometheusRecordRuleConfigYamlOnePool(ctx context.Context, pool *model.MonitorScrapePool) map[string]string
+	// GeneratePrometheusRecordRuleConfigYamlOnePool 根据单个采集池生成Prometheus的预聚合规则配置YAML，同时返回校验错误
+	GeneratePrometheusRecordRuleConfigYamlOnePool(ctx context.Context, pool *model.MonitorScrapePool) (map[string]string, []*model.MonitorConfigError)
 }
 
 type recordConfigCache struct {
-	mu             sync.RWMutex      // 读写锁，保护缓存数据
-	l              *zap.Logger       // 日志记录器
-	RecordRuleMap  map[string]string // 存储预聚合规则
-	localYamlDir   string            // 本地YAML目录
-	scrapePoolDao  scrapePoolDao.ScrapePoolDAO
-	alertRecordDao alertRecordDao.AlertManagerRecordDAO
+	mu            sync.RWMutex      // 读写锁，保护缓存数据
+	l             *zap.Logger       // 日志记录器
+	RecordRuleMap map[string]string // 存储预聚合规则
+	localYamlDir  string            // 本地YAML目录
+	scrapePoolDao scrapePoolDao.ScrapePoolDAO
+	groupBuilder  *ruleGroupBuilder
+	versionCache  ConfigVersionCache
+	errorCache    ConfigErrorCache
 }
 
-// RecordGroup 构造Prometheus record 结构体
-type RecordGroup struct {
-	Name  string         `yaml:"name"`
-	Rules []rulefmt.Rule `yaml:"rules"`
-}
-
-// RecordGroups 生成Prometheus record yaml
-type RecordGroups struct {
-	Groups []RecordGroup `yaml:"groups"`
-}
-
-func NewRecordConfig(l *zap.Logger, scrapePoolDao scrapePoolDao.ScrapePoolDAO, alertRecordDao alertRecordDao.AlertManagerRecordDAO) RecordConfigCache {
+func NewRecordConfig(l *zap.Logger, scrapePoolDao scrapePoolDao.ScrapePoolDAO, alertRecordDao alertRecordDao.AlertManagerRecordDAO, alertRuleDao alertRecordDao.AlertManagerRuleDAO, ruleGroupDao alertRecordDao.AlertManagerRuleGroupDAO, versionCache ConfigVersionCache, errorCache ConfigErrorCache) RecordConfigCache {
 	return &recordConfigCache{
-		l:              l,
-		localYamlDir:   viper.GetString("prometheus.local_yaml_dir"),
-		mu:             sync.RWMutex{},
-		RecordRuleMap:  make(map[string]string),
-		scrapePoolDao:  scrapePoolDao,
-		alertRecordDao: alertRecordDao,
+		l:             l,
+		localYamlDir:  viper.GetString("prometheus.local_yaml_dir"),
+		mu:            sync.RWMutex{},
+		RecordRuleMap: make(map[string]string),
+		scrapePoolDao: scrapePoolDao,
+		groupBuilder: &ruleGroupBuilder{
+			l:            l,
+			ruleGroupDao: ruleGroupDao,
+			alertRuleDao: alertRuleDao,
+			recordDao:    alertRecordDao,
+		},
+		versionCache: versionCache,
+		errorCache:   errorCache,
 	}
 }
 
@@ -97,14 +93,17 @@ func (r *recordConfigCache) GenerateRecordRuleConfigYaml(ctx context.Context) er
 
 	if len(pools) == 0 {
 		r.l.Info("没有找到支持预聚合的采集池")
+		r.errorCache.ReplaceConfigErrors(ConfigTypePrometheusRecord, nil)
 		return nil
 	}
 
 	recordConfigMap := make(map[string]string)
+	var configErrs []*model.MonitorConfigError
 
 	// 遍历每个采集池生成对应的预聚合规则配置
 	for _, pool := range pools {
-		oneMap := r.GeneratePrometheusRecordRuleConfigYamlOnePool(ctx, pool)
+		oneMap, poolErrs := r.GeneratePrometheusRecordRuleConfigYamlOnePool(ctx, pool)
+		configErrs = append(configErrs, poolErrs...)
 		if oneMap != nil {
 			for ip, out := range oneMap {
 				recordConfigMap[ip] = out
@@ -112,6 +111,8 @@ func (r *recordConfigCache) GenerateRecordRuleConfigYaml(ctx context.Context) er
 		}
 	}
 
+	r.errorCache.ReplaceConfigErrors(ConfigTypePrometheusRecord, configErrs)
+
 	r.mu.Lock()
 	r.RecordRuleMap = recordConfigMap
 	r.mu.Unlock()
@@ -120,62 +121,33 @@ func (r *recordConfigCache) GenerateRecordRuleConfigYaml(ctx context.Context) er
 }
 
 // GeneratePrometheusRecordRuleConfigYamlOnePool 根据单个采集池生成Prometheus的预聚合规则配置YAML
-func (r *recordConfigCache) GeneratePrometheusRecordRuleConfigYamlOnePool(ctx context.Context, pool *model.MonitorScrapePool) map[string]string {
-	rules, err := r.alertRecordDao.GetMonitorRecordRuleByPoolId(ctx, pool.ID)
+func (r *recordConfigCache) GeneratePrometheusRecordRuleConfigYamlOnePool(ctx context.Context, pool *model.MonitorScrapePool) (map[string]string, []*model.MonitorConfigError) {
+	// 构建规则组，只包含预聚合规则的规则组写入预聚合规则文件
+	groups, configErrs, err := r.groupBuilder.build(ctx, pool, ConfigTypePrometheusRecord)
 	if err != nil {
 		r.l.Error("[监控模块] 根据采集池ID获取预聚合规则失败",
 			zap.Error(err),
 			zap.String("池子", pool.Name),
 		)
 
-		return nil
-	}
-
-	if len(rules) == 0 {
-		return nil
+		return nil, nil
 	}
 
-	var recordGroups RecordGroups
-
-	// 构建规则组
-	for _, rule := range rules {
-		forD, err := pm.ParseDuration(rule.ForTime)
-		if err != nil {
-			r.l.Warn("[监控模块] 解析预聚合规则持续时间失败，使用默认值",
-				zap.Error(err),
-				zap.String("规则", rule.Name),
-			)
-			forD, _ = pm.ParseDuration("5s")
-		}
-		oneRule := rulefmt.Rule{
-			Alert: rule.Name, // 告警名称
-			Expr:  rule.Expr, // 预聚合表达式
-			For:   forD,      // 持续时间
-		}
-
-		recordGroup := RecordGroup{
-			Name:  rule.Name,
-			Rules: []rulefmt.Rule{oneRule},
-		}
-		recordGroups.Groups = append(recordGroups.Groups, recordGroup)
+	if len(groups) == 0 {
+		return nil, configErrs
 	}
 
-	numInstances := len(pool.PrometheusInstances)
-	if numInstances == 0 {
+	if len(pool.PrometheusInstances) == 0 {
 		r.l.Warn("[监控模块] 采集池中没有Prometheus实例", zap.String("池子", pool.Name))
-		return nil
+		return nil, configErrs
 	}
 
 	ruleMap := make(map[string]string)
 
-	// 分片逻辑，将规则分配给不同的Prometheus实例
-	for i, ip := range pool.PrometheusInstances {
-		var myRecordGroups RecordGroups
-		for j, group := range recordGroups.Groups {
-			if j%numInstances == i { // 按顺序平均分片
-				myRecordGroups.Groups = append(myRecordGroups.Groups, group)
-			}
-		}
+	// 分片逻辑，按规则组名称做一致性哈希分配给Prometheus实例
+	shards := shardRuleGroups(groups, pool.PrometheusInstances, pool.RuleReplicas)
+	for _, ip := range pool.PrometheusInstances {
+		myRecordGroups := RuleGroups{Groups: shards[ip]}
 
 		yamlData, err := yaml.Marshal(&myRecordGroups)
 		if err != nil {
@@ -192,7 +164,16 @@ func (r *recordConfigCache) GeneratePrometheusRecordRuleConfigYamlOnePool(ctx co
 			ip,
 		)
 
-		if err := os.WriteFile(fileName, yamlData, 0644); err != nil {
+		// 校验并记录配置版本，校验失败时回退到最近一次校验通过的版本
+		content, fallback, err := validateAndApply(ctx, r.versionCache, ConfigTypePrometheusRecord, pool.ID, ip, string(yamlData))
+		if err != nil {
+			configErrs = append(configErrs, newConfigError(ConfigTypePrometheusRecord, pool.ID, pool.Name, ip, "", err, fallback))
+			if !fallback {
+				continue
+			}
+		}
+
+		if err := os.WriteFile(fileName, []byte(content), 0644); err != nil {
 			r.l.Error("[监控模块] 写入预聚合规则文件失败",
 				zap.Error(err),
 				zap.String("文件路径", fileName),
@@ -200,8 +181,8 @@ func (r *recordConfigCache) GeneratePrometheusRecordRuleConfigYamlOnePool(ctx co
 			continue
 		}
 
-		ruleMap[ip] = string(yamlData)
+		ruleMap[ip] = content
 	}
 
-	return ruleMap
+	return ruleMap, configErrs
 }
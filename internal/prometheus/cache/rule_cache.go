@@ -31,9 +31,6 @@ import (
 	"github.com/GoSimplicity/AI-CloudOps/internal/model"
 	alertRuleDao "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/alert"
 	scrapePoolDao "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/scrape"
-	pkg "github.com/GoSimplicity/AI-CloudOps/pkg/utils/prometheus"
-	pm "github.com/prometheus/common/model"
-	"github.com/prometheus/prometheus/model/rulefmt"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v3"
@@ -46,8 +43,12 @@ type RuleConfigCache interface {
 	GetPrometheusAlertRuleConfigYamlByIp(ip string) string
 	// GenerateAlertRuleConfigYaml 生成并更新所有Prometheus的告警规则配置YAML
 	GenerateAlertRuleConfigYaml(ctx context.Context) error
-	// GeneratePrometheusAlertRuleConfigYamlOnePool 根据单个采集池生成Prometheus的告警规则配置YAML
-	GeneratePrometheusAlertRuleConfigYamlOnePool(ctx context.Context, pool *model.MonitorScrapePool) map[string]string
+	// GeneratePrometheusAlertRuleConfigYamlOnePool 根据单个采集池生成Prometheus的告警规则配置YAML，同时返回校验错误
+	GeneratePrometheusAlertRuleConfigYamlOnePool(ctx context.Context, pool *model.MonitorScrapePool) (map[string]string, []*model.MonitorConfigError)
+	// PreviewRuleShards 预览采集池的规则组在拟定的实例列表和副本数下的分配结果，以及相对当前分配迁移的规则组
+	PreviewRuleShards(ctx context.Context, pool *model.MonitorScrapePool, instances []string, replicas int) (*model.RuleShardPreviewResp, error)
+	// GetPoolRuleGroups 获取采集池内所有的告警规则组和预聚合规则组，不做分片，跳过无法通过校验的规则
+	GetPoolRuleGroups(ctx context.Context, pool *model.MonitorScrapePool) ([]RuleGroup, error)
 }
 
 type ruleConfigCache struct {
@@ -56,28 +57,26 @@ type ruleConfigCache struct {
 	l             *zap.Logger       // 日志记录器
 	localYamlDir  string            // 本地YAML目录
 	scrapePoolDao scrapePoolDao.ScrapePoolDAO
-	alertRuleDao  alertRuleDao.AlertManagerRuleDAO
+	groupBuilder  *ruleGroupBuilder
+	versionCache  ConfigVersionCache
+	errorCache    ConfigErrorCache
 }
 
-// RuleGroup 构造Prometheus Rule 规则的结构体
-type RuleGroup struct {
-	Name  string         `yaml:"name"`
-	Rules []rulefmt.Rule `yaml:"rules"`
-}
-
-// RuleGroups 生成Prometheus rule yaml
-type RuleGroups struct {
-	Groups []RuleGroup `yaml:"groups"`
-}
-
-func NewRuleConfigCache(l *zap.Logger, scrapePoolDao scrapePoolDao.ScrapePoolDAO, alertRuleDao alertRuleDao.AlertManagerRuleDAO) RuleConfigCache {
+func NewRuleConfigCache(l *zap.Logger, scrapePoolDao scrapePoolDao.ScrapePoolDAO, alertRuleDao alertRuleDao.AlertManagerRuleDAO, recordDao alertRuleDao.AlertManagerRecordDAO, ruleGroupDao alertRuleDao.AlertManagerRuleGroupDAO, versionCache ConfigVersionCache, errorCache ConfigErrorCache) RuleConfigCache {
 	return &ruleConfigCache{
 		l:             l,
 		AlertRuleMap:  make(map[string]string),
 		localYamlDir:  viper.GetString("prometheus.local_yaml_dir"),
 		mu:            sync.RWMutex{},
 		scrapePoolDao: scrapePoolDao,
-		alertRuleDao:  alertRuleDao,
+		groupBuilder: &ruleGroupBuilder{
+			l:            l,
+			ruleGroupDao: ruleGroupDao,
+			alertRuleDao: alertRuleDao,
+			recordDao:    recordDao,
+		},
+		versionCache: versionCache,
+		errorCache:   errorCache,
 	}
 }
 
@@ -98,14 +97,17 @@ func (r *ruleConfigCache) GenerateAlertRuleConfigYaml(ctx context.Context) error
 
 	if len(pools) == 0 {
 		r.l.Info("没有找到支持告警的采集池")
+		r.errorCache.ReplaceConfigErrors(ConfigTypePrometheusAlert, nil)
 		return nil
 	}
 
 	ruleConfigMap := make(map[string]string)
+	var configErrs []*model.MonitorConfigError
 
 	// 遍历每个采集池生成对应的规则配置
 	for _, pool := range pools {
-		oneMap := r.GeneratePrometheusAlertRuleConfigYamlOnePool(ctx, pool)
+		oneMap, poolErrs := r.GeneratePrometheusAlertRuleConfigYamlOnePool(ctx, pool)
+		configErrs = append(configErrs, poolErrs...)
 		if oneMap != nil {
 			for ip, out := range oneMap {
 				ruleConfigMap[ip] = out
@@ -113,6 +115,8 @@ func (r *ruleConfigCache) GenerateAlertRuleConfigYaml(ctx context.Context) error
 		}
 	}
 
+	r.errorCache.ReplaceConfigErrors(ConfigTypePrometheusAlert, configErrs)
+
 	r.mu.Lock()
 	r.AlertRuleMap = ruleConfigMap
 	r.mu.Unlock()
@@ -120,66 +124,31 @@ func (r *ruleConfigCache) GenerateAlertRuleConfigYaml(ctx context.Context) error
 	return nil
 }
 
-func (r *ruleConfigCache) GeneratePrometheusAlertRuleConfigYamlOnePool(ctx context.Context, pool *model.MonitorScrapePool) map[string]string {
-	rules, err := r.alertRuleDao.GetMonitorAlertRuleByPoolId(ctx, pool.ID)
+func (r *ruleConfigCache) GeneratePrometheusAlertRuleConfigYamlOnePool(ctx context.Context, pool *model.MonitorScrapePool) (map[string]string, []*model.MonitorConfigError) {
+	// 构建规则组，包含告警规则的规则组连同组内的预聚合规则一起写入告警规则文件
+	groups, configErrs, err := r.groupBuilder.build(ctx, pool, ConfigTypePrometheusAlert)
 	if err != nil {
 		r.l.Error("[监控模块] 根据采集池ID获取告警规则失败",
 			zap.Error(err),
 			zap.String("池子", pool.Name),
 		)
-		return nil
+		return nil, nil
 	}
-	if len(rules) == 0 {
-		return nil
+	if len(groups) == 0 {
+		return nil, configErrs
 	}
 
-	var ruleGroups RuleGroups
-
-	// 构建规则组
-	for _, rule := range rules {
-		ft, err := pm.ParseDuration(rule.ForTime)
-		if err != nil {
-			r.l.Warn("[监控模块] 解析告警规则持续时间失败，使用默认值",
-				zap.Error(err),
-				zap.String("规则", rule.Name),
-			)
-			ft, _ = pm.ParseDuration("5s")
-		}
-		lables := pkg.FromSliceTuMap(rule.Labels)
-		annotations := pkg.FromSliceTuMap(rule.Annotations)
-
-		oneRule := rulefmt.Rule{
-			Alert:       rule.Name,   // 告警名称
-			Expr:        rule.Expr,   // 告警表达式
-			For:         ft,          // 持续时间
-			Labels:      lables,      // 标签组
-			Annotations: annotations, // 注解组
-		}
-
-		ruleGroup := RuleGroup{
-			Name:  rule.Name,
-			Rules: []rulefmt.Rule{oneRule}, // 一个规则组可以包含多个规则
-		}
-		ruleGroups.Groups = append(ruleGroups.Groups, ruleGroup)
-	}
-
-	numInstances := len(pool.PrometheusInstances)
-	if numInstances == 0 {
+	if len(pool.PrometheusInstances) == 0 {
 		r.l.Warn("[监控模块] 采集池中没有Prometheus实例", zap.String("池子", pool.Name))
-		return nil
+		return nil, configErrs
 	}
 
 	ruleMap := make(map[string]string)
 
-	// 分片逻辑，将规则分配给不同的Prometheus实例，以减少服务器的负载
-	for i, ip := range pool.PrometheusInstances {
-		var myRuleGroups RuleGroups
-
-		for j, group := range ruleGroups.Groups {
-			if j%numInstances == i { // 按顺序平均分片
-				myRuleGroups.Groups = append(myRuleGroups.Groups, group)
-			}
-		}
+	// 分片逻辑，按规则组名称做一致性哈希分配给Prometheus实例，同一组内的规则始终在同一实例上评估
+	shards := shardRuleGroups(groups, pool.PrometheusInstances, pool.RuleReplicas)
+	for _, ip := range pool.PrometheusInstances {
+		myRuleGroups := RuleGroups{Groups: shards[ip]}
 
 		// 序列化规则组为YAML
 		yamlData, err := yaml.Marshal(&myRuleGroups)
@@ -197,7 +166,16 @@ func (r *ruleConfigCache) GeneratePrometheusAlertRuleConfigYamlOnePool(ctx conte
 			pool.Name,
 			ip,
 		)
-		if err := os.WriteFile(fileName, yamlData, 0644); err != nil {
+		// 校验并记录配置版本，校验失败时回退到最近一次校验通过的版本
+		content, fallback, err := validateAndApply(ctx, r.versionCache, ConfigTypePrometheusAlert, pool.ID, ip, string(yamlData))
+		if err != nil {
+			configErrs = append(configErrs, newConfigError(ConfigTypePrometheusAlert, pool.ID, pool.Name, ip, "", err, fallback))
+			if !fallback {
+				continue
+			}
+		}
+
+		if err := os.WriteFile(fileName, []byte(content), 0644); err != nil {
 			r.l.Error("[监控模块] 写入告警规则文件失败",
 				zap.Error(err),
 				zap.String("文件路径", fileName),
@@ -205,8 +183,21 @@ func (r *ruleConfigCache) GeneratePrometheusAlertRuleConfigYamlOnePool(ctx conte
 			continue
 		}
 
-		ruleMap[ip] = string(yamlData)
+		ruleMap[ip] = content
+	}
+
+	return ruleMap, configErrs
+}
+
+func (r *ruleConfigCache) GetPoolRuleGroups(ctx context.Context, pool *model.MonitorScrapePool) ([]RuleGroup, error) {
+	var result []RuleGroup
+	for _, configType := range []string{ConfigTypePrometheusAlert, ConfigTypePrometheusRecord} {
+		groups, _, err := r.groupBuilder.build(ctx, pool, configType)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, groups...)
 	}
 
-	return ruleMap
+	return result, nil
 }
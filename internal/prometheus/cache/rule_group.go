@@ -0,0 +1,218 @@
+package cache
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	alertRuleDao "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/alert"
+	pkg "github.com/GoSimplicity/AI-CloudOps/pkg/utils/prometheus"
+	pm "github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"go.uber.org/zap"
+	"sort"
+)
+
+// RuleGroup 构造Prometheus Rule 规则的结构体
+type RuleGroup struct {
+	Name     string         `yaml:"name"`
+	Interval pm.Duration    `yaml:"interval,omitempty"`
+	Limit    int            `yaml:"limit,omitempty"`
+	Rules    []rulefmt.Rule `yaml:"rules"`
+}
+
+// RuleGroups 生成Prometheus rule yaml
+type RuleGroups struct {
+	Groups []RuleGroup `yaml:"groups"`
+}
+
+// ruleGroupBuilder 将采集池内的告警规则和预聚合规则整理为规则组
+// 包含告警规则的规则组写入告警规则文件，只包含预聚合规则的规则组写入预聚合规则文件，
+// 这样为告警规则提供数据的预聚合规则可以和告警规则在同一个组内按顺序评估；未加入规则组的规则各自单独成组
+type ruleGroupBuilder struct {
+	l            *zap.Logger
+	ruleGroupDao alertRuleDao.AlertManagerRuleGroupDAO
+	alertRuleDao alertRuleDao.AlertManagerRuleDAO
+	recordDao    alertRuleDao.AlertManagerRecordDAO
+}
+
+// groupMember 规则组内的一条规则
+type groupMember struct {
+	id      int
+	order   int
+	name    string
+	isAlert bool
+	rule    rulefmt.Rule
+}
+
+// build 生成采集池内属于 configType 对应规则文件的规则组，返回的规则组顺序固定，用于分片
+func (b *ruleGroupBuilder) build(ctx context.Context, pool *model.MonitorScrapePool, configType string) ([]RuleGroup, []*model.MonitorConfigError, error) {
+	ruleGroups, err := b.ruleGroupDao.GetMonitorRuleGroupByPoolId(ctx, pool.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取规则组失败: %w", err)
+	}
+
+	alertRules, err := b.alertRuleDao.GetMonitorAlertRuleByPoolId(ctx, pool.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取告警规则失败: %w", err)
+	}
+
+	recordRules, err := b.recordDao.GetMonitorRecordRuleByPoolId(ctx, pool.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取预聚合规则失败: %w", err)
+	}
+
+	groupByID := make(map[int]*model.MonitorRuleGroup, len(ruleGroups))
+	for _, group := range ruleGroups {
+		groupByID[group.ID] = group
+	}
+
+	members := make(map[int][]groupMember)
+	var singles []groupMember
+
+	addMember := func(groupID int, member groupMember) {
+		if _, ok := groupByID[groupID]; ok {
+			members[groupID] = append(members[groupID], member)
+			return
+		}
+		singles = append(singles, member)
+	}
+
+	for _, rule := range alertRules {
+		addMember(rule.RuleGroupID, groupMember{
+			id:      rule.ID,
+			order:   rule.GroupOrder,
+			name:    rule.Name,
+			isAlert: true,
+			rule:    b.alertRule(rule),
+		})
+	}
+
+	for _, rule := range recordRules {
+		addMember(rule.RuleGroupID, recordMember(rule))
+	}
+
+	wantAlert := configType == ConfigTypePrometheusAlert
+
+	var result []RuleGroup
+	var configErrs []*model.MonitorConfigError
+	names := make(map[string]struct{})
+
+	// appendGroup 校验组内规则后加入结果，跳过无法通过校验的规则，避免单条规则影响整个池
+	appendGroup := func(group RuleGroup, groupMembers []groupMember) {
+		for _, member := range groupMembers {
+			if err := ValidateRule(member.rule); err != nil {
+				configErrs = append(configErrs, newConfigError(configType, pool.ID, pool.Name, "", member.name, err, false))
+				continue
+			}
+			group.Rules = append(group.Rules, member.rule)
+		}
+
+		if len(group.Rules) == 0 {
+			return
+		}
+
+		if _, ok := names[group.Name]; ok {
+			configErrs = append(configErrs, newConfigError(configType, pool.ID, pool.Name, "", group.Name, fmt.Errorf("规则组名称 %s 在同一规则文件中重复", group.Name), false))
+			return
+		}
+		names[group.Name] = struct{}{}
+
+		result = append(result, group)
+	}
+
+	for _, ruleGroup := range ruleGroups {
+		groupMembers := members[ruleGroup.ID]
+		if len(groupMembers) == 0 || hasAlertMember(groupMembers) != wantAlert {
+			continue
+		}
+
+		sort.SliceStable(groupMembers, func(i, j int) bool {
+			if groupMembers[i].order != groupMembers[j].order {
+				return groupMembers[i].order < groupMembers[j].order
+			}
+			return groupMembers[i].id < groupMembers[j].id
+		})
+
+		group := RuleGroup{
+			Name:  ruleGroup.Name,
+			Limit: ruleGroup.RuleLimit,
+		}
+		if ruleGroup.Interval != "" {
+			interval, err := pm.ParseDuration(ruleGroup.Interval)
+			if err != nil {
+				configErrs = append(configErrs, newConfigError(configType, pool.ID, pool.Name, "", ruleGroup.Name, fmt.Errorf("解析规则组评估间隔失败，使用全局评估间隔: %w", err), false))
+			} else {
+				group.Interval = interval
+			}
+		}
+
+		appendGroup(group, groupMembers)
+	}
+
+	for _, single := range singles {
+		if single.isAlert != wantAlert {
+			continue
+		}
+		appendGroup(RuleGroup{Name: single.name}, []groupMember{single})
+	}
+
+	return result, configErrs, nil
+}
+
+// alertRule 将告警规则转换为规则文件格式，持续时间无法解析时使用默认值
+func (b *ruleGroupBuilder) alertRule(rule *model.MonitorAlertRule) rulefmt.Rule {
+	ruleFmt, err := pkg.AlertRuleToRulefmt(rule)
+	if err != nil {
+		b.l.Warn("[监控模块] 解析告警规则持续时间失败，使用默认值",
+			zap.Error(err),
+			zap.String("规则", rule.Name),
+		)
+	}
+
+	return ruleFmt
+}
+
+// recordMember 将预聚合规则转换为规则组成员，单独成组时以记录的指标名称作为规则组名称
+func recordMember(rule *model.MonitorRecordRule) groupMember {
+	return groupMember{
+		id:    rule.ID,
+		order: rule.GroupOrder,
+		name:  rule.RecordName,
+		rule:  pkg.RecordRuleToRulefmt(rule),
+	}
+}
+
+func hasAlertMember(members []groupMember) bool {
+	for _, member := range members {
+		if member.isAlert {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,55 @@
+package cache
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"testing"
+
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+)
+
+func TestRecordMember(t *testing.T) {
+	member := recordMember(&model.MonitorRecordRule{
+		Model:      model.Model{ID: 3},
+		Name:       "节点存活数",
+		RecordName: "job:up:sum",
+		Expr:       "sum by (job) (up)",
+		GroupOrder: 2,
+	})
+
+	if member.name != "job:up:sum" {
+		t.Errorf("name = %q, want job:up:sum", member.name)
+	}
+	if member.rule.Record != "job:up:sum" {
+		t.Errorf("rule.Record = %q, want job:up:sum", member.rule.Record)
+	}
+	if member.isAlert {
+		t.Error("预聚合规则不应标记为告警规则")
+	}
+	if err := ValidateRule(member.rule); err != nil {
+		t.Errorf("ValidateRule() = %v", err)
+	}
+}
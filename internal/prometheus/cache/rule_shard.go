@@ -0,0 +1,160 @@
+package cache
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"github.com/cespare/xxhash/v2"
+	"sort"
+)
+
+// ruleGroupReplicas 返回每个规则组分配的实例数，未设置时为1，不超过实例数量
+func ruleGroupReplicas(replicas, numInstances int) int {
+	if replicas < 1 {
+		replicas = 1
+	}
+	if replicas > numInstances {
+		replicas = numInstances
+	}
+
+	return replicas
+}
+
+// rendezvousInstances 使用最高随机权重（rendezvous）哈希为规则组选出 replicas 个实例
+// 每个实例的得分只取决于规则组名称和实例本身，增删实例或规则组时只有少量规则组会迁移
+func rendezvousInstances(groupName string, instances []string, replicas int) []string {
+	type scored struct {
+		ip    string
+		score uint64
+	}
+
+	scores := make([]scored, 0, len(instances))
+	seen := make(map[string]struct{}, len(instances))
+	for _, ip := range instances {
+		if _, ok := seen[ip]; ok {
+			continue
+		}
+		seen[ip] = struct{}{}
+		scores = append(scores, scored{ip: ip, score: xxhash.Sum64String(groupName + "\xff" + ip)})
+	}
+
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].score != scores[j].score {
+			return scores[i].score > scores[j].score
+		}
+		return scores[i].ip < scores[j].ip
+	})
+
+	replicas = ruleGroupReplicas(replicas, len(scores))
+	result := make([]string, 0, replicas)
+	for _, s := range scores[:replicas] {
+		result = append(result, s.ip)
+	}
+
+	return result
+}
+
+// shardRuleGroups 将规则组分配到采集池的Prometheus实例，返回每个实例负责评估的规则组，保持规则组原有顺序
+// 每个实例都会出现在结果中，没有分到规则组的实例对应空列表
+func shardRuleGroups(groups []RuleGroup, instances []string, replicas int) map[string][]RuleGroup {
+	result := make(map[string][]RuleGroup, len(instances))
+	for _, ip := range instances {
+		result[ip] = nil
+	}
+
+	if len(instances) == 0 {
+		return result
+	}
+
+	for _, group := range groups {
+		for _, ip := range rendezvousInstances(group.Name, instances, replicas) {
+			result[ip] = append(result[ip], group)
+		}
+	}
+
+	return result
+}
+
+// PreviewRuleShards 对比采集池当前实例列表与拟定实例列表下告警规则组和预聚合规则组的分配结果
+func (r *ruleConfigCache) PreviewRuleShards(ctx context.Context, pool *model.MonitorScrapePool, instances []string, replicas int) (*model.RuleShardPreviewResp, error) {
+	resp := &model.RuleShardPreviewResp{
+		InstanceGroupCount: make(map[string]int),
+	}
+	for _, ip := range instances {
+		resp.InstanceGroupCount[ip] = 0
+	}
+
+	for _, configType := range []string{ConfigTypePrometheusAlert, ConfigTypePrometheusRecord} {
+		groups, _, err := r.groupBuilder.build(ctx, pool, configType)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, group := range groups {
+			current := rendezvousInstances(group.Name, pool.PrometheusInstances, pool.RuleReplicas)
+			proposed := rendezvousInstances(group.Name, instances, replicas)
+			moved := !sameInstances(current, proposed)
+
+			resp.Groups = append(resp.Groups, &model.RuleShardAssignment{
+				Name:       group.Name,
+				ConfigType: configType,
+				Current:    current,
+				Proposed:   proposed,
+				Moved:      moved,
+			})
+
+			resp.Total++
+			if moved {
+				resp.Moved++
+			}
+			for _, ip := range proposed {
+				resp.InstanceGroupCount[ip]++
+			}
+		}
+	}
+
+	return resp, nil
+}
+
+// sameInstances 判断两组实例是否相同，不考虑顺序
+func sameInstances(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	set := make(map[string]struct{}, len(a))
+	for _, ip := range a {
+		set[ip] = struct{}{}
+	}
+	for _, ip := range b {
+		if _, ok := set[ip]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,190 @@
+package cache
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestRuleGroupReplicas(t *testing.T) {
+	tests := []struct {
+		name         string
+		replicas     int
+		numInstances int
+		want         int
+	}{
+		{name: "未设置", replicas: 0, numInstances: 3, want: 1},
+		{name: "负数", replicas: -1, numInstances: 3, want: 1},
+		{name: "正常值", replicas: 2, numInstances: 3, want: 2},
+		{name: "超过实例数量", replicas: 5, numInstances: 3, want: 3},
+		{name: "没有实例", replicas: 2, numInstances: 0, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ruleGroupReplicas(tt.replicas, tt.numInstances); got != tt.want {
+				t.Errorf("ruleGroupReplicas(%d, %d) = %d, want %d", tt.replicas, tt.numInstances, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRendezvousInstances(t *testing.T) {
+	instances := []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}
+
+	tests := []struct {
+		name      string
+		instances []string
+		replicas  int
+		wantLen   int
+	}{
+		{name: "单副本", instances: instances, replicas: 1, wantLen: 1},
+		{name: "多副本", instances: instances, replicas: 2, wantLen: 2},
+		{name: "副本数超过实例数量", instances: instances, replicas: 5, wantLen: 3},
+		{name: "重复实例只计算一次", instances: append(instances, "10.0.0.1", "10.0.0.2"), replicas: 5, wantLen: 3},
+		{name: "没有实例", instances: nil, replicas: 1, wantLen: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rendezvousInstances("node", tt.instances, tt.replicas)
+			if len(got) != tt.wantLen {
+				t.Fatalf("rendezvousInstances() = %v, want %d instances", got, tt.wantLen)
+			}
+			seen := make(map[string]struct{}, len(got))
+			for _, ip := range got {
+				if _, ok := seen[ip]; ok {
+					t.Fatalf("rendezvousInstances() = %v 包含重复实例", got)
+				}
+				seen[ip] = struct{}{}
+			}
+		})
+	}
+}
+
+func TestRendezvousInstancesIgnoresOrder(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		group := fmt.Sprintf("group-%d", i)
+		a := rendezvousInstances(group, []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}, 2)
+		b := rendezvousInstances(group, []string{"10.0.0.3", "10.0.0.1", "10.0.0.2"}, 2)
+		if !reflect.DeepEqual(a, b) {
+			t.Fatalf("%s: 实例顺序不同时分配结果不同: %v, %v", group, a, b)
+		}
+	}
+}
+
+func TestRendezvousInstancesMinimalMovement(t *testing.T) {
+	before := []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}
+	after := append(append([]string{}, before...), "10.0.0.4")
+
+	moved := 0
+	for i := 0; i < 200; i++ {
+		group := fmt.Sprintf("group-%d", i)
+		current := rendezvousInstances(group, before, 1)
+		proposed := rendezvousInstances(group, after, 1)
+		if sameInstances(current, proposed) {
+			continue
+		}
+
+		// 新增实例时规则组只能迁移到新实例上
+		moved++
+		if proposed[0] != "10.0.0.4" {
+			t.Fatalf("%s 从 %v 迁移到了已有实例 %v", group, current, proposed)
+		}
+	}
+
+	if moved == 0 || moved > 100 {
+		t.Fatalf("新增一个实例后迁移了 %d/200 个规则组", moved)
+	}
+}
+
+func TestShardRuleGroups(t *testing.T) {
+	groups := make([]RuleGroup, 0, 10)
+	for i := 0; i < 10; i++ {
+		groups = append(groups, RuleGroup{Name: fmt.Sprintf("group-%d", i)})
+	}
+
+	tests := []struct {
+		name      string
+		instances []string
+		replicas  int
+		wantTotal int
+	}{
+		{name: "没有实例", instances: nil, replicas: 1, wantTotal: 0},
+		{name: "单副本", instances: []string{"10.0.0.1", "10.0.0.2"}, replicas: 1, wantTotal: 10},
+		{name: "每个实例都评估全部规则组", instances: []string{"10.0.0.1", "10.0.0.2"}, replicas: 2, wantTotal: 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := shardRuleGroups(groups, tt.instances, tt.replicas)
+			if len(result) != len(tt.instances) {
+				t.Fatalf("结果应包含每个实例, got %d", len(result))
+			}
+
+			total := 0
+			for ip, assigned := range result {
+				total += len(assigned)
+				// 分到的规则组保持原有顺序
+				last := -1
+				for _, group := range assigned {
+					var idx int
+					fmt.Sscanf(group.Name, "group-%d", &idx)
+					if idx <= last {
+						t.Fatalf("%s 的规则组顺序错误: %v", ip, assigned)
+					}
+					last = idx
+				}
+			}
+			if total != tt.wantTotal {
+				t.Errorf("分配的规则组总数 = %d, want %d", total, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestSameInstances(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []string
+		want bool
+	}{
+		{name: "都为空", want: true},
+		{name: "顺序不同", a: []string{"a", "b"}, b: []string{"b", "a"}, want: true},
+		{name: "数量不同", a: []string{"a"}, b: []string{"a", "b"}, want: false},
+		{name: "实例不同", a: []string{"a", "b"}, b: []string{"a", "c"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sameInstances(tt.a, tt.b); got != tt.want {
+				t.Errorf("sameInstances(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package cache
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	pkg "github.com/GoSimplicity/AI-CloudOps/pkg/utils/prometheus"
+	pcc "github.com/prometheus/common/config"
+	pc "github.com/prometheus/prometheus/config"
+)
+
+// applyScrapeOptions 将采集任务的 HTTP 客户端、标签处理、限制和URL参数设置到采集配置中
+func applyScrapeOptions(sc *pc.ScrapeConfig, job *model.MonitorScrapeJob) error {
+	if err := pkg.ValidateScrapeJobHTTPClient(job); err != nil {
+		return err
+	}
+
+	httpConfig := pcc.DefaultHTTPClientConfig
+	httpConfig.BearerToken = pcc.Secret(job.BearerToken)
+	httpConfig.BearerTokenFile = job.BearerTokenFile
+
+	if job.BasicAuthUsername != "" {
+		httpConfig.BasicAuth = &pcc.BasicAuth{
+			Username: job.BasicAuthUsername,
+			Password: pcc.Secret(job.BasicAuthPassword),
+		}
+	}
+
+	// 未设置是否校验证书时，k8s 类型沿用原来跳过校验的行为
+	insecureSkipVerify := job.TlsInsecureSkipVerify == 1
+	if job.TlsInsecureSkipVerify == 0 && job.ServiceDiscoveryType == model.ServiceDiscoveryK8s {
+		insecureSkipVerify = true
+	}
+
+	httpConfig.TLSConfig = pcc.TLSConfig{
+		CA:                 job.TlsCaContent,
+		CAFile:             job.TlsCaFilePath,
+		Cert:               job.TlsCertContent,
+		CertFile:           job.TlsCertFilePath,
+		Key:                pcc.Secret(job.TlsKeyContent),
+		KeyFile:            job.TlsKeyFilePath,
+		ServerName:         job.TlsServerName,
+		InsecureSkipVerify: insecureSkipVerify,
+	}
+
+	if job.ProxyUrl != "" {
+		proxyURL, err := pkg.ParseURL(job.ProxyUrl)
+		if err != nil {
+			return fmt.Errorf("解析代理地址失败: %w", err)
+		}
+		httpConfig.ProxyURL = *proxyURL
+	}
+
+	params, err := pkg.ParseScrapeParams(job.Params)
+	if err != nil {
+		return err
+	}
+
+	sc.HTTPClientConfig = httpConfig
+	sc.HonorLabels = job.HonorLabels == 1
+	sc.HonorTimestamps = job.HonorTimestamps != 2
+	sc.SampleLimit = uint(job.SampleLimit)
+	sc.TargetLimit = uint(job.TargetLimit)
+	sc.LabelLimit = uint(job.LabelLimit)
+	sc.Params = params
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package cache
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"testing"
+
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	pc "github.com/prometheus/prometheus/config"
+)
+
+func TestApplyScrapeOptions(t *testing.T) {
+	tests := []struct {
+		name             string
+		job              model.MonitorScrapeJob
+		wantInsecure     bool
+		wantHonorLabels  bool
+		wantHonorTs      bool
+		wantProxy        string
+		wantBasicAuthSet bool
+		wantErr          bool
+	}{
+		{name: "默认值", job: model.MonitorScrapeJob{}, wantHonorTs: true},
+		{name: "k8s 类型默认跳过证书校验", job: model.MonitorScrapeJob{ServiceDiscoveryType: model.ServiceDiscoveryK8s}, wantInsecure: true, wantHonorTs: true},
+		{name: "k8s 类型显式校验证书", job: model.MonitorScrapeJob{ServiceDiscoveryType: model.ServiceDiscoveryK8s, TlsInsecureSkipVerify: 2}, wantHonorTs: true},
+		{name: "保留标签且不使用目标时间戳", job: model.MonitorScrapeJob{HonorLabels: 1, HonorTimestamps: 2}, wantHonorLabels: true},
+		{name: "代理和 Basic Auth", job: model.MonitorScrapeJob{ProxyUrl: "http://10.0.0.1:3128", BasicAuthUsername: "admin"}, wantHonorTs: true, wantProxy: "http://10.0.0.1:3128", wantBasicAuthSet: true},
+		{name: "配置冲突", job: model.MonitorScrapeJob{BearerToken: "t", BasicAuthUsername: "admin"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := &pc.ScrapeConfig{}
+			err := applyScrapeOptions(sc, &tt.job)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyScrapeOptions() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if sc.HTTPClientConfig.TLSConfig.InsecureSkipVerify != tt.wantInsecure {
+				t.Errorf("InsecureSkipVerify = %v, want %v", sc.HTTPClientConfig.TLSConfig.InsecureSkipVerify, tt.wantInsecure)
+			}
+			if sc.HonorLabels != tt.wantHonorLabels || sc.HonorTimestamps != tt.wantHonorTs {
+				t.Errorf("HonorLabels = %v, HonorTimestamps = %v", sc.HonorLabels, sc.HonorTimestamps)
+			}
+			proxyURL := ""
+			if sc.HTTPClientConfig.ProxyURL.URL != nil {
+				proxyURL = sc.HTTPClientConfig.ProxyURL.String()
+			}
+			if proxyURL != tt.wantProxy {
+				t.Errorf("ProxyURL = %q, want %q", proxyURL, tt.wantProxy)
+			}
+			if (sc.HTTPClientConfig.BasicAuth != nil) != tt.wantBasicAuthSet {
+				t.Errorf("BasicAuth = %+v", sc.HTTPClientConfig.BasicAuth)
+			}
+		})
+	}
+}
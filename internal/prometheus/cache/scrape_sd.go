@@ -0,0 +1,132 @@
+package cache
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	pkg "github.com/GoSimplicity/AI-CloudOps/pkg/utils/prometheus"
+	pcc "github.com/prometheus/common/config"
+	"github.com/prometheus/prometheus/discovery"
+	"github.com/prometheus/prometheus/discovery/consul"
+	"github.com/prometheus/prometheus/discovery/dns"
+	"github.com/prometheus/prometheus/discovery/file"
+	"strings"
+)
+
+// staticSdConfigs 生成静态配置的服务发现
+func staticSdConfigs(job *model.MonitorScrapeJob) (discovery.Configs, error) {
+	if err := pkg.ValidateTargetGroups(job.StaticTargetGroups); err != nil {
+		return nil, err
+	}
+
+	return discovery.Configs{
+		discovery.StaticConfig(pkg.ToTargetGroups(job.StaticTargetGroups, job.Name)),
+	}, nil
+}
+
+// fileSdConfigs 生成文件服务发现，平台管理的采集目标组记录到 files 中，由 config-agent 写入 Prometheus 主机上 filesDir 下以任务ID命名的目标文件
+// 目标文件变化时 Prometheus 会自动重新加载，不需要重载实例
+func fileSdConfigs(job *model.MonitorScrapeJob, filesDir string, files map[string]string) (discovery.Configs, error) {
+	paths := append([]string{}, job.FileSdPaths...)
+
+	if len(job.FileSdTargetGroups) > 0 {
+		if err := pkg.ValidateTargetGroups(job.FileSdTargetGroups); err != nil {
+			return nil, err
+		}
+
+		groups := make([]model.ScrapeTargetGroup, 0, len(job.FileSdTargetGroups))
+		for _, group := range job.FileSdTargetGroups {
+			targets := make([]string, 0, len(group.Targets))
+			for _, target := range group.Targets {
+				targets = append(targets, strings.TrimSpace(target))
+			}
+			groups = append(groups, model.ScrapeTargetGroup{Targets: targets, Labels: group.Labels})
+		}
+
+		content, err := json.MarshalIndent(groups, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("序列化目标文件失败: %w", err)
+		}
+
+		paths = append(paths, addConfigFile(files, filesDir, fmt.Sprintf("file_sd_%d.json", job.ID), string(content)))
+	}
+
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("文件服务发现没有可用的目标文件")
+	}
+
+	return discovery.Configs{
+		&file.SDConfig{
+			Files:           paths,
+			RefreshInterval: pkg.GenPromDuration(job.RefreshInterval),
+		},
+	}, nil
+}
+
+// dnsSdConfigs 生成DNS服务发现
+func dnsSdConfigs(job *model.MonitorScrapeJob) (discovery.Configs, error) {
+	if len(job.DnsSdNames) == 0 {
+		return nil, fmt.Errorf("DNS服务发现没有配置域名")
+	}
+
+	sdType := strings.ToUpper(job.DnsSdType)
+	if sdType == "" {
+		sdType = "SRV"
+	}
+
+	return discovery.Configs{
+		&dns.SDConfig{
+			Names:           job.DnsSdNames,
+			RefreshInterval: pkg.GenPromDuration(job.RefreshInterval),
+			Type:            sdType,
+			Port:            job.DnsSdPort,
+		},
+	}, nil
+}
+
+// consulSdConfigs 生成Consul服务发现，未指定的字段使用 Prometheus 的默认值
+func consulSdConfigs(job *model.MonitorScrapeJob) (discovery.Configs, error) {
+	if job.ConsulServer == "" {
+		return nil, fmt.Errorf("Consul服务发现没有配置Consul地址")
+	}
+
+	sdConfig := consul.DefaultSDConfig
+	sdConfig.Server = job.ConsulServer
+	sdConfig.Datacenter = job.ConsulDatacenter
+	sdConfig.Token = pcc.Secret(job.ConsulToken)
+	sdConfig.Services = job.ConsulServices
+	sdConfig.ServiceTags = job.ConsulTags
+	if job.ConsulScheme != "" {
+		sdConfig.Scheme = job.ConsulScheme
+	}
+	if job.RefreshInterval > 0 {
+		sdConfig.RefreshInterval = pkg.GenPromDuration(job.RefreshInterval)
+	}
+
+	return discovery.Configs{&sdConfig}, nil
+}
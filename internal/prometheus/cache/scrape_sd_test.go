@@ -0,0 +1,119 @@
+package cache
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"github.com/prometheus/prometheus/discovery/file"
+)
+
+func TestFileSdConfigs(t *testing.T) {
+	groups := []model.ScrapeTargetGroup{{Targets: []string{" 10.0.0.1:9100"}, Labels: map[string]string{"env": "prod"}}}
+	const filesDir = "/etc/prometheus/platform_files"
+
+	tests := []struct {
+		name      string
+		job       *model.MonitorScrapeJob
+		wantFiles []string // file_sd_configs 引用的目标文件
+		wantWrite string   // 需要下发的目标文件
+		wantErr   bool
+	}{
+		{
+			name:      "平台管理的目标组写入目标文件",
+			job:       &model.MonitorScrapeJob{Model: model.Model{ID: 3}, Name: "node", FileSdTargetGroups: groups},
+			wantFiles: []string{filesDir + "/file_sd_3.json"},
+			wantWrite: filesDir + "/file_sd_3.json",
+		},
+		{
+			name:      "主机上已有的目标文件",
+			job:       &model.MonitorScrapeJob{Model: model.Model{ID: 3}, Name: "node", FileSdPaths: []string{"/etc/prometheus/targets/*.json"}},
+			wantFiles: []string{"/etc/prometheus/targets/*.json"},
+		},
+		{
+			name:      "同时配置",
+			job:       &model.MonitorScrapeJob{Model: model.Model{ID: 3}, Name: "node", FileSdTargetGroups: groups, FileSdPaths: []string{"/etc/prometheus/a.yml"}},
+			wantFiles: []string{"/etc/prometheus/a.yml", filesDir + "/file_sd_3.json"},
+			wantWrite: filesDir + "/file_sd_3.json",
+		},
+		{
+			name:    "都未配置",
+			job:     &model.MonitorScrapeJob{Name: "node"},
+			wantErr: true,
+		},
+		{
+			name:    "目标地址不合法",
+			job:     &model.MonitorScrapeJob{Name: "node", FileSdTargetGroups: []model.ScrapeTargetGroup{{Targets: []string{""}}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := make(map[string]string)
+			configs, err := fileSdConfigs(tt.job, filesDir, files)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("fileSdConfigs() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if len(configs) != 1 {
+				t.Fatalf("生成了 %d 个服务发现配置, want 1", len(configs))
+			}
+			sdConfig, ok := configs[0].(*file.SDConfig)
+			if !ok {
+				t.Fatalf("不应生成 %T", configs[0])
+			}
+			if !reflect.DeepEqual(sdConfig.Files, tt.wantFiles) {
+				t.Errorf("目标文件 = %v, want %v", sdConfig.Files, tt.wantFiles)
+			}
+
+			if tt.wantWrite == "" {
+				if len(files) != 0 {
+					t.Errorf("不应下发目标文件: %v", files)
+				}
+				return
+			}
+			if len(files) != 1 {
+				t.Fatalf("下发的目标文件 = %v, want %s", files, tt.wantWrite)
+			}
+
+			var written []model.ScrapeTargetGroup
+			if err := json.Unmarshal([]byte(files[tt.wantWrite]), &written); err != nil {
+				t.Fatalf("目标文件不是合法的 JSON: %v", err)
+			}
+			want := []model.ScrapeTargetGroup{{Targets: []string{"10.0.0.1:9100"}, Labels: map[string]string{"env": "prod"}}}
+			if !reflect.DeepEqual(written, want) {
+				t.Errorf("目标文件内容 = %+v, want %+v", written, want)
+			}
+		})
+	}
+}
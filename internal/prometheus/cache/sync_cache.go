@@ -0,0 +1,472 @@
+package cache
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	alertPoolDao "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/alert"
+	configDao "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/config"
+	scrapePoolDao "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/scrape"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 需要同步配置的实例类型
+const (
+	InstanceTypePrometheus   = "prometheus"
+	InstanceTypeAlertManager = "alertmanager"
+)
+
+const (
+	defaultPrometheusPort   = 9090
+	defaultAlertManagerPort = 9093
+	defaultReloadRetry      = 3
+	syncTimeout             = 5 * time.Minute
+	reloadRequestTimeout    = 10 * time.Second
+)
+
+type ConfigSyncCache interface {
+	// TriggerSync 异步检查所有实例的配置是否变化，对变化的实例调用 /-/reload，多次触发会被合并
+	TriggerSync()
+	// SyncInstances 立即同步实例配置，ip 为空表示所有实例，force 为 true 时忽略哈希比较强制重载
+	SyncInstances(ctx context.Context, ip string, force bool) error
+	// GetSyncStates 获取当前所有实例的同步状态
+	GetSyncStates(ctx context.Context) ([]*model.MonitorConfigSyncState, error)
+}
+
+// syncTarget 一个实例期望下发的配置
+type syncTarget struct {
+	instanceType string
+	poolID       int
+	poolName     string
+	ip           string
+	hash         string            // 期望配置的哈希，为空表示实例当前没有可下发的配置
+	configHashes map[string]string // 各类配置的哈希，与 config-agent 上报的已应用配置比较
+}
+
+type configSyncCache struct {
+	mu               sync.Mutex // 保证同一时间只有一次同步
+	once             sync.Once
+	pending          chan struct{}
+	l                *zap.Logger
+	client           *http.Client
+	enabled          bool
+	prometheusPort   int
+	alertManagerPort int
+	reloadRetry      int
+	promCache        PromConfigCache
+	alertCache       AlertConfigCache
+	ruleCache        RuleConfigCache
+	recordCache      RecordConfigCache
+	scrapePoolDao    scrapePoolDao.ScrapePoolDAO
+	alertPoolDao     alertPoolDao.AlertManagerPoolDAO
+	syncDao          configDao.ConfigSyncDAO
+	versionDao       configDao.ConfigVersionDAO
+}
+
+func NewConfigSyncCache(l *zap.Logger, promCache PromConfigCache, alertCache AlertConfigCache, ruleCache RuleConfigCache, recordCache RecordConfigCache, scrapePoolDao scrapePoolDao.ScrapePoolDAO, alertPoolDao alertPoolDao.AlertManagerPoolDAO, syncDao configDao.ConfigSyncDAO, versionDao configDao.ConfigVersionDAO) ConfigSyncCache {
+	prometheusPort := viper.GetInt("prometheus.prometheus_port")
+	if prometheusPort == 0 {
+		prometheusPort = defaultPrometheusPort
+	}
+
+	alertManagerPort := viper.GetInt("prometheus.alertmanager_port")
+	if alertManagerPort == 0 {
+		alertManagerPort = defaultAlertManagerPort
+	}
+
+	reloadRetry := viper.GetInt("prometheus.reload_retry")
+	if reloadRetry <= 0 {
+		reloadRetry = defaultReloadRetry
+	}
+
+	return &configSyncCache{
+		pending:          make(chan struct{}, 1),
+		l:                l,
+		client:           &http.Client{Timeout: reloadRequestTimeout},
+		enabled:          viper.GetInt("prometheus.enable_reload") == 1,
+		prometheusPort:   prometheusPort,
+		alertManagerPort: alertManagerPort,
+		reloadRetry:      reloadRetry,
+		promCache:        promCache,
+		alertCache:       alertCache,
+		ruleCache:        ruleCache,
+		recordCache:      recordCache,
+		scrapePoolDao:    scrapePoolDao,
+		alertPoolDao:     alertPoolDao,
+		syncDao:          syncDao,
+		versionDao:       versionDao,
+	}
+}
+
+func (s *configSyncCache) TriggerSync() {
+	if !s.enabled {
+		return
+	}
+
+	s.once.Do(func() {
+		go s.syncLoop()
+	})
+
+	// 已有待执行的同步时直接返回，同步时会读取最新的配置
+	select {
+	case s.pending <- struct{}{}:
+	default:
+	}
+}
+
+func (s *configSyncCache) syncLoop() {
+	for range s.pending {
+		ctx, cancel := context.WithTimeout(context.Background(), syncTimeout)
+		if err := s.SyncInstances(ctx, "", false); err != nil {
+			s.l.Warn("[监控模块] 部分实例配置同步失败", zap.Error(err))
+		}
+		cancel()
+	}
+}
+
+func (s *configSyncCache) SyncInstances(ctx context.Context, ip string, force bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	targets, err := s.getSyncTargets(ctx)
+	if err != nil {
+		return err
+	}
+
+	applied, err := s.getAppliedHashes(ctx, ip)
+	if err != nil {
+		return err
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+
+	for _, target := range targets {
+		if ip != "" && target.ip != ip {
+			continue
+		}
+
+		wg.Add(1)
+		go func(target syncTarget) {
+			defer wg.Done()
+
+			if err := s.syncOne(ctx, target, applied, force); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s %s: %w", target.instanceType, target.ip, err))
+				mu.Unlock()
+			}
+		}(target)
+	}
+
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+func (s *configSyncCache) GetSyncStates(ctx context.Context) ([]*model.MonitorConfigSyncState, error) {
+	targets, err := s.getSyncTargets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	states, err := s.syncDao.GetAllConfigSyncStates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := s.getAppliedHashes(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	stateMap := make(map[string]*model.MonitorConfigSyncState, len(states))
+	for _, state := range states {
+		stateMap[versionKey(state.InstanceType, state.InstanceIP)] = state
+	}
+
+	// 以当前池中的实例为准，已移出池的实例不再展示
+	result := make([]*model.MonitorConfigSyncState, 0, len(targets))
+	for _, target := range targets {
+		state, ok := stateMap[versionKey(target.instanceType, target.ip)]
+		if !ok {
+			state = &model.MonitorConfigSyncState{
+				InstanceType: target.instanceType,
+				InstanceIP:   target.ip,
+			}
+		}
+
+		state.PoolID = target.poolID
+		state.PoolName = target.poolName
+		state.DesiredHash = target.hash
+		if target.appliedBy(applied) {
+			state.AppliedHash = target.hash
+		}
+		// 重载成功只说明实例重新读取了本地文件，是否同步以 config-agent 上报的已应用配置为准
+		state.OutOfSync = target.hash != "" && state.AppliedHash != target.hash
+		result = append(result, state)
+	}
+
+	return result, nil
+}
+
+// getSyncTargets 根据池中的实例和当前缓存的配置计算每个实例期望的配置哈希
+func (s *configSyncCache) getSyncTargets(ctx context.Context) ([]syncTarget, error) {
+	scrapePools, err := s.scrapePoolDao.GetAllMonitorScrapePool(ctx)
+	if err != nil {
+		s.l.Error("[监控模块] 获取采集池失败", zap.Error(err))
+		return nil, err
+	}
+
+	alertPools, err := s.alertPoolDao.GetAllAlertManagerPools(ctx)
+	if err != nil {
+		s.l.Error("[监控模块] 获取AlertManager实例池失败", zap.Error(err))
+		return nil, err
+	}
+
+	var targets []syncTarget
+
+	for _, pool := range scrapePools {
+		for _, ip := range pool.PrometheusInstances {
+			// Prometheus 重载时会同时加载主配置和规则文件，三者任一变化都需要重载
+			target := syncTarget{
+				instanceType: InstanceTypePrometheus,
+				poolID:       pool.ID,
+				poolName:     pool.Name,
+				ip:           ip,
+			}
+			if mainConfig := s.promCache.GetPrometheusMainConfigByIP(ip); mainConfig != "" {
+				alertRules := s.ruleCache.GetPrometheusAlertRuleConfigYamlByIp(ip)
+				recordRules := s.recordCache.GetPrometheusRecordRuleConfigYamlByIp(ip)
+				target.hash = HashConfig(strings.Join([]string{mainConfig, alertRules, recordRules}, "\n---\n"))
+				target.configHashes = configHashes(map[string]string{
+					ConfigTypePrometheus:       mainConfig,
+					ConfigTypePrometheusAlert:  alertRules,
+					ConfigTypePrometheusRecord: recordRules,
+				})
+			}
+
+			targets = append(targets, target)
+		}
+	}
+
+	for _, pool := range alertPools {
+		for _, ip := range pool.AlertManagerInstances {
+			target := syncTarget{
+				instanceType: InstanceTypeAlertManager,
+				poolID:       pool.ID,
+				poolName:     pool.Name,
+				ip:           ip,
+			}
+			if content := s.alertCache.GetAlertManagerMainConfigYamlByIP(ip); content != "" {
+				target.hash = HashConfig(content)
+				target.configHashes = configHashes(map[string]string{ConfigTypeAlertManager: content})
+			}
+
+			targets = append(targets, target)
+		}
+	}
+
+	return targets, nil
+}
+
+// configHashes 计算各类配置的哈希，内容为空的配置不会下发给 config-agent，不参与比较
+func configHashes(contents map[string]string) map[string]string {
+	hashes := make(map[string]string, len(contents))
+	for configType, content := range contents {
+		if content != "" {
+			hashes[configType] = HashConfig(content)
+		}
+	}
+
+	return hashes
+}
+
+// appliedBy 实例通过 config-agent 上报的每类配置是否都已成功应用期望的版本
+func (t syncTarget) appliedBy(applied map[string]string) bool {
+	if t.hash == "" {
+		return false
+	}
+
+	for configType, hash := range t.configHashes {
+		if applied[versionKey(configType, t.ip)] != hash {
+			return false
+		}
+	}
+
+	return true
+}
+
+// getAppliedHashes 获取 config-agent 上报成功应用的配置哈希，键为配置类型与实例IP，ip 为空时获取所有实例
+func (s *configSyncCache) getAppliedHashes(ctx context.Context, ip string) (map[string]string, error) {
+	list, err := s.versionDao.GetConfigAppliedList(ctx, ip)
+	if err != nil {
+		s.l.Error("[监控模块] 获取实例已应用配置失败", zap.Error(err))
+		return nil, err
+	}
+
+	applied := make(map[string]string, len(list))
+	for _, item := range list {
+		if item.Success {
+			applied[versionKey(item.ConfigType, item.InstanceIP)] = item.Hash
+		}
+	}
+
+	return applied, nil
+}
+
+// syncOne config-agent 未确认实例已应用期望的配置时，调用实例的 /-/reload 并记录结果
+func (s *configSyncCache) syncOne(ctx context.Context, target syncTarget, applied map[string]string, force bool) error {
+	state, err := s.syncDao.GetConfigSyncState(ctx, target.instanceType, target.ip)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		state = &model.MonitorConfigSyncState{
+			InstanceType: target.instanceType,
+			InstanceIP:   target.ip,
+		}
+	}
+
+	unchanged := state.ID != 0 && state.PoolID == target.poolID && state.DesiredHash == target.hash
+	state.PoolID = target.poolID
+	state.DesiredHash = target.hash
+
+	// 实例没有可下发的配置时不重载，只记录期望状态
+	if target.hash == "" {
+		if unchanged {
+			return nil
+		}
+		return s.syncDao.SaveConfigSyncState(ctx, state)
+	}
+
+	// config-agent 写入配置后会自行重载，已确认应用时无需再由平台重载
+	// 只有 config-agent 确认后才记录 PushedHash，平台重载成功时实例可能还在使用旧的本地文件
+	if !force && target.appliedBy(applied) {
+		if unchanged && state.PushedHash == target.hash {
+			return nil
+		}
+		state.PushedHash = target.hash
+		return s.syncDao.SaveConfigSyncState(ctx, state)
+	}
+
+	attempts, reloadErr := s.reload(ctx, target)
+	state.ReloadAttempts = attempts
+	state.LastReloadAt = time.Now().Unix()
+	if reloadErr != nil {
+		state.LastReloadSuccess = false
+		state.LastError = reloadErr.Error()
+		s.l.Error("[监控模块] 实例重载配置失败",
+			zap.Error(reloadErr),
+			zap.String("实例类型", target.instanceType),
+			zap.String("IP", target.ip),
+			zap.Int("尝试次数", attempts),
+		)
+	} else {
+		state.LastReloadSuccess = true
+		state.LastError = ""
+		s.l.Info("[监控模块] 实例重载配置成功",
+			zap.String("实例类型", target.instanceType),
+			zap.String("IP", target.ip),
+		)
+	}
+
+	if err := s.syncDao.SaveConfigSyncState(ctx, state); err != nil {
+		return err
+	}
+
+	return reloadErr
+}
+
+// reload 调用实例的 /-/reload 接口，失败时按递增间隔重试，返回实际尝试次数
+func (s *configSyncCache) reload(ctx context.Context, target syncTarget) (int, error) {
+	url := fmt.Sprintf("http://%s/-/reload", s.instanceAddr(target))
+
+	var lastErr error
+	for attempt := 1; attempt <= s.reloadRetry; attempt++ {
+		if lastErr = s.postReload(ctx, url); lastErr == nil {
+			return attempt, nil
+		}
+
+		if attempt == s.reloadRetry {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return attempt, fmt.Errorf("%w: %v", lastErr, ctx.Err())
+		case <-time.After(time.Duration(attempt) * time.Second):
+		}
+	}
+
+	return s.reloadRetry, lastErr
+}
+
+func (s *configSyncCache) postReload(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("重载返回状态码 %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
+
+// instanceAddr 实例地址，实例本身带端口时直接使用，否则使用对应类型的默认端口
+func (s *configSyncCache) instanceAddr(target syncTarget) string {
+	if _, _, err := net.SplitHostPort(target.ip); err == nil {
+		return target.ip
+	}
+
+	port := s.prometheusPort
+	if target.instanceType == InstanceTypeAlertManager {
+		port = s.alertManagerPort
+	}
+
+	return net.JoinHostPort(target.ip, strconv.Itoa(port))
+}
@@ -0,0 +1,214 @@
+package cache
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	configDao "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/config"
+	"go.uber.org/zap"
+)
+
+func TestSyncTargetAppliedBy(t *testing.T) {
+	const ip = "10.0.0.1"
+	target := syncTarget{
+		instanceType: InstanceTypePrometheus,
+		ip:           ip,
+		hash:         "combined",
+		configHashes: configHashes(map[string]string{
+			ConfigTypePrometheus:       "global: {}",
+			ConfigTypePrometheusAlert:  "groups: []",
+			ConfigTypePrometheusRecord: "",
+		}),
+	}
+	mainHash := HashConfig("global: {}")
+	alertHash := HashConfig("groups: []")
+
+	tests := []struct {
+		name    string
+		target  syncTarget
+		applied map[string]string
+		want    bool
+	}{
+		{
+			name:   "所有配置都已应用",
+			target: target,
+			applied: map[string]string{
+				versionKey(ConfigTypePrometheus, ip):      mainHash,
+				versionKey(ConfigTypePrometheusAlert, ip): alertHash,
+			},
+			want: true,
+		},
+		{
+			name:   "规则文件仍是旧版本",
+			target: target,
+			applied: map[string]string{
+				versionKey(ConfigTypePrometheus, ip):      mainHash,
+				versionKey(ConfigTypePrometheusAlert, ip): HashConfig("groups: [old]"),
+			},
+		},
+		{
+			name:    "没有上报",
+			target:  target,
+			applied: map[string]string{},
+		},
+		{
+			name:   "其他实例的上报不算",
+			target: target,
+			applied: map[string]string{
+				versionKey(ConfigTypePrometheus, "10.0.0.2"):      mainHash,
+				versionKey(ConfigTypePrometheusAlert, "10.0.0.2"): alertHash,
+			},
+		},
+		{
+			name:    "没有可下发的配置",
+			target:  syncTarget{instanceType: InstanceTypeAlertManager, ip: ip},
+			applied: map[string]string{versionKey(ConfigTypeAlertManager, ip): ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.target.appliedBy(tt.applied); got != tt.want {
+				t.Errorf("appliedBy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigHashesSkipsEmpty(t *testing.T) {
+	hashes := configHashes(map[string]string{
+		ConfigTypeAlertManager:    "route: {}",
+		ConfigTypePrometheusAlert: "",
+	})
+
+	if len(hashes) != 1 || hashes[ConfigTypeAlertManager] != HashConfig("route: {}") {
+		t.Errorf("configHashes() = %v", hashes)
+	}
+}
+
+type fakeConfigSyncDAO struct {
+	configDao.ConfigSyncDAO
+	state *model.MonitorConfigSyncState
+	saved *model.MonitorConfigSyncState
+}
+
+func (f *fakeConfigSyncDAO) GetConfigSyncState(_ context.Context, _, _ string) (*model.MonitorConfigSyncState, error) {
+	if f.state == nil {
+		return nil, nil
+	}
+	state := *f.state
+	return &state, nil
+}
+
+func (f *fakeConfigSyncDAO) SaveConfigSyncState(_ context.Context, state *model.MonitorConfigSyncState) error {
+	f.saved = state
+	return nil
+}
+
+func TestSyncOnePushedHash(t *testing.T) {
+	var reloads atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reloads.Add(1)
+	}))
+	defer srv.Close()
+
+	ip := strings.TrimPrefix(srv.URL, "http://")
+	content := "route: {}"
+	target := syncTarget{
+		instanceType: InstanceTypeAlertManager,
+		poolID:       1,
+		ip:           ip,
+		hash:         HashConfig(content),
+		configHashes: configHashes(map[string]string{ConfigTypeAlertManager: content}),
+	}
+	applied := map[string]string{versionKey(ConfigTypeAlertManager, ip): HashConfig(content)}
+
+	tests := []struct {
+		name       string
+		state      *model.MonitorConfigSyncState
+		applied    map[string]string
+		wantReload bool
+		wantPushed string
+	}{
+		{
+			name:       "首次同步未确认时重载但不记录已加载",
+			applied:    map[string]string{},
+			wantReload: true,
+		},
+		{
+			name: "PushedHash 与期望一致但 config-agent 未确认时仍然重载",
+			state: &model.MonitorConfigSyncState{
+				Model:             model.Model{ID: 1},
+				PoolID:            1,
+				DesiredHash:       target.hash,
+				PushedHash:        target.hash,
+				LastReloadSuccess: true,
+			},
+			applied:    map[string]string{},
+			wantReload: true,
+			wantPushed: target.hash,
+		},
+		{
+			name:       "config-agent 确认后记录已加载的哈希且不重载",
+			state:      &model.MonitorConfigSyncState{Model: model.Model{ID: 1}, PoolID: 1, DesiredHash: target.hash},
+			applied:    applied,
+			wantPushed: target.hash,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reloads.Store(0)
+			dao := &fakeConfigSyncDAO{state: tt.state}
+			s := &configSyncCache{
+				l:           zap.NewNop(),
+				client:      srv.Client(),
+				reloadRetry: 1,
+				syncDao:     dao,
+			}
+
+			if err := s.syncOne(context.Background(), target, tt.applied, false); err != nil {
+				t.Fatalf("syncOne() err = %v", err)
+			}
+			if got := reloads.Load() > 0; got != tt.wantReload {
+				t.Errorf("重载 = %v, want %v", got, tt.wantReload)
+			}
+			if dao.saved == nil {
+				t.Fatal("syncOne() 未保存同步状态")
+			}
+			if dao.saved.PushedHash != tt.wantPushed {
+				t.Errorf("PushedHash = %q, want %q", dao.saved.PushedHash, tt.wantPushed)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package cache
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"github.com/go-kit/log"
+	altconfig "github.com/prometheus/alertmanager/config"
+	pc "github.com/prometheus/prometheus/config"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/prometheus/prometheus/promql/parser"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ValidateConfig 使用 Prometheus/AlertManager 自身的加载逻辑校验生成的配置内容
+func ValidateConfig(configType string, content string) error {
+	switch configType {
+	case ConfigTypePrometheus:
+		_, err := pc.Load(content, false, log.NewNopLogger())
+		return err
+	case ConfigTypePrometheusAlert, ConfigTypePrometheusRecord:
+		_, errs := rulefmt.Parse([]byte(content))
+		return errors.Join(errs...)
+	case ConfigTypeAlertManager:
+		_, err := altconfig.Load(content)
+		return err
+	default:
+		return fmt.Errorf("未知的配置类型: %s", configType)
+	}
+}
+
+// ValidateRule 校验单条规则，先检查 PromQL 表达式，再按规则文件的格式校验标签、注解等字段
+func ValidateRule(rule rulefmt.Rule) error {
+	if _, err := parser.ParseExpr(rule.Expr); err != nil {
+		return fmt.Errorf("PromQL 表达式不合法: %w", err)
+	}
+
+	name := rule.Alert
+	if rule.Record != "" {
+		name = rule.Record
+	}
+
+	yamlData, err := yaml.Marshal(&RuleGroups{Groups: []RuleGroup{{Name: name, Rules: []rulefmt.Rule{rule}}}})
+	if err != nil {
+		return err
+	}
+
+	_, errs := rulefmt.Parse(yamlData)
+	return errors.Join(errs...)
+}
+
+// validateAndApply 校验生成的配置，通过后记录版本并返回实例应使用的内容
+// 校验失败时回退到最近一次校验通过的版本，没有可用版本时返回空字符串
+func validateAndApply(ctx context.Context, versionCache ConfigVersionCache, configType string, poolID int, ip string, content string) (string, bool, error) {
+	if err := ValidateConfig(configType, content); err != nil {
+		lastGood, ok := fallbackConfig(ctx, versionCache, configType, ip)
+		return lastGood, ok, err
+	}
+
+	return versionCache.ApplyVersion(ctx, configType, poolID, ip, content), false, nil
+}
+
+// fallbackConfig 获取实例最近一次校验通过的配置，历史版本同样需要通过校验
+func fallbackConfig(ctx context.Context, versionCache ConfigVersionCache, configType string, ip string) (string, bool) {
+	lastGood, ok := versionCache.LastKnownGood(ctx, configType, ip)
+	if !ok || ValidateConfig(configType, lastGood) != nil {
+		return "", false
+	}
+
+	return lastGood, true
+}
+
+// newConfigError 构造一条配置校验错误
+func newConfigError(configType string, poolID int, poolName, ip, object string, err error, fallback bool) *model.MonitorConfigError {
+	return &model.MonitorConfigError{
+		ConfigType: configType,
+		PoolID:     poolID,
+		PoolName:   poolName,
+		InstanceIP: ip,
+		Object:     object,
+		Message:    err.Error(),
+		Fallback:   fallback,
+		CheckedAt:  time.Now().Unix(),
+	}
+}
+
+type ConfigErrorCache interface {
+	// ReplaceConfigErrors 用最近一次生成产生的错误替换该类配置之前记录的错误
+	ReplaceConfigErrors(configType string, errs []*model.MonitorConfigError)
+	// GetConfigErrors 获取配置校验错误，configType 为空时返回所有类型，poolID 为0时返回所有池
+	GetConfigErrors(configType string, poolID int) []*model.MonitorConfigError
+}
+
+type configErrorCache struct {
+	mu     sync.RWMutex
+	l      *zap.Logger
+	errors map[string][]*model.MonitorConfigError // 键为配置类型
+}
+
+func NewConfigErrorCache(l *zap.Logger) ConfigErrorCache {
+	return &configErrorCache{
+		l:      l,
+		errors: make(map[string][]*model.MonitorConfigError),
+	}
+}
+
+func (c *configErrorCache) ReplaceConfigErrors(configType string, errs []*model.MonitorConfigError) {
+	for _, e := range errs {
+		c.l.Warn("[监控模块] 配置校验失败",
+			zap.String("配置类型", e.ConfigType),
+			zap.String("池子", e.PoolName),
+			zap.String("IP", e.InstanceIP),
+			zap.String("对象", e.Object),
+			zap.Bool("回退", e.Fallback),
+			zap.String("错误", e.Message),
+		)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(errs) == 0 {
+		delete(c.errors, configType)
+		return
+	}
+	c.errors[configType] = errs
+}
+
+func (c *configErrorCache) GetConfigErrors(configType string, poolID int) []*model.MonitorConfigError {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make([]*model.MonitorConfigError, 0)
+	for t, errs := range c.errors {
+		if configType != "" && t != configType {
+			continue
+		}
+		for _, e := range errs {
+			if poolID != 0 && e.PoolID != poolID {
+				continue
+			}
+			result = append(result, e)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].ConfigType != result[j].ConfigType {
+			return result[i].ConfigType < result[j].ConfigType
+		}
+		return result[i].PoolID < result[j].PoolID
+	})
+
+	return result
+}
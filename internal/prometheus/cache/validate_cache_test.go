@@ -0,0 +1,185 @@
+package cache
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"go.uber.org/zap"
+)
+
+const validPrometheusConfig = `global:
+  scrape_interval: 30s
+scrape_configs:
+  - job_name: node
+    static_configs:
+      - targets: ["10.0.0.1:9100"]
+`
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		configType string
+		content    string
+		wantErr    bool
+	}{
+		{name: "合法的Prometheus配置", configType: ConfigTypePrometheus, content: validPrometheusConfig},
+		{name: "重复的采集任务", configType: ConfigTypePrometheus, content: validPrometheusConfig + `  - job_name: node
+    static_configs:
+      - targets: ["10.0.0.2:9100"]
+`, wantErr: true},
+		{name: "合法的告警规则", configType: ConfigTypePrometheusAlert, content: "groups:\n  - name: node\n    rules:\n      - alert: HostDown\n        expr: up == 0\n"},
+		{name: "告警规则表达式错误", configType: ConfigTypePrometheusAlert, content: "groups:\n  - name: node\n    rules:\n      - alert: HostDown\n        expr: up ==\n", wantErr: true},
+		{name: "预聚合规则名称不合法", configType: ConfigTypePrometheusRecord, content: "groups:\n  - name: node\n    rules:\n      - record: job up\n        expr: sum(up)\n", wantErr: true},
+		{name: "合法的AlertManager配置", configType: ConfigTypeAlertManager, content: "route:\n  receiver: default\nreceivers:\n  - name: default\n"},
+		{name: "AlertManager接收者不存在", configType: ConfigTypeAlertManager, content: "route:\n  receiver: missing\nreceivers:\n  - name: default\n", wantErr: true},
+		{name: "未知的配置类型", configType: "unknown", content: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateConfig(tt.configType, tt.content); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateConfig() err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    rulefmt.Rule
+		wantErr bool
+	}{
+		{name: "合法的告警规则", rule: rulefmt.Rule{Alert: "HostDown", Expr: "up == 0", Labels: map[string]string{"severity": "critical"}}},
+		{name: "合法的预聚合规则", rule: rulefmt.Rule{Record: "job:up:sum", Expr: "sum by (job) (up)"}},
+		{name: "表达式错误", rule: rulefmt.Rule{Alert: "HostDown", Expr: "up =="}, wantErr: true},
+		{name: "预聚合规则名称不合法", rule: rulefmt.Rule{Record: "job up", Expr: "sum(up)"}, wantErr: true},
+		{name: "标签名不合法", rule: rulefmt.Rule{Alert: "HostDown", Expr: "up == 0", Labels: map[string]string{"bad-name": "x"}}, wantErr: true},
+		{name: "注解模板错误", rule: rulefmt.Rule{Alert: "HostDown", Expr: "up == 0", Annotations: map[string]string{"summary": "{{ $value "}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateRule(tt.rule); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateRule() err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// fakeVersionCache 记录应用的配置，并返回预设的最近一次可用配置
+type fakeVersionCache struct {
+	ConfigVersionCache
+	applied  string
+	lastGood string
+}
+
+func (f *fakeVersionCache) ApplyVersion(_ context.Context, _ string, _ int, _ string, content string) string {
+	f.applied = content
+	return content
+}
+
+func (f *fakeVersionCache) LastKnownGood(_ context.Context, _, _ string) (string, bool) {
+	return f.lastGood, f.lastGood != ""
+}
+
+func TestValidateAndApply(t *testing.T) {
+	invalid := "scrape_configs: [\n"
+
+	tests := []struct {
+		name         string
+		content      string
+		lastGood     string
+		want         string
+		wantFallback bool
+		wantErr      bool
+	}{
+		{name: "校验通过", content: validPrometheusConfig, want: validPrometheusConfig},
+		{name: "校验失败回退到最近可用版本", content: invalid, lastGood: validPrometheusConfig, want: validPrometheusConfig, wantFallback: true, wantErr: true},
+		{name: "校验失败且没有可用版本", content: invalid, want: "", wantErr: true},
+		{name: "最近可用版本也无法通过校验", content: invalid, lastGood: invalid, want: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			versionCache := &fakeVersionCache{lastGood: tt.lastGood}
+			got, fallback, err := validateAndApply(context.Background(), versionCache, ConfigTypePrometheus, 1, "10.0.0.1", tt.content)
+			if (err != nil) != tt.wantErr || fallback != tt.wantFallback || got != tt.want {
+				t.Fatalf("validateAndApply() = %q, %v, %v", got, fallback, err)
+			}
+			if tt.wantErr && versionCache.applied != "" {
+				t.Error("校验失败的配置不应记录版本")
+			}
+		})
+	}
+}
+
+func TestConfigErrorCache(t *testing.T) {
+	c := NewConfigErrorCache(zap.NewNop())
+	c.ReplaceConfigErrors(ConfigTypePrometheus, []*model.MonitorConfigError{
+		newConfigError(ConfigTypePrometheus, 2, "b", "10.0.0.2", "", errors.New("bad"), false),
+		newConfigError(ConfigTypePrometheus, 1, "a", "10.0.0.1", "", errors.New("bad"), true),
+	})
+	c.ReplaceConfigErrors(ConfigTypeAlertManager, []*model.MonitorConfigError{
+		newConfigError(ConfigTypeAlertManager, 1, "a", "10.0.0.3", "", errors.New("bad"), false),
+	})
+
+	tests := []struct {
+		name       string
+		configType string
+		poolID     int
+		wantIPs    []string
+	}{
+		{name: "全部错误", wantIPs: []string{"10.0.0.3", "10.0.0.1", "10.0.0.2"}},
+		{name: "按配置类型过滤", configType: ConfigTypePrometheus, wantIPs: []string{"10.0.0.1", "10.0.0.2"}},
+		{name: "按池过滤", poolID: 1, wantIPs: []string{"10.0.0.3", "10.0.0.1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := c.GetConfigErrors(tt.configType, tt.poolID)
+			if len(got) != len(tt.wantIPs) {
+				t.Fatalf("GetConfigErrors() 返回 %d 条, want %d", len(got), len(tt.wantIPs))
+			}
+			for i, e := range got {
+				if e.InstanceIP != tt.wantIPs[i] {
+					t.Errorf("GetConfigErrors()[%d].InstanceIP = %s, want %s", i, e.InstanceIP, tt.wantIPs[i])
+				}
+			}
+		})
+	}
+
+	// 没有错误时清除该类配置之前的错误
+	c.ReplaceConfigErrors(ConfigTypePrometheus, nil)
+	if got := c.GetConfigErrors(ConfigTypePrometheus, 0); len(got) != 0 {
+		t.Errorf("清除后仍有 %d 条错误", len(got))
+	}
+}
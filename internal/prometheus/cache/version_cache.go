@@ -0,0 +1,301 @@
+package cache
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	configDao "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/config"
+	ijwt "github.com/GoSimplicity/AI-CloudOps/pkg/utils/jwt"
+	"go.uber.org/zap"
+	"sync"
+)
+
+// 配置类型，与 /api/monitor/prometheus_configs 下的拉取接口一一对应
+const (
+	ConfigTypePrometheus       = "prometheus"
+	ConfigTypePrometheusAlert  = "prometheus_alert"
+	ConfigTypePrometheusRecord = "prometheus_record"
+	ConfigTypeAlertManager     = "alertManager"
+)
+
+// defaultConfigTrigger 未指定触发操作时的默认值，即定时任务重新生成
+const defaultConfigTrigger = "定时刷新"
+
+type configTriggerKey struct{}
+
+// WithConfigTrigger 在上下文中记录触发配置重新生成的操作，用于标记配置版本的变更来源
+func WithConfigTrigger(ctx context.Context, trigger string) context.Context {
+	return context.WithValue(ctx, configTriggerKey{}, trigger)
+}
+
+// configChangeFromContext 从上下文中获取变更者和触发操作
+// 由接口触发时上下文为 gin.Context，可以直接取到登录用户
+func configChangeFromContext(ctx context.Context) (int, string) {
+	trigger, _ := ctx.Value(configTriggerKey{}).(string)
+	if trigger == "" {
+		trigger = defaultConfigTrigger
+	}
+
+	var userID int
+	if uc, ok := ctx.Value("user").(ijwt.UserClaims); ok {
+		userID = uc.Uid
+	}
+
+	return userID, trigger
+}
+
+// IsValidConfigType 判断配置类型是否合法
+func IsValidConfigType(configType string) bool {
+	switch configType {
+	case ConfigTypePrometheus, ConfigTypePrometheusAlert, ConfigTypePrometheusRecord, ConfigTypeAlertManager:
+		return true
+	default:
+		return false
+	}
+}
+
+// HashConfig 计算配置内容的SHA256哈希
+func HashConfig(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+type ConfigVersionCache interface {
+	// ApplyVersion 记录生成的配置，内容变化时产生新版本，返回实例实际应使用的配置内容
+	ApplyVersion(ctx context.Context, configType string, poolID int, ip string, content string) string
+	// Pin 将实例某类配置固定到指定版本
+	Pin(ctx context.Context, configType, ip string, versionID, userID int, reason string) error
+	// Unpin 取消实例某类配置的固定，恢复使用最新生成的配置
+	Unpin(ctx context.Context, configType, ip string) error
+	// GetPinnedVersionID 获取实例某类配置固定的版本ID，未固定时返回0
+	GetPinnedVersionID(configType, ip string) int
+	// LastKnownGood 获取实例某类配置最近一次可用的内容，固定了版本时返回固定版本的内容
+	LastKnownGood(ctx context.Context, configType, ip string) (string, bool)
+}
+
+type pinnedConfig struct {
+	versionID int
+	content   string
+}
+
+type configVersionCache struct {
+	mu         sync.Mutex
+	l          *zap.Logger
+	versionDao configDao.ConfigVersionDAO
+	latest     map[string]*model.MonitorConfigVersion // 每个实例每类配置的最新版本，键为 configType/ip
+	pins       map[string]pinnedConfig                // 每个实例每类配置固定的版本，键为 configType/ip
+	pinsLoaded bool
+}
+
+func NewConfigVersionCache(l *zap.Logger, versionDao configDao.ConfigVersionDAO) ConfigVersionCache {
+	return &configVersionCache{
+		l:          l,
+		versionDao: versionDao,
+		latest:     make(map[string]*model.MonitorConfigVersion),
+		pins:       make(map[string]pinnedConfig),
+	}
+}
+
+func versionKey(configType, ip string) string {
+	return fmt.Sprintf("%s/%s", configType, ip)
+}
+
+func (v *configVersionCache) ApplyVersion(ctx context.Context, configType string, poolID int, ip string, content string) string {
+	key := versionKey(configType, ip)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	// 版本记录失败不影响配置下发
+	if err := v.recordLocked(ctx, configType, poolID, ip, content); err != nil {
+		v.l.Error("[监控模块] 记录配置版本失败", zap.Error(err), zap.String("配置类型", configType), zap.String("IP", ip))
+	}
+
+	if err := v.loadPinsLocked(ctx); err != nil {
+		v.l.Error("[监控模块] 加载固定配置版本失败", zap.Error(err))
+		return content
+	}
+
+	if pin, ok := v.pins[key]; ok {
+		return pin.content
+	}
+
+	return content
+}
+
+func (v *configVersionCache) Pin(ctx context.Context, configType, ip string, versionID, userID int, reason string) error {
+	version, err := v.versionDao.GetConfigVersionById(ctx, versionID)
+	if err != nil {
+		return err
+	}
+
+	if version.ConfigType != configType || version.InstanceIP != ip {
+		return fmt.Errorf("版本 %d 不属于实例 %s 的 %s 配置", versionID, ip, configType)
+	}
+
+	pin := &model.MonitorConfigPin{
+		ConfigType: configType,
+		InstanceIP: ip,
+		VersionID:  versionID,
+		UserID:     userID,
+		Reason:     reason,
+	}
+	if err := v.versionDao.SaveConfigPin(ctx, pin); err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	v.pins[versionKey(configType, ip)] = pinnedConfig{versionID: version.ID, content: version.Content}
+	v.mu.Unlock()
+
+	return nil
+}
+
+func (v *configVersionCache) Unpin(ctx context.Context, configType, ip string) error {
+	if err := v.versionDao.DeleteConfigPin(ctx, configType, ip); err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	delete(v.pins, versionKey(configType, ip))
+	v.mu.Unlock()
+
+	return nil
+}
+
+func (v *configVersionCache) GetPinnedVersionID(configType, ip string) int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.pins[versionKey(configType, ip)].versionID
+}
+
+func (v *configVersionCache) LastKnownGood(ctx context.Context, configType, ip string) (string, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if err := v.loadPinsLocked(ctx); err != nil {
+		v.l.Error("[监控模块] 加载固定配置版本失败", zap.Error(err))
+	} else if pin, ok := v.pins[versionKey(configType, ip)]; ok {
+		return pin.content, true
+	}
+
+	latest, err := v.versionDao.GetLatestConfigVersion(ctx, configType, ip)
+	if err != nil {
+		v.l.Error("[监控模块] 获取最新配置版本失败", zap.Error(err), zap.String("配置类型", configType), zap.String("IP", ip))
+		return "", false
+	}
+	if latest == nil {
+		return "", false
+	}
+
+	return latest.Content, true
+}
+
+// recordLocked 内容与最新版本不同时写入新版本，调用方需持有锁
+func (v *configVersionCache) recordLocked(ctx context.Context, configType string, poolID int, ip string, content string) error {
+	key := versionKey(configType, ip)
+	hash := HashConfig(content)
+
+	latest, ok := v.latest[key]
+	if !ok {
+		var err error
+		latest, err = v.versionDao.GetLatestConfigVersion(ctx, configType, ip)
+		if err != nil {
+			return err
+		}
+		if latest != nil {
+			latest.Content = ""
+			v.latest[key] = latest
+		}
+	}
+
+	if latest != nil && latest.Hash == hash {
+		return nil
+	}
+
+	userID, trigger := configChangeFromContext(ctx)
+	version := &model.MonitorConfigVersion{
+		ConfigType: configType,
+		InstanceIP: ip,
+		PoolID:     poolID,
+		Version:    1,
+		Hash:       hash,
+		Content:    content,
+		UserID:     userID,
+		Trigger:    trigger,
+	}
+	if latest != nil {
+		version.Version = latest.Version + 1
+	}
+
+	if err := v.versionDao.CreateConfigVersion(ctx, version); err != nil {
+		return err
+	}
+
+	// 缓存中只保留哈希和版本号，避免长期持有配置内容
+	v.latest[key] = &model.MonitorConfigVersion{
+		NoUniqueIndexModel: model.NoUniqueIndexModel{ID: version.ID},
+		Version:            version.Version,
+		Hash:               version.Hash,
+	}
+
+	v.l.Info("[监控模块] 生成新的配置版本",
+		zap.String("配置类型", configType),
+		zap.String("IP", ip),
+		zap.Int("版本", version.Version),
+		zap.String("触发操作", trigger),
+	)
+
+	return nil
+}
+
+// loadPinsLocked 首次使用时从数据库加载所有固定的配置版本，调用方需持有锁
+func (v *configVersionCache) loadPinsLocked(ctx context.Context) error {
+	if v.pinsLoaded {
+		return nil
+	}
+
+	pins, err := v.versionDao.GetAllConfigPins(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, pin := range pins {
+		version, err := v.versionDao.GetConfigVersionById(ctx, pin.VersionID)
+		if err != nil {
+			return err
+		}
+		v.pins[versionKey(pin.ConfigType, pin.InstanceIP)] = pinnedConfig{versionID: version.ID, content: version.Content}
+	}
+
+	v.pinsLoaded = true
+	return nil
+}
@@ -0,0 +1,76 @@
+package cache
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+import (
+	"context"
+	"sync"
+)
+
+type ConfigWatchCache interface {
+	// NotifyChanged 配置重新生成后唤醒所有等待中的请求，由请求自行判断内容是否变化
+	NotifyChanged()
+	// WaitForChange 阻塞直到 current 返回内容的哈希与 hash 不同或 ctx 结束，返回最新的内容
+	WaitForChange(ctx context.Context, hash string, current func() string) string
+}
+
+type configWatchCache struct {
+	mu      sync.Mutex
+	changed chan struct{} // 每次通知时关闭并替换为新的通道
+}
+
+func NewConfigWatchCache() ConfigWatchCache {
+	return &configWatchCache{
+		changed: make(chan struct{}),
+	}
+}
+
+func (w *configWatchCache) NotifyChanged() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	close(w.changed)
+	w.changed = make(chan struct{})
+}
+
+func (w *configWatchCache) WaitForChange(ctx context.Context, hash string, current func() string) string {
+	for {
+		// 先取通道再读内容，避免读取和等待之间的通知被遗漏
+		w.mu.Lock()
+		changed := w.changed
+		w.mu.Unlock()
+
+		content := current()
+		if HashConfig(content) != hash {
+			return content
+		}
+
+		select {
+		case <-ctx.Done():
+			return content
+		case <-changed:
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package alert
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"strings"
+)
+
+type AlertManagerInhibitDAO interface {
+	// GetEnabledMonitorInhibitRulesByPoolId 获取 AlertManager 实例启用的抑制规则，用于生成配置
+	GetEnabledMonitorInhibitRulesByPoolId(ctx context.Context, poolId int) ([]*model.MonitorInhibitRule, error)
+	SearchMonitorInhibitRuleByName(ctx context.Context, name string) ([]*model.MonitorInhibitRule, error)
+	GetMonitorInhibitRuleList(ctx context.Context) ([]*model.MonitorInhibitRule, error)
+	GetMonitorInhibitRuleById(ctx context.Context, id int) (*model.MonitorInhibitRule, error)
+	CreateMonitorInhibitRule(ctx context.Context, inhibitRule *model.MonitorInhibitRule) error
+	UpdateMonitorInhibitRule(ctx context.Context, inhibitRule *model.MonitorInhibitRule) error
+	DeleteMonitorInhibitRule(ctx context.Context, id int) error
+	CheckMonitorInhibitRuleNameExists(ctx context.Context, inhibitRule *model.MonitorInhibitRule) (bool, error)
+}
+
+type alertManagerInhibitDAO struct {
+	db *gorm.DB
+	l  *zap.Logger
+}
+
+func NewAlertManagerInhibitDAO(db *gorm.DB, l *zap.Logger) AlertManagerInhibitDAO {
+	return &alertManagerInhibitDAO{
+		db: db,
+		l:  l,
+	}
+}
+
+func (a *alertManagerInhibitDAO) GetEnabledMonitorInhibitRulesByPoolId(ctx context.Context, poolId int) ([]*model.MonitorInhibitRule, error) {
+	var inhibitRules []*model.MonitorInhibitRule
+
+	if err := a.db.WithContext(ctx).
+		Where("pool_id = ? AND enable = ?", poolId, 1).
+		Order("id").
+		Find(&inhibitRules).Error; err != nil {
+		a.l.Error("获取 MonitorInhibitRule 失败", zap.Error(err), zap.Int("poolId", poolId))
+		return nil, err
+	}
+
+	return inhibitRules, nil
+}
+
+func (a *alertManagerInhibitDAO) SearchMonitorInhibitRuleByName(ctx context.Context, name string) ([]*model.MonitorInhibitRule, error) {
+	var inhibitRules []*model.MonitorInhibitRule
+
+	if err := a.db.WithContext(ctx).
+		Where("LOWER(name) LIKE ?", "%"+strings.ToLower(name)+"%").
+		Find(&inhibitRules).Error; err != nil {
+		a.l.Error("通过名称搜索 MonitorInhibitRule 失败", zap.Error(err))
+		return nil, err
+	}
+
+	return inhibitRules, nil
+}
+
+func (a *alertManagerInhibitDAO) GetMonitorInhibitRuleList(ctx context.Context) ([]*model.MonitorInhibitRule, error) {
+	var inhibitRules []*model.MonitorInhibitRule
+
+	if err := a.db.WithContext(ctx).Find(&inhibitRules).Error; err != nil {
+		a.l.Error("获取所有 MonitorInhibitRule 失败", zap.Error(err))
+		return nil, err
+	}
+
+	return inhibitRules, nil
+}
+
+func (a *alertManagerInhibitDAO) GetMonitorInhibitRuleById(ctx context.Context, id int) (*model.MonitorInhibitRule, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("无效的 ID: %d", id)
+	}
+
+	var inhibitRule model.MonitorInhibitRule
+	if err := a.db.WithContext(ctx).First(&inhibitRule, id).Error; err != nil {
+		a.l.Error("获取 MonitorInhibitRule 失败", zap.Error(err), zap.Int("id", id))
+		return nil, err
+	}
+
+	return &inhibitRule, nil
+}
+
+func (a *alertManagerInhibitDAO) CreateMonitorInhibitRule(ctx context.Context, inhibitRule *model.MonitorInhibitRule) error {
+	if err := a.db.WithContext(ctx).Create(inhibitRule).Error; err != nil {
+		a.l.Error("创建 MonitorInhibitRule 失败", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (a *alertManagerInhibitDAO) UpdateMonitorInhibitRule(ctx context.Context, inhibitRule *model.MonitorInhibitRule) error {
+	if inhibitRule.ID == 0 {
+		return fmt.Errorf("MonitorInhibitRule 的 ID 必须设置且非零")
+	}
+
+	// 相等标签和描述允许清空，因此使用 Select 更新
+	if err := a.db.WithContext(ctx).
+		Model(&model.MonitorInhibitRule{}).
+		Where("id = ?", inhibitRule.ID).
+		Select("name", "pool_id", "enable", "source_matchers", "target_matchers", "equal_labels", "description").
+		Updates(inhibitRule).Error; err != nil {
+		a.l.Error("更新 MonitorInhibitRule 失败", zap.Error(err), zap.Int("id", inhibitRule.ID))
+		return err
+	}
+
+	return nil
+}
+
+func (a *alertManagerInhibitDAO) DeleteMonitorInhibitRule(ctx context.Context, id int) error {
+	if err := a.db.WithContext(ctx).Delete(&model.MonitorInhibitRule{}, id).Error; err != nil {
+		a.l.Error("删除 MonitorInhibitRule 失败", zap.Error(err), zap.Int("id", id))
+		return err
+	}
+
+	return nil
+}
+
+func (a *alertManagerInhibitDAO) CheckMonitorInhibitRuleNameExists(ctx context.Context, inhibitRule *model.MonitorInhibitRule) (bool, error) {
+	var count int64
+
+	if err := a.db.WithContext(ctx).
+		Model(&model.MonitorInhibitRule{}).
+		Where("name = ?", inhibitRule.Name).
+		Where("id != ?", inhibitRule.ID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
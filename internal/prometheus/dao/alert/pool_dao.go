@@ -104,6 +104,16 @@ func (a *alertManagerPoolDAO) UpdateMonitorAlertManagerPool(ctx context.Context,
 		return err
 	}
 
+	// SMTP 设置允许清空，零值需要单独更新
+	if err := a.db.WithContext(ctx).
+		Model(&model.MonitorAlertManagerPool{}).
+		Where("id = ?", monitorAlertManagerPool.ID).
+		Select("smtp_smarthost", "smtp_from", "smtp_auth_username", "smtp_auth_password").
+		Updates(monitorAlertManagerPool).Error; err != nil {
+		a.l.Error("更新 MonitorAlertManagerPool SMTP设置失败", zap.Error(err), zap.Int("id", monitorAlertManagerPool.ID))
+		return err
+	}
+
 	return nil
 }
 
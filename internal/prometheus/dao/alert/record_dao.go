@@ -155,6 +155,18 @@ func (a *alertManagerRecordDAO) UpdateMonitorRecordRule(ctx context.Context, rec
 		return err
 	}
 
+	// 规则组ID为0表示移出规则组，零值需要单独更新
+	if err := a.db.WithContext(ctx).
+		Model(&model.MonitorRecordRule{}).
+		Where("id = ?", recordRule.ID).
+		Updates(map[string]interface{}{
+			"rule_group_id": recordRule.RuleGroupID,
+			"group_order":   recordRule.GroupOrder,
+		}).Error; err != nil {
+		a.l.Error("更新 MonitorRecordRule 规则组失败", zap.Error(err), zap.Int("id", recordRule.ID))
+		return err
+	}
+
 	return nil
 }
 
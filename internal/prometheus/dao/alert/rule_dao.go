@@ -154,6 +154,18 @@ func (a *alertManagerRuleDAO) UpdateMonitorAlertRule(ctx context.Context, monito
 		return err
 	}
 
+	// 规则组ID为0表示移出规则组，零值需要单独更新
+	if err := a.db.WithContext(ctx).
+		Model(&model.MonitorAlertRule{}).
+		Where("id = ?", monitorAlertRule.ID).
+		Updates(map[string]interface{}{
+			"rule_group_id": monitorAlertRule.RuleGroupID,
+			"group_order":   monitorAlertRule.GroupOrder,
+		}).Error; err != nil {
+		a.l.Error("更新 MonitorAlertRule 规则组失败", zap.Error(err), zap.Int("id", monitorAlertRule.ID))
+		return err
+	}
+
 	return nil
 }
 
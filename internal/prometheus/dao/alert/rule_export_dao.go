@@ -0,0 +1,149 @@
+package alert
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type AlertManagerRuleExportDAO interface {
+	GetMonitorRuleExportList(ctx context.Context) ([]*model.MonitorRuleExport, error)
+	GetMonitorRuleExportById(ctx context.Context, id int) (*model.MonitorRuleExport, error)
+	CreateMonitorRuleExport(ctx context.Context, ruleExport *model.MonitorRuleExport) error
+	UpdateMonitorRuleExport(ctx context.Context, ruleExport *model.MonitorRuleExport) error
+	DeleteMonitorRuleExport(ctx context.Context, id int) error
+	// CheckMonitorRuleExportExists 检查采集池是否已经配置同步到同一集群的同一命名空间
+	CheckMonitorRuleExportExists(ctx context.Context, ruleExport *model.MonitorRuleExport) (bool, error)
+	// UpdateMonitorRuleExportSyncStatus 记录最近一次同步的结果
+	UpdateMonitorRuleExportSyncStatus(ctx context.Context, id int, syncAt int64, objectCount int, syncErr string) error
+}
+
+type alertManagerRuleExportDAO struct {
+	db *gorm.DB
+	l  *zap.Logger
+}
+
+func NewAlertManagerRuleExportDAO(db *gorm.DB, l *zap.Logger) AlertManagerRuleExportDAO {
+	return &alertManagerRuleExportDAO{
+		db: db,
+		l:  l,
+	}
+}
+
+func (a *alertManagerRuleExportDAO) GetMonitorRuleExportList(ctx context.Context) ([]*model.MonitorRuleExport, error) {
+	var ruleExports []*model.MonitorRuleExport
+
+	if err := a.db.WithContext(ctx).Order("id").Find(&ruleExports).Error; err != nil {
+		a.l.Error("获取所有 MonitorRuleExport 失败", zap.Error(err))
+		return nil, err
+	}
+
+	return ruleExports, nil
+}
+
+func (a *alertManagerRuleExportDAO) GetMonitorRuleExportById(ctx context.Context, id int) (*model.MonitorRuleExport, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("无效的 ID: %d", id)
+	}
+
+	var ruleExport model.MonitorRuleExport
+	if err := a.db.WithContext(ctx).First(&ruleExport, id).Error; err != nil {
+		a.l.Error("获取 MonitorRuleExport 失败", zap.Error(err), zap.Int("id", id))
+		return nil, err
+	}
+
+	return &ruleExport, nil
+}
+
+func (a *alertManagerRuleExportDAO) CreateMonitorRuleExport(ctx context.Context, ruleExport *model.MonitorRuleExport) error {
+	if err := a.db.WithContext(ctx).Create(ruleExport).Error; err != nil {
+		a.l.Error("创建 MonitorRuleExport 失败", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (a *alertManagerRuleExportDAO) UpdateMonitorRuleExport(ctx context.Context, ruleExport *model.MonitorRuleExport) error {
+	if ruleExport.ID == 0 {
+		return fmt.Errorf("MonitorRuleExport 的 ID 必须设置且非零")
+	}
+
+	// 标签允许清空，因此使用 Select 更新；采集池、集群和命名空间创建后不允许修改
+	if err := a.db.WithContext(ctx).
+		Model(&model.MonitorRuleExport{}).
+		Where("id = ?", ruleExport.ID).
+		Select("labels", "enable").
+		Updates(ruleExport).Error; err != nil {
+		a.l.Error("更新 MonitorRuleExport 失败", zap.Error(err), zap.Int("id", ruleExport.ID))
+		return err
+	}
+
+	return nil
+}
+
+func (a *alertManagerRuleExportDAO) DeleteMonitorRuleExport(ctx context.Context, id int) error {
+	if err := a.db.WithContext(ctx).Delete(&model.MonitorRuleExport{}, id).Error; err != nil {
+		a.l.Error("删除 MonitorRuleExport 失败", zap.Error(err), zap.Int("id", id))
+		return err
+	}
+
+	return nil
+}
+
+func (a *alertManagerRuleExportDAO) CheckMonitorRuleExportExists(ctx context.Context, ruleExport *model.MonitorRuleExport) (bool, error) {
+	var count int64
+
+	if err := a.db.WithContext(ctx).
+		Model(&model.MonitorRuleExport{}).
+		Where("pool_id = ? AND cluster_id = ? AND namespace = ?", ruleExport.PoolID, ruleExport.ClusterID, ruleExport.Namespace).
+		Where("id != ?", ruleExport.ID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (a *alertManagerRuleExportDAO) UpdateMonitorRuleExportSyncStatus(ctx context.Context, id int, syncAt int64, objectCount int, syncErr string) error {
+	if err := a.db.WithContext(ctx).
+		Model(&model.MonitorRuleExport{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"last_sync_at":     syncAt,
+			"last_sync_object": objectCount,
+			"last_sync_error":  syncErr,
+		}).Error; err != nil {
+		a.l.Error("更新 MonitorRuleExport 同步状态失败", zap.Error(err), zap.Int("id", id))
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,210 @@
+package alert
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type AlertManagerRuleGroupDAO interface {
+	GetMonitorRuleGroupList(ctx context.Context) ([]*model.MonitorRuleGroup, error)
+	SearchMonitorRuleGroupByName(ctx context.Context, name string) ([]*model.MonitorRuleGroup, error)
+	GetMonitorRuleGroupById(ctx context.Context, id int) (*model.MonitorRuleGroup, error)
+	GetMonitorRuleGroupByPoolId(ctx context.Context, poolId int) ([]*model.MonitorRuleGroup, error)
+	CreateMonitorRuleGroup(ctx context.Context, ruleGroup *model.MonitorRuleGroup) error
+	UpdateMonitorRuleGroup(ctx context.Context, ruleGroup *model.MonitorRuleGroup) error
+	DeleteMonitorRuleGroup(ctx context.Context, id int) error
+	CheckMonitorRuleGroupNameExists(ctx context.Context, ruleGroup *model.MonitorRuleGroup) (bool, error)
+	GetAlertRulesByGroupId(ctx context.Context, groupId int) ([]*model.MonitorAlertRule, error)
+	GetRecordRulesByGroupId(ctx context.Context, groupId int) ([]*model.MonitorRecordRule, error)
+}
+
+type alertManagerRuleGroupDAO struct {
+	db *gorm.DB
+	l  *zap.Logger
+}
+
+func NewAlertManagerRuleGroupDAO(db *gorm.DB, l *zap.Logger) AlertManagerRuleGroupDAO {
+	return &alertManagerRuleGroupDAO{
+		db: db,
+		l:  l,
+	}
+}
+
+func (a *alertManagerRuleGroupDAO) GetMonitorRuleGroupList(ctx context.Context) ([]*model.MonitorRuleGroup, error) {
+	var ruleGroups []*model.MonitorRuleGroup
+
+	if err := a.db.WithContext(ctx).Find(&ruleGroups).Error; err != nil {
+		a.l.Error("获取所有 MonitorRuleGroup 失败", zap.Error(err))
+		return nil, err
+	}
+
+	return ruleGroups, nil
+}
+
+func (a *alertManagerRuleGroupDAO) SearchMonitorRuleGroupByName(ctx context.Context, name string) ([]*model.MonitorRuleGroup, error) {
+	if name == "" {
+		return nil, fmt.Errorf("name 不能为空")
+	}
+
+	var ruleGroups []*model.MonitorRuleGroup
+
+	if err := a.db.WithContext(ctx).
+		Where("name LIKE ?", "%"+name+"%").
+		Find(&ruleGroups).Error; err != nil {
+		a.l.Error("通过名称搜索 MonitorRuleGroup 失败", zap.Error(err), zap.String("name", name))
+		return nil, err
+	}
+
+	return ruleGroups, nil
+}
+
+func (a *alertManagerRuleGroupDAO) GetMonitorRuleGroupById(ctx context.Context, id int) (*model.MonitorRuleGroup, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("无效的 ID: %d", id)
+	}
+
+	var ruleGroup model.MonitorRuleGroup
+	if err := a.db.WithContext(ctx).First(&ruleGroup, id).Error; err != nil {
+		a.l.Error("获取 MonitorRuleGroup 失败", zap.Error(err), zap.Int("id", id))
+		return nil, err
+	}
+
+	return &ruleGroup, nil
+}
+
+func (a *alertManagerRuleGroupDAO) GetMonitorRuleGroupByPoolId(ctx context.Context, poolId int) ([]*model.MonitorRuleGroup, error) {
+	var ruleGroups []*model.MonitorRuleGroup
+
+	if err := a.db.WithContext(ctx).
+		Where("pool_id = ?", poolId).
+		Order("id").
+		Find(&ruleGroups).Error; err != nil {
+		a.l.Error("获取采集池的 MonitorRuleGroup 失败", zap.Error(err), zap.Int("poolId", poolId))
+		return nil, err
+	}
+
+	return ruleGroups, nil
+}
+
+func (a *alertManagerRuleGroupDAO) CreateMonitorRuleGroup(ctx context.Context, ruleGroup *model.MonitorRuleGroup) error {
+	if err := a.db.WithContext(ctx).Create(ruleGroup).Error; err != nil {
+		a.l.Error("创建 MonitorRuleGroup 失败", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (a *alertManagerRuleGroupDAO) UpdateMonitorRuleGroup(ctx context.Context, ruleGroup *model.MonitorRuleGroup) error {
+	if ruleGroup.ID == 0 {
+		return fmt.Errorf("MonitorRuleGroup 的 ID 必须设置且非零")
+	}
+
+	// 评估间隔、数量上限和描述允许清空，因此使用 Select 更新
+	if err := a.db.WithContext(ctx).
+		Model(&model.MonitorRuleGroup{}).
+		Where("id = ?", ruleGroup.ID).
+		Select("name", "pool_id", "interval", "rule_limit", "description").
+		Updates(ruleGroup).Error; err != nil {
+		a.l.Error("更新 MonitorRuleGroup 失败", zap.Error(err), zap.Int("id", ruleGroup.ID))
+		return err
+	}
+
+	return nil
+}
+
+// DeleteMonitorRuleGroup 删除规则组，组内规则恢复为各自单独成组
+func (a *alertManagerRuleGroupDAO) DeleteMonitorRuleGroup(ctx context.Context, id int) error {
+	return a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.MonitorAlertRule{}).
+			Where("rule_group_id = ?", id).
+			Update("rule_group_id", 0).Error; err != nil {
+			a.l.Error("移出规则组内的告警规则失败", zap.Error(err), zap.Int("id", id))
+			return err
+		}
+
+		if err := tx.Model(&model.MonitorRecordRule{}).
+			Where("rule_group_id = ?", id).
+			Update("rule_group_id", 0).Error; err != nil {
+			a.l.Error("移出规则组内的预聚合规则失败", zap.Error(err), zap.Int("id", id))
+			return err
+		}
+
+		if err := tx.Delete(&model.MonitorRuleGroup{}, id).Error; err != nil {
+			a.l.Error("删除 MonitorRuleGroup 失败", zap.Error(err), zap.Int("id", id))
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (a *alertManagerRuleGroupDAO) CheckMonitorRuleGroupNameExists(ctx context.Context, ruleGroup *model.MonitorRuleGroup) (bool, error) {
+	var count int64
+
+	if err := a.db.WithContext(ctx).
+		Model(&model.MonitorRuleGroup{}).
+		Where("name = ?", ruleGroup.Name).
+		Where("id != ?", ruleGroup.ID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (a *alertManagerRuleGroupDAO) GetAlertRulesByGroupId(ctx context.Context, groupId int) ([]*model.MonitorAlertRule, error) {
+	var alertRules []*model.MonitorAlertRule
+
+	if err := a.db.WithContext(ctx).
+		Where("rule_group_id = ?", groupId).
+		Order("group_order, id").
+		Find(&alertRules).Error; err != nil {
+		a.l.Error("获取规则组内的告警规则失败", zap.Error(err), zap.Int("groupId", groupId))
+		return nil, err
+	}
+
+	return alertRules, nil
+}
+
+func (a *alertManagerRuleGroupDAO) GetRecordRulesByGroupId(ctx context.Context, groupId int) ([]*model.MonitorRecordRule, error) {
+	var recordRules []*model.MonitorRecordRule
+
+	if err := a.db.WithContext(ctx).
+		Where("rule_group_id = ?", groupId).
+		Order("group_order, id").
+		Find(&recordRules).Error; err != nil {
+		a.l.Error("获取规则组内的预聚合规则失败", zap.Error(err), zap.Int("groupId", groupId))
+		return nil, err
+	}
+
+	return recordRules, nil
+}
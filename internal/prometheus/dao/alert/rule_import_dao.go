@@ -0,0 +1,161 @@
+package alert
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type AlertManagerRuleImportDAO interface {
+	GetRuleGroupsByNames(ctx context.Context, names []string) ([]*model.MonitorRuleGroup, error)
+	GetAlertRulesByNames(ctx context.Context, names []string) ([]*model.MonitorAlertRule, error)
+	// GetRecordRulesByNames 获取规则名称或记录名称在 names 中的预聚合规则
+	GetRecordRulesByNames(ctx context.Context, names []string) ([]*model.MonitorRecordRule, error)
+	// ImportRules 在一个事务中保存导入的规则组和规则，ID 为0时新建，否则更新。
+	// 规则通过 RuleGroupName 关联规则组，新建规则组的ID在事务中回填
+	ImportRules(ctx context.Context, ruleGroups []*model.MonitorRuleGroup, alertRules []*model.MonitorAlertRule, recordRules []*model.MonitorRecordRule) error
+}
+
+type alertManagerRuleImportDAO struct {
+	db *gorm.DB
+	l  *zap.Logger
+}
+
+func NewAlertManagerRuleImportDAO(db *gorm.DB, l *zap.Logger) AlertManagerRuleImportDAO {
+	return &alertManagerRuleImportDAO{
+		db: db,
+		l:  l,
+	}
+}
+
+func (a *alertManagerRuleImportDAO) GetRuleGroupsByNames(ctx context.Context, names []string) ([]*model.MonitorRuleGroup, error) {
+	var ruleGroups []*model.MonitorRuleGroup
+	if len(names) == 0 {
+		return ruleGroups, nil
+	}
+
+	if err := a.db.WithContext(ctx).Where("name IN ?", names).Find(&ruleGroups).Error; err != nil {
+		a.l.Error("按名称获取 MonitorRuleGroup 失败", zap.Error(err))
+		return nil, err
+	}
+
+	return ruleGroups, nil
+}
+
+func (a *alertManagerRuleImportDAO) GetAlertRulesByNames(ctx context.Context, names []string) ([]*model.MonitorAlertRule, error) {
+	var alertRules []*model.MonitorAlertRule
+	if len(names) == 0 {
+		return alertRules, nil
+	}
+
+	if err := a.db.WithContext(ctx).Where("name IN ?", names).Find(&alertRules).Error; err != nil {
+		a.l.Error("按名称获取 MonitorAlertRule 失败", zap.Error(err))
+		return nil, err
+	}
+
+	return alertRules, nil
+}
+
+func (a *alertManagerRuleImportDAO) GetRecordRulesByNames(ctx context.Context, names []string) ([]*model.MonitorRecordRule, error) {
+	var recordRules []*model.MonitorRecordRule
+	if len(names) == 0 {
+		return recordRules, nil
+	}
+
+	if err := a.db.WithContext(ctx).
+		Where("name IN ? OR record_name IN ?", names, names).
+		Find(&recordRules).Error; err != nil {
+		a.l.Error("按名称获取 MonitorRecordRule 失败", zap.Error(err))
+		return nil, err
+	}
+
+	return recordRules, nil
+}
+
+func (a *alertManagerRuleImportDAO) ImportRules(ctx context.Context, ruleGroups []*model.MonitorRuleGroup, alertRules []*model.MonitorAlertRule, recordRules []*model.MonitorRecordRule) error {
+	return a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		groupIDs := make(map[string]int, len(ruleGroups))
+		for _, ruleGroup := range ruleGroups {
+			if ruleGroup.ID == 0 {
+				if err := tx.Create(ruleGroup).Error; err != nil {
+					a.l.Error("导入时创建 MonitorRuleGroup 失败", zap.Error(err), zap.String("name", ruleGroup.Name))
+					return err
+				}
+			} else if err := tx.Model(&model.MonitorRuleGroup{}).
+				Where("id = ?", ruleGroup.ID).
+				Select("interval", "rule_limit").
+				Updates(ruleGroup).Error; err != nil {
+				a.l.Error("导入时更新 MonitorRuleGroup 失败", zap.Error(err), zap.Int("id", ruleGroup.ID))
+				return err
+			}
+			groupIDs[ruleGroup.Name] = ruleGroup.ID
+		}
+
+		for _, alertRule := range alertRules {
+			alertRule.RuleGroupID = groupIDs[alertRule.RuleGroupName]
+			if alertRule.ID == 0 {
+				if err := tx.Create(alertRule).Error; err != nil {
+					a.l.Error("导入时创建 MonitorAlertRule 失败", zap.Error(err), zap.String("name", alertRule.Name))
+					return err
+				}
+				continue
+			}
+
+			// 标签、注解和持续时间允许清空，因此使用 Select 更新
+			if err := tx.Model(&model.MonitorAlertRule{}).
+				Where("id = ?", alertRule.ID).
+				Select("pool_id", "send_group_id", "tree_node_id", "expr", "severity", "for_time", "labels", "annotations", "rule_group_id", "group_order").
+				Updates(alertRule).Error; err != nil {
+				a.l.Error("导入时更新 MonitorAlertRule 失败", zap.Error(err), zap.Int("id", alertRule.ID))
+				return err
+			}
+		}
+
+		for _, recordRule := range recordRules {
+			recordRule.RuleGroupID = groupIDs[recordRule.RuleGroupName]
+			if recordRule.ID == 0 {
+				if err := tx.Create(recordRule).Error; err != nil {
+					a.l.Error("导入时创建 MonitorRecordRule 失败", zap.Error(err), zap.String("name", recordRule.Name))
+					return err
+				}
+				continue
+			}
+
+			if err := tx.Model(&model.MonitorRecordRule{}).
+				Where("id = ?", recordRule.ID).
+				Select("pool_id", "tree_node_id", "expr", "rule_group_id", "group_order").
+				Updates(recordRule).Error; err != nil {
+				a.l.Error("导入时更新 MonitorRecordRule 失败", zap.Error(err), zap.Int("id", recordRule.ID))
+				return err
+			}
+		}
+
+		return nil
+	})
+}
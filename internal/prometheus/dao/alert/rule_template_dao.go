@@ -0,0 +1,219 @@
+package alert
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"strings"
+)
+
+type AlertManagerRuleTemplateDAO interface {
+	GetMonitorAlertRuleTemplateList(ctx context.Context) ([]*model.MonitorAlertRuleTemplate, error)
+	SearchMonitorAlertRuleTemplateByName(ctx context.Context, name string) ([]*model.MonitorAlertRuleTemplate, error)
+	GetMonitorAlertRuleTemplateById(ctx context.Context, id int) (*model.MonitorAlertRuleTemplate, error)
+	CreateMonitorAlertRuleTemplate(ctx context.Context, template *model.MonitorAlertRuleTemplate) error
+	// DeleteMonitorAlertRuleTemplate 删除模板，由模板生成的告警规则保留并解除与模板的关联
+	DeleteMonitorAlertRuleTemplate(ctx context.Context, id int) error
+	CheckMonitorAlertRuleTemplateNameExists(ctx context.Context, template *model.MonitorAlertRuleTemplate) (bool, error)
+	// GetTemplateInstances 获取由模板生成的告警规则
+	GetTemplateInstances(ctx context.Context, templateID int) ([]*model.MonitorAlertRule, error)
+	// CountTemplateInstances 统计每个模板生成的告警规则数量
+	CountTemplateInstances(ctx context.Context) (map[int]int, error)
+	// SaveTemplateInstances 在一个事务中更新模板（template 不为 nil 时）并保存重新渲染的告警规则，ID 为0时新建，否则更新
+	SaveTemplateInstances(ctx context.Context, template *model.MonitorAlertRuleTemplate, rules []*model.MonitorAlertRule) error
+}
+
+type alertManagerRuleTemplateDAO struct {
+	db *gorm.DB
+	l  *zap.Logger
+}
+
+func NewAlertManagerRuleTemplateDAO(db *gorm.DB, l *zap.Logger) AlertManagerRuleTemplateDAO {
+	return &alertManagerRuleTemplateDAO{
+		db: db,
+		l:  l,
+	}
+}
+
+func (a *alertManagerRuleTemplateDAO) GetMonitorAlertRuleTemplateList(ctx context.Context) ([]*model.MonitorAlertRuleTemplate, error) {
+	var templates []*model.MonitorAlertRuleTemplate
+
+	if err := a.db.WithContext(ctx).Order("id").Find(&templates).Error; err != nil {
+		a.l.Error("获取所有 MonitorAlertRuleTemplate 失败", zap.Error(err))
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+func (a *alertManagerRuleTemplateDAO) SearchMonitorAlertRuleTemplateByName(ctx context.Context, name string) ([]*model.MonitorAlertRuleTemplate, error) {
+	var templates []*model.MonitorAlertRuleTemplate
+
+	if err := a.db.WithContext(ctx).
+		Where("LOWER(name) LIKE ?", "%"+strings.ToLower(name)+"%").
+		Order("id").
+		Find(&templates).Error; err != nil {
+		a.l.Error("通过名称搜索 MonitorAlertRuleTemplate 失败", zap.Error(err))
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+func (a *alertManagerRuleTemplateDAO) GetMonitorAlertRuleTemplateById(ctx context.Context, id int) (*model.MonitorAlertRuleTemplate, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("无效的 ID: %d", id)
+	}
+
+	var template model.MonitorAlertRuleTemplate
+	if err := a.db.WithContext(ctx).First(&template, id).Error; err != nil {
+		a.l.Error("获取 MonitorAlertRuleTemplate 失败", zap.Error(err), zap.Int("id", id))
+		return nil, err
+	}
+
+	return &template, nil
+}
+
+func (a *alertManagerRuleTemplateDAO) CreateMonitorAlertRuleTemplate(ctx context.Context, template *model.MonitorAlertRuleTemplate) error {
+	if err := a.db.WithContext(ctx).Create(template).Error; err != nil {
+		a.l.Error("创建 MonitorAlertRuleTemplate 失败", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (a *alertManagerRuleTemplateDAO) DeleteMonitorAlertRuleTemplate(ctx context.Context, id int) error {
+	return a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.MonitorAlertRule{}).
+			Where("template_id = ?", id).
+			Updates(map[string]interface{}{
+				"template_id":     0,
+				"template_params": nil,
+			}).Error; err != nil {
+			a.l.Error("解除告警规则与模板的关联失败", zap.Error(err), zap.Int("templateId", id))
+			return err
+		}
+
+		if err := tx.Delete(&model.MonitorAlertRuleTemplate{}, id).Error; err != nil {
+			a.l.Error("删除 MonitorAlertRuleTemplate 失败", zap.Error(err), zap.Int("id", id))
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (a *alertManagerRuleTemplateDAO) CheckMonitorAlertRuleTemplateNameExists(ctx context.Context, template *model.MonitorAlertRuleTemplate) (bool, error) {
+	var count int64
+
+	if err := a.db.WithContext(ctx).
+		Model(&model.MonitorAlertRuleTemplate{}).
+		Where("name = ?", template.Name).
+		Where("id != ?", template.ID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (a *alertManagerRuleTemplateDAO) GetTemplateInstances(ctx context.Context, templateID int) ([]*model.MonitorAlertRule, error) {
+	var rules []*model.MonitorAlertRule
+
+	if err := a.db.WithContext(ctx).
+		Where("template_id = ?", templateID).
+		Order("tree_node_id").
+		Find(&rules).Error; err != nil {
+		a.l.Error("获取模板生成的告警规则失败", zap.Error(err), zap.Int("templateId", templateID))
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func (a *alertManagerRuleTemplateDAO) CountTemplateInstances(ctx context.Context) (map[int]int, error) {
+	var counts []struct {
+		TemplateID int
+		Count      int
+	}
+
+	if err := a.db.WithContext(ctx).
+		Model(&model.MonitorAlertRule{}).
+		Select("template_id, COUNT(*) AS count").
+		Where("template_id > 0").
+		Group("template_id").
+		Scan(&counts).Error; err != nil {
+		a.l.Error("统计模板生成的告警规则数量失败", zap.Error(err))
+		return nil, err
+	}
+
+	result := make(map[int]int, len(counts))
+	for _, c := range counts {
+		result[c.TemplateID] = c.Count
+	}
+
+	return result, nil
+}
+
+func (a *alertManagerRuleTemplateDAO) SaveTemplateInstances(ctx context.Context, template *model.MonitorAlertRuleTemplate, rules []*model.MonitorAlertRule) error {
+	return a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 模板的标签、注解、参数等允许清空，因此使用 Select 更新
+		if template != nil {
+			if err := tx.Model(&model.MonitorAlertRuleTemplate{}).
+				Where("id = ?", template.ID).
+				Select("name", "pool_id", "send_group_id", "expr", "for_time", "severity", "labels", "annotations", "parameters", "description").
+				Updates(template).Error; err != nil {
+				a.l.Error("更新 MonitorAlertRuleTemplate 失败", zap.Error(err), zap.Int("id", template.ID))
+				return err
+			}
+		}
+
+		for _, rule := range rules {
+			if rule.ID == 0 {
+				if err := tx.Create(rule).Error; err != nil {
+					a.l.Error("创建模板告警规则失败", zap.Error(err), zap.String("name", rule.Name))
+					return err
+				}
+				continue
+			}
+
+			if err := tx.Model(&model.MonitorAlertRule{}).
+				Where("id = ?", rule.ID).
+				Select("pool_id", "send_group_id", "expr", "for_time", "severity", "labels", "annotations", "template_params").
+				Updates(rule).Error; err != nil {
+				a.l.Error("更新模板告警规则失败", zap.Error(err), zap.Int("id", rule.ID))
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,148 @@
+package alert
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type AlertManagerRuleTestDAO interface {
+	GetRuleTestList(ctx context.Context, ruleType string, ruleID int) ([]*model.MonitorRuleTest, error)
+	GetRuleTestById(ctx context.Context, id int) (*model.MonitorRuleTest, error)
+	CreateRuleTest(ctx context.Context, ruleTest *model.MonitorRuleTest) error
+	UpdateRuleTest(ctx context.Context, ruleTest *model.MonitorRuleTest) error
+	UpdateRuleTestResult(ctx context.Context, id int, passed bool, runAt int64) error
+	DeleteRuleTest(ctx context.Context, id int) error
+	DeleteRuleTestsByRule(ctx context.Context, ruleType string, ruleID int) error
+}
+
+type alertManagerRuleTestDAO struct {
+	db *gorm.DB
+	l  *zap.Logger
+}
+
+func NewAlertManagerRuleTestDAO(db *gorm.DB, l *zap.Logger) AlertManagerRuleTestDAO {
+	return &alertManagerRuleTestDAO{
+		db: db,
+		l:  l,
+	}
+}
+
+func (a *alertManagerRuleTestDAO) GetRuleTestList(ctx context.Context, ruleType string, ruleID int) ([]*model.MonitorRuleTest, error) {
+	var ruleTests []*model.MonitorRuleTest
+
+	if err := a.db.WithContext(ctx).
+		Where("rule_type = ?", ruleType).
+		Where("rule_id = ?", ruleID).
+		Order("id").
+		Find(&ruleTests).Error; err != nil {
+		a.l.Error("获取规则单元测试列表失败", zap.Error(err), zap.String("ruleType", ruleType), zap.Int("ruleId", ruleID))
+		return nil, err
+	}
+
+	return ruleTests, nil
+}
+
+func (a *alertManagerRuleTestDAO) GetRuleTestById(ctx context.Context, id int) (*model.MonitorRuleTest, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("无效的 ID: %d", id)
+	}
+
+	var ruleTest model.MonitorRuleTest
+	if err := a.db.WithContext(ctx).First(&ruleTest, id).Error; err != nil {
+		a.l.Error("获取规则单元测试失败", zap.Error(err), zap.Int("id", id))
+		return nil, err
+	}
+
+	return &ruleTest, nil
+}
+
+func (a *alertManagerRuleTestDAO) CreateRuleTest(ctx context.Context, ruleTest *model.MonitorRuleTest) error {
+	if err := a.db.WithContext(ctx).Create(ruleTest).Error; err != nil {
+		a.l.Error("创建规则单元测试失败", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (a *alertManagerRuleTestDAO) UpdateRuleTest(ctx context.Context, ruleTest *model.MonitorRuleTest) error {
+	if ruleTest.ID == 0 {
+		return fmt.Errorf("规则单元测试的 ID 必须设置且非零")
+	}
+
+	// 期望结果允许清空，因此使用 Select 更新所有字段
+	if err := a.db.WithContext(ctx).
+		Model(&model.MonitorRuleTest{}).
+		Where("id = ?", ruleTest.ID).
+		Select("name", "interval", "input_series", "eval_time", "expr", "exp_alerts", "exp_samples").
+		Updates(ruleTest).Error; err != nil {
+		a.l.Error("更新规则单元测试失败", zap.Error(err), zap.Int("id", ruleTest.ID))
+		return err
+	}
+
+	return nil
+}
+
+func (a *alertManagerRuleTestDAO) UpdateRuleTestResult(ctx context.Context, id int, passed bool, runAt int64) error {
+	if err := a.db.WithContext(ctx).
+		Model(&model.MonitorRuleTest{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"last_passed": passed,
+			"last_run_at": runAt,
+		}).Error; err != nil {
+		a.l.Error("更新规则单元测试结果失败", zap.Error(err), zap.Int("id", id))
+		return err
+	}
+
+	return nil
+}
+
+func (a *alertManagerRuleTestDAO) DeleteRuleTest(ctx context.Context, id int) error {
+	if err := a.db.WithContext(ctx).Delete(&model.MonitorRuleTest{}, id).Error; err != nil {
+		a.l.Error("删除规则单元测试失败", zap.Error(err), zap.Int("id", id))
+		return err
+	}
+
+	return nil
+}
+
+func (a *alertManagerRuleTestDAO) DeleteRuleTestsByRule(ctx context.Context, ruleType string, ruleID int) error {
+	if err := a.db.WithContext(ctx).
+		Where("rule_type = ?", ruleType).
+		Where("rule_id = ?", ruleID).
+		Delete(&model.MonitorRuleTest{}).Error; err != nil {
+		a.l.Error("删除规则的单元测试失败", zap.Error(err), zap.String("ruleType", ruleType), zap.Int("ruleId", ruleID))
+		return err
+	}
+
+	return nil
+}
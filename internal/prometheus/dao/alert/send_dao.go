@@ -168,6 +168,17 @@ func (a *alertManagerSendDAO) UpdateMonitorSendGroup(ctx context.Context, monito
 		return err
 	}
 
+	// 时间段、通知设置和路由设置允许清空，零值需要单独更新
+	if err := a.db.WithContext(ctx).
+		Model(&model.MonitorSendGroup{}).
+		Where("id = ?", monitorSendGroup.ID).
+		Select("mute_time_interval_ids", "active_time_interval_ids", "email_to", "webhooks",
+			"group_by", "group_wait", "group_interval", "matchers", "routes").
+		Updates(monitorSendGroup).Error; err != nil {
+		a.l.Error("更新 MonitorSendGroup 时间段、通知和路由设置失败", zap.Error(err), zap.Int("id", monitorSendGroup.ID))
+		return err
+	}
+
 	return nil
 }
 
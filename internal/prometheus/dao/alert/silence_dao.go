@@ -0,0 +1,150 @@
+package alert
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type AlertManagerSilenceDAO interface {
+	GetMonitorSilenceList(ctx context.Context, poolId int) ([]*model.MonitorSilence, error)
+	GetMonitorSilenceById(ctx context.Context, id int) (*model.MonitorSilence, error)
+	CreateMonitorSilence(ctx context.Context, silence *model.MonitorSilence) error
+	UpdateMonitorSilence(ctx context.Context, silence *model.MonitorSilence) error
+	// LinkAlertEventSilence 将告警事件关联到静默记录并标记为已屏蔽
+	LinkAlertEventSilence(ctx context.Context, eventId int, silenceId int) error
+	// UnlinkAlertEventSilence 解除告警事件与静默记录的关联，仍为已屏蔽状态的事件恢复为告警中
+	UnlinkAlertEventSilence(ctx context.Context, silenceId int) error
+}
+
+type alertManagerSilenceDAO struct {
+	db *gorm.DB
+	l  *zap.Logger
+}
+
+func NewAlertManagerSilenceDAO(db *gorm.DB, l *zap.Logger) AlertManagerSilenceDAO {
+	return &alertManagerSilenceDAO{
+		db: db,
+		l:  l,
+	}
+}
+
+func (a *alertManagerSilenceDAO) GetMonitorSilenceList(ctx context.Context, poolId int) ([]*model.MonitorSilence, error) {
+	var silences []*model.MonitorSilence
+
+	query := a.db.WithContext(ctx)
+	if poolId > 0 {
+		query = query.Where("pool_id = ?", poolId)
+	}
+
+	if err := query.Order("id DESC").Find(&silences).Error; err != nil {
+		a.l.Error("获取 MonitorSilence 列表失败", zap.Error(err), zap.Int("poolId", poolId))
+		return nil, err
+	}
+
+	return silences, nil
+}
+
+func (a *alertManagerSilenceDAO) GetMonitorSilenceById(ctx context.Context, id int) (*model.MonitorSilence, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("无效的 ID: %d", id)
+	}
+
+	var silence model.MonitorSilence
+	if err := a.db.WithContext(ctx).First(&silence, id).Error; err != nil {
+		a.l.Error("获取 MonitorSilence 失败", zap.Error(err), zap.Int("id", id))
+		return nil, err
+	}
+
+	return &silence, nil
+}
+
+func (a *alertManagerSilenceDAO) CreateMonitorSilence(ctx context.Context, silence *model.MonitorSilence) error {
+	if err := a.db.WithContext(ctx).Create(silence).Error; err != nil {
+		a.l.Error("创建 MonitorSilence 失败", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (a *alertManagerSilenceDAO) UpdateMonitorSilence(ctx context.Context, silence *model.MonitorSilence) error {
+	if silence.ID == 0 {
+		return fmt.Errorf("MonitorSilence 的 ID 必须设置且非零")
+	}
+
+	if err := a.db.WithContext(ctx).
+		Model(&model.MonitorSilence{}).
+		Where("id = ?", silence.ID).
+		Select("matchers", "starts_at", "ends_at", "comment", "created_by", "instance_silence_ids").
+		Updates(silence).Error; err != nil {
+		a.l.Error("更新 MonitorSilence 失败", zap.Error(err), zap.Int("id", silence.ID))
+		return err
+	}
+
+	return nil
+}
+
+func (a *alertManagerSilenceDAO) LinkAlertEventSilence(ctx context.Context, eventId int, silenceId int) error {
+	if err := a.db.WithContext(ctx).
+		Model(&model.MonitorAlertEvent{}).
+		Where("id = ?", eventId).
+		Updates(map[string]interface{}{
+			"silence_id": fmt.Sprintf("%d", silenceId),
+			"status":     model.AlertEventStatusSilenced,
+		}).Error; err != nil {
+		a.l.Error("关联告警事件与静默失败", zap.Error(err), zap.Int("eventId", eventId), zap.Int("silenceId", silenceId))
+		return err
+	}
+
+	return nil
+}
+
+func (a *alertManagerSilenceDAO) UnlinkAlertEventSilence(ctx context.Context, silenceId int) error {
+	id := fmt.Sprintf("%d", silenceId)
+
+	return a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.MonitorAlertEvent{}).
+			Where("silence_id = ? AND status = ?", id, model.AlertEventStatusSilenced).
+			Update("status", model.AlertEventStatusFiring).Error; err != nil {
+			a.l.Error("恢复告警事件状态失败", zap.Error(err), zap.Int("silenceId", silenceId))
+			return err
+		}
+
+		if err := tx.Model(&model.MonitorAlertEvent{}).
+			Where("silence_id = ?", id).
+			Update("silence_id", "").Error; err != nil {
+			a.l.Error("解除告警事件与静默的关联失败", zap.Error(err), zap.Int("silenceId", silenceId))
+			return err
+		}
+
+		return nil
+	})
+}
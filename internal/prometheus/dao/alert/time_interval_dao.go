@@ -0,0 +1,160 @@
+package alert
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"strings"
+)
+
+type AlertManagerTimeIntervalDAO interface {
+	GetMonitorTimeIntervalsByPoolId(ctx context.Context, poolId int) ([]*model.MonitorTimeInterval, error)
+	SearchMonitorTimeIntervalByName(ctx context.Context, name string) ([]*model.MonitorTimeInterval, error)
+	GetMonitorTimeIntervalList(ctx context.Context) ([]*model.MonitorTimeInterval, error)
+	GetMonitorTimeIntervalById(ctx context.Context, id int) (*model.MonitorTimeInterval, error)
+	CreateMonitorTimeInterval(ctx context.Context, timeInterval *model.MonitorTimeInterval) error
+	UpdateMonitorTimeInterval(ctx context.Context, timeInterval *model.MonitorTimeInterval) error
+	DeleteMonitorTimeInterval(ctx context.Context, id int) error
+	CheckMonitorTimeIntervalNameExists(ctx context.Context, timeInterval *model.MonitorTimeInterval) (bool, error)
+}
+
+type alertManagerTimeIntervalDAO struct {
+	db *gorm.DB
+	l  *zap.Logger
+}
+
+func NewAlertManagerTimeIntervalDAO(db *gorm.DB, l *zap.Logger) AlertManagerTimeIntervalDAO {
+	return &alertManagerTimeIntervalDAO{
+		db: db,
+		l:  l,
+	}
+}
+
+func (a *alertManagerTimeIntervalDAO) GetMonitorTimeIntervalsByPoolId(ctx context.Context, poolId int) ([]*model.MonitorTimeInterval, error) {
+	var timeIntervals []*model.MonitorTimeInterval
+
+	if err := a.db.WithContext(ctx).
+		Where("pool_id = ?", poolId).
+		Order("id").
+		Find(&timeIntervals).Error; err != nil {
+		a.l.Error("获取 MonitorTimeInterval 失败", zap.Error(err), zap.Int("poolId", poolId))
+		return nil, err
+	}
+
+	return timeIntervals, nil
+}
+
+func (a *alertManagerTimeIntervalDAO) SearchMonitorTimeIntervalByName(ctx context.Context, name string) ([]*model.MonitorTimeInterval, error) {
+	var timeIntervals []*model.MonitorTimeInterval
+
+	if err := a.db.WithContext(ctx).
+		Where("LOWER(name) LIKE ?", "%"+strings.ToLower(name)+"%").
+		Find(&timeIntervals).Error; err != nil {
+		a.l.Error("通过名称搜索 MonitorTimeInterval 失败", zap.Error(err))
+		return nil, err
+	}
+
+	return timeIntervals, nil
+}
+
+func (a *alertManagerTimeIntervalDAO) GetMonitorTimeIntervalList(ctx context.Context) ([]*model.MonitorTimeInterval, error) {
+	var timeIntervals []*model.MonitorTimeInterval
+
+	if err := a.db.WithContext(ctx).Find(&timeIntervals).Error; err != nil {
+		a.l.Error("获取所有 MonitorTimeInterval 失败", zap.Error(err))
+		return nil, err
+	}
+
+	return timeIntervals, nil
+}
+
+func (a *alertManagerTimeIntervalDAO) GetMonitorTimeIntervalById(ctx context.Context, id int) (*model.MonitorTimeInterval, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("无效的 ID: %d", id)
+	}
+
+	var timeInterval model.MonitorTimeInterval
+	if err := a.db.WithContext(ctx).First(&timeInterval, id).Error; err != nil {
+		a.l.Error("获取 MonitorTimeInterval 失败", zap.Error(err), zap.Int("id", id))
+		return nil, err
+	}
+
+	return &timeInterval, nil
+}
+
+func (a *alertManagerTimeIntervalDAO) CreateMonitorTimeInterval(ctx context.Context, timeInterval *model.MonitorTimeInterval) error {
+	if err := a.db.WithContext(ctx).Create(timeInterval).Error; err != nil {
+		a.l.Error("创建 MonitorTimeInterval 失败", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (a *alertManagerTimeIntervalDAO) UpdateMonitorTimeInterval(ctx context.Context, timeInterval *model.MonitorTimeInterval) error {
+	if timeInterval.ID == 0 {
+		return fmt.Errorf("MonitorTimeInterval 的 ID 必须设置且非零")
+	}
+
+	// 时间段只能被同一 AlertManager 实例的发送组引用，因此实例创建后不允许修改；描述允许清空，使用 Select 更新
+	if err := a.db.WithContext(ctx).
+		Model(&model.MonitorTimeInterval{}).
+		Where("id = ?", timeInterval.ID).
+		Select("name", "time_intervals", "description").
+		Updates(timeInterval).Error; err != nil {
+		a.l.Error("更新 MonitorTimeInterval 失败", zap.Error(err), zap.Int("id", timeInterval.ID))
+		return err
+	}
+
+	return nil
+}
+
+func (a *alertManagerTimeIntervalDAO) DeleteMonitorTimeInterval(ctx context.Context, id int) error {
+	if err := a.db.WithContext(ctx).Delete(&model.MonitorTimeInterval{}, id).Error; err != nil {
+		a.l.Error("删除 MonitorTimeInterval 失败", zap.Error(err), zap.Int("id", id))
+		return err
+	}
+
+	return nil
+}
+
+func (a *alertManagerTimeIntervalDAO) CheckMonitorTimeIntervalNameExists(ctx context.Context, timeInterval *model.MonitorTimeInterval) (bool, error) {
+	var count int64
+
+	if err := a.db.WithContext(ctx).
+		Model(&model.MonitorTimeInterval{}).
+		Where("name = ?", timeInterval.Name).
+		Where("id != ?", timeInterval.ID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
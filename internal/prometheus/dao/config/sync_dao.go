@@ -0,0 +1,116 @@
+package config
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type ConfigSyncDAO interface {
+	GetAllConfigSyncStates(ctx context.Context) ([]*model.MonitorConfigSyncState, error)
+	GetConfigSyncState(ctx context.Context, instanceType, ip string) (*model.MonitorConfigSyncState, error)
+	SaveConfigSyncState(ctx context.Context, state *model.MonitorConfigSyncState) error
+}
+
+type configSyncDAO struct {
+	db *gorm.DB
+	l  *zap.Logger
+}
+
+func NewConfigSyncDAO(db *gorm.DB, l *zap.Logger) ConfigSyncDAO {
+	return &configSyncDAO{
+		db: db,
+		l:  l,
+	}
+}
+
+func (c *configSyncDAO) GetAllConfigSyncStates(ctx context.Context) ([]*model.MonitorConfigSyncState, error) {
+	var states []*model.MonitorConfigSyncState
+
+	if err := c.db.WithContext(ctx).Find(&states).Error; err != nil {
+		c.l.Error("获取所有实例同步状态失败", zap.Error(err))
+		return nil, err
+	}
+
+	return states, nil
+}
+
+// GetConfigSyncState 获取实例的同步状态，不存在时返回 nil
+func (c *configSyncDAO) GetConfigSyncState(ctx context.Context, instanceType, ip string) (*model.MonitorConfigSyncState, error) {
+	var state model.MonitorConfigSyncState
+
+	err := c.db.WithContext(ctx).
+		Where("instance_type = ? AND instance_ip = ?", instanceType, ip).
+		First(&state).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		c.l.Error("获取实例同步状态失败", zap.Error(err), zap.String("instanceType", instanceType), zap.String("ip", ip))
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+// SaveConfigSyncState 保存实例的同步状态，ID 为0时新建
+func (c *configSyncDAO) SaveConfigSyncState(ctx context.Context, state *model.MonitorConfigSyncState) error {
+	if state == nil {
+		c.l.Error("SaveConfigSyncState 失败: state 为 nil")
+		return fmt.Errorf("state 不能为空")
+	}
+
+	if state.ID == 0 {
+		if err := c.db.WithContext(ctx).Create(state).Error; err != nil {
+			c.l.Error("创建实例同步状态失败", zap.Error(err), zap.String("instanceType", state.InstanceType), zap.String("ip", state.InstanceIP))
+			return err
+		}
+		return nil
+	}
+
+	// 使用 map 更新，保证布尔值和空字符串也能写入
+	if err := c.db.WithContext(ctx).
+		Model(&model.MonitorConfigSyncState{}).
+		Where("id = ?", state.ID).
+		Updates(map[string]interface{}{
+			"pool_id":             state.PoolID,
+			"desired_hash":        state.DesiredHash,
+			"pushed_hash":         state.PushedHash,
+			"last_reload_at":      state.LastReloadAt,
+			"last_reload_success": state.LastReloadSuccess,
+			"last_error":          state.LastError,
+			"reload_attempts":     state.ReloadAttempts,
+		}).Error; err != nil {
+		c.l.Error("更新实例同步状态失败", zap.Error(err), zap.Int("id", state.ID))
+		return err
+	}
+
+	return nil
+}
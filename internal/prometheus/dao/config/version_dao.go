@@ -0,0 +1,273 @@
+package config
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type ConfigVersionDAO interface {
+	GetLatestConfigVersion(ctx context.Context, configType, ip string) (*model.MonitorConfigVersion, error)
+	GetConfigVersionById(ctx context.Context, id int) (*model.MonitorConfigVersion, error)
+	GetConfigVersionList(ctx context.Context, configType, ip string) ([]*model.MonitorConfigVersion, error)
+	CreateConfigVersion(ctx context.Context, version *model.MonitorConfigVersion) error
+	GetAllConfigPins(ctx context.Context) ([]*model.MonitorConfigPin, error)
+	GetConfigPin(ctx context.Context, configType, ip string) (*model.MonitorConfigPin, error)
+	SaveConfigPin(ctx context.Context, pin *model.MonitorConfigPin) error
+	DeleteConfigPin(ctx context.Context, configType, ip string) error
+	GetConfigVersionByHash(ctx context.Context, configType, ip, hash string) (*model.MonitorConfigVersion, error)
+	GetConfigAppliedList(ctx context.Context, ip string) ([]*model.MonitorConfigApplied, error)
+	SaveConfigApplied(ctx context.Context, applied *model.MonitorConfigApplied) error
+}
+
+type configVersionDAO struct {
+	db *gorm.DB
+	l  *zap.Logger
+}
+
+func NewConfigVersionDAO(db *gorm.DB, l *zap.Logger) ConfigVersionDAO {
+	return &configVersionDAO{
+		db: db,
+		l:  l,
+	}
+}
+
+// GetLatestConfigVersion 获取实例某类配置的最新版本，不存在时返回 nil
+func (c *configVersionDAO) GetLatestConfigVersion(ctx context.Context, configType, ip string) (*model.MonitorConfigVersion, error) {
+	var version model.MonitorConfigVersion
+
+	err := c.db.WithContext(ctx).
+		Where("config_type = ? AND instance_ip = ?", configType, ip).
+		Order("version DESC").
+		First(&version).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		c.l.Error("获取最新配置版本失败", zap.Error(err), zap.String("configType", configType), zap.String("ip", ip))
+		return nil, err
+	}
+
+	return &version, nil
+}
+
+func (c *configVersionDAO) GetConfigVersionById(ctx context.Context, id int) (*model.MonitorConfigVersion, error) {
+	if id <= 0 {
+		c.l.Error("GetConfigVersionById 失败: 无效的 ID", zap.Int("id", id))
+		return nil, fmt.Errorf("无效的 ID: %d", id)
+	}
+
+	var version model.MonitorConfigVersion
+
+	if err := c.db.WithContext(ctx).Where("id = ?", id).First(&version).Error; err != nil {
+		c.l.Error("获取配置版本失败", zap.Error(err), zap.Int("id", id))
+		return nil, err
+	}
+
+	return &version, nil
+}
+
+// GetConfigVersionList 获取实例某类配置的所有版本，按版本号倒序，不包含配置内容
+func (c *configVersionDAO) GetConfigVersionList(ctx context.Context, configType, ip string) ([]*model.MonitorConfigVersion, error) {
+	var versions []*model.MonitorConfigVersion
+
+	if err := c.db.WithContext(ctx).
+		Omit("content").
+		Where("config_type = ? AND instance_ip = ?", configType, ip).
+		Order("version DESC").
+		Find(&versions).Error; err != nil {
+		c.l.Error("获取配置版本列表失败", zap.Error(err), zap.String("configType", configType), zap.String("ip", ip))
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+func (c *configVersionDAO) CreateConfigVersion(ctx context.Context, version *model.MonitorConfigVersion) error {
+	if version == nil {
+		c.l.Error("CreateConfigVersion 失败: version 为 nil")
+		return fmt.Errorf("version 不能为空")
+	}
+
+	if err := c.db.WithContext(ctx).Create(version).Error; err != nil {
+		c.l.Error("创建配置版本失败", zap.Error(err), zap.String("configType", version.ConfigType), zap.String("ip", version.InstanceIP))
+		return err
+	}
+
+	return nil
+}
+
+func (c *configVersionDAO) GetAllConfigPins(ctx context.Context) ([]*model.MonitorConfigPin, error) {
+	var pins []*model.MonitorConfigPin
+
+	if err := c.db.WithContext(ctx).Find(&pins).Error; err != nil {
+		c.l.Error("获取所有固定配置版本失败", zap.Error(err))
+		return nil, err
+	}
+
+	return pins, nil
+}
+
+// GetConfigPin 获取实例某类配置的固定版本，不存在时返回 nil
+func (c *configVersionDAO) GetConfigPin(ctx context.Context, configType, ip string) (*model.MonitorConfigPin, error) {
+	var pin model.MonitorConfigPin
+
+	err := c.db.WithContext(ctx).
+		Where("config_type = ? AND instance_ip = ?", configType, ip).
+		First(&pin).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		c.l.Error("获取固定配置版本失败", zap.Error(err), zap.String("configType", configType), zap.String("ip", ip))
+		return nil, err
+	}
+
+	return &pin, nil
+}
+
+// SaveConfigPin 固定实例某类配置的版本，已固定时更新为新的版本
+func (c *configVersionDAO) SaveConfigPin(ctx context.Context, pin *model.MonitorConfigPin) error {
+	existing, err := c.GetConfigPin(ctx, pin.ConfigType, pin.InstanceIP)
+	if err != nil {
+		return err
+	}
+
+	if existing == nil {
+		if err := c.db.WithContext(ctx).Create(pin).Error; err != nil {
+			c.l.Error("创建固定配置版本失败", zap.Error(err), zap.String("configType", pin.ConfigType), zap.String("ip", pin.InstanceIP))
+			return err
+		}
+		return nil
+	}
+
+	pin.ID = existing.ID
+	if err := c.db.WithContext(ctx).
+		Model(&model.MonitorConfigPin{}).
+		Where("id = ?", existing.ID).
+		Updates(map[string]interface{}{
+			"version_id": pin.VersionID,
+			"user_id":    pin.UserID,
+			"reason":     pin.Reason,
+		}).Error; err != nil {
+		c.l.Error("更新固定配置版本失败", zap.Error(err), zap.Int("id", existing.ID))
+		return err
+	}
+
+	return nil
+}
+
+func (c *configVersionDAO) DeleteConfigPin(ctx context.Context, configType, ip string) error {
+	if err := c.db.WithContext(ctx).
+		Where("config_type = ? AND instance_ip = ?", configType, ip).
+		Delete(&model.MonitorConfigPin{}).Error; err != nil {
+		c.l.Error("删除固定配置版本失败", zap.Error(err), zap.String("configType", configType), zap.String("ip", ip))
+		return err
+	}
+
+	return nil
+}
+
+// GetConfigVersionByHash 根据配置哈希查找实例某类配置的版本，不存在时返回 nil
+func (c *configVersionDAO) GetConfigVersionByHash(ctx context.Context, configType, ip, hash string) (*model.MonitorConfigVersion, error) {
+	var version model.MonitorConfigVersion
+
+	err := c.db.WithContext(ctx).
+		Omit("content").
+		Where("config_type = ? AND instance_ip = ? AND hash = ?", configType, ip, hash).
+		Order("version DESC").
+		First(&version).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		c.l.Error("根据哈希获取配置版本失败", zap.Error(err), zap.String("configType", configType), zap.String("ip", ip))
+		return nil, err
+	}
+
+	return &version, nil
+}
+
+// GetConfigAppliedList 获取实例上报的已应用配置，ip 为空时返回所有实例
+func (c *configVersionDAO) GetConfigAppliedList(ctx context.Context, ip string) ([]*model.MonitorConfigApplied, error) {
+	var applied []*model.MonitorConfigApplied
+
+	db := c.db.WithContext(ctx)
+	if ip != "" {
+		db = db.Where("instance_ip = ?", ip)
+	}
+
+	if err := db.Order("instance_ip, config_type").Find(&applied).Error; err != nil {
+		c.l.Error("获取已应用配置列表失败", zap.Error(err), zap.String("ip", ip))
+		return nil, err
+	}
+
+	return applied, nil
+}
+
+// SaveConfigApplied 保存实例上报的已应用配置，每个实例每类配置只保留最新一条
+func (c *configVersionDAO) SaveConfigApplied(ctx context.Context, applied *model.MonitorConfigApplied) error {
+	var existing model.MonitorConfigApplied
+
+	err := c.db.WithContext(ctx).
+		Where("config_type = ? AND instance_ip = ?", applied.ConfigType, applied.InstanceIP).
+		First(&existing).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.l.Error("获取已应用配置失败", zap.Error(err), zap.String("configType", applied.ConfigType), zap.String("ip", applied.InstanceIP))
+		return err
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if err := c.db.WithContext(ctx).Create(applied).Error; err != nil {
+			c.l.Error("创建已应用配置失败", zap.Error(err), zap.String("configType", applied.ConfigType), zap.String("ip", applied.InstanceIP))
+			return err
+		}
+		return nil
+	}
+
+	applied.ID = existing.ID
+	if err := c.db.WithContext(ctx).
+		Model(&model.MonitorConfigApplied{}).
+		Where("id = ?", existing.ID).
+		Updates(map[string]interface{}{
+			"hash":       applied.Hash,
+			"version_id": applied.VersionID,
+			"version":    applied.Version,
+			"applied_at": applied.AppliedAt,
+			"success":    applied.Success,
+			"error":      applied.Error,
+		}).Error; err != nil {
+		c.l.Error("更新已应用配置失败", zap.Error(err), zap.Int("id", existing.ID))
+		return err
+	}
+
+	return nil
+}
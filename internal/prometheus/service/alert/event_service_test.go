@@ -0,0 +1,93 @@
+package alert
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"go.uber.org/zap"
+)
+
+// fakeConcurrentSilenceService 记录同时创建静默的最大并发数
+type fakeConcurrentSilenceService struct {
+	AlertManagerSilenceService
+	mu      sync.Mutex
+	running int
+	max     int
+}
+
+func (f *fakeConcurrentSilenceService) CreateMonitorSilence(_ context.Context, req *model.SilenceCreateReq, _ int) (*model.MonitorSilence, error) {
+	f.mu.Lock()
+	f.running++
+	if f.running > f.max {
+		f.max = f.running
+	}
+	f.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	f.mu.Lock()
+	f.running--
+	f.mu.Unlock()
+
+	if req.EventID%7 == 0 {
+		return nil, errors.New("boom")
+	}
+	return &model.MonitorSilence{}, nil
+}
+
+func TestBatchEventAlertSilenceBoundedConcurrency(t *testing.T) {
+	silenceService := &fakeConcurrentSilenceService{}
+	svc := &alertManagerEventService{silenceService: silenceService, l: zap.NewNop()}
+
+	ids := make([]int, 30)
+	for i := range ids {
+		ids[i] = i + 1
+	}
+
+	err := svc.BatchEventAlertSilence(context.Background(), &model.BatchEventAlertSilenceRequest{
+		IDs:                      ids,
+		AlertEventSilenceRequest: model.AlertEventSilenceRequest{Time: "1h"},
+	}, 1)
+	if err == nil {
+		t.Fatal("部分事件失败时应返回错误")
+	}
+
+	msg := err.Error()
+	first, second, third := strings.Index(msg, "事件 ID 7:"), strings.Index(msg, "事件 ID 14:"), strings.Index(msg, "事件 ID 21:")
+	if first < 0 || !(first < second && second < third) || strings.Contains(msg, "事件 ID 1:") {
+		t.Fatalf("错误应按请求顺序只包含失败的事件: %s", msg)
+	}
+	if silenceService.max < 2 || silenceService.max > 10 {
+		t.Fatalf("最大并发数应在 2 到 10 之间, 实际 %d", silenceService.max)
+	}
+}
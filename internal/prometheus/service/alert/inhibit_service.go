@@ -0,0 +1,185 @@
+package alert
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/cache"
+	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/alert"
+	userDao "github.com/GoSimplicity/AI-CloudOps/internal/user/dao"
+	pkg "github.com/GoSimplicity/AI-CloudOps/pkg/utils/prometheus"
+	"go.uber.org/zap"
+)
+
+type AlertManagerInhibitService interface {
+	GetMonitorInhibitRuleList(ctx context.Context, searchName *string) ([]*model.MonitorInhibitRule, error)
+	CreateMonitorInhibitRule(ctx context.Context, inhibitRule *model.MonitorInhibitRule) error
+	UpdateMonitorInhibitRule(ctx context.Context, inhibitRule *model.MonitorInhibitRule) error
+	DeleteMonitorInhibitRule(ctx context.Context, id int) error
+}
+
+type alertManagerInhibitService struct {
+	dao     alert.AlertManagerInhibitDAO
+	poolDao alert.AlertManagerPoolDAO
+	cache   cache.MonitorCache
+	userDao userDao.UserDAO
+	l       *zap.Logger
+}
+
+func NewAlertManagerInhibitService(dao alert.AlertManagerInhibitDAO, poolDao alert.AlertManagerPoolDAO, cache cache.MonitorCache, l *zap.Logger, userDao userDao.UserDAO) AlertManagerInhibitService {
+	return &alertManagerInhibitService{
+		dao:     dao,
+		poolDao: poolDao,
+		cache:   cache,
+		userDao: userDao,
+		l:       l,
+	}
+}
+
+func (a *alertManagerInhibitService) GetMonitorInhibitRuleList(ctx context.Context, searchName *string) ([]*model.MonitorInhibitRule, error) {
+	inhibitRules, err := pkg.HandleList(ctx, searchName,
+		a.dao.SearchMonitorInhibitRuleByName,
+		a.dao.GetMonitorInhibitRuleList)
+	if err != nil {
+		return nil, err
+	}
+
+	poolNames := make(map[int]string)
+	userNames := make(map[int]string)
+	for _, inhibitRule := range inhibitRules {
+		if _, ok := poolNames[inhibitRule.PoolID]; !ok {
+			if pool, err := a.poolDao.GetAlertPoolByID(ctx, inhibitRule.PoolID); err == nil {
+				poolNames[inhibitRule.PoolID] = pool.Name
+			}
+		}
+		if _, ok := userNames[inhibitRule.UserID]; !ok {
+			if user, err := a.userDao.GetUserByID(ctx, inhibitRule.UserID); err == nil {
+				userNames[inhibitRule.UserID] = user.Username
+			}
+		}
+
+		inhibitRule.Key = fmt.Sprintf("%d", inhibitRule.ID)
+		inhibitRule.PoolName = poolNames[inhibitRule.PoolID]
+		inhibitRule.CreateUserName = userNames[inhibitRule.UserID]
+	}
+
+	return inhibitRules, nil
+}
+
+func (a *alertManagerInhibitService) CreateMonitorInhibitRule(ctx context.Context, inhibitRule *model.MonitorInhibitRule) error {
+	if err := a.checkMonitorInhibitRule(ctx, inhibitRule); err != nil {
+		return err
+	}
+
+	if inhibitRule.Enable == 0 {
+		inhibitRule.Enable = 1
+	}
+
+	if err := a.dao.CreateMonitorInhibitRule(ctx, inhibitRule); err != nil {
+		a.l.Error("创建抑制规则失败", zap.Error(err))
+		return err
+	}
+
+	// 更新缓存
+	if err := a.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, fmt.Sprintf("创建抑制规则: %s", inhibitRule.Name))); err != nil {
+		a.l.Error("更新缓存失败", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (a *alertManagerInhibitService) UpdateMonitorInhibitRule(ctx context.Context, inhibitRule *model.MonitorInhibitRule) error {
+	old, err := a.dao.GetMonitorInhibitRuleById(ctx, inhibitRule.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := a.checkMonitorInhibitRule(ctx, inhibitRule); err != nil {
+		return err
+	}
+
+	if inhibitRule.Enable == 0 {
+		inhibitRule.Enable = old.Enable
+	}
+
+	if err := a.dao.UpdateMonitorInhibitRule(ctx, inhibitRule); err != nil {
+		a.l.Error("更新抑制规则失败", zap.Error(err))
+		return err
+	}
+
+	// 更新缓存
+	if err := a.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, fmt.Sprintf("更新抑制规则: %s", inhibitRule.Name))); err != nil {
+		a.l.Error("更新缓存失败", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (a *alertManagerInhibitService) DeleteMonitorInhibitRule(ctx context.Context, id int) error {
+	inhibitRule, err := a.dao.GetMonitorInhibitRuleById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := a.dao.DeleteMonitorInhibitRule(ctx, id); err != nil {
+		a.l.Error("删除抑制规则失败", zap.Error(err))
+		return err
+	}
+
+	// 更新缓存
+	if err := a.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, fmt.Sprintf("删除抑制规则: %s", inhibitRule.Name))); err != nil {
+		a.l.Error("更新缓存失败", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// checkMonitorInhibitRule 检查名称、AlertManager 实例以及匹配条件和相等标签的语法
+func (a *alertManagerInhibitService) checkMonitorInhibitRule(ctx context.Context, inhibitRule *model.MonitorInhibitRule) error {
+	exists, err := a.dao.CheckMonitorInhibitRuleNameExists(ctx, inhibitRule)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errors.New("抑制规则已存在")
+	}
+
+	if _, err := a.poolDao.GetAlertPoolByID(ctx, inhibitRule.PoolID); err != nil {
+		return fmt.Errorf("获取AlertManager实例失败: %w", err)
+	}
+
+	if _, err := pkg.BuildInhibitRule(inhibitRule); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -28,6 +28,7 @@ package alert
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/GoSimplicity/AI-CloudOps/internal/model"
 	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/cache"
 	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/alert"
@@ -117,7 +118,7 @@ func (a *alertManagerOnDutyService) CreateMonitorOnDutyGroup(ctx context.Context
 	}
 
 	// 更新缓存
-	if err := a.cache.MonitorCacheManager(ctx); err != nil {
+	if err := a.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, fmt.Sprintf("创建值班组: %s", monitorOnDutyGroup.Name))); err != nil {
 		a.l.Error("更新缓存失败", zap.Error(err))
 		return err
 	}
@@ -165,7 +166,7 @@ func (a *alertManagerOnDutyService) CreateMonitorOnDutyGroupChange(ctx context.C
 		return err
 	}
 
-	if err := a.cache.MonitorCacheManager(ctx); err != nil {
+	if err := a.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, fmt.Sprintf("值班组换班: 值班组ID %d", monitorOnDutyChange.OnDutyGroupID))); err != nil {
 		a.l.Error("更新缓存失败", zap.Error(err))
 		return err
 	}
@@ -213,7 +214,7 @@ func (a *alertManagerOnDutyService) DeleteMonitorOnDutyGroup(ctx context.Context
 	}
 
 	// 更新缓存
-	if err := a.cache.MonitorCacheManager(ctx); err != nil {
+	if err := a.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, fmt.Sprintf("删除值班组: ID %d", id))); err != nil {
 		a.l.Error("更新缓存失败", zap.Error(err))
 		return err
 	}
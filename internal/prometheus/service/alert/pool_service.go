@@ -28,6 +28,7 @@ package alert
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/GoSimplicity/AI-CloudOps/internal/model"
 	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/cache"
 	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/alert"
@@ -79,6 +80,14 @@ func (a *alertManagerPoolService) CreateMonitorAlertManagerPool(ctx context.Cont
 		return errors.New("AlertManager 集群池 IP 已存在")
 	}
 
+	// 校验 SMTP 设置
+	if err := pkg.ValidateAlertManagerPoolSmtp(monitorAlertManagerPool); err != nil {
+		return err
+	}
+	if monitorAlertManagerPool.SmtpRequireTLS == 0 {
+		monitorAlertManagerPool.SmtpRequireTLS = 1
+	}
+
 	// 创建 AlertManager 集群池
 	if err := a.dao.CreateMonitorAlertManagerPool(ctx, monitorAlertManagerPool); err != nil {
 		a.l.Error("创建 AlertManager 集群池失败", zap.Error(err))
@@ -86,7 +95,7 @@ func (a *alertManagerPoolService) CreateMonitorAlertManagerPool(ctx context.Cont
 	}
 
 	// 更新缓存
-	if err := a.cache.MonitorCacheManager(ctx); err != nil {
+	if err := a.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, fmt.Sprintf("创建AlertManager实例池: %s", monitorAlertManagerPool.Name))); err != nil {
 		a.l.Error("更新缓存失败", zap.Error(err))
 		return err
 	}
@@ -108,6 +117,23 @@ func (a *alertManagerPoolService) UpdateMonitorAlertManagerPool(ctx context.Cont
 		return errors.New("AlertManager 集群池 IP 已存在")
 	}
 
+	// 校验 SMTP 设置，使用邮件通知的发送组需要保留 SMTP 服务器
+	if err := pkg.ValidateAlertManagerPoolSmtp(monitorAlertManagerPool); err != nil {
+		return err
+	}
+	if monitorAlertManagerPool.SmtpSmarthost == "" {
+		sendGroups, err := a.sendDao.GetMonitorSendGroupByPoolId(ctx, monitorAlertManagerPool.ID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			a.l.Error("更新 AlertManager 集群池失败：获取关联发送组时出错", zap.Error(err))
+			return err
+		}
+		for _, sendGroup := range sendGroups {
+			if _, email, _ := pkg.SendGroupNotifyTargets(sendGroup); email {
+				return fmt.Errorf("发送组 %s 使用邮件通知，不能清空 SMTP 设置", sendGroup.Name)
+			}
+		}
+	}
+
 	// 更新 AlertManager 集群池
 	if err := a.dao.UpdateMonitorAlertManagerPool(ctx, monitorAlertManagerPool); err != nil {
 		a.l.Error("更新 AlertManager 集群池失败", zap.Error(err))
@@ -115,7 +141,7 @@ func (a *alertManagerPoolService) UpdateMonitorAlertManagerPool(ctx context.Cont
 	}
 
 	// 更新缓存
-	if err := a.cache.MonitorCacheManager(ctx); err != nil {
+	if err := a.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, fmt.Sprintf("更新AlertManager实例池: %s", monitorAlertManagerPool.Name))); err != nil {
 		a.l.Error("更新缓存失败", zap.Error(err))
 		return err
 	}
@@ -143,7 +169,7 @@ func (a *alertManagerPoolService) DeleteMonitorAlertManagerPool(ctx context.Cont
 	}
 
 	// 更新缓存
-	if err := a.cache.MonitorCacheManager(ctx); err != nil {
+	if err := a.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, fmt.Sprintf("删除AlertManager实例池: ID %d", id))); err != nil {
 		a.l.Error("更新缓存失败", zap.Error(err))
 		return err
 	}
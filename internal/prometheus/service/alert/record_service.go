@@ -48,18 +48,22 @@ type AlertManagerRecordService interface {
 }
 
 type alertManagerRecordService struct {
-	dao     alert.AlertManagerRecordDAO
-	cache   cache.MonitorCache
-	userDao userDao.UserDAO
-	l       *zap.Logger
+	dao      alert.AlertManagerRecordDAO
+	testDao  alert.AlertManagerRuleTestDAO
+	groupDao alert.AlertManagerRuleGroupDAO
+	cache    cache.MonitorCache
+	userDao  userDao.UserDAO
+	l        *zap.Logger
 }
 
-func NewAlertManagerRecordService(dao alert.AlertManagerRecordDAO, cache cache.MonitorCache, l *zap.Logger, userDao userDao.UserDAO) AlertManagerRecordService {
+func NewAlertManagerRecordService(dao alert.AlertManagerRecordDAO, testDao alert.AlertManagerRuleTestDAO, groupDao alert.AlertManagerRuleGroupDAO, cache cache.MonitorCache, l *zap.Logger, userDao userDao.UserDAO) AlertManagerRecordService {
 	return &alertManagerRecordService{
-		dao:     dao,
-		userDao: userDao,
-		l:       l,
-		cache:   cache,
+		dao:      dao,
+		testDao:  testDao,
+		groupDao: groupDao,
+		userDao:  userDao,
+		l:        l,
+		cache:    cache,
 	}
 }
 
@@ -81,6 +85,11 @@ func (a *alertManagerRecordService) CreateMonitorRecordRule(ctx context.Context,
 		return errors.New("记录规则已存在")
 	}
 
+	// 加入规则组时，规则必须与规则组属于同一个采集池
+	if err := checkRuleGroupPool(ctx, a.groupDao, monitorRecordRule.RuleGroupID, monitorRecordRule.PoolID); err != nil {
+		return err
+	}
+
 	// 创建记录规则
 	if err := a.dao.CreateMonitorRecordRule(ctx, monitorRecordRule); err != nil {
 		a.l.Error("创建记录规则失败", zap.Error(err))
@@ -88,7 +97,7 @@ func (a *alertManagerRecordService) CreateMonitorRecordRule(ctx context.Context,
 	}
 
 	// 更新缓存
-	if err := a.cache.MonitorCacheManager(ctx); err != nil {
+	if err := a.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, fmt.Sprintf("创建预聚合规则: %s", monitorRecordRule.Name))); err != nil {
 		a.l.Error("更新缓存失败", zap.Error(err))
 		return err
 	}
@@ -97,6 +106,28 @@ func (a *alertManagerRecordService) CreateMonitorRecordRule(ctx context.Context,
 }
 
 func (a *alertManagerRecordService) UpdateMonitorRecordRule(ctx context.Context, monitorRecordRule *model.MonitorRecordRule) error {
+	// 加入规则组时，规则必须与规则组属于同一个采集池
+	if err := checkRuleGroupPool(ctx, a.groupDao, monitorRecordRule.RuleGroupID, monitorRecordRule.PoolID); err != nil {
+		return err
+	}
+
+	// 要求单元测试通过时，使用更新后的规则运行已有的测试用例
+	requireTestPass := monitorRecordRule.RequireTestPass
+	if requireTestPass == 0 {
+		old, err := a.dao.GetMonitorRecordRuleById(ctx, monitorRecordRule.ID)
+		if err != nil {
+			return err
+		}
+		requireTestPass = old.RequireTestPass
+	}
+
+	if requireTestPass == 1 {
+		rule := pkg.RecordRuleToRulefmt(monitorRecordRule)
+		if err := checkRuleTests(ctx, a.testDao, model.RuleTypeRecord, monitorRecordRule.ID, rule); err != nil {
+			return err
+		}
+	}
+
 	// 更新记录规则
 	if err := a.dao.UpdateMonitorRecordRule(ctx, monitorRecordRule); err != nil {
 		a.l.Error("更新记录规则失败", zap.Error(err))
@@ -104,7 +135,7 @@ func (a *alertManagerRecordService) UpdateMonitorRecordRule(ctx context.Context,
 	}
 
 	// 更新缓存
-	if err := a.cache.MonitorCacheManager(ctx); err != nil {
+	if err := a.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, fmt.Sprintf("更新预聚合规则: %s", monitorRecordRule.Name))); err != nil {
 		a.l.Error("更新缓存失败", zap.Error(err))
 		return err
 	}
@@ -119,8 +150,13 @@ func (a *alertManagerRecordService) DeleteMonitorRecordRule(ctx context.Context,
 		return err
 	}
 
+	// 删除记录规则关联的单元测试
+	if err := a.testDao.DeleteRuleTestsByRule(ctx, model.RuleTypeRecord, id); err != nil {
+		a.l.Warn("删除记录规则的单元测试失败", zap.Error(err), zap.Int("id", id))
+	}
+
 	// 更新缓存
-	if err := a.cache.MonitorCacheManager(ctx); err != nil {
+	if err := a.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, fmt.Sprintf("删除预聚合规则: ID %d", id))); err != nil {
 		a.l.Error("更新缓存失败", zap.Error(err))
 		return err
 	}
@@ -147,7 +183,7 @@ func (a *alertManagerRecordService) EnableSwitchMonitorRecordRule(ctx context.Co
 	}
 
 	// 更新缓存
-	if err := a.cache.MonitorCacheManager(ctx); err != nil {
+	if err := a.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, fmt.Sprintf("切换预聚合规则启用状态: ID %d", id))); err != nil {
 		a.l.Error("更新缓存失败", zap.Error(err))
 		return err
 	}
@@ -0,0 +1,369 @@
+package alert
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/k8s/client"
+	"github.com/GoSimplicity/AI-CloudOps/internal/k8s/dao/admin"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/cache"
+	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/alert"
+	scrapeDao "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/scrape"
+	userDao "github.com/GoSimplicity/AI-CloudOps/internal/user/dao"
+	k8sUtils "github.com/GoSimplicity/AI-CloudOps/pkg/utils/k8s"
+	pkg "github.com/GoSimplicity/AI-CloudOps/pkg/utils/prometheus"
+	"go.uber.org/zap"
+	k8sErr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"strings"
+	"time"
+)
+
+type AlertManagerRuleExportService interface {
+	GetMonitorRuleExportList(ctx context.Context) ([]*model.MonitorRuleExport, error)
+	CreateMonitorRuleExport(ctx context.Context, ruleExport *model.MonitorRuleExport) error
+	UpdateMonitorRuleExport(ctx context.Context, ruleExport *model.MonitorRuleExport) error
+	// DeleteMonitorRuleExport 删除导出配置，同时清理集群中已同步的 PrometheusRule
+	DeleteMonitorRuleExport(ctx context.Context, id int) error
+	// SyncMonitorRuleExport 立即将采集池的规则同步到集群，创建或更新每个规则组对应的 PrometheusRule 并清理多余的资源
+	SyncMonitorRuleExport(ctx context.Context, id int) (*model.MonitorRuleExport, error)
+	// SyncAllMonitorRuleExports 同步所有导出配置，由定时任务调用
+	SyncAllMonitorRuleExports(ctx context.Context) error
+}
+
+type alertManagerRuleExportService struct {
+	dao        alert.AlertManagerRuleExportDAO
+	poolDao    scrapeDao.ScrapePoolDAO
+	clusterDao admin.ClusterDAO
+	client     client.K8sClient
+	ruleCache  cache.RuleConfigCache
+	userDao    userDao.UserDAO
+	l          *zap.Logger
+}
+
+func NewAlertManagerRuleExportService(dao alert.AlertManagerRuleExportDAO, poolDao scrapeDao.ScrapePoolDAO, clusterDao admin.ClusterDAO, client client.K8sClient, ruleCache cache.RuleConfigCache, userDao userDao.UserDAO, l *zap.Logger) AlertManagerRuleExportService {
+	return &alertManagerRuleExportService{
+		dao:        dao,
+		poolDao:    poolDao,
+		clusterDao: clusterDao,
+		client:     client,
+		ruleCache:  ruleCache,
+		userDao:    userDao,
+		l:          l,
+	}
+}
+
+func (a *alertManagerRuleExportService) GetMonitorRuleExportList(ctx context.Context) ([]*model.MonitorRuleExport, error) {
+	ruleExports, err := a.dao.GetMonitorRuleExportList(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	poolNames := make(map[int]string)
+	clusterNames := make(map[int]string)
+	userNames := make(map[int]string)
+	for _, ruleExport := range ruleExports {
+		if _, ok := poolNames[ruleExport.PoolID]; !ok {
+			if pool, err := a.poolDao.GetMonitorScrapePoolById(ctx, ruleExport.PoolID); err == nil {
+				poolNames[ruleExport.PoolID] = pool.Name
+			}
+		}
+		if _, ok := clusterNames[ruleExport.ClusterID]; !ok {
+			if cluster, err := a.clusterDao.GetClusterByID(ctx, ruleExport.ClusterID); err == nil {
+				clusterNames[ruleExport.ClusterID] = cluster.Name
+			}
+		}
+		if _, ok := userNames[ruleExport.UserID]; !ok {
+			if user, err := a.userDao.GetUserByID(ctx, ruleExport.UserID); err == nil {
+				userNames[ruleExport.UserID] = user.Username
+			}
+		}
+
+		ruleExport.PoolName = poolNames[ruleExport.PoolID]
+		ruleExport.ClusterName = clusterNames[ruleExport.ClusterID]
+		ruleExport.CreateUserName = userNames[ruleExport.UserID]
+	}
+
+	return ruleExports, nil
+}
+
+func (a *alertManagerRuleExportService) CreateMonitorRuleExport(ctx context.Context, ruleExport *model.MonitorRuleExport) error {
+	if err := a.checkMonitorRuleExport(ctx, ruleExport); err != nil {
+		return err
+	}
+
+	if ruleExport.Enable == 0 {
+		ruleExport.Enable = 1
+	}
+
+	if err := a.dao.CreateMonitorRuleExport(ctx, ruleExport); err != nil {
+		a.l.Error("创建规则导出配置失败", zap.Error(err))
+		return err
+	}
+
+	// 创建后立即同步一次，同步失败记录在导出配置上，不影响创建
+	if _, err := a.SyncMonitorRuleExport(ctx, ruleExport.ID); err != nil {
+		a.l.Warn("首次同步 PrometheusRule 失败", zap.Error(err), zap.Int("id", ruleExport.ID))
+	}
+
+	return nil
+}
+
+func (a *alertManagerRuleExportService) UpdateMonitorRuleExport(ctx context.Context, ruleExport *model.MonitorRuleExport) error {
+	old, err := a.dao.GetMonitorRuleExportById(ctx, ruleExport.ID)
+	if err != nil {
+		return err
+	}
+
+	if old.PoolID != ruleExport.PoolID || old.ClusterID != ruleExport.ClusterID || old.Namespace != ruleExport.Namespace {
+		return errors.New("不允许修改导出配置的采集池、集群和命名空间，请删除后重新创建")
+	}
+
+	if err := checkPrometheusRuleLabels(ruleExport.Labels); err != nil {
+		return err
+	}
+
+	if err := a.dao.UpdateMonitorRuleExport(ctx, ruleExport); err != nil {
+		return err
+	}
+
+	// 标签或启用状态变化后立即同步，禁用时会清理集群中已同步的资源
+	if _, err := a.SyncMonitorRuleExport(ctx, ruleExport.ID); err != nil {
+		a.l.Warn("同步 PrometheusRule 失败", zap.Error(err), zap.Int("id", ruleExport.ID))
+	}
+
+	return nil
+}
+
+func (a *alertManagerRuleExportService) DeleteMonitorRuleExport(ctx context.Context, id int) error {
+	ruleExport, err := a.dao.GetMonitorRuleExportById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// 不再期望任何资源，同步时会清理集群中所有已同步的 PrometheusRule
+	if _, err := a.reconcile(ctx, ruleExport, nil); err != nil {
+		return fmt.Errorf("清理集群中的 PrometheusRule 失败: %w", err)
+	}
+
+	return a.dao.DeleteMonitorRuleExport(ctx, id)
+}
+
+func (a *alertManagerRuleExportService) SyncMonitorRuleExport(ctx context.Context, id int) (*model.MonitorRuleExport, error) {
+	ruleExport, err := a.dao.GetMonitorRuleExportById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	objectCount, syncErr := a.sync(ctx, ruleExport)
+
+	ruleExport.LastSyncAt = time.Now().Unix()
+	ruleExport.LastSyncObject = objectCount
+	ruleExport.LastSyncError = ""
+	if syncErr != nil {
+		ruleExport.LastSyncError = syncErr.Error()
+	}
+
+	if err := a.dao.UpdateMonitorRuleExportSyncStatus(ctx, ruleExport.ID, ruleExport.LastSyncAt, ruleExport.LastSyncObject, ruleExport.LastSyncError); err != nil {
+		return nil, err
+	}
+
+	return ruleExport, syncErr
+}
+
+func (a *alertManagerRuleExportService) SyncAllMonitorRuleExports(ctx context.Context) error {
+	ruleExports, err := a.dao.GetMonitorRuleExportList(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, ruleExport := range ruleExports {
+		if _, err := a.SyncMonitorRuleExport(ctx, ruleExport.ID); err != nil {
+			a.l.Error("同步 PrometheusRule 失败", zap.Error(err), zap.Int("id", ruleExport.ID))
+			errs = append(errs, fmt.Errorf("导出配置 %d: %w", ruleExport.ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// sync 根据采集池当前的规则生成期望的 PrometheusRule，导出配置禁用时不期望任何资源
+func (a *alertManagerRuleExportService) sync(ctx context.Context, ruleExport *model.MonitorRuleExport) (int, error) {
+	if ruleExport.Enable != 1 {
+		return a.reconcile(ctx, ruleExport, nil)
+	}
+
+	pool, err := a.poolDao.GetMonitorScrapePoolById(ctx, ruleExport.PoolID)
+	if err != nil {
+		return 0, fmt.Errorf("获取采集池失败: %w", err)
+	}
+
+	groups, err := a.ruleCache.GetPoolRuleGroups(ctx, pool)
+	if err != nil {
+		return 0, fmt.Errorf("获取采集池的规则组失败: %w", err)
+	}
+
+	return a.reconcile(ctx, ruleExport, groups)
+}
+
+// reconcile 使用服务端应用创建或更新每个规则组对应的 PrometheusRule，再删除带有平台标签但不在期望列表中的资源。
+// 返回同步后集群中属于该采集池的资源数量
+func (a *alertManagerRuleExportService) reconcile(ctx context.Context, ruleExport *model.MonitorRuleExport, groups []cache.RuleGroup) (int, error) {
+	dynClient, err := k8sUtils.GetDynamicClient(ctx, ruleExport.ClusterID, a.clusterDao, a.client)
+	if err != nil {
+		return 0, err
+	}
+
+	resource := dynClient.Resource(pkg.PrometheusRuleGVR).Namespace(ruleExport.Namespace)
+	extraLabels := pkg.FromSliceTuMap(ruleExport.Labels)
+
+	var errs []error
+	names, err := prometheusRuleNames(ruleExport.PoolID, groups)
+	if err != nil {
+		a.l.Error("规则组的 PrometheusRule 名称重复", zap.Error(err), zap.Int("poolId", ruleExport.PoolID))
+		errs = append(errs, err)
+	}
+
+	desired := make(map[string]struct{}, len(groups))
+	for i, group := range groups {
+		name := names[i]
+		if name == "" {
+			continue
+		}
+		desired[name] = struct{}{}
+
+		obj, err := pkg.BuildPrometheusRule(name, ruleExport.Namespace, ruleExport.PoolID, extraLabels, group)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("生成 %s 失败: %w", name, err))
+			continue
+		}
+
+		if _, err := resource.Apply(ctx, name, obj, metav1.ApplyOptions{FieldManager: pkg.PrometheusRuleFieldManager, Force: true}); err != nil {
+			a.l.Error("应用 PrometheusRule 失败", zap.Error(err), zap.String("name", name), zap.String("namespace", ruleExport.Namespace))
+			errs = append(errs, fmt.Errorf("应用 %s 失败: %w", name, err))
+		}
+	}
+
+	list, err := resource.List(ctx, metav1.ListOptions{LabelSelector: pkg.PrometheusRuleSelector(ruleExport.PoolID)})
+	if err != nil {
+		errs = append(errs, fmt.Errorf("获取已同步的 PrometheusRule 失败: %w", err))
+		return len(desired), errors.Join(errs...)
+	}
+
+	count := 0
+	for _, item := range list.Items {
+		if _, ok := desired[item.GetName()]; ok {
+			count++
+			continue
+		}
+
+		if err := resource.Delete(ctx, item.GetName(), metav1.DeleteOptions{}); err != nil && !k8sErr.IsNotFound(err) {
+			a.l.Error("清理 PrometheusRule 失败", zap.Error(err), zap.String("name", item.GetName()), zap.String("namespace", ruleExport.Namespace))
+			errs = append(errs, fmt.Errorf("清理 %s 失败: %w", item.GetName(), err))
+			count++
+			continue
+		}
+		a.l.Info("清理不再需要的 PrometheusRule", zap.String("name", item.GetName()), zap.String("namespace", ruleExport.Namespace))
+	}
+
+	return count, errors.Join(errs...)
+}
+
+// prometheusRuleNames 生成每个规则组对应的资源名称。告警规则组和预聚合规则组同名或转换后名称相同时返回错误，
+// 重复的规则组名称为空，不会被同步，避免后应用的规则组覆盖前一个
+func prometheusRuleNames(poolID int, groups []cache.RuleGroup) ([]string, error) {
+	names := make([]string, len(groups))
+	owners := make(map[string]string, len(groups))
+
+	var errs []error
+	for i, group := range groups {
+		name := pkg.PrometheusRuleName(poolID, group.Name)
+		if owner, ok := owners[name]; ok {
+			errs = append(errs, fmt.Errorf("规则组 %s 与 %s 的 PrometheusRule 名称重复: %s", group.Name, owner, name))
+			continue
+		}
+		owners[name] = group.Name
+		names[i] = name
+	}
+
+	return names, errors.Join(errs...)
+}
+
+func (a *alertManagerRuleExportService) checkMonitorRuleExport(ctx context.Context, ruleExport *model.MonitorRuleExport) error {
+	if errs := validation.IsDNS1123Label(ruleExport.Namespace); len(errs) > 0 {
+		return fmt.Errorf("命名空间 %s 不合法: %s", ruleExport.Namespace, strings.Join(errs, "; "))
+	}
+
+	if err := checkPrometheusRuleLabels(ruleExport.Labels); err != nil {
+		return err
+	}
+
+	if _, err := a.poolDao.GetMonitorScrapePoolById(ctx, ruleExport.PoolID); err != nil {
+		return fmt.Errorf("采集池不存在: %w", err)
+	}
+
+	if _, err := a.clusterDao.GetClusterByID(ctx, ruleExport.ClusterID); err != nil {
+		return fmt.Errorf("集群不存在: %w", err)
+	}
+
+	exists, err := a.dao.CheckMonitorRuleExportExists(ctx, ruleExport)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errors.New("该采集池已配置同步到相同的集群和命名空间")
+	}
+
+	return nil
+}
+
+// checkPrometheusRuleLabels 校验附加标签是否为合法的 K8s 标签，平台使用的标签不允许覆盖
+func checkPrometheusRuleLabels(labels []string) error {
+	for _, label := range labels {
+		parts := strings.SplitN(label, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("标签 %s 格式错误，应为 key=v", label)
+		}
+
+		if errs := validation.IsQualifiedName(parts[0]); len(errs) > 0 {
+			return fmt.Errorf("标签名 %s 不合法: %s", parts[0], strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(parts[1]); len(errs) > 0 {
+			return fmt.Errorf("标签 %s 的值不合法: %s", parts[0], strings.Join(errs, "; "))
+		}
+
+		if parts[0] == pkg.PrometheusRuleManagedByLabel || parts[0] == pkg.PrometheusRulePoolLabel {
+			return fmt.Errorf("标签 %s 由平台管理，不允许设置", parts[0])
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package alert
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"testing"
+
+	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/cache"
+	pkg "github.com/GoSimplicity/AI-CloudOps/pkg/utils/prometheus"
+)
+
+func TestPrometheusRuleNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		groups  []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:   "名称不重复",
+			groups: []string{"node", "node-record"},
+			want:   []string{pkg.PrometheusRuleName(1, "node"), pkg.PrometheusRuleName(1, "node-record")},
+		},
+		{
+			name:    "告警规则组和预聚合规则组同名",
+			groups:  []string{"node", "cpu", "node"},
+			want:    []string{pkg.PrometheusRuleName(1, "node"), pkg.PrometheusRuleName(1, "cpu"), ""},
+			wantErr: true,
+		},
+		{
+			name:   "转换后名称不同的规则组",
+			groups: []string{"Node", "node"},
+			want:   []string{pkg.PrometheusRuleName(1, "Node"), pkg.PrometheusRuleName(1, "node")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups := make([]cache.RuleGroup, 0, len(tt.groups))
+			for _, name := range tt.groups {
+				groups = append(groups, cache.RuleGroup{Name: name})
+			}
+
+			got, err := prometheusRuleNames(1, groups)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("names[%d] = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package alert
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/cache"
+	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/alert"
+	scrapeDao "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/scrape"
+	pkg "github.com/GoSimplicity/AI-CloudOps/pkg/utils/prometheus"
+	pm "github.com/prometheus/common/model"
+	"go.uber.org/zap"
+)
+
+type AlertManagerRuleGroupService interface {
+	GetMonitorRuleGroupList(ctx context.Context, searchName *string) ([]*model.MonitorRuleGroup, error)
+	GetMonitorRuleGroupDetail(ctx context.Context, id int) (*model.RuleGroupDetailResp, error)
+	CreateMonitorRuleGroup(ctx context.Context, ruleGroup *model.MonitorRuleGroup) error
+	UpdateMonitorRuleGroup(ctx context.Context, ruleGroup *model.MonitorRuleGroup) error
+	DeleteMonitorRuleGroup(ctx context.Context, id int) error
+	// PreviewRuleShards 预览采集池更换Prometheus实例列表或副本数后，哪些规则组会迁移到其他实例
+	PreviewRuleShards(ctx context.Context, req *model.RuleShardPreviewReq) (*model.RuleShardPreviewResp, error)
+}
+
+type alertManagerRuleGroupService struct {
+	dao       alert.AlertManagerRuleGroupDAO
+	poolDao   scrapeDao.ScrapePoolDAO
+	cache     cache.MonitorCache
+	ruleCache cache.RuleConfigCache
+	l         *zap.Logger
+}
+
+func NewAlertManagerRuleGroupService(dao alert.AlertManagerRuleGroupDAO, poolDao scrapeDao.ScrapePoolDAO, cache cache.MonitorCache, ruleCache cache.RuleConfigCache, l *zap.Logger) AlertManagerRuleGroupService {
+	return &alertManagerRuleGroupService{
+		dao:       dao,
+		poolDao:   poolDao,
+		cache:     cache,
+		ruleCache: ruleCache,
+		l:         l,
+	}
+}
+
+func (a *alertManagerRuleGroupService) GetMonitorRuleGroupList(ctx context.Context, searchName *string) ([]*model.MonitorRuleGroup, error) {
+	ruleGroups, err := pkg.HandleList(ctx, searchName,
+		a.dao.SearchMonitorRuleGroupByName,
+		a.dao.GetMonitorRuleGroupList)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ruleGroup := range ruleGroups {
+		ruleGroup.Key = fmt.Sprintf("%d", ruleGroup.ID)
+
+		alertRules, err := a.dao.GetAlertRulesByGroupId(ctx, ruleGroup.ID)
+		if err != nil {
+			return nil, err
+		}
+		recordRules, err := a.dao.GetRecordRulesByGroupId(ctx, ruleGroup.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		ruleGroup.AlertRuleCount = len(alertRules)
+		ruleGroup.RecordRuleCount = len(recordRules)
+	}
+
+	return ruleGroups, nil
+}
+
+func (a *alertManagerRuleGroupService) GetMonitorRuleGroupDetail(ctx context.Context, id int) (*model.RuleGroupDetailResp, error) {
+	ruleGroup, err := a.dao.GetMonitorRuleGroupById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	alertRules, err := a.dao.GetAlertRulesByGroupId(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	recordRules, err := a.dao.GetRecordRulesByGroupId(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	ruleGroup.AlertRuleCount = len(alertRules)
+	ruleGroup.RecordRuleCount = len(recordRules)
+
+	return &model.RuleGroupDetailResp{
+		Group:       ruleGroup,
+		AlertRules:  alertRules,
+		RecordRules: recordRules,
+	}, nil
+}
+
+func (a *alertManagerRuleGroupService) CreateMonitorRuleGroup(ctx context.Context, ruleGroup *model.MonitorRuleGroup) error {
+	if err := a.checkMonitorRuleGroup(ctx, ruleGroup); err != nil {
+		return err
+	}
+
+	// 新建的规则组内还没有规则，不影响生成的配置，无需更新缓存
+	if err := a.dao.CreateMonitorRuleGroup(ctx, ruleGroup); err != nil {
+		a.l.Error("创建规则组失败", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (a *alertManagerRuleGroupService) UpdateMonitorRuleGroup(ctx context.Context, ruleGroup *model.MonitorRuleGroup) error {
+	old, err := a.dao.GetMonitorRuleGroupById(ctx, ruleGroup.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := a.checkMonitorRuleGroup(ctx, ruleGroup); err != nil {
+		return err
+	}
+
+	// 组内规则都属于规则组的采集池，有规则时不允许修改采集池
+	if old.PoolID != ruleGroup.PoolID {
+		detail, err := a.GetMonitorRuleGroupDetail(ctx, ruleGroup.ID)
+		if err != nil {
+			return err
+		}
+		if len(detail.AlertRules) > 0 || len(detail.RecordRules) > 0 {
+			return errors.New("规则组内还有规则，不能修改所属的采集池")
+		}
+	}
+
+	if err := a.dao.UpdateMonitorRuleGroup(ctx, ruleGroup); err != nil {
+		a.l.Error("更新规则组失败", zap.Error(err))
+		return err
+	}
+
+	// 更新缓存
+	if err := a.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, fmt.Sprintf("更新规则组: %s", ruleGroup.Name))); err != nil {
+		a.l.Error("更新缓存失败", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// DeleteMonitorRuleGroup 删除规则组，组内规则不会被删除，恢复为各自单独成组
+func (a *alertManagerRuleGroupService) DeleteMonitorRuleGroup(ctx context.Context, id int) error {
+	if err := a.dao.DeleteMonitorRuleGroup(ctx, id); err != nil {
+		a.l.Error("删除规则组失败", zap.Error(err))
+		return err
+	}
+
+	// 更新缓存
+	if err := a.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, fmt.Sprintf("删除规则组: ID %d", id))); err != nil {
+		a.l.Error("更新缓存失败", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (a *alertManagerRuleGroupService) PreviewRuleShards(ctx context.Context, req *model.RuleShardPreviewReq) (*model.RuleShardPreviewResp, error) {
+	pool, err := a.poolDao.GetMonitorScrapePoolById(ctx, req.PoolID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.PrometheusInstances) == 0 {
+		return nil, errors.New("拟定的Prometheus实例列表不能为空")
+	}
+
+	replicas := req.RuleReplicas
+	if replicas == 0 {
+		replicas = pool.RuleReplicas
+	}
+	if replicas < 0 {
+		return nil, errors.New("规则组副本数不能为负数")
+	}
+
+	resp, err := a.ruleCache.PreviewRuleShards(ctx, pool, req.PrometheusInstances, replicas)
+	if err != nil {
+		a.l.Error("预览规则组分片失败", zap.Error(err), zap.Int("poolId", req.PoolID))
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// checkMonitorRuleGroup 检查规则组名称是否重复，以及评估间隔和数量上限是否合法
+func (a *alertManagerRuleGroupService) checkMonitorRuleGroup(ctx context.Context, ruleGroup *model.MonitorRuleGroup) error {
+	exists, err := a.dao.CheckMonitorRuleGroupNameExists(ctx, ruleGroup)
+	if err != nil {
+		a.l.Error("检查规则组名称是否存在时出错", zap.Error(err))
+		return err
+	}
+	if exists {
+		return errors.New("规则组名称已存在")
+	}
+
+	if ruleGroup.Interval != "" {
+		interval, err := pm.ParseDuration(ruleGroup.Interval)
+		if err != nil {
+			return fmt.Errorf("规则组评估间隔格式错误: %w", err)
+		}
+		if interval <= 0 {
+			return errors.New("规则组评估间隔必须大于0")
+		}
+	}
+
+	if ruleGroup.RuleLimit < 0 {
+		return errors.New("规则组数量上限不能为负数")
+	}
+
+	return nil
+}
+
+// checkRuleGroupPool 检查规则加入的规则组是否属于同一个采集池，ruleGroupID 为0表示规则不加入规则组
+func checkRuleGroupPool(ctx context.Context, dao alert.AlertManagerRuleGroupDAO, ruleGroupID, poolID int) error {
+	if ruleGroupID == 0 {
+		return nil
+	}
+
+	ruleGroup, err := dao.GetMonitorRuleGroupById(ctx, ruleGroupID)
+	if err != nil {
+		return fmt.Errorf("获取规则组失败: %w", err)
+	}
+
+	if ruleGroup.PoolID != poolID {
+		return fmt.Errorf("规则与规则组 %s 不属于同一个采集池", ruleGroup.Name)
+	}
+
+	return nil
+}
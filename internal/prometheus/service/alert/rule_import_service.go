@@ -0,0 +1,459 @@
+package alert
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/cache"
+	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/alert"
+	scrapeDao "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/scrape"
+	pkg "github.com/GoSimplicity/AI-CloudOps/pkg/utils/prometheus"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"go.uber.org/zap"
+	"sort"
+	"unicode/utf8"
+)
+
+// maxImportNameLength 规则组和规则名称的最大长度，与创建接口的校验一致
+const maxImportNameLength = 50
+
+type AlertManagerRuleImportService interface {
+	// ImportRules 导入 rulefmt 格式的规则文件，DryRun 时只返回导入报告。
+	// 存在冲突或无效的规则时不导入任何规则，返回报告和错误
+	ImportRules(ctx context.Context, req *model.RuleImportReq, userID int) (*model.RuleImportResp, error)
+}
+
+type alertManagerRuleImportService struct {
+	dao     alert.AlertManagerRuleImportDAO
+	testDao alert.AlertManagerRuleTestDAO
+	poolDao scrapeDao.ScrapePoolDAO
+	cache   cache.MonitorCache
+	l       *zap.Logger
+}
+
+func NewAlertManagerRuleImportService(dao alert.AlertManagerRuleImportDAO, testDao alert.AlertManagerRuleTestDAO, poolDao scrapeDao.ScrapePoolDAO, cache cache.MonitorCache, l *zap.Logger) AlertManagerRuleImportService {
+	return &alertManagerRuleImportService{
+		dao:     dao,
+		testDao: testDao,
+		poolDao: poolDao,
+		cache:   cache,
+		l:       l,
+	}
+}
+
+// ruleImportPlan 规则文件解析后的导入计划
+type ruleImportPlan struct {
+	resp        *model.RuleImportResp
+	ruleGroups  []*model.MonitorRuleGroup
+	alertRules  []*model.MonitorAlertRule
+	recordRules []*model.MonitorRecordRule
+}
+
+// add 记录条目的处理方式，只有新建和更新的条目会写入数据库
+func (p *ruleImportPlan) add(item *model.RuleImportItem) bool {
+	p.resp.Items = append(p.resp.Items, item)
+
+	switch item.Action {
+	case model.RuleImportActionCreate:
+		p.resp.Created++
+	case model.RuleImportActionUpdate:
+		p.resp.Updated++
+	case model.RuleImportActionConflict:
+		p.resp.Conflicts++
+	case model.RuleImportActionInvalid:
+		p.resp.Invalid++
+	}
+
+	return item.Action == model.RuleImportActionCreate || item.Action == model.RuleImportActionUpdate
+}
+
+func (a *alertManagerRuleImportService) ImportRules(ctx context.Context, req *model.RuleImportReq, userID int) (*model.RuleImportResp, error) {
+	if req.Content == "" {
+		return nil, errors.New("规则文件内容不能为空")
+	}
+
+	pool, err := a.poolDao.GetMonitorScrapePoolById(ctx, req.PoolID)
+	if err != nil {
+		return nil, fmt.Errorf("获取采集池失败: %w", err)
+	}
+
+	groups, ruleErrs, err := parseImportRuleFile(req.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := a.buildImportPlan(ctx, req, groups, ruleErrs, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.DryRun {
+		return plan.resp, nil
+	}
+
+	if plan.resp.Conflicts > 0 || plan.resp.Invalid > 0 {
+		return plan.resp, fmt.Errorf("存在 %d 个冲突和 %d 个无效的规则组或规则，未导入任何规则", plan.resp.Conflicts, plan.resp.Invalid)
+	}
+
+	if err := a.dao.ImportRules(ctx, plan.ruleGroups, plan.alertRules, plan.recordRules); err != nil {
+		a.l.Error("导入规则失败", zap.Error(err), zap.String("pool", pool.Name))
+		return plan.resp, fmt.Errorf("导入规则失败: %w", err)
+	}
+
+	if err := a.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, fmt.Sprintf("导入规则文件到采集池 %s: 新建 %d 个，更新 %d 个", pool.Name, plan.resp.Created, plan.resp.Updated))); err != nil {
+		a.l.Error("更新缓存失败", zap.Error(err))
+		return plan.resp, err
+	}
+
+	return plan.resp, nil
+}
+
+// parseImportRuleFile 使用 rulefmt 解析规则文件，单条规则的校验错误按 "规则组名称/规则序号" 返回，
+// 其他错误（如 YAML 格式错误、规则组名称为空或重复）使整个文件无法导入
+func parseImportRuleFile(content string) ([]rulefmt.RuleGroup, map[string][]string, error) {
+	groups, errs := rulefmt.Parse([]byte(content))
+	if groups == nil {
+		return nil, nil, fmt.Errorf("解析规则文件失败: %w", errors.Join(errs...))
+	}
+
+	ruleErrs := make(map[string][]string)
+	var fileErrs []error
+	for _, err := range errs {
+		var ruleErr *rulefmt.Error
+		if errors.As(err, &ruleErr) {
+			key := ruleErrorKey(ruleErr.Group, ruleErr.Rule)
+			ruleErrs[key] = append(ruleErrs[key], ruleErr.Err.Error())
+			continue
+		}
+		fileErrs = append(fileErrs, err)
+	}
+
+	if len(fileErrs) > 0 {
+		return nil, nil, fmt.Errorf("规则文件校验失败: %w", errors.Join(fileErrs...))
+	}
+	if len(groups.Groups) == 0 {
+		return nil, nil, errors.New("规则文件中没有规则组")
+	}
+
+	return groups.Groups, ruleErrs, nil
+}
+
+// ruleErrorKey rulefmt 中规则序号从1开始
+func ruleErrorKey(group string, rule int) string {
+	return fmt.Sprintf("%s/%d", group, rule)
+}
+
+func (a *alertManagerRuleImportService) buildImportPlan(ctx context.Context, req *model.RuleImportReq, groups []rulefmt.RuleGroup, ruleErrs map[string][]string, userID int) (*ruleImportPlan, error) {
+	var groupNames, alertNames, recordNames []string
+	for _, group := range groups {
+		groupNames = append(groupNames, group.Name)
+		for _, rule := range group.Rules {
+			if rule.Alert.Value != "" {
+				alertNames = append(alertNames, rule.Alert.Value)
+			} else {
+				recordNames = append(recordNames, rule.Record.Value)
+			}
+		}
+	}
+
+	existingGroups, err := a.dao.GetRuleGroupsByNames(ctx, groupNames)
+	if err != nil {
+		return nil, err
+	}
+	existingAlerts, err := a.dao.GetAlertRulesByNames(ctx, alertNames)
+	if err != nil {
+		return nil, err
+	}
+	existingRecords, err := a.dao.GetRecordRulesByNames(ctx, recordNames)
+	if err != nil {
+		return nil, err
+	}
+
+	groupByName := make(map[string]*model.MonitorRuleGroup, len(existingGroups))
+	for _, group := range existingGroups {
+		groupByName[group.Name] = group
+	}
+	alertByName := make(map[string]*model.MonitorAlertRule, len(existingAlerts))
+	for _, rule := range existingAlerts {
+		alertByName[rule.Name] = rule
+	}
+	// 预聚合规则的规则名称和记录名称都唯一，同一个名称可能对应两条规则
+	recordsByName := make(map[string][]*model.MonitorRecordRule)
+	for _, rule := range existingRecords {
+		recordsByName[rule.Name] = append(recordsByName[rule.Name], rule)
+		if rule.RecordName != rule.Name {
+			recordsByName[rule.RecordName] = append(recordsByName[rule.RecordName], rule)
+		}
+	}
+
+	enable := req.Enable
+	if enable == 0 {
+		enable = 1
+	}
+
+	plan := &ruleImportPlan{
+		resp: &model.RuleImportResp{
+			DryRun: req.DryRun,
+			Items:  make([]*model.RuleImportItem, 0),
+		},
+	}
+	seenAlerts := make(map[string]struct{})
+	seenRecords := make(map[string]struct{})
+
+	for _, group := range groups {
+		groupItem := &model.RuleImportItem{
+			Type:   model.RuleImportTypeGroup,
+			Group:  group.Name,
+			Name:   group.Name,
+			Action: model.RuleImportActionCreate,
+		}
+		ruleGroup := &model.MonitorRuleGroup{
+			Name:        group.Name,
+			UserID:      userID,
+			PoolID:      req.PoolID,
+			RuleLimit:   group.Limit,
+			Description: "从规则文件导入",
+		}
+		if group.Interval > 0 {
+			ruleGroup.Interval = group.Interval.String()
+		}
+
+		existing := groupByName[group.Name]
+		switch {
+		case utf8.RuneCountInString(group.Name) > maxImportNameLength:
+			groupItem.Action = model.RuleImportActionInvalid
+			groupItem.Reason = fmt.Sprintf("规则组名称超过 %d 个字符", maxImportNameLength)
+		case group.QueryOffset != nil:
+			groupItem.Action = model.RuleImportActionInvalid
+			groupItem.Reason = "平台的规则组不支持 query_offset"
+		case existing != nil && existing.PoolID != req.PoolID:
+			groupItem.Action = model.RuleImportActionConflict
+			groupItem.Reason = fmt.Sprintf("同名规则组已属于采集池 %d", existing.PoolID)
+			groupItem.ExistingID = existing.ID
+		case existing != nil:
+			groupItem.Action = model.RuleImportActionUpdate
+			groupItem.ExistingID = existing.ID
+			ruleGroup.ID = existing.ID
+		}
+
+		if plan.add(groupItem) {
+			plan.ruleGroups = append(plan.ruleGroups, ruleGroup)
+		}
+
+		for i, rule := range group.Rules {
+			item := &model.RuleImportItem{
+				Group:  group.Name,
+				Action: model.RuleImportActionCreate,
+			}
+			if rule.Alert.Value != "" {
+				item.Type = model.RuleTypeAlert
+				item.Name = rule.Alert.Value
+			} else {
+				item.Type = model.RuleTypeRecord
+				item.Name = rule.Record.Value
+			}
+
+			if reason := checkImportRule(rule, ruleErrs[ruleErrorKey(group.Name, i+1)]); reason != "" {
+				item.Action = model.RuleImportActionInvalid
+				item.Reason = reason
+				plan.add(item)
+				continue
+			}
+
+			switch groupItem.Action {
+			case model.RuleImportActionConflict:
+				item.Action = model.RuleImportActionConflict
+				item.Reason = fmt.Sprintf("所属规则组 %s 存在冲突", group.Name)
+				plan.add(item)
+				continue
+			case model.RuleImportActionInvalid:
+				item.Action = model.RuleImportActionInvalid
+				item.Reason = fmt.Sprintf("所属规则组 %s 无效", group.Name)
+				plan.add(item)
+				continue
+			}
+
+			if item.Type == model.RuleTypeAlert {
+				if alertRule := a.planAlertRule(ctx, req, item, rule, seenAlerts, alertByName[item.Name], enable, userID, i+1); plan.add(item) {
+					plan.alertRules = append(plan.alertRules, alertRule)
+				}
+				continue
+			}
+
+			if recordRule := a.planRecordRule(ctx, req, item, rule, seenRecords, recordsByName[item.Name], enable, userID, i+1); plan.add(item) {
+				plan.recordRules = append(plan.recordRules, recordRule)
+			}
+		}
+	}
+
+	return plan, nil
+}
+
+// checkImportRule 检查规则是否能保存到平台，返回无效的原因
+func checkImportRule(rule rulefmt.RuleNode, errs []string) string {
+	if len(errs) > 0 {
+		return errs[0]
+	}
+
+	name := rule.Alert.Value
+	if name == "" {
+		name = rule.Record.Value
+	}
+	if utf8.RuneCountInString(name) > maxImportNameLength {
+		return fmt.Sprintf("规则名称超过 %d 个字符", maxImportNameLength)
+	}
+
+	if _, err := pkg.PromqlExprCheck(rule.Expr.Value); err != nil {
+		return err.Error()
+	}
+
+	if rule.KeepFiringFor != 0 {
+		return "平台的告警规则不支持 keep_firing_for"
+	}
+	if rule.Record.Value != "" && len(rule.Labels) > 0 {
+		return "平台的预聚合规则不支持 labels"
+	}
+
+	return ""
+}
+
+func (a *alertManagerRuleImportService) planAlertRule(ctx context.Context, req *model.RuleImportReq, item *model.RuleImportItem, rule rulefmt.RuleNode, seen map[string]struct{}, existing *model.MonitorAlertRule, enable, userID, order int) *model.MonitorAlertRule {
+	alertRule := &model.MonitorAlertRule{
+		Name:            item.Name,
+		UserID:          userID,
+		PoolID:          req.PoolID,
+		SendGroupID:     req.SendGroupID,
+		TreeNodeID:      req.TreeNodeID,
+		Enable:          enable,
+		Expr:            rule.Expr.Value,
+		Severity:        rule.Labels["severity"],
+		ForTime:         rule.For.String(),
+		Labels:          mapToStringList(rule.Labels),
+		Annotations:     mapToStringList(rule.Annotations),
+		RequireTestPass: 2,
+		RuleGroupName:   item.Group,
+		GroupOrder:      order,
+	}
+
+	if _, ok := seen[item.Name]; ok {
+		item.Action = model.RuleImportActionConflict
+		item.Reason = "与文件中前面的同名告警规则冲突"
+		return alertRule
+	}
+	seen[item.Name] = struct{}{}
+
+	if existing == nil {
+		return alertRule
+	}
+
+	item.ExistingID = existing.ID
+	if existing.PoolID != req.PoolID {
+		item.Action = model.RuleImportActionConflict
+		item.Reason = fmt.Sprintf("同名告警规则已属于采集池 %d", existing.PoolID)
+		return alertRule
+	}
+
+	item.Action = model.RuleImportActionUpdate
+	alertRule.ID = existing.ID
+
+	// 与更新接口一致，要求单元测试通过的规则需要使用导入的内容运行已有的测试用例
+	if existing.RequireTestPass == 1 {
+		formatted, err := pkg.AlertRuleToRulefmt(alertRule)
+		if err == nil {
+			err = checkRuleTests(ctx, a.testDao, model.RuleTypeAlert, existing.ID, formatted)
+		}
+		if err != nil {
+			item.Action = model.RuleImportActionInvalid
+			item.Reason = err.Error()
+		}
+	}
+
+	return alertRule
+}
+
+func (a *alertManagerRuleImportService) planRecordRule(ctx context.Context, req *model.RuleImportReq, item *model.RuleImportItem, rule rulefmt.RuleNode, seen map[string]struct{}, existing []*model.MonitorRecordRule, enable, userID, order int) *model.MonitorRecordRule {
+	recordRule := &model.MonitorRecordRule{
+		Name:            item.Name,
+		RecordName:      item.Name,
+		UserID:          userID,
+		PoolID:          req.PoolID,
+		TreeNodeID:      req.TreeNodeID,
+		Enable:          enable,
+		Expr:            rule.Expr.Value,
+		RequireTestPass: 2,
+		RuleGroupName:   item.Group,
+		GroupOrder:      order,
+	}
+
+	if _, ok := seen[item.Name]; ok {
+		item.Action = model.RuleImportActionConflict
+		item.Reason = "与文件中前面的同名预聚合规则冲突"
+		return recordRule
+	}
+	seen[item.Name] = struct{}{}
+
+	switch {
+	case len(existing) == 0:
+		return recordRule
+	case len(existing) > 1:
+		item.Action = model.RuleImportActionConflict
+		item.Reason = "规则名称和记录名称分别与不同的预聚合规则重复"
+		return recordRule
+	}
+
+	item.ExistingID = existing[0].ID
+	if existing[0].PoolID != req.PoolID {
+		item.Action = model.RuleImportActionConflict
+		item.Reason = fmt.Sprintf("同名预聚合规则已属于采集池 %d", existing[0].PoolID)
+		return recordRule
+	}
+
+	item.Action = model.RuleImportActionUpdate
+	recordRule.ID = existing[0].ID
+
+	if existing[0].RequireTestPass == 1 {
+		if err := checkRuleTests(ctx, a.testDao, model.RuleTypeRecord, existing[0].ID, pkg.RecordRuleToRulefmt(recordRule)); err != nil {
+			item.Action = model.RuleImportActionInvalid
+			item.Reason = err.Error()
+		}
+	}
+
+	return recordRule
+}
+
+// mapToStringList 将标签或注解转换为平台使用的 key=v 列表，按键排序保证结果稳定
+func mapToStringList(m map[string]string) model.StringList {
+	list := make(model.StringList, 0, len(m))
+	for k, v := range m {
+		list = append(list, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(list)
+
+	return list
+}
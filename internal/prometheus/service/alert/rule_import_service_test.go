@@ -0,0 +1,189 @@
+package alert
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/alert"
+	scrapeDao "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/scrape"
+	"go.uber.org/zap"
+)
+
+func TestParseImportRuleFile(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		wantGroups    int
+		wantRuleErrs  []string
+		wantFileError bool
+	}{
+		{
+			name:       "合法文件",
+			content:    "groups:\n  - name: node\n    rules:\n      - alert: HostDown\n        expr: up == 0\n      - record: job:up:sum\n        expr: sum by (job) (up)\n",
+			wantGroups: 1,
+		},
+		{
+			name:         "规则错误记录到对应规则",
+			content:      "groups:\n  - name: node\n    rules:\n      - alert: HostDown\n        expr: up == 0\n      - record: job up\n        expr: sum(up)\n",
+			wantGroups:   1,
+			wantRuleErrs: []string{"node/2"},
+		},
+		{name: "YAML格式错误", content: "groups: [\n", wantFileError: true},
+		{name: "规则组名称重复", content: "groups:\n  - name: node\n    rules: []\n  - name: node\n    rules: []\n", wantFileError: true},
+		{name: "没有规则组", content: "groups: []\n", wantFileError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups, ruleErrs, err := parseImportRuleFile(tt.content)
+			if (err != nil) != tt.wantFileError {
+				t.Fatalf("parseImportRuleFile() err = %v, wantErr %v", err, tt.wantFileError)
+			}
+			if tt.wantFileError {
+				return
+			}
+			if len(groups) != tt.wantGroups || len(ruleErrs) != len(tt.wantRuleErrs) {
+				t.Fatalf("parseImportRuleFile() = %d groups, rule errors %v", len(groups), ruleErrs)
+			}
+			for _, key := range tt.wantRuleErrs {
+				if len(ruleErrs[key]) == 0 {
+					t.Errorf("缺少规则 %s 的错误: %v", key, ruleErrs)
+				}
+			}
+		})
+	}
+}
+
+type fakeRuleImportDAO struct {
+	alert.AlertManagerRuleImportDAO
+	groups   []*model.MonitorRuleGroup
+	alerts   []*model.MonitorAlertRule
+	records  []*model.MonitorRecordRule
+	imported bool
+}
+
+func (f *fakeRuleImportDAO) GetRuleGroupsByNames(_ context.Context, _ []string) ([]*model.MonitorRuleGroup, error) {
+	return f.groups, nil
+}
+
+func (f *fakeRuleImportDAO) GetAlertRulesByNames(_ context.Context, _ []string) ([]*model.MonitorAlertRule, error) {
+	return f.alerts, nil
+}
+
+func (f *fakeRuleImportDAO) GetRecordRulesByNames(_ context.Context, _ []string) ([]*model.MonitorRecordRule, error) {
+	return f.records, nil
+}
+
+func (f *fakeRuleImportDAO) ImportRules(_ context.Context, _ []*model.MonitorRuleGroup, _ []*model.MonitorAlertRule, _ []*model.MonitorRecordRule) error {
+	f.imported = true
+	return nil
+}
+
+type fakeScrapePoolDAO struct {
+	scrapeDao.ScrapePoolDAO
+}
+
+func (fakeScrapePoolDAO) GetMonitorScrapePoolById(_ context.Context, id int) (*model.MonitorScrapePool, error) {
+	return &model.MonitorScrapePool{Model: model.Model{ID: id}}, nil
+}
+
+func TestImportRulesPlan(t *testing.T) {
+	content := `groups:
+  - name: node
+    interval: 1m
+    rules:
+      - alert: HostDown
+        expr: up == 0
+        for: 5m
+        labels:
+          severity: critical
+      - alert: HostDown
+        expr: up == 0
+      - record: job:up:sum
+        expr: sum by (job) (up)
+      - record: job:up:count
+        expr: count by (job) (up)
+        labels:
+          team: db
+  - name: other
+    rules:
+      - alert: DiskFull
+        expr: node_filesystem_avail_bytes == 0
+`
+
+	dao := &fakeRuleImportDAO{
+		groups:  []*model.MonitorRuleGroup{{Model: model.Model{ID: 10}, Name: "node", PoolID: 1}, {Model: model.Model{ID: 11}, Name: "other", PoolID: 2}},
+		records: []*model.MonitorRecordRule{{Model: model.Model{ID: 20}, Name: "job:up:sum", RecordName: "job:up:sum", PoolID: 1}},
+	}
+	svc := &alertManagerRuleImportService{dao: dao, poolDao: fakeScrapePoolDAO{}, l: zap.NewNop()}
+
+	resp, err := svc.ImportRules(context.Background(), &model.RuleImportReq{PoolID: 1, Content: content, DryRun: true}, 1)
+	if err != nil {
+		t.Fatalf("ImportRules() err = %v", err)
+	}
+
+	want := []struct {
+		name   string
+		action string
+	}{
+		{name: "node", action: model.RuleImportActionUpdate},
+		{name: "HostDown", action: model.RuleImportActionCreate},
+		{name: "HostDown", action: model.RuleImportActionConflict},
+		{name: "job:up:sum", action: model.RuleImportActionUpdate},
+		{name: "job:up:count", action: model.RuleImportActionInvalid},
+		{name: "other", action: model.RuleImportActionConflict},
+		{name: "DiskFull", action: model.RuleImportActionConflict},
+	}
+	if len(resp.Items) != len(want) {
+		t.Fatalf("ImportRules() 返回 %d 个条目, want %d: %+v", len(resp.Items), len(want), resp.Items)
+	}
+	for i, item := range resp.Items {
+		if item.Name != want[i].name || item.Action != want[i].action {
+			t.Errorf("Items[%d] = %s %s (%s), want %s %s", i, item.Name, item.Action, item.Reason, want[i].name, want[i].action)
+		}
+	}
+	if resp.Created != 1 || resp.Updated != 2 || resp.Conflicts != 3 || resp.Invalid != 1 {
+		t.Errorf("统计 = %+v", resp)
+	}
+	if dao.imported {
+		t.Error("DryRun 时不应导入规则")
+	}
+
+	// 存在冲突时不导入任何规则
+	if _, err := svc.ImportRules(context.Background(), &model.RuleImportReq{PoolID: 1, Content: content}, 1); err == nil || dao.imported {
+		t.Errorf("存在冲突时应返回错误且不导入, err = %v", err)
+	}
+}
+
+func TestMapToStringList(t *testing.T) {
+	got := mapToStringList(map[string]string{"severity": "critical", "env": "prod"})
+	if len(got) != 2 || got[0] != "env=prod" || got[1] != "severity=critical" {
+		t.Errorf("mapToStringList() = %v", got)
+	}
+}
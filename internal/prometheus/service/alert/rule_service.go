@@ -49,18 +49,22 @@ type AlertManagerRuleService interface {
 }
 
 type alertManagerRuleService struct {
-	dao     alert.AlertManagerRuleDAO
-	cache   cache.MonitorCache
-	userDao userDao.UserDAO
-	l       *zap.Logger
+	dao      alert.AlertManagerRuleDAO
+	testDao  alert.AlertManagerRuleTestDAO
+	groupDao alert.AlertManagerRuleGroupDAO
+	cache    cache.MonitorCache
+	userDao  userDao.UserDAO
+	l        *zap.Logger
 }
 
-func NewAlertManagerRuleService(dao alert.AlertManagerRuleDAO, cache cache.MonitorCache, l *zap.Logger, userDao userDao.UserDAO) AlertManagerRuleService {
+func NewAlertManagerRuleService(dao alert.AlertManagerRuleDAO, testDao alert.AlertManagerRuleTestDAO, groupDao alert.AlertManagerRuleGroupDAO, cache cache.MonitorCache, l *zap.Logger, userDao userDao.UserDAO) AlertManagerRuleService {
 	return &alertManagerRuleService{
-		dao:     dao,
-		userDao: userDao,
-		l:       l,
-		cache:   cache,
+		dao:      dao,
+		testDao:  testDao,
+		groupDao: groupDao,
+		userDao:  userDao,
+		l:        l,
+		cache:    cache,
 	}
 }
 
@@ -86,6 +90,11 @@ func (a *alertManagerRuleService) CreateMonitorAlertRule(ctx context.Context, mo
 		return errors.New("告警规则已存在")
 	}
 
+	// 加入规则组时，规则必须与规则组属于同一个采集池
+	if err := checkRuleGroupPool(ctx, a.groupDao, monitorAlertRule.RuleGroupID, monitorAlertRule.PoolID); err != nil {
+		return err
+	}
+
 	// 创建告警规则
 	if err := a.dao.CreateMonitorAlertRule(ctx, monitorAlertRule); err != nil {
 		a.l.Error("创建告警规则失败", zap.Error(err))
@@ -93,7 +102,7 @@ func (a *alertManagerRuleService) CreateMonitorAlertRule(ctx context.Context, mo
 	}
 
 	// 更新缓存
-	if err := a.cache.MonitorCacheManager(ctx); err != nil {
+	if err := a.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, fmt.Sprintf("创建告警规则: %s", monitorAlertRule.Name))); err != nil {
 		a.l.Error("更新缓存失败", zap.Error(err))
 		return err
 	}
@@ -102,6 +111,31 @@ func (a *alertManagerRuleService) CreateMonitorAlertRule(ctx context.Context, mo
 }
 
 func (a *alertManagerRuleService) UpdateMonitorAlertRule(ctx context.Context, monitorAlertRule *model.MonitorAlertRule) error {
+	// 加入规则组时，规则必须与规则组属于同一个采集池
+	if err := checkRuleGroupPool(ctx, a.groupDao, monitorAlertRule.RuleGroupID, monitorAlertRule.PoolID); err != nil {
+		return err
+	}
+
+	// 要求单元测试通过时，使用更新后的规则运行已有的测试用例
+	requireTestPass := monitorAlertRule.RequireTestPass
+	if requireTestPass == 0 {
+		old, err := a.dao.GetMonitorAlertRuleById(ctx, monitorAlertRule.ID)
+		if err != nil {
+			return err
+		}
+		requireTestPass = old.RequireTestPass
+	}
+
+	if requireTestPass == 1 {
+		rule, err := pkg.AlertRuleToRulefmt(monitorAlertRule)
+		if err != nil {
+			return err
+		}
+		if err := checkRuleTests(ctx, a.testDao, model.RuleTypeAlert, monitorAlertRule.ID, rule); err != nil {
+			return err
+		}
+	}
+
 	// 更新告警规则
 	if err := a.dao.UpdateMonitorAlertRule(ctx, monitorAlertRule); err != nil {
 		a.l.Error("更新告警规则失败", zap.Error(err))
@@ -109,7 +143,7 @@ func (a *alertManagerRuleService) UpdateMonitorAlertRule(ctx context.Context, mo
 	}
 
 	// 更新缓存
-	if err := a.cache.MonitorCacheManager(ctx); err != nil {
+	if err := a.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, fmt.Sprintf("更新告警规则: %s", monitorAlertRule.Name))); err != nil {
 		a.l.Error("更新缓存失败", zap.Error(err))
 		return err
 	}
@@ -124,7 +158,7 @@ func (a *alertManagerRuleService) EnableSwitchMonitorAlertRule(ctx context.Conte
 	}
 
 	// 更新缓存
-	if err := a.cache.MonitorCacheManager(ctx); err != nil {
+	if err := a.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, fmt.Sprintf("切换告警规则启用状态: ID %d", id))); err != nil {
 		a.l.Error("更新缓存失败", zap.Error(err))
 		return err
 	}
@@ -140,7 +174,7 @@ func (a *alertManagerRuleService) BatchEnableSwitchMonitorAlertRule(ctx context.
 	}
 
 	// 更新缓存
-	if err := a.cache.MonitorCacheManager(ctx); err != nil {
+	if err := a.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, fmt.Sprintf("批量切换告警规则启用状态: ID %v", ids))); err != nil {
 		a.l.Error("更新缓存失败", zap.Error(err))
 		return err
 	}
@@ -155,8 +189,13 @@ func (a *alertManagerRuleService) DeleteMonitorAlertRule(ctx context.Context, id
 		return err
 	}
 
+	// 删除告警规则关联的单元测试
+	if err := a.testDao.DeleteRuleTestsByRule(ctx, model.RuleTypeAlert, id); err != nil {
+		a.l.Warn("删除告警规则的单元测试失败", zap.Error(err), zap.Int("id", id))
+	}
+
 	// 更新缓存
-	if err := a.cache.MonitorCacheManager(ctx); err != nil {
+	if err := a.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, fmt.Sprintf("删除告警规则: ID %d", id))); err != nil {
 		a.l.Error("更新缓存失败", zap.Error(err))
 		return err
 	}
@@ -0,0 +1,462 @@
+package alert
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/cache"
+	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/alert"
+	scrapeDao "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/scrape"
+	"github.com/GoSimplicity/AI-CloudOps/internal/tree/dao/tree_node"
+	userDao "github.com/GoSimplicity/AI-CloudOps/internal/user/dao"
+	pkg "github.com/GoSimplicity/AI-CloudOps/pkg/utils/prometheus"
+	"go.uber.org/zap"
+	"strings"
+)
+
+type AlertManagerRuleTemplateService interface {
+	GetMonitorAlertRuleTemplateList(ctx context.Context, searchName *string) ([]*model.MonitorAlertRuleTemplate, error)
+	// GetMonitorAlertRuleTemplateDetail 获取模板及由模板生成的告警规则
+	GetMonitorAlertRuleTemplateDetail(ctx context.Context, id int) (*model.RuleTemplateDetailResp, error)
+	CreateMonitorAlertRuleTemplate(ctx context.Context, template *model.MonitorAlertRuleTemplate) error
+	// UpdateMonitorAlertRuleTemplate 更新模板并重新渲染所有由模板生成的告警规则，返回被覆盖的偏离
+	UpdateMonitorAlertRuleTemplate(ctx context.Context, template *model.MonitorAlertRuleTemplate) (*model.RuleTemplateRenderResp, error)
+	// DeleteMonitorAlertRuleTemplate 删除模板，已生成的告警规则保留为普通告警规则
+	DeleteMonitorAlertRuleTemplate(ctx context.Context, id int) error
+	// InstantiateMonitorAlertRuleTemplate 针对树节点实例化模板，节点已有实例时使用新的参数重新生成
+	InstantiateMonitorAlertRuleTemplate(ctx context.Context, req *model.RuleTemplateInstantiateReq, userID int) (*model.RuleTemplateRenderResp, error)
+	// GetMonitorAlertRuleTemplateDrift 获取与模板当前渲染结果不一致的告警规则
+	GetMonitorAlertRuleTemplateDrift(ctx context.Context, id int) ([]*model.RuleTemplateDrift, error)
+}
+
+type alertManagerRuleTemplateService struct {
+	dao         alert.AlertManagerRuleTemplateDAO
+	ruleDao     alert.AlertManagerRuleDAO
+	testDao     alert.AlertManagerRuleTestDAO
+	groupDao    alert.AlertManagerRuleGroupDAO
+	poolDao     scrapeDao.ScrapePoolDAO
+	treeNodeDao tree_node.TreeNodeDAO
+	cache       cache.MonitorCache
+	userDao     userDao.UserDAO
+	l           *zap.Logger
+}
+
+func NewAlertManagerRuleTemplateService(dao alert.AlertManagerRuleTemplateDAO, ruleDao alert.AlertManagerRuleDAO, testDao alert.AlertManagerRuleTestDAO, groupDao alert.AlertManagerRuleGroupDAO, poolDao scrapeDao.ScrapePoolDAO, treeNodeDao tree_node.TreeNodeDAO, cache cache.MonitorCache, userDao userDao.UserDAO, l *zap.Logger) AlertManagerRuleTemplateService {
+	return &alertManagerRuleTemplateService{
+		dao:         dao,
+		ruleDao:     ruleDao,
+		testDao:     testDao,
+		groupDao:    groupDao,
+		poolDao:     poolDao,
+		treeNodeDao: treeNodeDao,
+		cache:       cache,
+		userDao:     userDao,
+		l:           l,
+	}
+}
+
+func (a *alertManagerRuleTemplateService) GetMonitorAlertRuleTemplateList(ctx context.Context, searchName *string) ([]*model.MonitorAlertRuleTemplate, error) {
+	templates, err := pkg.HandleList(ctx, searchName,
+		a.dao.SearchMonitorAlertRuleTemplateByName,
+		a.dao.GetMonitorAlertRuleTemplateList)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := a.dao.CountTemplateInstances(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	poolNames := make(map[int]string)
+	userNames := make(map[int]string)
+	for _, template := range templates {
+		if _, ok := poolNames[template.PoolID]; !ok {
+			if pool, err := a.poolDao.GetMonitorScrapePoolById(ctx, template.PoolID); err == nil {
+				poolNames[template.PoolID] = pool.Name
+			}
+		}
+		if _, ok := userNames[template.UserID]; !ok {
+			if user, err := a.userDao.GetUserByID(ctx, template.UserID); err == nil {
+				userNames[template.UserID] = user.Username
+			}
+		}
+
+		template.Key = fmt.Sprintf("%d", template.ID)
+		template.PoolName = poolNames[template.PoolID]
+		template.CreateUserName = userNames[template.UserID]
+		template.InstanceCount = counts[template.ID]
+	}
+
+	return templates, nil
+}
+
+func (a *alertManagerRuleTemplateService) GetMonitorAlertRuleTemplateDetail(ctx context.Context, id int) (*model.RuleTemplateDetailResp, error) {
+	template, err := a.dao.GetMonitorAlertRuleTemplateById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	instances, err := a.dao.GetTemplateInstances(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	template.InstanceCount = len(instances)
+	if pool, err := a.poolDao.GetMonitorScrapePoolById(ctx, template.PoolID); err == nil {
+		template.PoolName = pool.Name
+	}
+	if user, err := a.userDao.GetUserByID(ctx, template.UserID); err == nil {
+		template.CreateUserName = user.Username
+	}
+
+	return &model.RuleTemplateDetailResp{
+		Template:  template,
+		Instances: instances,
+	}, nil
+}
+
+func (a *alertManagerRuleTemplateService) CreateMonitorAlertRuleTemplate(ctx context.Context, template *model.MonitorAlertRuleTemplate) error {
+	if err := a.checkMonitorAlertRuleTemplate(ctx, template); err != nil {
+		return err
+	}
+
+	if err := a.dao.CreateMonitorAlertRuleTemplate(ctx, template); err != nil {
+		a.l.Error("创建告警规则模板失败", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (a *alertManagerRuleTemplateService) UpdateMonitorAlertRuleTemplate(ctx context.Context, template *model.MonitorAlertRuleTemplate) (*model.RuleTemplateRenderResp, error) {
+	old, err := a.dao.GetMonitorAlertRuleTemplateById(ctx, template.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.checkMonitorAlertRuleTemplate(ctx, template); err != nil {
+		return nil, err
+	}
+
+	instances, err := a.dao.GetTemplateInstances(ctx, template.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := a.getTreeNodes(ctx, instances)
+	if err != nil {
+		return nil, err
+	}
+
+	// 先按修改前的模板计算偏离，这些手工修改会在重新渲染时被覆盖
+	resp := &model.RuleTemplateRenderResp{
+		Created: []string{},
+		Updated: []string{},
+		Drifted: a.diffInstances(old, instances, nodes),
+	}
+
+	rendered := make([]*model.MonitorAlertRule, 0, len(instances))
+	for _, instance := range instances {
+		node, ok := nodes[instance.TreeNodeID]
+		if !ok {
+			return nil, fmt.Errorf("告警规则 %s 绑定的树节点 %d 不存在", instance.Name, instance.TreeNodeID)
+		}
+
+		rule, err := a.renderInstance(ctx, template, instance, node, instance.TemplateParams)
+		if err != nil {
+			return nil, fmt.Errorf("重新渲染告警规则 %s 失败: %w", instance.Name, err)
+		}
+		rendered = append(rendered, rule)
+		resp.Updated = append(resp.Updated, rule.Name)
+	}
+
+	if err := a.dao.SaveTemplateInstances(ctx, template, rendered); err != nil {
+		a.l.Error("更新告警规则模板失败", zap.Error(err), zap.Int("id", template.ID))
+		return nil, err
+	}
+
+	if len(rendered) > 0 {
+		if err := a.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, fmt.Sprintf("更新告警规则模板: %s", template.Name))); err != nil {
+			a.l.Error("更新缓存失败", zap.Error(err))
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
+
+func (a *alertManagerRuleTemplateService) DeleteMonitorAlertRuleTemplate(ctx context.Context, id int) error {
+	if err := a.dao.DeleteMonitorAlertRuleTemplate(ctx, id); err != nil {
+		a.l.Error("删除告警规则模板失败", zap.Error(err), zap.Int("id", id))
+		return err
+	}
+
+	return nil
+}
+
+func (a *alertManagerRuleTemplateService) InstantiateMonitorAlertRuleTemplate(ctx context.Context, req *model.RuleTemplateInstantiateReq, userID int) (*model.RuleTemplateRenderResp, error) {
+	template, err := a.dao.GetMonitorAlertRuleTemplateById(ctx, req.TemplateID)
+	if err != nil {
+		return nil, err
+	}
+
+	instances, err := a.dao.GetTemplateInstances(ctx, template.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[int]*model.MonitorAlertRule, len(instances))
+	for _, instance := range instances {
+		existing[instance.TreeNodeID] = instance
+	}
+
+	resp := &model.RuleTemplateRenderResp{
+		Created: []string{},
+		Updated: []string{},
+		Drifted: []*model.RuleTemplateDrift{},
+	}
+
+	seen := make(map[int]bool, len(req.Instances))
+	rendered := make([]*model.MonitorAlertRule, 0, len(req.Instances))
+	for _, item := range req.Instances {
+		if seen[item.TreeNodeID] {
+			return nil, fmt.Errorf("树节点 %d 重复", item.TreeNodeID)
+		}
+		seen[item.TreeNodeID] = true
+
+		node, err := a.treeNodeDao.GetByIDNoPreload(ctx, item.TreeNodeID)
+		if err != nil {
+			return nil, err
+		}
+		if node == nil {
+			return nil, fmt.Errorf("树节点 %d 不存在", item.TreeNodeID)
+		}
+
+		instance := existing[item.TreeNodeID]
+		if instance != nil {
+			if drift := a.diffInstance(template, instance, node); drift != nil {
+				resp.Drifted = append(resp.Drifted, drift)
+			}
+		}
+
+		rule, err := a.renderInstance(ctx, template, instance, node, item.Params)
+		if err != nil {
+			return nil, fmt.Errorf("渲染树节点 %s 的告警规则失败: %w", node.Title, err)
+		}
+
+		if instance == nil {
+			rule.UserID = userID
+			exists, err := a.ruleDao.CheckMonitorAlertRuleNameExists(ctx, rule)
+			if err != nil {
+				return nil, err
+			}
+			if exists {
+				return nil, fmt.Errorf("告警规则 %s 已存在", rule.Name)
+			}
+			resp.Created = append(resp.Created, rule.Name)
+		} else {
+			resp.Updated = append(resp.Updated, rule.Name)
+		}
+		rendered = append(rendered, rule)
+	}
+
+	if err := a.dao.SaveTemplateInstances(ctx, nil, rendered); err != nil {
+		a.l.Error("实例化告警规则模板失败", zap.Error(err), zap.Int("templateId", template.ID))
+		return nil, err
+	}
+
+	if err := a.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, fmt.Sprintf("实例化告警规则模板: %s", template.Name))); err != nil {
+		a.l.Error("更新缓存失败", zap.Error(err))
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (a *alertManagerRuleTemplateService) GetMonitorAlertRuleTemplateDrift(ctx context.Context, id int) ([]*model.RuleTemplateDrift, error) {
+	template, err := a.dao.GetMonitorAlertRuleTemplateById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	instances, err := a.dao.GetTemplateInstances(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := a.getTreeNodes(ctx, instances)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.diffInstances(template, instances, nodes), nil
+}
+
+// checkMonitorAlertRuleTemplate 检查模板名称、采集池和参数定义
+func (a *alertManagerRuleTemplateService) checkMonitorAlertRuleTemplate(ctx context.Context, template *model.MonitorAlertRuleTemplate) error {
+	for i := range template.Parameters {
+		template.Parameters[i].Name = strings.TrimSpace(template.Parameters[i].Name)
+		if template.Parameters[i].Type == "" {
+			template.Parameters[i].Type = model.RuleTemplateParamString
+		}
+	}
+
+	if err := pkg.ValidateRuleTemplate(template); err != nil {
+		return err
+	}
+
+	exists, err := a.dao.CheckMonitorAlertRuleTemplateNameExists(ctx, template)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errors.New("告警规则模板已存在")
+	}
+
+	if _, err := a.poolDao.GetMonitorScrapePoolById(ctx, template.PoolID); err != nil {
+		return fmt.Errorf("获取采集池失败: %w", err)
+	}
+
+	return nil
+}
+
+// renderInstance 渲染树节点的告警规则，instance 为 nil 时生成新的告警规则
+func (a *alertManagerRuleTemplateService) renderInstance(ctx context.Context, template *model.MonitorAlertRuleTemplate, instance *model.MonitorAlertRule, node *model.TreeNode, params map[string]string) (*model.MonitorAlertRule, error) {
+	values, err := pkg.ResolveTemplateParams(template, params, node)
+	if err != nil {
+		return nil, err
+	}
+
+	rule, err := pkg.RenderAlertRuleTemplate(template, values)
+	if err != nil {
+		return nil, err
+	}
+	rule.TemplateParams = params
+
+	if instance == nil {
+		rule.Name = fmt.Sprintf("%s_%d", template.Name, node.ID)
+		rule.TreeNodeID = node.ID
+		rule.Enable = 1
+		rule.RequireTestPass = 2
+		return rule, nil
+	}
+
+	rule.ID = instance.ID
+	rule.Name = instance.Name
+	rule.TreeNodeID = instance.TreeNodeID
+
+	// 已加入规则组的告警规则必须与规则组属于同一个采集池
+	if err := checkRuleGroupPool(ctx, a.groupDao, instance.RuleGroupID, rule.PoolID); err != nil {
+		return nil, err
+	}
+
+	if instance.RequireTestPass == 1 {
+		ruleFmt, err := pkg.AlertRuleToRulefmt(rule)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkRuleTests(ctx, a.testDao, model.RuleTypeAlert, instance.ID, ruleFmt); err != nil {
+			return nil, err
+		}
+	}
+
+	return rule, nil
+}
+
+// diffInstances 对比所有实例与模板当前的渲染结果，只返回存在偏离的实例
+func (a *alertManagerRuleTemplateService) diffInstances(template *model.MonitorAlertRuleTemplate, instances []*model.MonitorAlertRule, nodes map[int]*model.TreeNode) []*model.RuleTemplateDrift {
+	drifts := make([]*model.RuleTemplateDrift, 0)
+	for _, instance := range instances {
+		node, ok := nodes[instance.TreeNodeID]
+		if !ok {
+			drifts = append(drifts, &model.RuleTemplateDrift{
+				RuleID:     instance.ID,
+				RuleName:   instance.Name,
+				TreeNodeID: instance.TreeNodeID,
+				Error:      fmt.Sprintf("树节点 %d 不存在", instance.TreeNodeID),
+			})
+			continue
+		}
+
+		if drift := a.diffInstance(template, instance, node); drift != nil {
+			drifts = append(drifts, drift)
+		}
+	}
+
+	return drifts
+}
+
+// diffInstance 对比单个实例与模板当前的渲染结果，没有偏离时返回 nil
+func (a *alertManagerRuleTemplateService) diffInstance(template *model.MonitorAlertRuleTemplate, instance *model.MonitorAlertRule, node *model.TreeNode) *model.RuleTemplateDrift {
+	drift := &model.RuleTemplateDrift{
+		RuleID:     instance.ID,
+		RuleName:   instance.Name,
+		TreeNodeID: instance.TreeNodeID,
+	}
+
+	values, err := pkg.ResolveTemplateParams(template, instance.TemplateParams, node)
+	if err != nil {
+		drift.Error = err.Error()
+		return drift
+	}
+
+	expected, err := pkg.RenderAlertRuleTemplate(template, values)
+	if err != nil {
+		drift.Error = err.Error()
+		return drift
+	}
+
+	drift.Fields = pkg.DiffRuleTemplateInstance(expected, instance)
+	if len(drift.Fields) == 0 {
+		return nil
+	}
+
+	return drift
+}
+
+// getTreeNodes 获取实例绑定的树节点，按ID索引
+func (a *alertManagerRuleTemplateService) getTreeNodes(ctx context.Context, instances []*model.MonitorAlertRule) (map[int]*model.TreeNode, error) {
+	ids := make([]int, 0, len(instances))
+	for _, instance := range instances {
+		ids = append(ids, instance.TreeNodeID)
+	}
+
+	list, err := a.treeNodeDao.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[int]*model.TreeNode, len(list))
+	for _, node := range list {
+		nodes[node.ID] = node
+	}
+
+	return nodes, nil
+}
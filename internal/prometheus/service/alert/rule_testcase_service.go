@@ -0,0 +1,236 @@
+package alert
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/alert"
+	userDao "github.com/GoSimplicity/AI-CloudOps/internal/user/dao"
+	pkg "github.com/GoSimplicity/AI-CloudOps/pkg/utils/prometheus"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+type AlertManagerRuleTestService interface {
+	GetRuleTestList(ctx context.Context, ruleType string, ruleID int) ([]*model.MonitorRuleTest, error)
+	CreateRuleTest(ctx context.Context, ruleTest *model.MonitorRuleTest) error
+	UpdateRuleTest(ctx context.Context, ruleTest *model.MonitorRuleTest) error
+	DeleteRuleTest(ctx context.Context, id int) error
+	RunRuleTests(ctx context.Context, req *model.RuleTestRunReq) (*model.RuleTestRunResp, error)
+}
+
+type alertManagerRuleTestService struct {
+	dao       alert.AlertManagerRuleTestDAO
+	ruleDao   alert.AlertManagerRuleDAO
+	recordDao alert.AlertManagerRecordDAO
+	userDao   userDao.UserDAO
+	l         *zap.Logger
+}
+
+func NewAlertManagerRuleTestService(dao alert.AlertManagerRuleTestDAO, ruleDao alert.AlertManagerRuleDAO, recordDao alert.AlertManagerRecordDAO, l *zap.Logger, userDao userDao.UserDAO) AlertManagerRuleTestService {
+	return &alertManagerRuleTestService{
+		dao:       dao,
+		ruleDao:   ruleDao,
+		recordDao: recordDao,
+		userDao:   userDao,
+		l:         l,
+	}
+}
+
+func (a *alertManagerRuleTestService) GetRuleTestList(ctx context.Context, ruleType string, ruleID int) ([]*model.MonitorRuleTest, error) {
+	ruleTests, err := a.dao.GetRuleTestList(ctx, ruleType, ruleID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ruleTest := range ruleTests {
+		user, err := a.userDao.GetUserByID(ctx, ruleTest.UserID)
+		if err != nil {
+			a.l.Warn("获取创建用户名失败", zap.Error(err), zap.Int("userId", ruleTest.UserID))
+			continue
+		}
+		ruleTest.CreateUserName = user.Username
+	}
+
+	return ruleTests, nil
+}
+
+func (a *alertManagerRuleTestService) CreateRuleTest(ctx context.Context, ruleTest *model.MonitorRuleTest) error {
+	if _, err := a.getRule(ctx, ruleTest.RuleType, ruleTest.RuleID); err != nil {
+		return err
+	}
+
+	if err := checkRuleTestExpectation(ruleTest); err != nil {
+		return err
+	}
+
+	if err := a.dao.CreateRuleTest(ctx, ruleTest); err != nil {
+		a.l.Error("创建规则单元测试失败", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (a *alertManagerRuleTestService) UpdateRuleTest(ctx context.Context, ruleTest *model.MonitorRuleTest) error {
+	old, err := a.dao.GetRuleTestById(ctx, ruleTest.ID)
+	if err != nil {
+		return err
+	}
+
+	// 测试用例关联的规则不允许修改
+	ruleTest.RuleType = old.RuleType
+	ruleTest.RuleID = old.RuleID
+	if err := checkRuleTestExpectation(ruleTest); err != nil {
+		return err
+	}
+
+	if err := a.dao.UpdateRuleTest(ctx, ruleTest); err != nil {
+		a.l.Error("更新规则单元测试失败", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (a *alertManagerRuleTestService) DeleteRuleTest(ctx context.Context, id int) error {
+	if err := a.dao.DeleteRuleTest(ctx, id); err != nil {
+		a.l.Error("删除规则单元测试失败", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// RunRuleTests 使用规则当前保存的定义运行测试用例，并记录每个用例最近一次的运行结果
+func (a *alertManagerRuleTestService) RunRuleTests(ctx context.Context, req *model.RuleTestRunReq) (*model.RuleTestRunResp, error) {
+	rule, err := a.getRule(ctx, req.RuleType, req.RuleID)
+	if err != nil {
+		return nil, err
+	}
+
+	ruleTests, err := a.dao.GetRuleTestList(ctx, req.RuleType, req.RuleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.IDs) > 0 {
+		ids := make(map[int]struct{}, len(req.IDs))
+		for _, id := range req.IDs {
+			ids[id] = struct{}{}
+		}
+
+		var selected []*model.MonitorRuleTest
+		for _, ruleTest := range ruleTests {
+			if _, ok := ids[ruleTest.ID]; ok {
+				selected = append(selected, ruleTest)
+			}
+		}
+		ruleTests = selected
+	}
+
+	if len(ruleTests) == 0 {
+		return nil, errors.New("规则没有可运行的单元测试")
+	}
+
+	resp := pkg.RunRuleTests(ctx, rule, ruleTests)
+
+	now := time.Now().Unix()
+	for _, result := range resp.Results {
+		if err := a.dao.UpdateRuleTestResult(ctx, result.TestID, result.Passed, now); err != nil {
+			a.l.Warn("记录规则单元测试结果失败", zap.Error(err), zap.Int("id", result.TestID))
+		}
+	}
+
+	return resp, nil
+}
+
+// getRule 获取测试用例关联的规则，并转换为规则文件格式
+func (a *alertManagerRuleTestService) getRule(ctx context.Context, ruleType string, ruleID int) (rulefmt.Rule, error) {
+	switch ruleType {
+	case model.RuleTypeAlert:
+		rule, err := a.ruleDao.GetMonitorAlertRuleById(ctx, ruleID)
+		if err != nil {
+			return rulefmt.Rule{}, fmt.Errorf("获取告警规则失败: %w", err)
+		}
+		return pkg.AlertRuleToRulefmt(rule)
+	case model.RuleTypeRecord:
+		rule, err := a.recordDao.GetMonitorRecordRuleById(ctx, ruleID)
+		if err != nil {
+			return rulefmt.Rule{}, fmt.Errorf("获取预聚合规则失败: %w", err)
+		}
+		return pkg.RecordRuleToRulefmt(rule), nil
+	default:
+		return rulefmt.Rule{}, fmt.Errorf("不支持的规则类型: %s", ruleType)
+	}
+}
+
+// checkRuleTestExpectation 检查测试用例的期望结果与规则类型是否匹配
+func checkRuleTestExpectation(ruleTest *model.MonitorRuleTest) error {
+	if ruleTest.RuleType == model.RuleTypeAlert && (len(ruleTest.ExpSamples) > 0 || ruleTest.Expr != "") {
+		return errors.New("告警规则的测试用例只能设置期望的告警")
+	}
+	if ruleTest.RuleType == model.RuleTypeRecord && len(ruleTest.ExpAlerts) > 0 {
+		return errors.New("预聚合规则的测试用例只能设置期望的样本")
+	}
+
+	return nil
+}
+
+// checkRuleTests 使用即将保存的规则定义运行规则已有的测试用例，有用例未通过时返回错误
+func checkRuleTests(ctx context.Context, dao alert.AlertManagerRuleTestDAO, ruleType string, ruleID int, rule rulefmt.Rule) error {
+	ruleTests, err := dao.GetRuleTestList(ctx, ruleType, ruleID)
+	if err != nil {
+		return err
+	}
+	if len(ruleTests) == 0 {
+		return nil
+	}
+
+	resp := pkg.RunRuleTests(ctx, rule, ruleTests)
+	if resp.Passed {
+		return nil
+	}
+
+	var failed []string
+	for _, result := range resp.Results {
+		if result.Passed {
+			continue
+		}
+		if result.Error != "" {
+			failed = append(failed, fmt.Sprintf("%s: %s", result.Name, result.Error))
+			continue
+		}
+		failed = append(failed, fmt.Sprintf("%s:\n%s", result.Name, result.Diff))
+	}
+
+	return fmt.Errorf("规则单元测试未通过\n%s", strings.Join(failed, "\n"))
+}
@@ -28,11 +28,13 @@ package alert
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/GoSimplicity/AI-CloudOps/internal/model"
 	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/cache"
 	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/alert"
 	userDao "github.com/GoSimplicity/AI-CloudOps/internal/user/dao"
 	pkg "github.com/GoSimplicity/AI-CloudOps/pkg/utils/prometheus"
+	altconfig "github.com/prometheus/alertmanager/config"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
@@ -47,15 +49,19 @@ type AlertManagerSendService interface {
 type alertManagerSendService struct {
 	dao     alert.AlertManagerSendDAO
 	ruleDao alert.AlertManagerRuleDAO
+	timeDao alert.AlertManagerTimeIntervalDAO
+	poolDao alert.AlertManagerPoolDAO
 	cache   cache.MonitorCache
 	userDao userDao.UserDAO
 	l       *zap.Logger
 }
 
-func NewAlertManagerSendService(dao alert.AlertManagerSendDAO, ruleDao alert.AlertManagerRuleDAO, cache cache.MonitorCache, l *zap.Logger, userDao userDao.UserDAO) AlertManagerSendService {
+func NewAlertManagerSendService(dao alert.AlertManagerSendDAO, ruleDao alert.AlertManagerRuleDAO, timeDao alert.AlertManagerTimeIntervalDAO, poolDao alert.AlertManagerPoolDAO, cache cache.MonitorCache, l *zap.Logger, userDao userDao.UserDAO) AlertManagerSendService {
 	return &alertManagerSendService{
 		dao:     dao,
 		ruleDao: ruleDao,
+		timeDao: timeDao,
+		poolDao: poolDao,
 		userDao: userDao,
 		l:       l,
 		cache:   cache,
@@ -80,6 +86,21 @@ func (a *alertManagerSendService) CreateMonitorSendGroup(ctx context.Context, mo
 		return errors.New("发送组已存在")
 	}
 
+	// 检查静默和生效时间段
+	if err := checkSendGroupTimeIntervals(ctx, a.timeDao, monitorSendGroup); err != nil {
+		return err
+	}
+
+	// 检查邮件和第三方Webhook通知设置
+	if err := a.checkSendGroupNotify(ctx, monitorSendGroup); err != nil {
+		return err
+	}
+
+	// 检查分组、匹配条件和子路由设置
+	if err := a.checkSendGroupRouting(ctx, monitorSendGroup); err != nil {
+		return err
+	}
+
 	// 创建发送组
 	if err := a.dao.CreateMonitorSendGroup(ctx, monitorSendGroup); err != nil {
 		a.l.Error("创建发送组失败", zap.Error(err))
@@ -87,7 +108,7 @@ func (a *alertManagerSendService) CreateMonitorSendGroup(ctx context.Context, mo
 	}
 
 	// 更新缓存
-	if err := a.cache.MonitorCacheManager(ctx); err != nil {
+	if err := a.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, fmt.Sprintf("创建发送组: %s", monitorSendGroup.Name))); err != nil {
 		a.l.Error("更新缓存失败", zap.Error(err))
 		return err
 	}
@@ -96,6 +117,21 @@ func (a *alertManagerSendService) CreateMonitorSendGroup(ctx context.Context, mo
 }
 
 func (a *alertManagerSendService) UpdateMonitorSendGroup(ctx context.Context, monitorSendGroup *model.MonitorSendGroup) error {
+	// 检查静默和生效时间段
+	if err := checkSendGroupTimeIntervals(ctx, a.timeDao, monitorSendGroup); err != nil {
+		return err
+	}
+
+	// 检查邮件和第三方Webhook通知设置
+	if err := a.checkSendGroupNotify(ctx, monitorSendGroup); err != nil {
+		return err
+	}
+
+	// 检查分组、匹配条件和子路由设置
+	if err := a.checkSendGroupRouting(ctx, monitorSendGroup); err != nil {
+		return err
+	}
+
 	// 更新发送组
 	if err := a.dao.UpdateMonitorSendGroup(ctx, monitorSendGroup); err != nil {
 		a.l.Error("更新发送组失败", zap.Error(err))
@@ -103,7 +139,7 @@ func (a *alertManagerSendService) UpdateMonitorSendGroup(ctx context.Context, mo
 	}
 
 	// 更新缓存
-	if err := a.cache.MonitorCacheManager(ctx); err != nil {
+	if err := a.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, fmt.Sprintf("更新发送组: %s", monitorSendGroup.Name))); err != nil {
 		a.l.Error("更新缓存失败", zap.Error(err))
 		return err
 	}
@@ -123,6 +159,23 @@ func (a *alertManagerSendService) DeleteMonitorSendGroup(ctx context.Context, id
 		return errors.New("发送组存在关联资源，无法删除")
 	}
 
+	// 检查是否有其他发送组的子路由将告警交给该发送组
+	sendGroup, err := a.dao.GetMonitorSendGroupById(ctx, id)
+	if err != nil {
+		a.l.Error("删除发送组失败：获取发送组时出错", zap.Error(err))
+		return err
+	}
+	poolSendGroups, err := a.dao.GetMonitorSendGroupByPoolId(ctx, sendGroup.PoolID)
+	if err != nil {
+		a.l.Error("删除发送组失败：获取同实例发送组时出错", zap.Error(err))
+		return err
+	}
+	for _, other := range poolSendGroups {
+		if other.ID != id && routesReferenceSendGroup(other.Routes, id) {
+			return fmt.Errorf("发送组 %s 的子路由引用了该发送组，无法删除", other.Name)
+		}
+	}
+
 	// 删除发送组
 	if err := a.dao.DeleteMonitorSendGroup(ctx, id); err != nil {
 		a.l.Error("删除发送组失败", zap.Error(err))
@@ -130,10 +183,49 @@ func (a *alertManagerSendService) DeleteMonitorSendGroup(ctx context.Context, id
 	}
 
 	// 更新缓存
-	if err := a.cache.MonitorCacheManager(ctx); err != nil {
+	if err := a.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, fmt.Sprintf("删除发送组: ID %d", id))); err != nil {
 		a.l.Error("更新缓存失败", zap.Error(err))
 		return err
 	}
 
 	return nil
 }
+
+// checkSendGroupNotify 检查发送组的邮件和第三方Webhook通知设置，邮件通知依赖所在 AlertManager 实例的 SMTP 设置
+func (a *alertManagerSendService) checkSendGroupNotify(ctx context.Context, sendGroup *model.MonitorSendGroup) error {
+	pool, err := a.poolDao.GetAlertPoolByID(ctx, sendGroup.PoolID)
+	if err != nil {
+		return fmt.Errorf("获取AlertManager实例失败: %w", err)
+	}
+
+	return pkg.ValidateSendGroupNotify(sendGroup, pool)
+}
+
+// checkSendGroupRouting 检查发送组的分组、匹配条件和子路由，子路由只能将告警交给同一 AlertManager 实例的发送组
+func (a *alertManagerSendService) checkSendGroupRouting(ctx context.Context, sendGroup *model.MonitorSendGroup) error {
+	poolSendGroups, err := a.dao.GetMonitorSendGroupByPoolId(ctx, sendGroup.PoolID)
+	if err != nil {
+		return fmt.Errorf("获取同实例发送组失败: %w", err)
+	}
+
+	receiverNames := make(map[int]string, len(poolSendGroups)+1)
+	for _, poolSendGroup := range poolSendGroups {
+		receiverNames[poolSendGroup.ID] = poolSendGroup.Name
+	}
+	if sendGroup.ID != 0 {
+		receiverNames[sendGroup.ID] = sendGroup.Name
+	}
+
+	return pkg.ApplySendGroupRouting(&altconfig.Route{Receiver: sendGroup.Name}, sendGroup, receiverNames)
+}
+
+// routesReferenceSendGroup 判断子路由中是否有路由将告警交给指定的发送组
+func routesReferenceSendGroup(routes []model.SendGroupRoute, sendGroupID int) bool {
+	for _, route := range routes {
+		if route.ReceiverSendGroupID == sendGroupID || routesReferenceSendGroup(route.Routes, sendGroupID) {
+			return true
+		}
+	}
+
+	return false
+}
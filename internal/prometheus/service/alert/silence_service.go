@@ -0,0 +1,441 @@
+package alert
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/alert"
+	userDao "github.com/GoSimplicity/AI-CloudOps/internal/user/dao"
+	pkg "github.com/GoSimplicity/AI-CloudOps/pkg/utils/prometheus"
+	pm "github.com/prometheus/common/model"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	defaultAlertManagerPort = 9093
+	maxSilenceDuration      = 30 * 24 * time.Hour // 单次静默的最长时间
+	maxSilenceCommentLength = 500
+	silenceRequestTimeout   = 10 * time.Second
+)
+
+type AlertManagerSilenceService interface {
+	GetMonitorSilenceList(ctx context.Context, req *model.SilenceListReq) ([]*model.MonitorSilence, error)
+	GetMonitorSilenceById(ctx context.Context, id int) (*model.MonitorSilence, error)
+	// CreateMonitorSilence 创建静默并下发到实例池的每个实例，至少一个实例成功即保存，失败的实例在 InstanceErrors 中返回
+	CreateMonitorSilence(ctx context.Context, req *model.SilenceCreateReq, userId int) (*model.MonitorSilence, error)
+	// UpdateMonitorSilence 修改未过期的静默，AlertManager 可能为修改后的静默分配新的ID
+	UpdateMonitorSilence(ctx context.Context, req *model.SilenceUpdateReq, userId int) (*model.MonitorSilence, error)
+	// ExpireMonitorSilence 使静默立即过期，并解除与告警事件的关联；部分实例失败时返回静默和错误，静默保持生效且只保留失败实例的静默ID
+	ExpireMonitorSilence(ctx context.Context, id int) (*model.MonitorSilence, error)
+}
+
+type alertManagerSilenceService struct {
+	dao              alert.AlertManagerSilenceDAO
+	eventDao         alert.AlertManagerEventDAO
+	sendDao          alert.AlertManagerSendDAO
+	poolDao          alert.AlertManagerPoolDAO
+	userDao          userDao.UserDAO
+	client           *http.Client
+	alertManagerPort int
+	l                *zap.Logger
+}
+
+func NewAlertManagerSilenceService(dao alert.AlertManagerSilenceDAO, eventDao alert.AlertManagerEventDAO, sendDao alert.AlertManagerSendDAO, poolDao alert.AlertManagerPoolDAO, l *zap.Logger, userDao userDao.UserDAO) AlertManagerSilenceService {
+	alertManagerPort := viper.GetInt("prometheus.alertmanager_port")
+	if alertManagerPort == 0 {
+		alertManagerPort = defaultAlertManagerPort
+	}
+
+	return &alertManagerSilenceService{
+		dao:              dao,
+		eventDao:         eventDao,
+		sendDao:          sendDao,
+		poolDao:          poolDao,
+		userDao:          userDao,
+		client:           &http.Client{Timeout: silenceRequestTimeout},
+		alertManagerPort: alertManagerPort,
+		l:                l,
+	}
+}
+
+func (a *alertManagerSilenceService) GetMonitorSilenceList(ctx context.Context, req *model.SilenceListReq) ([]*model.MonitorSilence, error) {
+	silences, err := a.dao.GetMonitorSilenceList(ctx, req.PoolID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	poolNames := make(map[int]string)
+	result := make([]*model.MonitorSilence, 0, len(silences))
+	for _, silence := range silences {
+		silence.State = silenceState(silence, now)
+		if req.State != "" && silence.State != req.State {
+			continue
+		}
+
+		silence.Key = fmt.Sprintf("%d", silence.ID)
+		silence.PoolName = a.getPoolName(ctx, poolNames, silence.PoolID)
+		result = append(result, silence)
+	}
+
+	return result, nil
+}
+
+func (a *alertManagerSilenceService) GetMonitorSilenceById(ctx context.Context, id int) (*model.MonitorSilence, error) {
+	silence, err := a.dao.GetMonitorSilenceById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	silence.Key = fmt.Sprintf("%d", silence.ID)
+	silence.State = silenceState(silence, time.Now())
+	silence.PoolName = a.getPoolName(ctx, make(map[int]string), silence.PoolID)
+
+	return silence, nil
+}
+
+func (a *alertManagerSilenceService) CreateMonitorSilence(ctx context.Context, req *model.SilenceCreateReq, userId int) (*model.MonitorSilence, error) {
+	poolID := req.PoolID
+	matchers := req.Matchers
+
+	// 从告警事件创建时，实例池取事件发送组所在的实例，未指定匹配条件时使用事件的标签
+	var event *model.MonitorAlertEvent
+	if req.EventID > 0 {
+		var err error
+		event, err = a.eventDao.GetAlertEventByID(ctx, req.EventID)
+		if err != nil {
+			return nil, fmt.Errorf("获取告警事件失败: %w", err)
+		}
+
+		sendGroup, err := a.sendDao.GetMonitorSendGroupById(ctx, event.SendGroupID)
+		if err != nil {
+			return nil, fmt.Errorf("获取告警事件的发送组失败: %w", err)
+		}
+		if poolID != 0 && poolID != sendGroup.PoolID {
+			return nil, errors.New("告警事件不属于指定的AlertManager实例")
+		}
+		poolID = sendGroup.PoolID
+
+		if len(matchers) == 0 {
+			matchers, err = pkg.AlertEventSilenceMatchers(event, req.UseName)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if poolID == 0 {
+		return nil, errors.New("必须指定AlertManager实例或告警事件")
+	}
+
+	pool, err := a.poolDao.GetAlertPoolByID(ctx, poolID)
+	if err != nil {
+		return nil, fmt.Errorf("获取AlertManager实例失败: %w", err)
+	}
+
+	silence := &model.MonitorSilence{
+		PoolID:   pool.ID,
+		EventID:  req.EventID,
+		Matchers: matchers,
+		UserID:   userId,
+	}
+	if err := a.fillSilence(ctx, silence, req.StartsAt, req.Duration, req.Comment, userId); err != nil {
+		return nil, err
+	}
+
+	amMatchers, err := pkg.BuildSilenceMatchers(silence.Matchers)
+	if err != nil {
+		return nil, err
+	}
+
+	silence.InstanceSilenceIDs, silence.InstanceErrors = a.postSilence(ctx, pool, silence, amMatchers, nil)
+	if len(silence.InstanceSilenceIDs) == 0 {
+		return nil, instanceErrors("创建静默失败", silence.InstanceErrors)
+	}
+
+	if err := a.dao.CreateMonitorSilence(ctx, silence); err != nil {
+		a.l.Error("保存静默记录失败", zap.Error(err), zap.Any("instanceSilenceIds", silence.InstanceSilenceIDs))
+		return nil, err
+	}
+
+	if event != nil {
+		if err := a.dao.LinkAlertEventSilence(ctx, event.ID, silence.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	a.l.Info("创建静默成功", zap.Int("id", silence.ID), zap.String("池子", pool.Name), zap.Any("failedInstances", silence.InstanceErrors))
+
+	silence.Key = fmt.Sprintf("%d", silence.ID)
+	silence.State = silenceState(silence, time.Now())
+	silence.PoolName = pool.Name
+	return silence, nil
+}
+
+func (a *alertManagerSilenceService) UpdateMonitorSilence(ctx context.Context, req *model.SilenceUpdateReq, userId int) (*model.MonitorSilence, error) {
+	silence, err := a.dao.GetMonitorSilenceById(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if silenceState(silence, time.Now()) == model.SilenceStateExpired {
+		return nil, errors.New("静默已过期，不能修改")
+	}
+
+	pool, err := a.poolDao.GetAlertPoolByID(ctx, silence.PoolID)
+	if err != nil {
+		return nil, fmt.Errorf("获取AlertManager实例失败: %w", err)
+	}
+
+	startsAt := req.StartsAt
+	if startsAt == 0 {
+		startsAt = silence.StartsAt.Unix()
+	}
+
+	silence.Matchers = req.Matchers
+	if err := a.fillSilence(ctx, silence, startsAt, req.Duration, req.Comment, userId); err != nil {
+		return nil, err
+	}
+
+	amMatchers, err := pkg.BuildSilenceMatchers(silence.Matchers)
+	if err != nil {
+		return nil, err
+	}
+
+	silenceIDs, errs := a.postSilence(ctx, pool, silence, amMatchers, silence.InstanceSilenceIDs)
+	if len(silenceIDs) == 0 {
+		return nil, instanceErrors("修改静默失败", errs)
+	}
+
+	// 修改失败的实例保留原静默ID，以便之后仍能使其过期
+	for instance, id := range silence.InstanceSilenceIDs {
+		if _, ok := silenceIDs[instance]; !ok {
+			silenceIDs[instance] = id
+		}
+	}
+	silence.InstanceSilenceIDs = silenceIDs
+
+	if err := a.dao.UpdateMonitorSilence(ctx, silence); err != nil {
+		return nil, err
+	}
+
+	a.l.Info("修改静默成功", zap.Int("id", silence.ID), zap.String("池子", pool.Name), zap.Any("failedInstances", errs))
+
+	silence.Key = fmt.Sprintf("%d", silence.ID)
+	silence.State = silenceState(silence, time.Now())
+	silence.PoolName = pool.Name
+	silence.InstanceErrors = errs
+	return silence, nil
+}
+
+func (a *alertManagerSilenceService) ExpireMonitorSilence(ctx context.Context, id int) (*model.MonitorSilence, error) {
+	silence, err := a.dao.GetMonitorSilenceById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if silenceState(silence, now) == model.SilenceStateExpired {
+		return nil, errors.New("静默已过期")
+	}
+
+	// 只保留过期失败实例的静默ID，重试时不再请求已经过期成功的实例
+	errs := make(map[string]string)
+	remaining := make(map[string]string)
+	for instance, silenceID := range silence.InstanceSilenceIDs {
+		baseURL := pkg.AlertManagerInstanceURL(instance, a.alertManagerPort)
+		if err := pkg.ExpireAlertManagerSilence(ctx, a.client, baseURL, silenceID); err != nil {
+			a.l.Error("AlertManager实例过期静默失败", zap.Error(err), zap.String("实例", instance), zap.String("silenceId", silenceID))
+			errs[instance] = err.Error()
+			remaining[instance] = silenceID
+		}
+	}
+
+	silence.Key = fmt.Sprintf("%d", silence.ID)
+	silence.PoolName = a.getPoolName(ctx, make(map[int]string), silence.PoolID)
+
+	// 部分实例失败时静默保持生效，也不解除与告警事件的关联，调用方可以再次过期重试
+	if len(errs) > 0 {
+		if len(remaining) < len(silence.InstanceSilenceIDs) {
+			silence.InstanceSilenceIDs = remaining
+			if err := a.dao.UpdateMonitorSilence(ctx, silence); err != nil {
+				return nil, err
+			}
+		}
+
+		silence.State = silenceState(silence, now)
+		silence.InstanceErrors = errs
+		return silence, instanceErrors("过期静默失败", errs)
+	}
+
+	silence.EndsAt = now
+	if err := a.dao.UpdateMonitorSilence(ctx, silence); err != nil {
+		return nil, err
+	}
+
+	if err := a.dao.UnlinkAlertEventSilence(ctx, silence.ID); err != nil {
+		return nil, err
+	}
+
+	a.l.Info("过期静默成功", zap.Int("id", silence.ID))
+
+	silence.State = model.SilenceStateExpired
+	return silence, nil
+}
+
+// fillSilence 校验并设置静默的开始、结束时间、说明和创建者，创建者取当前登录用户
+func (a *alertManagerSilenceService) fillSilence(ctx context.Context, silence *model.MonitorSilence, startsAt int64, duration, comment string, userId int) error {
+	d, err := pm.ParseDuration(duration)
+	if err != nil {
+		return fmt.Errorf("无效的静默时长: %w", err)
+	}
+	if d <= 0 {
+		return errors.New("静默时长必须大于0")
+	}
+	if time.Duration(d) > maxSilenceDuration {
+		return fmt.Errorf("静默时长不能超过 %s", pm.Duration(maxSilenceDuration))
+	}
+
+	comment = strings.TrimSpace(comment)
+	if comment == "" {
+		return errors.New("静默说明不能为空")
+	}
+	if len([]rune(comment)) > maxSilenceCommentLength {
+		return fmt.Errorf("静默说明不能超过 %d 个字符", maxSilenceCommentLength)
+	}
+
+	now := time.Now()
+	start := now
+	if startsAt > 0 && time.Unix(startsAt, 0).After(now) {
+		start = time.Unix(startsAt, 0)
+	}
+
+	user, err := a.userDao.GetUserByID(ctx, userId)
+	if err != nil {
+		return fmt.Errorf("获取当前用户失败: %w", err)
+	}
+	createdBy := user.RealName
+	if createdBy == "" {
+		createdBy = user.Username
+	}
+
+	silence.StartsAt = start
+	silence.EndsAt = start.Add(time.Duration(d))
+	silence.Comment = comment
+	silence.CreatedBy = createdBy
+
+	return nil
+}
+
+// postSilence 将静默提交到实例池的每个实例，existing 中有该实例的静默ID时修改原静默，返回成功实例的静默ID和失败实例的原因
+func (a *alertManagerSilenceService) postSilence(ctx context.Context, pool *model.MonitorAlertManagerPool, silence *model.MonitorSilence, matchers []pkg.AlertManagerSilenceMatcher, existing map[string]string) (map[string]string, map[string]string) {
+	silenceIDs := make(map[string]string)
+	errs := make(map[string]string)
+
+	for _, instance := range pool.AlertManagerInstances {
+		if instance == "" {
+			continue
+		}
+
+		amSilence := &pkg.AlertManagerSilence{
+			ID:        existing[instance],
+			Matchers:  matchers,
+			StartsAt:  silence.StartsAt,
+			EndsAt:    silence.EndsAt,
+			CreatedBy: silence.CreatedBy,
+			Comment:   silence.Comment,
+		}
+
+		baseURL := pkg.AlertManagerInstanceURL(instance, a.alertManagerPort)
+		silenceID, err := pkg.PostAlertManagerSilence(ctx, a.client, baseURL, amSilence)
+		if err != nil {
+			a.l.Error("AlertManager实例提交静默失败", zap.Error(err), zap.String("实例", instance), zap.String("池子", pool.Name))
+			errs[instance] = err.Error()
+			continue
+		}
+		silenceIDs[instance] = silenceID
+	}
+
+	if len(silenceIDs) == 0 && len(errs) == 0 {
+		errs[pool.Name] = "AlertManager实例池没有实例"
+	}
+	if len(errs) == 0 {
+		errs = nil
+	}
+
+	return silenceIDs, errs
+}
+
+// getPoolName 获取 AlertManager 实例名称
+func (a *alertManagerSilenceService) getPoolName(ctx context.Context, poolNames map[int]string, poolID int) string {
+	if name, ok := poolNames[poolID]; ok {
+		return name
+	}
+
+	pool, err := a.poolDao.GetAlertPoolByID(ctx, poolID)
+	if err != nil {
+		a.l.Warn("获取AlertManager实例失败", zap.Error(err), zap.Int("poolId", poolID))
+		poolNames[poolID] = ""
+		return ""
+	}
+
+	poolNames[poolID] = pool.Name
+	return pool.Name
+}
+
+// silenceState 根据开始和结束时间计算静默状态
+func silenceState(silence *model.MonitorSilence, now time.Time) string {
+	switch {
+	case !now.Before(silence.EndsAt):
+		return model.SilenceStateExpired
+	case now.Before(silence.StartsAt):
+		return model.SilenceStatePending
+	default:
+		return model.SilenceStateActive
+	}
+}
+
+// instanceErrors 将各实例的失败原因合并为一个错误
+func instanceErrors(prefix string, errs map[string]string) error {
+	instances := make([]string, 0, len(errs))
+	for instance := range errs {
+		instances = append(instances, instance)
+	}
+	slices.Sort(instances)
+
+	msgs := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", instance, errs[instance]))
+	}
+
+	return fmt.Errorf("%s: %s", prefix, strings.Join(msgs, "; "))
+}
@@ -0,0 +1,144 @@
+package alert
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/alert"
+	"go.uber.org/zap"
+)
+
+type fakeSilenceDAO struct {
+	alert.AlertManagerSilenceDAO
+	silence  *model.MonitorSilence
+	updates  int
+	unlinked bool
+}
+
+func (f *fakeSilenceDAO) GetMonitorSilenceById(_ context.Context, _ int) (*model.MonitorSilence, error) {
+	// 返回副本，模拟每次从数据库重新读取
+	silence := *f.silence
+	silence.InstanceSilenceIDs = make(map[string]string)
+	for instance, id := range f.silence.InstanceSilenceIDs {
+		silence.InstanceSilenceIDs[instance] = id
+	}
+	return &silence, nil
+}
+
+func (f *fakeSilenceDAO) UpdateMonitorSilence(_ context.Context, silence *model.MonitorSilence) error {
+	f.updates++
+	f.silence.EndsAt = silence.EndsAt
+	f.silence.InstanceSilenceIDs = silence.InstanceSilenceIDs
+	return nil
+}
+
+func (f *fakeSilenceDAO) UnlinkAlertEventSilence(_ context.Context, _ int) error {
+	f.unlinked = true
+	return nil
+}
+
+type fakePoolDAO struct {
+	alert.AlertManagerPoolDAO
+}
+
+func (fakePoolDAO) GetAlertPoolByID(_ context.Context, _ int) (*model.MonitorAlertManagerPool, error) {
+	return &model.MonitorAlertManagerPool{Name: "pool"}, nil
+}
+
+func TestExpireMonitorSilenceRetriesFailedInstances(t *testing.T) {
+	// 同一个 AlertManager 通过 127.0.0.1 和 localhost 两个实例名访问，localhost 先失败一次
+	failLocalhost := true
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, _ := net.SplitHostPort(r.Host)
+		if host == "localhost" && failLocalhost {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		deleted = append(deleted, r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	_, portStr, _ := net.SplitHostPort(server.Listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+
+	endsAt := time.Now().Add(time.Hour)
+	dao := &fakeSilenceDAO{silence: &model.MonitorSilence{
+		NoUniqueIndexModel: model.NoUniqueIndexModel{ID: 1},
+		StartsAt:           time.Now().Add(-time.Hour),
+		EndsAt:             endsAt,
+		InstanceSilenceIDs: map[string]string{
+			"127.0.0.1": "s1",
+			"localhost": "s2",
+		},
+	}}
+	svc := &alertManagerSilenceService{
+		dao:              dao,
+		poolDao:          fakePoolDAO{},
+		client:           server.Client(),
+		alertManagerPort: port,
+		l:                zap.NewNop(),
+	}
+
+	silence, err := svc.ExpireMonitorSilence(context.Background(), 1)
+	if err == nil {
+		t.Fatal("部分实例失败时应返回错误")
+	}
+	if silence == nil || silence.InstanceErrors["localhost"] == "" {
+		t.Fatalf("应返回失败实例: %+v", silence)
+	}
+	if silence.State != model.SilenceStateActive {
+		t.Fatalf("部分失败后静默应保持生效, 实际 %s", silence.State)
+	}
+	if !dao.silence.EndsAt.Equal(endsAt) || dao.unlinked {
+		t.Fatal("部分失败时不应修改结束时间或解除告警事件关联")
+	}
+	if len(dao.silence.InstanceSilenceIDs) != 1 || dao.silence.InstanceSilenceIDs["localhost"] != "s2" {
+		t.Fatalf("只应保留失败实例的静默ID, 实际 %v", dao.silence.InstanceSilenceIDs)
+	}
+
+	failLocalhost = false
+	deleted = nil
+	silence, err = svc.ExpireMonitorSilence(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("重试应成功: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0] != "/api/v2/silence/s2" {
+		t.Fatalf("重试只应请求失败的实例, 实际 %v", deleted)
+	}
+	if silence.State != model.SilenceStateExpired || !dao.unlinked {
+		t.Fatal("全部成功后静默应过期并解除告警事件关联")
+	}
+}
@@ -0,0 +1,198 @@
+package alert
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/cache"
+	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/alert"
+	userDao "github.com/GoSimplicity/AI-CloudOps/internal/user/dao"
+	pkg "github.com/GoSimplicity/AI-CloudOps/pkg/utils/prometheus"
+	"go.uber.org/zap"
+	"slices"
+)
+
+type AlertManagerTimeIntervalService interface {
+	GetMonitorTimeIntervalList(ctx context.Context, searchName *string) ([]*model.MonitorTimeInterval, error)
+	CreateMonitorTimeInterval(ctx context.Context, timeInterval *model.MonitorTimeInterval) error
+	UpdateMonitorTimeInterval(ctx context.Context, timeInterval *model.MonitorTimeInterval) error
+	// DeleteMonitorTimeInterval 删除时间段，仍被发送组引用时拒绝删除
+	DeleteMonitorTimeInterval(ctx context.Context, id int) error
+}
+
+type alertManagerTimeIntervalService struct {
+	dao     alert.AlertManagerTimeIntervalDAO
+	poolDao alert.AlertManagerPoolDAO
+	sendDao alert.AlertManagerSendDAO
+	cache   cache.MonitorCache
+	userDao userDao.UserDAO
+	l       *zap.Logger
+}
+
+func NewAlertManagerTimeIntervalService(dao alert.AlertManagerTimeIntervalDAO, poolDao alert.AlertManagerPoolDAO, sendDao alert.AlertManagerSendDAO, cache cache.MonitorCache, l *zap.Logger, userDao userDao.UserDAO) AlertManagerTimeIntervalService {
+	return &alertManagerTimeIntervalService{
+		dao:     dao,
+		poolDao: poolDao,
+		sendDao: sendDao,
+		cache:   cache,
+		userDao: userDao,
+		l:       l,
+	}
+}
+
+func (a *alertManagerTimeIntervalService) GetMonitorTimeIntervalList(ctx context.Context, searchName *string) ([]*model.MonitorTimeInterval, error) {
+	timeIntervals, err := pkg.HandleList(ctx, searchName,
+		a.dao.SearchMonitorTimeIntervalByName,
+		a.dao.GetMonitorTimeIntervalList)
+	if err != nil {
+		return nil, err
+	}
+
+	poolNames := make(map[int]string)
+	userNames := make(map[int]string)
+	for _, timeInterval := range timeIntervals {
+		if _, ok := poolNames[timeInterval.PoolID]; !ok {
+			if pool, err := a.poolDao.GetAlertPoolByID(ctx, timeInterval.PoolID); err == nil {
+				poolNames[timeInterval.PoolID] = pool.Name
+			}
+		}
+		if _, ok := userNames[timeInterval.UserID]; !ok {
+			if user, err := a.userDao.GetUserByID(ctx, timeInterval.UserID); err == nil {
+				userNames[timeInterval.UserID] = user.Username
+			}
+		}
+
+		timeInterval.Key = fmt.Sprintf("%d", timeInterval.ID)
+		timeInterval.PoolName = poolNames[timeInterval.PoolID]
+		timeInterval.CreateUserName = userNames[timeInterval.UserID]
+	}
+
+	return timeIntervals, nil
+}
+
+func (a *alertManagerTimeIntervalService) CreateMonitorTimeInterval(ctx context.Context, timeInterval *model.MonitorTimeInterval) error {
+	if err := a.checkMonitorTimeInterval(ctx, timeInterval); err != nil {
+		return err
+	}
+
+	if _, err := a.poolDao.GetAlertPoolByID(ctx, timeInterval.PoolID); err != nil {
+		return fmt.Errorf("获取AlertManager实例失败: %w", err)
+	}
+
+	if err := a.dao.CreateMonitorTimeInterval(ctx, timeInterval); err != nil {
+		a.l.Error("创建时间段失败", zap.Error(err))
+		return err
+	}
+
+	// 新建的时间段还没有被发送组引用，不需要更新配置
+	return nil
+}
+
+func (a *alertManagerTimeIntervalService) UpdateMonitorTimeInterval(ctx context.Context, timeInterval *model.MonitorTimeInterval) error {
+	if err := a.checkMonitorTimeInterval(ctx, timeInterval); err != nil {
+		return err
+	}
+
+	if err := a.dao.UpdateMonitorTimeInterval(ctx, timeInterval); err != nil {
+		a.l.Error("更新时间段失败", zap.Error(err))
+		return err
+	}
+
+	// 更新缓存
+	if err := a.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, fmt.Sprintf("更新时间段: %s", timeInterval.Name))); err != nil {
+		a.l.Error("更新缓存失败", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (a *alertManagerTimeIntervalService) DeleteMonitorTimeInterval(ctx context.Context, id int) error {
+	timeInterval, err := a.dao.GetMonitorTimeIntervalById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// 检查同一 AlertManager 实例的发送组是否仍在使用该时间段
+	sendGroups, err := a.sendDao.GetMonitorSendGroupByPoolId(ctx, timeInterval.PoolID)
+	if err != nil {
+		return err
+	}
+	for _, sendGroup := range sendGroups {
+		if slices.Contains(sendGroup.MuteTimeIntervalIDs, id) || slices.Contains(sendGroup.ActiveTimeIntervalIDs, id) {
+			return fmt.Errorf("时间段被发送组 %s 使用，无法删除", sendGroup.Name)
+		}
+	}
+
+	if err := a.dao.DeleteMonitorTimeInterval(ctx, id); err != nil {
+		a.l.Error("删除时间段失败", zap.Error(err))
+		return err
+	}
+
+	// 更新缓存
+	if err := a.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, fmt.Sprintf("删除时间段: %s", timeInterval.Name))); err != nil {
+		a.l.Error("更新缓存失败", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// checkMonitorTimeInterval 检查名称是否重复以及时间段条件的语法
+func (a *alertManagerTimeIntervalService) checkMonitorTimeInterval(ctx context.Context, timeInterval *model.MonitorTimeInterval) error {
+	exists, err := a.dao.CheckMonitorTimeIntervalNameExists(ctx, timeInterval)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errors.New("时间段已存在")
+	}
+
+	if _, err := pkg.BuildTimeInterval(timeInterval); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// checkSendGroupTimeIntervals 检查发送组引用的时间段存在且属于发送组所在的 AlertManager 实例
+func checkSendGroupTimeIntervals(ctx context.Context, dao alert.AlertManagerTimeIntervalDAO, sendGroup *model.MonitorSendGroup) error {
+	ids := append(append([]int{}, sendGroup.MuteTimeIntervalIDs...), sendGroup.ActiveTimeIntervalIDs...)
+	for _, id := range ids {
+		timeInterval, err := dao.GetMonitorTimeIntervalById(ctx, id)
+		if err != nil {
+			return fmt.Errorf("获取时间段 %d 失败: %w", id, err)
+		}
+		if timeInterval.PoolID != sendGroup.PoolID {
+			return fmt.Errorf("时间段 %s 与发送组不属于同一个AlertManager实例", timeInterval.Name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,259 @@
+package query
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	pkg "github.com/GoSimplicity/AI-CloudOps/pkg/utils/prometheus"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	pm "github.com/prometheus/common/model"
+	pc "github.com/prometheus/prometheus/config"
+	"go.uber.org/zap"
+	"sort"
+	"time"
+)
+
+const defaultBacktestWindow = 7 * 24 * time.Hour
+
+// BacktestAlertRule 在 [start-for, end] 上按规则的评估间隔执行范围查询，按 Prometheus 的告警状态机
+// 重放每个评估点：序列出现时进入 pending，持续满足 for 后触发，序列消失时恢复
+func (p *promQueryService) BacktestAlertRule(ctx context.Context, req *model.AlertRuleBacktestReq, userID int) (*model.AlertRuleBacktestResp, error) {
+	rule, err := p.backtestRule(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// 与生成规则文件时使用相同的持续时间
+	ft, err := pkg.AlertRuleForTime(rule.ForTime)
+	if err != nil {
+		return nil, fmt.Errorf("持续时间格式错误: %w", err)
+	}
+	forTime := time.Duration(ft)
+
+	step, err := p.ruleEvaluationInterval(ctx, rule)
+	if err != nil {
+		return nil, err
+	}
+
+	end := time.Now().Truncate(time.Second)
+	if req.End > 0 {
+		end = time.Unix(req.End, 0)
+	}
+	start := end.Add(-defaultBacktestWindow)
+	if req.Start > 0 {
+		start = time.Unix(req.Start, 0)
+	}
+	if !start.Before(end) {
+		return nil, errors.New("回测的开始时间必须早于结束时间")
+	}
+
+	// 提前 for 开始查询，使回测开始时已经 pending 的告警能够按时触发
+	queryStart := start.Add(-forTime)
+	if end.Sub(queryStart)/step > maxQueryPoints {
+		return nil, fmt.Errorf("回测评估点数超过 %d，请缩小回测时间范围", maxQueryPoints)
+	}
+
+	pool, instance, err := p.resolveInstance(ctx, rule.PoolID, req.Instance, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := p.timeout(req.TimeoutSeconds)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	value, _, err := p.newAPI(instance).QueryRange(ctx, rule.Expr, v1.Range{Start: queryStart, End: end, Step: step}, v1.WithTimeout(timeout))
+	if err != nil {
+		p.l.Error("回测告警规则失败", zap.Error(err), zap.String("pool", pool.Name), zap.String("instance", instance), zap.String("expr", rule.Expr))
+		return nil, fmt.Errorf("查询 %s 失败: %w", instance, err)
+	}
+	matrix, ok := value.(pm.Matrix)
+	if !ok {
+		return nil, fmt.Errorf("告警表达式的结果必须是 vector，实际为 %s", value.Type())
+	}
+
+	// 最后一个评估点，之后没有评估的告警视为仍在触发
+	lastEval := pm.TimeFromUnixNano(queryStart.Add(end.Sub(queryStart) / step * step).UnixNano())
+	windowStart := pm.TimeFromUnixNano(start.UnixNano())
+	ruleLabels := pkg.FromSliceTuMap(rule.Labels)
+
+	// 去掉指标名称后可能有多个序列的标签相同，按告警标签合并
+	seriesMap := make(map[pm.Fingerprint]*model.AlertRuleBacktestSeries)
+	for _, stream := range matrix {
+		intervals := replayAlertStates(stream.Values, step, forTime, lastEval)
+
+		labelSet := pm.LabelSet{}
+		for name, value := range alertLabels(stream.Metric) {
+			labelSet[pm.LabelName(name)] = pm.LabelValue(value)
+		}
+		for name, value := range ruleLabels {
+			labelSet[pm.LabelName(name)] = pm.LabelValue(value)
+		}
+		if rule.Name != "" {
+			labelSet[pm.AlertNameLabel] = pm.LabelValue(rule.Name)
+		}
+
+		fingerprint := labelSet.Fingerprint()
+		for _, interval := range intervals {
+			// 在回测开始前已经恢复的告警不计入结果
+			if interval.LastFiring < windowStart.Unix() {
+				continue
+			}
+
+			series, ok := seriesMap[fingerprint]
+			if !ok {
+				series = &model.AlertRuleBacktestSeries{
+					Labels:    make(map[string]string, len(labelSet)),
+					Intervals: make([]*model.AlertRuleBacktestInterval, 0),
+				}
+				for name, value := range labelSet {
+					series.Labels[string(name)] = string(value)
+				}
+				seriesMap[fingerprint] = series
+			}
+			series.Intervals = append(series.Intervals, interval)
+			series.Count++
+		}
+	}
+
+	resp := &model.AlertRuleBacktestResp{
+		Instance: instance,
+		Start:    start.Unix(),
+		End:      end.Unix(),
+		Step:     int(step / time.Second),
+		ForTime:  pm.Duration(forTime).String(),
+		Series:   make([]*model.AlertRuleBacktestSeries, 0, len(seriesMap)),
+	}
+	for _, series := range seriesMap {
+		sort.Slice(series.Intervals, func(i, j int) bool {
+			return series.Intervals[i].FiredAt < series.Intervals[j].FiredAt
+		})
+		resp.FiringCount += series.Count
+		resp.Series = append(resp.Series, series)
+	}
+
+	sort.Slice(resp.Series, func(i, j int) bool {
+		if resp.Series[i].Count != resp.Series[j].Count {
+			return resp.Series[i].Count > resp.Series[j].Count
+		}
+		return resp.Series[i].Intervals[0].FiredAt < resp.Series[j].Intervals[0].FiredAt
+	})
+
+	return resp, nil
+}
+
+// backtestRule 获取要回测的告警规则，优先使用请求中尚未保存的规则
+func (p *promQueryService) backtestRule(ctx context.Context, req *model.AlertRuleBacktestReq) (*model.MonitorAlertRule, error) {
+	rule := req.Rule
+	if rule == nil {
+		if req.RuleID == 0 {
+			return nil, errors.New("必须指定告警规则ID或告警规则")
+		}
+
+		saved, err := p.ruleDao.GetMonitorAlertRuleById(ctx, req.RuleID)
+		if err != nil {
+			return nil, fmt.Errorf("获取告警规则失败: %w", err)
+		}
+		rule = saved
+	}
+
+	if rule.PoolID == 0 {
+		return nil, errors.New("告警规则未关联采集池")
+	}
+	if rule.Expr == "" {
+		return nil, errors.New("告警规则表达式不能为空")
+	}
+
+	return rule, nil
+}
+
+// ruleEvaluationInterval 获取规则的评估间隔，规则组未设置时使用 Prometheus 的全局评估间隔
+func (p *promQueryService) ruleEvaluationInterval(ctx context.Context, rule *model.MonitorAlertRule) (time.Duration, error) {
+	interval := time.Duration(pc.DefaultGlobalConfig.EvaluationInterval)
+	if rule.RuleGroupID == 0 {
+		return interval, nil
+	}
+
+	ruleGroup, err := p.ruleGroupDao.GetMonitorRuleGroupById(ctx, rule.RuleGroupID)
+	if err != nil {
+		return 0, fmt.Errorf("获取规则组失败: %w", err)
+	}
+
+	if ruleGroup.Interval != "" {
+		duration, err := pm.ParseDuration(ruleGroup.Interval)
+		if err != nil {
+			return 0, fmt.Errorf("规则组 %s 的评估间隔格式错误: %w", ruleGroup.Name, err)
+		}
+		if duration > 0 {
+			interval = time.Duration(duration)
+		}
+	}
+
+	return interval, nil
+}
+
+// replayAlertStates 按评估点重放单个序列的告警状态，返回每次触发的时间段。
+// 相邻两个点的间隔超过 step 说明中间有评估点没有结果，此时告警恢复
+func replayAlertStates(samples []pm.SamplePair, step, forTime time.Duration, lastEval pm.Time) []*model.AlertRuleBacktestInterval {
+	var (
+		intervals []*model.AlertRuleBacktestInterval
+		current   *model.AlertRuleBacktestInterval
+		activeAt  pm.Time
+	)
+
+	for i, sample := range samples {
+		if i == 0 || sample.Timestamp.Sub(samples[i-1].Timestamp) > step {
+			if current != nil {
+				current.Resolved = true
+				intervals = append(intervals, current)
+				current = nil
+			}
+			activeAt = sample.Timestamp
+		}
+
+		if sample.Timestamp.Sub(activeAt) < forTime {
+			continue
+		}
+
+		if current == nil {
+			current = &model.AlertRuleBacktestInterval{
+				ActiveSince: activeAt.Unix(),
+				FiredAt:     sample.Timestamp.Unix(),
+			}
+		}
+		current.LastFiring = sample.Timestamp.Unix()
+	}
+
+	if current != nil {
+		current.Resolved = samples[len(samples)-1].Timestamp.Before(lastEval)
+		intervals = append(intervals, current)
+	}
+
+	return intervals
+}
@@ -0,0 +1,123 @@
+package query
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	pm "github.com/prometheus/common/model"
+)
+
+// samplesAt 生成指定秒数上的样本
+func samplesAt(seconds ...int64) []pm.SamplePair {
+	samples := make([]pm.SamplePair, 0, len(seconds))
+	for _, s := range seconds {
+		samples = append(samples, pm.SamplePair{Timestamp: pm.TimeFromUnix(s), Value: 1})
+	}
+	return samples
+}
+
+func TestReplayAlertStates(t *testing.T) {
+	step := time.Minute
+
+	tests := []struct {
+		name     string
+		samples  []pm.SamplePair
+		forTime  time.Duration
+		lastEval int64
+		want     []*model.AlertRuleBacktestInterval
+	}{
+		{
+			name:     "没有样本",
+			samples:  nil,
+			lastEval: 600,
+			want:     nil,
+		},
+		{
+			name:     "未设置for时立即触发并持续到结束",
+			samples:  samplesAt(0, 60, 120),
+			lastEval: 120,
+			want:     []*model.AlertRuleBacktestInterval{{ActiveSince: 0, FiredAt: 0, LastFiring: 120, Resolved: false}},
+		},
+		{
+			name:     "最后一个点早于最后评估时间时已恢复",
+			samples:  samplesAt(0, 60),
+			lastEval: 180,
+			want:     []*model.AlertRuleBacktestInterval{{ActiveSince: 0, FiredAt: 0, LastFiring: 60, Resolved: true}},
+		},
+		{
+			name:     "满足for时间后才触发",
+			samples:  samplesAt(0, 60, 120, 180),
+			forTime:  2 * time.Minute,
+			lastEval: 180,
+			want:     []*model.AlertRuleBacktestInterval{{ActiveSince: 0, FiredAt: 120, LastFiring: 180, Resolved: false}},
+		},
+		{
+			name:     "pending期间中断不触发",
+			samples:  samplesAt(0, 60, 180, 240, 300),
+			forTime:  2 * time.Minute,
+			lastEval: 300,
+			want:     []*model.AlertRuleBacktestInterval{{ActiveSince: 180, FiredAt: 300, LastFiring: 300, Resolved: false}},
+		},
+		{
+			name:     "中断后重新触发为两个时间段",
+			samples:  samplesAt(0, 60, 240, 300),
+			lastEval: 600,
+			want: []*model.AlertRuleBacktestInterval{
+				{ActiveSince: 0, FiredAt: 0, LastFiring: 60, Resolved: true},
+				{ActiveSince: 240, FiredAt: 240, LastFiring: 300, Resolved: true},
+			},
+		},
+		{
+			name:     "始终未满足for时间",
+			samples:  samplesAt(0, 60, 180),
+			forTime:  5 * time.Minute,
+			lastEval: 180,
+			want:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := replayAlertStates(tt.samples, step, tt.forTime, pm.TimeFromUnix(tt.lastEval))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("replayAlertStates() = %s, want %s", formatIntervals(got), formatIntervals(tt.want))
+			}
+		})
+	}
+}
+
+func formatIntervals(intervals []*model.AlertRuleBacktestInterval) string {
+	result := make([]model.AlertRuleBacktestInterval, 0, len(intervals))
+	for _, interval := range intervals {
+		result = append(result, *interval)
+	}
+	return fmt.Sprintf("%+v", result)
+}
@@ -0,0 +1,401 @@
+package query
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	alertDao "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/alert"
+	scrapeDao "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/scrape"
+	casbinDao "github.com/GoSimplicity/AI-CloudOps/internal/system/dao/casbin"
+	userDao "github.com/GoSimplicity/AI-CloudOps/internal/user/dao"
+	"github.com/prometheus/client_golang/api"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	pm "github.com/prometheus/common/model"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultPrometheusPort = 9090
+	defaultQueryTimeout   = 30 * time.Second
+	maxQueryTimeout       = 5 * time.Minute
+	defaultQueryStep      = 15 * time.Second
+	// maxQueryPoints Prometheus 范围查询单条序列允许返回的最大点数
+	maxQueryPoints = 11000
+)
+
+// 告警规则预览中序列的状态
+const (
+	AlertStateFiring  = "firing"
+	AlertStatePending = "pending"
+)
+
+type PromQueryService interface {
+	// Query 在采集池的Prometheus实例上执行即时查询
+	Query(ctx context.Context, req *model.PromQueryReq, userID int) (*model.PromQueryResp, error)
+	// QueryRange 在采集池的Prometheus实例上执行范围查询
+	QueryRange(ctx context.Context, req *model.PromQueryReq, userID int) (*model.PromQueryResp, error)
+	// PreviewAlertRule 根据表达式和持续时间预览告警规则当前会触发的告警
+	PreviewAlertRule(ctx context.Context, req *model.AlertRulePreviewReq, userID int) (*model.AlertRulePreviewResp, error)
+	// BacktestAlertRule 使用历史数据回测告警规则，返回每组标签在回测时间内的触发情况
+	BacktestAlertRule(ctx context.Context, req *model.AlertRuleBacktestReq, userID int) (*model.AlertRuleBacktestResp, error)
+}
+
+type promQueryService struct {
+	poolDao        scrapeDao.ScrapePoolDAO
+	ruleDao        alertDao.AlertManagerRuleDAO
+	ruleGroupDao   alertDao.AlertManagerRuleGroupDAO
+	userDao        userDao.UserDAO
+	casbinDao      casbinDao.CasbinDAO
+	client         *http.Client
+	prometheusPort int
+	defaultTimeout time.Duration
+	l              *zap.Logger
+}
+
+func NewPromQueryService(poolDao scrapeDao.ScrapePoolDAO, ruleDao alertDao.AlertManagerRuleDAO, ruleGroupDao alertDao.AlertManagerRuleGroupDAO, userDao userDao.UserDAO, casbinDao casbinDao.CasbinDAO, l *zap.Logger) PromQueryService {
+	prometheusPort := viper.GetInt("prometheus.prometheus_port")
+	if prometheusPort == 0 {
+		prometheusPort = defaultPrometheusPort
+	}
+
+	defaultTimeout := time.Duration(viper.GetInt("prometheus.query_timeout")) * time.Second
+	if defaultTimeout <= 0 || defaultTimeout > maxQueryTimeout {
+		defaultTimeout = defaultQueryTimeout
+	}
+
+	return &promQueryService{
+		poolDao:        poolDao,
+		ruleDao:        ruleDao,
+		ruleGroupDao:   ruleGroupDao,
+		userDao:        userDao,
+		casbinDao:      casbinDao,
+		client:         &http.Client{},
+		prometheusPort: prometheusPort,
+		defaultTimeout: defaultTimeout,
+		l:              l,
+	}
+}
+
+func (p *promQueryService) Query(ctx context.Context, req *model.PromQueryReq, userID int) (*model.PromQueryResp, error) {
+	pool, instance, err := p.resolveInstance(ctx, req.PoolID, req.Instance, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ts := time.Now()
+	if req.Time > 0 {
+		ts = time.Unix(req.Time, 0)
+	}
+
+	timeout := p.timeout(req.TimeoutSeconds)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	value, warnings, err := p.newAPI(instance).Query(ctx, req.Query, ts, v1.WithTimeout(timeout))
+	if err != nil {
+		p.l.Error("执行即时查询失败", zap.Error(err), zap.String("pool", pool.Name), zap.String("instance", instance), zap.String("query", req.Query))
+		return nil, fmt.Errorf("查询 %s 失败: %w", instance, err)
+	}
+
+	return newQueryResp(instance, value, warnings), nil
+}
+
+func (p *promQueryService) QueryRange(ctx context.Context, req *model.PromQueryReq, userID int) (*model.PromQueryResp, error) {
+	pool, instance, err := p.resolveInstance(ctx, req.PoolID, req.Instance, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	end := time.Now()
+	if req.End > 0 {
+		end = time.Unix(req.End, 0)
+	}
+	if req.Start <= 0 {
+		return nil, errors.New("范围查询必须指定开始时间")
+	}
+	start := time.Unix(req.Start, 0)
+	if !start.Before(end) {
+		return nil, errors.New("范围查询的开始时间必须早于结束时间")
+	}
+
+	step := time.Duration(req.Step) * time.Second
+	if step <= 0 {
+		step = poolStep(pool)
+	}
+	if end.Sub(start)/step > maxQueryPoints {
+		return nil, fmt.Errorf("查询点数超过 %d，请增大步长或缩小时间范围", maxQueryPoints)
+	}
+
+	timeout := p.timeout(req.TimeoutSeconds)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	value, warnings, err := p.newAPI(instance).QueryRange(ctx, req.Query, v1.Range{Start: start, End: end, Step: step}, v1.WithTimeout(timeout))
+	if err != nil {
+		p.l.Error("执行范围查询失败", zap.Error(err), zap.String("pool", pool.Name), zap.String("instance", instance), zap.String("query", req.Query))
+		return nil, fmt.Errorf("查询 %s 失败: %w", instance, err)
+	}
+
+	return newQueryResp(instance, value, warnings), nil
+}
+
+// PreviewAlertRule 与 Prometheus 规则评估的逻辑一致：表达式有结果的序列进入 pending，
+// 连续满足持续时间后变为 firing。通过在 [now-for, now] 上按采集间隔执行范围查询，
+// 在整个窗口内每个步长都有结果的序列视为 firing，只在窗口末尾连续出现的视为 pending
+func (p *promQueryService) PreviewAlertRule(ctx context.Context, req *model.AlertRulePreviewReq, userID int) (*model.AlertRulePreviewResp, error) {
+	var forTime time.Duration
+	if req.ForTime != "" {
+		duration, err := pm.ParseDuration(req.ForTime)
+		if err != nil {
+			return nil, fmt.Errorf("持续时间格式错误: %w", err)
+		}
+		forTime = time.Duration(duration)
+	}
+
+	pool, instance, err := p.resolveInstance(ctx, req.PoolID, req.Instance, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := p.timeout(req.TimeoutSeconds)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	promAPI := p.newAPI(instance)
+	now := time.Now().Truncate(time.Second)
+	resp := &model.AlertRulePreviewResp{
+		Instance: instance,
+		Series:   make([]*model.AlertRulePreviewSeries, 0),
+	}
+
+	// 没有持续时间时表达式当前有结果即触发，只需即时查询
+	if forTime == 0 {
+		value, _, err := promAPI.Query(ctx, req.Expr, now, v1.WithTimeout(timeout))
+		if err != nil {
+			return nil, fmt.Errorf("查询 %s 失败: %w", instance, err)
+		}
+		vector, ok := value.(pm.Vector)
+		if !ok {
+			return nil, fmt.Errorf("告警表达式的结果必须是 vector，实际为 %s", value.Type())
+		}
+		for _, sample := range vector {
+			resp.Series = append(resp.Series, &model.AlertRulePreviewSeries{
+				Labels:      alertLabels(sample.Metric),
+				Value:       float64(sample.Value),
+				ActiveSince: now.Unix(),
+				State:       AlertStateFiring,
+			})
+		}
+		resp.FiringCount = len(resp.Series)
+		return resp, nil
+	}
+
+	step := poolStep(pool)
+	if forTime/step > maxQueryPoints {
+		step = forTime / maxQueryPoints
+	}
+	start := now.Add(-forTime)
+
+	value, _, err := promAPI.QueryRange(ctx, req.Expr, v1.Range{Start: start, End: now, Step: step}, v1.WithTimeout(timeout))
+	if err != nil {
+		p.l.Error("预览告警规则失败", zap.Error(err), zap.String("pool", pool.Name), zap.String("instance", instance), zap.String("expr", req.Expr))
+		return nil, fmt.Errorf("查询 %s 失败: %w", instance, err)
+	}
+	matrix, ok := value.(pm.Matrix)
+	if !ok {
+		return nil, fmt.Errorf("告警表达式的结果必须是 vector，实际为 %s", value.Type())
+	}
+
+	// 范围查询的评估时间点为 start + k*step，最后一个点不一定等于 now
+	lastTs := pm.TimeFromUnixNano(start.Add(forTime / step * step).UnixNano())
+	for _, stream := range matrix {
+		samples := stream.Values
+		// 当前时刻没有结果的序列不会处于 pending 或 firing 状态
+		if len(samples) == 0 || samples[len(samples)-1].Timestamp != lastTs {
+			continue
+		}
+
+		// 向前查找连续满足表达式的起始点
+		first := len(samples) - 1
+		for first > 0 && samples[first].Timestamp.Sub(samples[first-1].Timestamp) <= step {
+			first--
+		}
+
+		activeSince := samples[first].Timestamp.Time()
+		state := AlertStatePending
+		if !activeSince.After(start) {
+			state = AlertStateFiring
+			resp.FiringCount++
+		} else {
+			resp.PendingCount++
+		}
+
+		resp.Series = append(resp.Series, &model.AlertRulePreviewSeries{
+			Labels:      alertLabels(stream.Metric),
+			Value:       float64(samples[len(samples)-1].Value),
+			ActiveSince: activeSince.Unix(),
+			State:       state,
+		})
+	}
+
+	// firing 的序列排在前面，同一状态按持续时间从长到短排列
+	sort.SliceStable(resp.Series, func(i, j int) bool {
+		if resp.Series[i].State != resp.Series[j].State {
+			return resp.Series[i].State == AlertStateFiring
+		}
+		return resp.Series[i].ActiveSince < resp.Series[j].ActiveSince
+	})
+
+	return resp, nil
+}
+
+// resolveInstance 检查用户对采集池的查询权限，并确定执行查询的Prometheus实例
+func (p *promQueryService) resolveInstance(ctx context.Context, poolID int, instance string, userID int) (*model.MonitorScrapePool, string, error) {
+	pool, err := p.poolDao.GetMonitorScrapePoolById(ctx, poolID)
+	if err != nil {
+		return nil, "", fmt.Errorf("获取采集池失败: %w", err)
+	}
+
+	if err := p.checkPermission(ctx, pool, userID); err != nil {
+		return nil, "", err
+	}
+
+	if len(pool.PrometheusInstances) == 0 {
+		return nil, "", fmt.Errorf("采集池 %s 没有Prometheus实例", pool.Name)
+	}
+
+	if instance == "" {
+		return pool, pool.PrometheusInstances[0], nil
+	}
+
+	for _, ip := range pool.PrometheusInstances {
+		if ip == instance {
+			return pool, ip, nil
+		}
+	}
+
+	return nil, "", fmt.Errorf("实例 %s 不属于采集池 %s", instance, pool.Name)
+}
+
+// checkPermission 采集池的创建者和服务账号可以直接查询，其他用户需要角色拥有
+// /api/monitor/prometheus_query/pools/<采集池ID> 的 GET 权限
+func (p *promQueryService) checkPermission(ctx context.Context, pool *model.MonitorScrapePool, userID int) error {
+	if pool.UserID == userID {
+		return nil
+	}
+
+	user, err := p.userDao.GetUserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("获取用户信息失败: %w", err)
+	}
+
+	if user.AccountType == 2 {
+		return nil
+	}
+
+	path := fmt.Sprintf("/api/monitor/prometheus_query/pools/%d", pool.ID)
+	for _, role := range user.Roles {
+		ok, err := p.casbinDao.CheckPermission(ctx, role.RoleValue, path, http.MethodGet)
+		if err != nil {
+			p.l.Error("检查采集池查询权限失败", zap.Error(err), zap.Int("userId", userID), zap.String("role", role.RoleValue))
+			return errors.New("检查权限失败")
+		}
+		if ok {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("没有查询采集池 %s 的权限", pool.Name)
+}
+
+// timeout 计算查询超时时间，未指定时使用默认值，超过上限时使用上限
+func (p *promQueryService) timeout(seconds int) time.Duration {
+	if seconds <= 0 {
+		return p.defaultTimeout
+	}
+
+	timeout := time.Duration(seconds) * time.Second
+	if timeout > maxQueryTimeout {
+		return maxQueryTimeout
+	}
+
+	return timeout
+}
+
+func (p *promQueryService) newAPI(instance string) v1.API {
+	address := instance
+	if _, _, err := net.SplitHostPort(instance); err != nil {
+		address = net.JoinHostPort(instance, strconv.Itoa(p.prometheusPort))
+	}
+
+	// 地址固定为合法的 http 地址，NewClient 不会返回错误
+	client, _ := api.NewClient(api.Config{
+		Address: "http://" + address,
+		Client:  p.client,
+	})
+
+	return v1.NewAPI(client)
+}
+
+// poolStep 使用采集池的采集间隔作为默认步长
+func poolStep(pool *model.MonitorScrapePool) time.Duration {
+	if pool.ScrapeInterval > 0 {
+		return time.Duration(pool.ScrapeInterval) * time.Second
+	}
+
+	return defaultQueryStep
+}
+
+// alertLabels 与 Prometheus 生成告警时一致，去掉序列的指标名称
+func alertLabels(metric pm.Metric) map[string]string {
+	labels := make(map[string]string, len(metric))
+	for name, value := range metric {
+		if name == pm.MetricNameLabel {
+			continue
+		}
+		labels[string(name)] = string(value)
+	}
+
+	return labels
+}
+
+func newQueryResp(instance string, value pm.Value, warnings v1.Warnings) *model.PromQueryResp {
+	return &model.PromQueryResp{
+		Instance:   instance,
+		ResultType: value.Type().String(),
+		Result:     value,
+		Warnings:   warnings,
+	}
+}
@@ -0,0 +1,169 @@
+package query
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	scrapeDao "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/scrape"
+	"go.uber.org/zap"
+)
+
+type fakePoolDAO struct {
+	scrapeDao.ScrapePoolDAO
+	pool *model.MonitorScrapePool
+}
+
+func (f *fakePoolDAO) GetMonitorScrapePoolById(_ context.Context, _ int) (*model.MonitorScrapePool, error) {
+	return f.pool, nil
+}
+
+func TestResolveInstance(t *testing.T) {
+	pool := &model.MonitorScrapePool{Name: "pool", UserID: 1, PrometheusInstances: model.StringList{"10.0.0.1", "10.0.0.2"}}
+	svc := &promQueryService{poolDao: &fakePoolDAO{pool: pool}, l: zap.NewNop()}
+
+	tests := []struct {
+		name     string
+		instance string
+		want     string
+		wantErr  bool
+	}{
+		{name: "未指定实例时使用第一个实例", instance: "", want: "10.0.0.1"},
+		{name: "指定采集池内的实例", instance: "10.0.0.2", want: "10.0.0.2"},
+		{name: "实例不属于采集池", instance: "10.0.0.3", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, got, err := svc.resolveInstance(context.Background(), 1, tt.instance, 1)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("resolveInstance() = %q, %v, want %q wantErr %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestQueryTimeout(t *testing.T) {
+	svc := &promQueryService{defaultTimeout: defaultQueryTimeout}
+
+	tests := []struct {
+		seconds int
+		want    time.Duration
+	}{
+		{seconds: 0, want: defaultQueryTimeout},
+		{seconds: -1, want: defaultQueryTimeout},
+		{seconds: 10, want: 10 * time.Second},
+		{seconds: 3600, want: maxQueryTimeout},
+	}
+
+	for _, tt := range tests {
+		if got := svc.timeout(tt.seconds); got != tt.want {
+			t.Errorf("timeout(%d) = %v, want %v", tt.seconds, got, tt.want)
+		}
+	}
+}
+
+func TestPreviewAlertRule(t *testing.T) {
+	// 模拟 Prometheus 的范围查询：always 在整个窗口内都有结果，recent 只在最后两个点有结果，gone 当前没有结果
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/api/v1/query_range") {
+			http.NotFound(w, r)
+			return
+		}
+		_ = r.ParseForm()
+		start, _ := strconv.ParseFloat(r.Form.Get("start"), 64)
+		end, _ := strconv.ParseFloat(r.Form.Get("end"), 64)
+		step, _ := strconv.ParseFloat(r.Form.Get("step"), 64)
+
+		var points []float64
+		for ts := start; ts <= end; ts += step {
+			points = append(points, ts)
+		}
+		values := func(from, to int) [][]interface{} {
+			result := make([][]interface{}, 0)
+			for _, ts := range points[from:to] {
+				result = append(result, []interface{}{ts, "1"})
+			}
+			return result
+		}
+
+		n := len(points)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": "success",
+			"data": map[string]interface{}{
+				"resultType": "matrix",
+				"result": []map[string]interface{}{
+					{"metric": map[string]string{"__name__": "up", "instance": "recent"}, "values": values(n-2, n)},
+					{"metric": map[string]string{"__name__": "up", "instance": "always"}, "values": values(0, n)},
+					{"metric": map[string]string{"__name__": "up", "instance": "gone"}, "values": values(0, n-1)},
+				},
+			},
+		})
+	}))
+	defer server.Close()
+
+	instance := strings.TrimPrefix(server.URL, "http://")
+	pool := &model.MonitorScrapePool{Name: "pool", UserID: 1, ScrapeInterval: 15, PrometheusInstances: model.StringList{instance}}
+	svc := &promQueryService{
+		poolDao:        &fakePoolDAO{pool: pool},
+		client:         server.Client(),
+		defaultTimeout: defaultQueryTimeout,
+		l:              zap.NewNop(),
+	}
+
+	resp, err := svc.PreviewAlertRule(context.Background(), &model.AlertRulePreviewReq{PoolID: 1, Expr: "up == 1", ForTime: "5m"}, 1)
+	if err != nil {
+		t.Fatalf("PreviewAlertRule() err = %v", err)
+	}
+
+	if resp.FiringCount != 1 || resp.PendingCount != 1 || len(resp.Series) != 2 {
+		t.Fatalf("PreviewAlertRule() = %+v", resp)
+	}
+	if resp.Series[0].Labels["instance"] != "always" || resp.Series[0].State != AlertStateFiring {
+		t.Errorf("第一条序列应为 firing 的 always: %+v", resp.Series[0])
+	}
+	if resp.Series[1].Labels["instance"] != "recent" || resp.Series[1].State != AlertStatePending {
+		t.Errorf("第二条序列应为 pending 的 recent: %+v", resp.Series[1])
+	}
+	if _, ok := resp.Series[0].Labels["__name__"]; ok {
+		t.Error("告警标签不应包含指标名称")
+	}
+}
+
+func TestPreviewAlertRuleInvalidForTime(t *testing.T) {
+	svc := &promQueryService{l: zap.NewNop()}
+	if _, err := svc.PreviewAlertRule(context.Background(), &model.AlertRulePreviewReq{ForTime: "five minutes"}, 1); err == nil {
+		t.Error("持续时间格式错误时应返回错误")
+	}
+}
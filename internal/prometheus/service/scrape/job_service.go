@@ -28,11 +28,14 @@ package scrape
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/GoSimplicity/AI-CloudOps/internal/model"
 	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/cache"
 	scrapeJobDao "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/scrape"
 	userDao "github.com/GoSimplicity/AI-CloudOps/internal/user/dao"
 	pkg "github.com/GoSimplicity/AI-CloudOps/pkg/utils/prometheus"
+	pc "github.com/prometheus/prometheus/config"
+	"github.com/prometheus/prometheus/model/relabel"
 	"go.uber.org/zap"
 )
 
@@ -41,21 +44,27 @@ type ScrapeJobService interface {
 	CreateMonitorScrapeJob(ctx context.Context, monitorScrapeJob *model.MonitorScrapeJob) error
 	UpdateMonitorScrapeJob(ctx context.Context, monitorScrapeJob *model.MonitorScrapeJob) error
 	DeleteMonitorScrapeJob(ctx context.Context, id int) error
+	// SimulateRelabel 模拟采集任务对目标执行 relabel 和实例间的哈希分片
+	SimulateRelabel(ctx context.Context, req *model.RelabelSimulateReq) (*model.RelabelSimulateResp, error)
 }
 
 type scrapeJobService struct {
-	dao     scrapeJobDao.ScrapeJobDAO
-	cache   cache.MonitorCache
-	userDao userDao.UserDAO
-	l       *zap.Logger
+	dao       scrapeJobDao.ScrapeJobDAO
+	poolDao   scrapeJobDao.ScrapePoolDAO
+	cache     cache.MonitorCache
+	promCache cache.PromConfigCache
+	userDao   userDao.UserDAO
+	l         *zap.Logger
 }
 
-func NewPrometheusScrapeService(dao scrapeJobDao.ScrapeJobDAO, cache cache.MonitorCache, l *zap.Logger, userDao userDao.UserDAO) ScrapeJobService {
+func NewPrometheusScrapeService(dao scrapeJobDao.ScrapeJobDAO, poolDao scrapeJobDao.ScrapePoolDAO, cache cache.MonitorCache, promCache cache.PromConfigCache, l *zap.Logger, userDao userDao.UserDAO) ScrapeJobService {
 	return &scrapeJobService{
-		dao:     dao,
-		userDao: userDao,
-		l:       l,
-		cache:   cache,
+		dao:       dao,
+		poolDao:   poolDao,
+		userDao:   userDao,
+		l:         l,
+		cache:     cache,
+		promCache: promCache,
 	}
 }
 
@@ -77,6 +86,21 @@ func (s *scrapeJobService) CreateMonitorScrapeJob(ctx context.Context, monitorSc
 		return errors.New("抓取作业已存在")
 	}
 
+	// 检查服务发现配置
+	if err := pkg.ValidateScrapeJobDiscovery(monitorScrapeJob); err != nil {
+		return err
+	}
+
+	// 检查 HTTP 客户端配置
+	if err := pkg.ValidateScrapeJobHTTPClient(monitorScrapeJob); err != nil {
+		return err
+	}
+
+	// 检查 relabel 配置
+	if err := pkg.ValidateScrapeJobRelabel(monitorScrapeJob); err != nil {
+		return err
+	}
+
 	// 创建抓取作业
 	if err := s.dao.CreateMonitorScrapeJob(ctx, monitorScrapeJob); err != nil {
 		s.l.Error("创建抓取作业失败", zap.Error(err))
@@ -84,7 +108,7 @@ func (s *scrapeJobService) CreateMonitorScrapeJob(ctx context.Context, monitorSc
 	}
 
 	// 更新缓存
-	if err := s.cache.MonitorCacheManager(ctx); err != nil {
+	if err := s.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, fmt.Sprintf("创建采集任务: %s", monitorScrapeJob.Name))); err != nil {
 		s.l.Error("更新缓存失败", zap.Error(err))
 		return err
 	}
@@ -94,6 +118,21 @@ func (s *scrapeJobService) CreateMonitorScrapeJob(ctx context.Context, monitorSc
 }
 
 func (s *scrapeJobService) UpdateMonitorScrapeJob(ctx context.Context, monitorScrapeJob *model.MonitorScrapeJob) error {
+	// 检查服务发现配置
+	if err := pkg.ValidateScrapeJobDiscovery(monitorScrapeJob); err != nil {
+		return err
+	}
+
+	// 检查 HTTP 客户端配置
+	if err := pkg.ValidateScrapeJobHTTPClient(monitorScrapeJob); err != nil {
+		return err
+	}
+
+	// 检查 relabel 配置
+	if err := pkg.ValidateScrapeJobRelabel(monitorScrapeJob); err != nil {
+		return err
+	}
+
 	// 更新抓取作业
 	if err := s.dao.UpdateMonitorScrapeJob(ctx, monitorScrapeJob); err != nil {
 		s.l.Error("更新抓取作业失败", zap.Error(err))
@@ -101,7 +140,7 @@ func (s *scrapeJobService) UpdateMonitorScrapeJob(ctx context.Context, monitorSc
 	}
 
 	// 更新缓存
-	if err := s.cache.MonitorCacheManager(ctx); err != nil {
+	if err := s.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, fmt.Sprintf("更新采集任务: %s", monitorScrapeJob.Name))); err != nil {
 		s.l.Error("更新缓存失败", zap.Error(err))
 		return err
 	}
@@ -119,7 +158,7 @@ func (s *scrapeJobService) DeleteMonitorScrapeJob(ctx context.Context, id int) e
 	}
 
 	// 更新缓存
-	if err := s.cache.MonitorCacheManager(ctx); err != nil {
+	if err := s.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, fmt.Sprintf("删除采集任务: ID %d", id))); err != nil {
 		s.l.Error("更新缓存失败", zap.Error(err))
 		return err
 	}
@@ -127,3 +166,69 @@ func (s *scrapeJobService) DeleteMonitorScrapeJob(ctx context.Context, id int) e
 	s.l.Info("删除抓取作业成功", zap.Int("id", id))
 	return nil
 }
+
+func (s *scrapeJobService) SimulateRelabel(ctx context.Context, req *model.RelabelSimulateReq) (*model.RelabelSimulateResp, error) {
+	job := req.Job
+	if job == nil {
+		if req.JobID == 0 {
+			return nil, errors.New("必须指定采集任务或采集任务ID")
+		}
+
+		var err error
+		job, err = s.dao.GetMonitorScrapeJobById(ctx, req.JobID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	relabelConfigs, err := pkg.ParseRelabelConfigs(pkg.RelabelFieldRelabel, job.RelabelConfigsYamlString)
+	if err != nil {
+		return nil, err
+	}
+
+	initial := pkg.TargetInitialLabels(job, req.Labels)
+	final, steps, kept, dropReason := pkg.SimulateRelabelSteps(initial, relabelConfigs)
+
+	resp := &model.RelabelSimulateResp{
+		Kept:          kept,
+		DropReason:    dropReason,
+		InitialLabels: initial.Map(),
+		InstanceIndex: -1,
+		Steps:         steps,
+	}
+	if !kept {
+		return resp, nil
+	}
+	resp.FinalLabels = pkg.FinalTargetLabels(final)
+
+	var instances []string
+	if job.PoolID != 0 {
+		pool, err := s.poolDao.GetMonitorScrapePoolById(ctx, job.PoolID)
+		if err != nil {
+			return nil, err
+		}
+		instances = pool.PrometheusInstances
+	}
+
+	switch len(instances) {
+	case 0:
+		resp.Kept = false
+		resp.DropReason = "采集任务所属的采集池没有Prometheus实例"
+	case 1:
+		resp.InstanceIndex = 0
+		resp.InstanceIP = instances[0]
+	default:
+		// 与生成配置时一样追加 hashmod 和 keep 规则，找出保留该目标的实例
+		scrapeConfigs := []*pc.ScrapeConfig{{JobName: job.Name, RelabelConfigs: relabelConfigs}}
+		for idx, ip := range instances {
+			sharded := s.promCache.ApplyHashMod(scrapeConfigs, len(instances), idx)
+			if _, keep := relabel.Process(initial, sharded[0].RelabelConfigs...); keep {
+				resp.InstanceIndex = idx
+				resp.InstanceIP = ip
+				break
+			}
+		}
+	}
+
+	return resp, nil
+}
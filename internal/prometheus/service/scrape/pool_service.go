@@ -28,6 +28,7 @@ package scrape
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/GoSimplicity/AI-CloudOps/internal/model"
 	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/cache"
 	scrapeJobDao "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/scrape"
@@ -78,6 +79,15 @@ func (s *scrapePoolService) CreateMonitorScrapePool(ctx context.Context, monitor
 		return errors.New("抓取池已存在")
 	}
 
+	if err := checkRuleReplicas(monitorScrapePool); err != nil {
+		return err
+	}
+
+	// 检查远程读写配置
+	if err := pkg.ValidateRemoteConfigs(monitorScrapePool); err != nil {
+		return err
+	}
+
 	// 创建抓取池
 	if err := s.dao.CreateMonitorScrapePool(ctx, monitorScrapePool); err != nil {
 		s.l.Error("创建抓取池失败", zap.Error(err))
@@ -85,7 +95,7 @@ func (s *scrapePoolService) CreateMonitorScrapePool(ctx context.Context, monitor
 	}
 
 	// 更新缓存
-	if err := s.cache.MonitorCacheManager(ctx); err != nil {
+	if err := s.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, fmt.Sprintf("创建采集池: %s", monitorScrapePool.Name))); err != nil {
 		s.l.Error("更新缓存失败", zap.Error(err))
 		return err
 	}
@@ -122,6 +132,15 @@ func (s *scrapePoolService) UpdateMonitorScrapePool(ctx context.Context, monitor
 		return errors.New("抓取池 IP 已存在")
 	}
 
+	if err := checkRuleReplicas(monitorScrapePool); err != nil {
+		return err
+	}
+
+	// 检查远程读写配置
+	if err := pkg.ValidateRemoteConfigs(monitorScrapePool); err != nil {
+		return err
+	}
+
 	// 更新抓取池
 	if err := s.dao.UpdateMonitorScrapePool(ctx, monitorScrapePool); err != nil {
 		s.l.Error("更新抓取池失败", zap.Error(err))
@@ -129,7 +148,7 @@ func (s *scrapePoolService) UpdateMonitorScrapePool(ctx context.Context, monitor
 	}
 
 	// 更新缓存
-	if err := s.cache.MonitorCacheManager(ctx); err != nil {
+	if err := s.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, fmt.Sprintf("更新采集池: %s", monitorScrapePool.Name))); err != nil {
 		s.l.Error("更新缓存失败", zap.Error(err))
 		return err
 	}
@@ -157,7 +176,7 @@ func (s *scrapePoolService) DeleteMonitorScrapePool(ctx context.Context, id int)
 	}
 
 	// 更新缓存
-	if err := s.cache.MonitorCacheManager(ctx); err != nil {
+	if err := s.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, fmt.Sprintf("删除采集池: ID %d", id))); err != nil {
 		s.l.Error("更新缓存失败", zap.Error(err))
 		return err
 	}
@@ -165,3 +184,16 @@ func (s *scrapePoolService) DeleteMonitorScrapePool(ctx context.Context, id int)
 	s.l.Info("删除抓取池成功", zap.Int("id", id))
 	return nil
 }
+
+// checkRuleReplicas 检查规则组副本数，不能超过采集池中的Prometheus实例数量
+func checkRuleReplicas(pool *model.MonitorScrapePool) error {
+	if pool.RuleReplicas < 0 {
+		return errors.New("规则组副本数不能为负数")
+	}
+
+	if pool.RuleReplicas > 1 && pool.RuleReplicas > len(pool.PrometheusInstances) {
+		return fmt.Errorf("规则组副本数 %d 超过了Prometheus实例数量 %d", pool.RuleReplicas, len(pool.PrometheusInstances))
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package yaml
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+import (
+	"context"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/cache"
+	"go.uber.org/zap"
+)
+
+type ConfigSyncService interface {
+	GetConfigSyncStates(ctx context.Context, outOfSync bool) ([]*model.MonitorConfigSyncState, error)
+	ReloadInstances(ctx context.Context, ip string) error
+}
+
+type configSyncService struct {
+	syncCache cache.ConfigSyncCache
+	l         *zap.Logger
+}
+
+func NewConfigSyncService(syncCache cache.ConfigSyncCache, l *zap.Logger) ConfigSyncService {
+	return &configSyncService{
+		syncCache: syncCache,
+		l:         l,
+	}
+}
+
+func (c *configSyncService) GetConfigSyncStates(ctx context.Context, outOfSync bool) ([]*model.MonitorConfigSyncState, error) {
+	states, err := c.syncCache.GetSyncStates(ctx)
+	if err != nil {
+		c.l.Error("获取实例同步状态失败", zap.Error(err))
+		return nil, err
+	}
+
+	if !outOfSync {
+		return states, nil
+	}
+
+	result := make([]*model.MonitorConfigSyncState, 0, len(states))
+	for _, state := range states {
+		if state.OutOfSync {
+			result = append(result, state)
+		}
+	}
+
+	return result, nil
+}
+
+// ReloadInstances 忽略哈希比较，立即重载指定实例，ip 为空时重载所有实例
+func (c *configSyncService) ReloadInstances(ctx context.Context, ip string) error {
+	if err := c.syncCache.SyncInstances(ctx, ip, true); err != nil {
+		c.l.Error("手动重载实例配置失败", zap.Error(err), zap.String("ip", ip))
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,291 @@
+package yaml
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/cache"
+	configDao "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/config"
+	userDao "github.com/GoSimplicity/AI-CloudOps/internal/user/dao"
+	"github.com/GoSimplicity/AI-CloudOps/pkg/utils/general"
+	"go.uber.org/zap"
+	"time"
+)
+
+type ConfigVersionService interface {
+	GetConfigVersionList(ctx context.Context, configType, ip string) ([]*model.MonitorConfigVersion, error)
+	GetConfigVersionById(ctx context.Context, id int) (*model.MonitorConfigVersion, error)
+	DiffConfigVersions(ctx context.Context, fromID, toID int) (*model.ConfigVersionDiffResp, error)
+	GetConfigPinList(ctx context.Context) ([]*model.MonitorConfigPin, error)
+	PinConfigVersion(ctx context.Context, req *model.ConfigVersionPinReq, userID int) error
+	RollbackConfigVersion(ctx context.Context, req *model.ConfigVersionPinReq, userID int) error
+	UnpinConfigVersion(ctx context.Context, configType, ip string) error
+	ReportAppliedVersion(ctx context.Context, req *model.ConfigAppliedReportReq) error
+	GetAppliedVersionList(ctx context.Context, ip string) ([]*model.MonitorConfigApplied, error)
+}
+
+type configVersionService struct {
+	versionDao   configDao.ConfigVersionDAO
+	versionCache cache.ConfigVersionCache
+	cache        cache.MonitorCache
+	userDao      userDao.UserDAO
+	l            *zap.Logger
+}
+
+func NewConfigVersionService(versionDao configDao.ConfigVersionDAO, versionCache cache.ConfigVersionCache, cache cache.MonitorCache, userDao userDao.UserDAO, l *zap.Logger) ConfigVersionService {
+	return &configVersionService{
+		versionDao:   versionDao,
+		versionCache: versionCache,
+		cache:        cache,
+		userDao:      userDao,
+		l:            l,
+	}
+}
+
+func (c *configVersionService) GetConfigVersionList(ctx context.Context, configType, ip string) ([]*model.MonitorConfigVersion, error) {
+	if !cache.IsValidConfigType(configType) {
+		return nil, fmt.Errorf("不支持的配置类型: %s", configType)
+	}
+
+	versions, err := c.versionDao.GetConfigVersionList(ctx, configType, ip)
+	if err != nil {
+		c.l.Error("获取配置版本列表失败", zap.Error(err))
+		return nil, err
+	}
+
+	pinnedID := c.versionCache.GetPinnedVersionID(configType, ip)
+	userNames := make(map[int]string)
+	for _, version := range versions {
+		version.Pinned = version.ID == pinnedID
+		version.CreateUserName = c.getUserName(ctx, userNames, version.UserID)
+	}
+
+	return versions, nil
+}
+
+func (c *configVersionService) GetConfigVersionById(ctx context.Context, id int) (*model.MonitorConfigVersion, error) {
+	version, err := c.versionDao.GetConfigVersionById(ctx, id)
+	if err != nil {
+		c.l.Error("获取配置版本失败", zap.Error(err), zap.Int("id", id))
+		return nil, err
+	}
+
+	version.Pinned = version.ID == c.versionCache.GetPinnedVersionID(version.ConfigType, version.InstanceIP)
+	version.CreateUserName = c.getUserName(ctx, make(map[int]string), version.UserID)
+
+	return version, nil
+}
+
+func (c *configVersionService) DiffConfigVersions(ctx context.Context, fromID, toID int) (*model.ConfigVersionDiffResp, error) {
+	from, err := c.GetConfigVersionById(ctx, fromID)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := c.GetConfigVersionById(ctx, toID)
+	if err != nil {
+		return nil, err
+	}
+
+	if from.ConfigType != to.ConfigType {
+		return nil, errors.New("只能对比同一类型的配置版本")
+	}
+
+	diff := general.UnifiedDiff(
+		fmt.Sprintf("%s@%s v%d", from.ConfigType, from.InstanceIP, from.Version),
+		fmt.Sprintf("%s@%s v%d", to.ConfigType, to.InstanceIP, to.Version),
+		from.Content,
+		to.Content,
+	)
+
+	return &model.ConfigVersionDiffResp{
+		From: from,
+		To:   to,
+		Diff: diff,
+	}, nil
+}
+
+func (c *configVersionService) GetConfigPinList(ctx context.Context) ([]*model.MonitorConfigPin, error) {
+	pins, err := c.versionDao.GetAllConfigPins(ctx)
+	if err != nil {
+		c.l.Error("获取固定配置版本列表失败", zap.Error(err))
+		return nil, err
+	}
+
+	userNames := make(map[int]string)
+	for _, pin := range pins {
+		version, err := c.versionDao.GetConfigVersionById(ctx, pin.VersionID)
+		if err == nil {
+			pin.Version = version.Version
+		}
+		pin.CreateUserName = c.getUserName(ctx, userNames, pin.UserID)
+	}
+
+	return pins, nil
+}
+
+// PinConfigVersion 固定实例的配置版本，未指定版本时固定当前最新版本
+func (c *configVersionService) PinConfigVersion(ctx context.Context, req *model.ConfigVersionPinReq, userID int) error {
+	if !cache.IsValidConfigType(req.ConfigType) {
+		return fmt.Errorf("不支持的配置类型: %s", req.ConfigType)
+	}
+
+	versionID := req.VersionID
+	if versionID == 0 {
+		latest, err := c.versionDao.GetLatestConfigVersion(ctx, req.ConfigType, req.IP)
+		if err != nil {
+			return err
+		}
+		if latest == nil {
+			return fmt.Errorf("实例 %s 还没有 %s 配置版本", req.IP, req.ConfigType)
+		}
+		versionID = latest.ID
+	}
+
+	if err := c.versionCache.Pin(ctx, req.ConfigType, req.IP, versionID, userID, req.Reason); err != nil {
+		c.l.Error("固定配置版本失败", zap.Error(err), zap.String("ip", req.IP), zap.Int("versionId", versionID))
+		return err
+	}
+
+	return c.refresh(ctx, fmt.Sprintf("固定 %s 的 %s 配置到版本ID %d", req.IP, req.ConfigType, versionID))
+}
+
+// RollbackConfigVersion 将实例的配置回滚到指定的历史版本，回滚后保持固定直到取消固定
+func (c *configVersionService) RollbackConfigVersion(ctx context.Context, req *model.ConfigVersionPinReq, userID int) error {
+	if req.VersionID == 0 {
+		return errors.New("回滚必须指定目标版本")
+	}
+
+	if err := c.PinConfigVersion(ctx, req, userID); err != nil {
+		return err
+	}
+
+	c.l.Info("回滚配置版本成功", zap.String("ip", req.IP), zap.String("configType", req.ConfigType), zap.Int("versionId", req.VersionID))
+	return nil
+}
+
+// UnpinConfigVersion 取消固定，实例恢复使用最新生成的配置
+func (c *configVersionService) UnpinConfigVersion(ctx context.Context, configType, ip string) error {
+	if !cache.IsValidConfigType(configType) {
+		return fmt.Errorf("不支持的配置类型: %s", configType)
+	}
+
+	if err := c.versionCache.Unpin(ctx, configType, ip); err != nil {
+		c.l.Error("取消固定配置版本失败", zap.Error(err), zap.String("ip", ip))
+		return err
+	}
+
+	return c.refresh(ctx, fmt.Sprintf("取消固定 %s 的 %s 配置", ip, configType))
+}
+
+// ReportAppliedVersion 记录 config-agent 上报的实例已应用配置，根据哈希匹配对应的版本
+// 上报接口不经过登录校验，必须携带有效的实例Token，实例IP由Token确定
+func (c *configVersionService) ReportAppliedVersion(ctx context.Context, req *model.ConfigAppliedReportReq) error {
+	if req.Token == "" {
+		return fmt.Errorf("上报已应用配置必须携带实例Token")
+	}
+
+	ip, err := resolveInstanceIP("", req.Token)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().Unix()
+	for _, item := range req.Items {
+		if !cache.IsValidConfigType(item.ConfigType) {
+			return fmt.Errorf("不支持的配置类型: %s", item.ConfigType)
+		}
+
+		applied := &model.MonitorConfigApplied{
+			ConfigType: item.ConfigType,
+			InstanceIP: ip,
+			Hash:       item.Hash,
+			AppliedAt:  now,
+			Success:    req.Success,
+			Error:      req.Error,
+		}
+
+		version, err := c.versionDao.GetConfigVersionByHash(ctx, item.ConfigType, ip, item.Hash)
+		if err != nil {
+			return err
+		}
+		if version != nil {
+			applied.VersionID = version.ID
+			applied.Version = version.Version
+		}
+
+		if err := c.versionDao.SaveConfigApplied(ctx, applied); err != nil {
+			c.l.Error("保存实例已应用配置失败", zap.Error(err), zap.String("ip", ip), zap.String("configType", item.ConfigType))
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *configVersionService) GetAppliedVersionList(ctx context.Context, ip string) ([]*model.MonitorConfigApplied, error) {
+	list, err := c.versionDao.GetConfigAppliedList(ctx, ip)
+	if err != nil {
+		c.l.Error("获取实例已应用配置失败", zap.Error(err), zap.String("ip", ip))
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// refresh 重新生成配置，使固定或取消固定立即生效
+func (c *configVersionService) refresh(ctx context.Context, trigger string) error {
+	if err := c.cache.MonitorCacheManager(cache.WithConfigTrigger(ctx, trigger)); err != nil {
+		c.l.Error("更新缓存失败", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// getUserName 获取用户名，userID 为 0 表示系统生成
+func (c *configVersionService) getUserName(ctx context.Context, userNames map[int]string, userID int) string {
+	if userID == 0 {
+		return "system"
+	}
+
+	if name, ok := userNames[userID]; ok {
+		return name
+	}
+
+	user, err := c.userDao.GetUserByID(ctx, userID)
+	if err != nil {
+		c.l.Warn("获取用户信息失败", zap.Error(err), zap.Int("userId", userID))
+		userNames[userID] = ""
+		return ""
+	}
+
+	userNames[userID] = user.Username
+	return user.Username
+}
@@ -0,0 +1,88 @@
+package yaml
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"github.com/spf13/viper"
+)
+
+func setAgentTokens(t *testing.T) {
+	t.Helper()
+
+	viper.Set("prometheus.agent_tokens", []map[string]interface{}{
+		{"token": "Token-A", "ip": "10.0.0.1"},
+	})
+	t.Cleanup(func() { viper.Set("prometheus.agent_tokens", nil) })
+}
+
+func TestResolveInstanceIP(t *testing.T) {
+	setAgentTokens(t)
+
+	tests := []struct {
+		name    string
+		ip      string
+		token   string
+		want    string
+		wantErr bool
+	}{
+		{name: "只有IP", ip: "10.0.0.2", want: "10.0.0.2"},
+		{name: "Token优先于IP", ip: "10.0.0.2", token: "Token-A", want: "10.0.0.1"},
+		{name: "Token区分大小写", token: "token-a", wantErr: true},
+		{name: "无效Token", token: "unknown", wantErr: true},
+		{name: "都为空", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveInstanceIP(tt.ip, tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveInstanceIP() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("resolveInstanceIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReportAppliedVersionRequiresToken(t *testing.T) {
+	setAgentTokens(t)
+	svc := &configVersionService{}
+
+	reqs := []*model.ConfigAppliedReportReq{
+		{IP: "10.0.0.1", Success: true},
+		{IP: "10.0.0.1", Token: "unknown", Success: true},
+	}
+	for _, req := range reqs {
+		if err := svc.ReportAppliedVersion(context.Background(), req); err == nil {
+			t.Errorf("缺少有效 Token 的上报应该被拒绝: %+v", req)
+		}
+	}
+}
@@ -27,7 +27,11 @@ package yaml
 
 import (
 	"context"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
 	alertCache "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/cache"
+	"github.com/spf13/viper"
+	"time"
 )
 
 type ConfigYamlService interface {
@@ -35,6 +39,12 @@ type ConfigYamlService interface {
 	GetMonitorAlertManagerYaml(ctx context.Context, ip string) string
 	GetMonitorPrometheusAlertRuleYaml(ctx context.Context, ip string) string
 	GetMonitorPrometheusRecordYaml(ctx context.Context, ip string) string
+	GetMonitorPrometheusFiles(ctx context.Context, ip string) string
+	GetMonitorAlertManagerFiles(ctx context.Context, ip string) string
+	GetConfigErrors(ctx context.Context, req *model.ConfigErrorListReq) ([]*model.MonitorConfigError, error)
+	ResolveInstanceIP(ip, token string) (string, error)
+	// GetMonitorConfig 获取实例某类配置及其哈希，hash 与当前配置一致且 wait 大于0时等待配置变化或超时
+	GetMonitorConfig(ctx context.Context, configType, ip, hash string, wait time.Duration) (string, string, error)
 }
 
 type configYamlService struct {
@@ -42,14 +52,18 @@ type configYamlService struct {
 	alertCache  alertCache.AlertConfigCache
 	ruleCache   alertCache.RuleConfigCache
 	recordCache alertCache.RecordConfigCache
+	errorCache  alertCache.ConfigErrorCache
+	watchCache  alertCache.ConfigWatchCache
 }
 
-func NewPrometheusConfigService(promCache alertCache.PromConfigCache, alertCache alertCache.AlertConfigCache, ruleCache alertCache.RuleConfigCache, recordCache alertCache.RecordConfigCache) ConfigYamlService {
+func NewPrometheusConfigService(promCache alertCache.PromConfigCache, alertCache alertCache.AlertConfigCache, ruleCache alertCache.RuleConfigCache, recordCache alertCache.RecordConfigCache, errorCache alertCache.ConfigErrorCache, watchCache alertCache.ConfigWatchCache) ConfigYamlService {
 	return &configYamlService{
 		promCache:   promCache,
 		alertCache:  alertCache,
 		ruleCache:   ruleCache,
 		recordCache: recordCache,
+		errorCache:  errorCache,
+		watchCache:  watchCache,
 	}
 }
 
@@ -68,3 +82,79 @@ func (c *configYamlService) GetMonitorPrometheusAlertRuleYaml(ctx context.Contex
 func (c *configYamlService) GetMonitorPrometheusRecordYaml(ctx context.Context, ip string) string {
 	return c.recordCache.GetPrometheusRecordRuleConfigYamlByIp(ip)
 }
+
+func (c *configYamlService) GetMonitorPrometheusFiles(ctx context.Context, ip string) string {
+	return c.promCache.GetPrometheusFilesByIP(ip)
+}
+
+func (c *configYamlService) GetMonitorAlertManagerFiles(ctx context.Context, ip string) string {
+	return c.alertCache.GetAlertManagerFilesByIP(ip)
+}
+
+func (c *configYamlService) GetConfigErrors(ctx context.Context, req *model.ConfigErrorListReq) ([]*model.MonitorConfigError, error) {
+	if req.ConfigType != "" && !alertCache.IsValidConfigType(req.ConfigType) {
+		return nil, fmt.Errorf("不支持的配置类型: %s", req.ConfigType)
+	}
+
+	return c.errorCache.GetConfigErrors(req.ConfigType, req.PoolID), nil
+}
+
+func (c *configYamlService) ResolveInstanceIP(ip, token string) (string, error) {
+	return resolveInstanceIP(ip, token)
+}
+
+// resolveInstanceIP 确定拉取配置的实例IP，携带 Token 时根据 prometheus.agent_tokens 中的映射查找
+func resolveInstanceIP(ip, token string) (string, error) {
+	if token == "" {
+		if ip == "" {
+			return "", fmt.Errorf("必须指定实例IP或Token")
+		}
+		return ip, nil
+	}
+
+	// 使用列表而不是 map 配置，避免 viper 将作为键的 Token 转为小写
+	var agentTokens []struct {
+		Token string `mapstructure:"token"`
+		IP    string `mapstructure:"ip"`
+	}
+	if err := viper.UnmarshalKey("prometheus.agent_tokens", &agentTokens); err != nil {
+		return "", fmt.Errorf("解析实例Token配置失败: %w", err)
+	}
+
+	for _, agentToken := range agentTokens {
+		if agentToken.Token == token {
+			return agentToken.IP, nil
+		}
+	}
+
+	return "", fmt.Errorf("无效的实例Token")
+}
+
+func (c *configYamlService) GetMonitorConfig(ctx context.Context, configType, ip, hash string, wait time.Duration) (string, string, error) {
+	var current func() string
+	switch configType {
+	case alertCache.ConfigTypePrometheus:
+		current = func() string { return c.GetMonitorPrometheusYaml(ctx, ip) }
+	case alertCache.ConfigTypePrometheusAlert:
+		current = func() string { return c.GetMonitorPrometheusAlertRuleYaml(ctx, ip) }
+	case alertCache.ConfigTypePrometheusRecord:
+		current = func() string { return c.GetMonitorPrometheusRecordYaml(ctx, ip) }
+	case alertCache.ConfigTypePrometheusFiles:
+		current = func() string { return c.GetMonitorPrometheusFiles(ctx, ip) }
+	case alertCache.ConfigTypeAlertManager:
+		current = func() string { return c.GetMonitorAlertManagerYaml(ctx, ip) }
+	case alertCache.ConfigTypeAlertManagerFiles:
+		current = func() string { return c.GetMonitorAlertManagerFiles(ctx, ip) }
+	default:
+		return "", "", fmt.Errorf("不支持的配置类型: %s", configType)
+	}
+
+	content := current()
+	if wait > 0 && hash != "" && alertCache.HashConfig(content) == hash {
+		waitCtx, cancel := context.WithTimeout(ctx, wait)
+		defer cancel()
+		content = c.watchCache.WaitForChange(waitCtx, hash, current)
+	}
+
+	return content, alertCache.HashConfig(content), nil
+}
@@ -26,24 +26,27 @@ package api
  */
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
 	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/webhook/dao"
+	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/webhook/request"
 	"github.com/GoSimplicity/AI-CloudOps/pkg/utils/apiresponse"
-	pkg "github.com/GoSimplicity/AI-CloudOps/pkg/utils/apiresponse"
+	promPkg "github.com/GoSimplicity/AI-CloudOps/pkg/utils/prometheus"
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/alertmanager/notify/webhook"
-	"github.com/prometheus/alertmanager/pkg/labels"
 	"github.com/prometheus/alertmanager/template"
-	"github.com/prometheus/alertmanager/types"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
+	"net/http"
 	"strconv"
-	"strings"
 	"sync"
 	"time"
 )
 
+// defaultAlertManagerPort AlertManager 实例未带端口时使用的默认端口
+const defaultAlertManagerPort = 9093
+
 // WebHookHandler 负责处理Webhook相关的HTTP请求
 type WebHookHandler struct {
 	l          *zap.Logger
@@ -51,15 +54,24 @@ type WebHookHandler struct {
 	alertQueue chan template.Alert // 告警队列，用于异步处理
 	workerWG   sync.WaitGroup      // 工作组用于等待所有工作者完成
 	quitChan   chan struct{}       // 用于优雅地关闭工作者的通道
+	client     *http.Client        // 调用AlertManager API的客户端
+	amPort     int                 // AlertManager 实例未带端口时使用的端口
 }
 
 // NewWebHookHandler 创建一个新的WebHookHandler实例，并启动告警处理工作者
 func NewWebHookHandler(l *zap.Logger, dao dao.WebhookDao, alertQueue chan template.Alert) *WebHookHandler {
+	amPort := viper.GetInt("webhook.alertmanager_port")
+	if amPort == 0 {
+		amPort = defaultAlertManagerPort
+	}
+
 	return &WebHookHandler{
 		l:          l,
 		dao:        dao,
 		alertQueue: alertQueue,
 		quitChan:   make(chan struct{}),
+		client:     &http.Client{Timeout: time.Duration(viper.GetInt("webhook.im_feishu.request_timeout_seconds")) * time.Second},
+		amPort:     amPort,
 	}
 }
 
@@ -107,7 +119,7 @@ func (w *WebHookHandler) MonitorAlertReceive(ctx *gin.Context) {
 	apiresponse.SuccessWithMessage(ctx, "Alerts received and are being processed")
 }
 
-// MonitorAlertSilence 处理静默告警的请求
+// MonitorAlertSilence 处理静默告警的请求，静默会下发到告警所属发送组的AlertManager实例池中的每个实例
 func (w *WebHookHandler) MonitorAlertSilence(ctx *gin.Context) {
 	fingerprint := ctx.DefaultQuery("fingerprint", "")
 	hour := ctx.DefaultQuery("hour", "")
@@ -126,46 +138,73 @@ func (w *WebHookHandler) MonitorAlertSilence(ctx *gin.Context) {
 		return
 	}
 
-	// 解析标签信息
-	labelsM := map[string]string{}
-	for _, label := range event.Labels {
-		kvs := strings.Split(label, "=")
-		if len(kvs) == 2 {
-			labelsM[kvs[0]] = kvs[1]
-		}
+	pool, err := w.getEventPool(ctx, event)
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
 	}
 
-	event.LabelsMatcher = labelsM
-	// 创建告警匹配器
-	matchers := labels.Matchers{}
-	for k, v := range event.LabelsMatcher {
-		matchers = append(matchers, &labels.Matcher{Type: labels.MatchEqual, Name: k, Value: v})
+	// 根据告警标签生成匹配条件
+	matchers, err := promPkg.AlertEventSilenceMatchers(event, false)
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
+	}
+	amMatchers, err := promPkg.BuildSilenceMatchers(matchers)
+	if err != nil {
+		apiresponse.ErrorWithMessage(ctx, err.Error())
+		return
 	}
 
-	// 构建静默信息
-	silence := types.Silence{
-		Matchers:  matchers,
-		StartsAt:  time.Now(),
-		EndsAt:    time.Now().Add(time.Duration(hourInt) * time.Hour),
-		CreatedBy: "admin",
-		Comment:   "admin处理静默请求",
+	now := time.Now()
+	silence := &model.MonitorSilence{
+		PoolID:             pool.ID,
+		EventID:            event.ID,
+		Matchers:           matchers,
+		StartsAt:           now,
+		EndsAt:             now.Add(time.Duration(hourInt) * time.Hour),
+		Comment:            "通过告警卡片静默",
+		CreatedBy:          "admin",
+		InstanceSilenceIDs: map[string]string{},
+	}
+	amSilence := &promPkg.AlertManagerSilence{
+		Matchers:  amMatchers,
+		StartsAt:  silence.StartsAt,
+		EndsAt:    silence.EndsAt,
+		CreatedBy: silence.CreatedBy,
+		Comment:   silence.Comment,
 	}
 
-	// 发送静默请求到Alertmanager
-	jsonStr, _ := json.Marshal(silence)
-	url := fmt.Sprintf("%s/api/v1/silences", viper.GetString("webhook.alert_manager_api"))
-	_, err = pkg.PostWithJsonString(w.l, "AlertSilence", viper.GetInt("webhook.im_feishu.request_timeout_seconds"), url, string(jsonStr), nil, nil)
+	// 逐个实例创建静默，记录每个实例的结果
+	results := make([]request.SilenceInstanceResult, 0, len(pool.AlertManagerInstances))
+	for _, instance := range pool.AlertManagerInstances {
+		result := request.SilenceInstanceResult{Instance: instance}
+		silenceID, err := promPkg.PostAlertManagerSilence(ctx, w.client, promPkg.AlertManagerInstanceURL(instance, w.amPort), amSilence)
+		if err != nil {
+			w.l.Error("调用Alertmanager静默接口失败", zap.Error(err), zap.String("instance", instance), zap.Int("poolId", pool.ID))
+			result.Error = err.Error()
+		} else {
+			result.SilenceID = silenceID
+			silence.InstanceSilenceIDs[instance] = silenceID
+		}
+		results = append(results, result)
+	}
 
-	if err != nil {
-		w.l.Error("调用Alertmanager静默接口失败", zap.Error(err))
-		apiresponse.ErrorWithMessage(ctx, "Failed to silence alert")
+	if len(silence.InstanceSilenceIDs) == 0 {
+		apiresponse.ErrorWithDetails(ctx, results, "Failed to silence alert")
+		return
+	}
+
+	if err := w.dao.CreateEventSilence(ctx, silence, event.ID); err != nil {
+		w.l.Error("保存静默记录失败", zap.Error(err), zap.Any("instanceSilenceIds", silence.InstanceSilenceIDs))
+		apiresponse.ErrorWithDetails(ctx, results, "Failed to save silence")
 		return
 	}
 
-	apiresponse.Success(ctx)
+	apiresponse.SuccessWithData(ctx, results)
 }
 
-// MonitorAlertUnSilence 处理取消静默告警的请求
+// MonitorAlertUnSilence 处理取消静默告警的请求，使告警关联的静默在实例池的每个实例上过期
 func (w *WebHookHandler) MonitorAlertUnSilence(ctx *gin.Context) {
 	fingerprint := ctx.Query("fingerprint")
 	if fingerprint == "" {
@@ -179,15 +218,84 @@ func (w *WebHookHandler) MonitorAlertUnSilence(ctx *gin.Context) {
 		apiresponse.ErrorWithMessage(ctx, "No silence found for the event")
 		return
 	}
-	// 取消静默
-	silenceURL := fmt.Sprintf("%s/api/v1/silence/%s", viper.GetString("webhook.alert_manager_api"), event.SilenceID)
-	_, err = apiresponse.DeleteWithId(w.l, "MonitorAlertUnSilence", viper.GetInt("webhook.im_feishu.request_timeout_seconds"), silenceURL, nil, nil)
 
-	if err != nil {
-		w.l.Error("取消告警静默失败", zap.Error(err))
-		apiresponse.ErrorWithMessage(ctx, "Failed to unsilence alert")
+	// SilenceID 为静默记录的ID，旧数据中保存的是AlertManager返回的静默ID，此时在实例池的每个实例上过期该ID
+	var silence *model.MonitorSilence
+	instanceSilenceIDs := map[string]string{}
+	if id, err := strconv.Atoi(event.SilenceID); err == nil {
+		silence, err = w.dao.GetMonitorSilenceById(ctx, id)
+		if err != nil || silence == nil {
+			apiresponse.ErrorWithMessage(ctx, "No silence found for the event")
+			return
+		}
+		instanceSilenceIDs = silence.InstanceSilenceIDs
+	} else {
+		pool, err := w.getEventPool(ctx, event)
+		if err != nil {
+			apiresponse.ErrorWithMessage(ctx, err.Error())
+			return
+		}
+		for _, instance := range pool.AlertManagerInstances {
+			instanceSilenceIDs[instance] = event.SilenceID
+		}
+	}
+
+	results := make([]request.SilenceInstanceResult, 0, len(instanceSilenceIDs))
+	remaining := make(map[string]string)
+	for instance, silenceID := range instanceSilenceIDs {
+		result := request.SilenceInstanceResult{Instance: instance, SilenceID: silenceID}
+		if err := promPkg.ExpireAlertManagerSilence(ctx, w.client, promPkg.AlertManagerInstanceURL(instance, w.amPort), silenceID); err != nil {
+			w.l.Error("取消告警静默失败", zap.Error(err), zap.String("instance", instance), zap.String("silenceId", silenceID))
+			result.Error = err.Error()
+			remaining[instance] = silenceID
+		}
+		results = append(results, result)
+	}
+
+	// 有实例失败时不标记静默过期，也不解除事件关联，静默记录只保留失败实例的静默ID，再次取消静默时只重试这些实例
+	if len(remaining) > 0 {
+		if silence != nil && len(remaining) < len(instanceSilenceIDs) {
+			silence.InstanceSilenceIDs = remaining
+			if err := w.dao.UpdateSilenceInstanceIDs(ctx, silence); err != nil {
+				apiresponse.ErrorWithDetails(ctx, results, "Failed to update silence")
+				return
+			}
+		}
+		apiresponse.ErrorWithDetails(ctx, results, "Failed to unsilence alert")
+		return
+	}
+
+	if silence != nil {
+		silence.EndsAt = time.Now()
+	}
+	if err := w.dao.ExpireEventSilence(ctx, silence, event.ID); err != nil {
+		apiresponse.ErrorWithDetails(ctx, results, "Failed to update silence")
 		return
 	}
 
-	apiresponse.Success(ctx)
+	apiresponse.SuccessWithData(ctx, results)
+}
+
+// getEventPool 通过告警事件的发送组找到所属的AlertManager实例池
+func (w *WebHookHandler) getEventPool(ctx context.Context, event *model.MonitorAlertEvent) (*model.MonitorAlertManagerPool, error) {
+	sendGroup, err := w.dao.GetSendGroupById(ctx, event.SendGroupID)
+	if err != nil {
+		return nil, err
+	}
+	if sendGroup == nil {
+		return nil, fmt.Errorf("告警事件关联的发送组 %d 不存在", event.SendGroupID)
+	}
+
+	pool, err := w.dao.GetAlertManagerPoolById(ctx, sendGroup.PoolID)
+	if err != nil {
+		return nil, err
+	}
+	if pool == nil {
+		return nil, fmt.Errorf("发送组 %s 关联的AlertManager实例 %d 不存在", sendGroup.Name, sendGroup.PoolID)
+	}
+	if len(pool.AlertManagerInstances) == 0 {
+		return nil, fmt.Errorf("AlertManager实例 %s 没有配置实例地址", pool.Name)
+	}
+
+	return pool, nil
 }
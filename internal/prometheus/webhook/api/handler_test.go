@@ -0,0 +1,129 @@
+package api
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/webhook/dao"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type fakeWebhookDao struct {
+	dao.WebhookDao
+	silence *model.MonitorSilence
+	expired bool
+}
+
+func (f *fakeWebhookDao) GetMonitorAlertEventByFingerprintId(_ context.Context, _ string) (*model.MonitorAlertEvent, error) {
+	return &model.MonitorAlertEvent{SilenceID: strconv.Itoa(f.silence.ID)}, nil
+}
+
+func (f *fakeWebhookDao) GetMonitorSilenceById(_ context.Context, _ int) (*model.MonitorSilence, error) {
+	// 返回副本，模拟每次从数据库重新读取
+	silence := *f.silence
+	silence.InstanceSilenceIDs = make(map[string]string)
+	for instance, id := range f.silence.InstanceSilenceIDs {
+		silence.InstanceSilenceIDs[instance] = id
+	}
+	return &silence, nil
+}
+
+func (f *fakeWebhookDao) UpdateSilenceInstanceIDs(_ context.Context, silence *model.MonitorSilence) error {
+	f.silence.InstanceSilenceIDs = silence.InstanceSilenceIDs
+	return nil
+}
+
+func (f *fakeWebhookDao) ExpireEventSilence(_ context.Context, silence *model.MonitorSilence, _ int) error {
+	f.silence.EndsAt = silence.EndsAt
+	f.expired = true
+	return nil
+}
+
+func TestMonitorAlertUnSilenceRetriesFailedInstances(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// 同一个 AlertManager 通过 127.0.0.1 和 localhost 两个实例名访问，localhost 先失败一次
+	failLocalhost := true
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, _ := net.SplitHostPort(r.Host)
+		if host == "localhost" && failLocalhost {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		deleted = append(deleted, r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	_, portStr, _ := net.SplitHostPort(server.Listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+
+	endsAt := time.Now().Add(time.Hour)
+	fake := &fakeWebhookDao{silence: &model.MonitorSilence{
+		NoUniqueIndexModel: model.NoUniqueIndexModel{ID: 1},
+		EndsAt:             endsAt,
+		InstanceSilenceIDs: map[string]string{
+			"127.0.0.1": "s1",
+			"localhost": "s2",
+		},
+	}}
+	handler := &WebHookHandler{l: zap.NewNop(), dao: fake, client: server.Client(), amPort: port}
+
+	unsilence := func() {
+		w := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(w)
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/api/v1/alerts/unsilence?fingerprint=fp", nil)
+		handler.MonitorAlertUnSilence(ctx)
+	}
+
+	unsilence()
+	if fake.expired || !fake.silence.EndsAt.Equal(endsAt) {
+		t.Fatal("部分实例失败时不应标记静默过期")
+	}
+	if len(fake.silence.InstanceSilenceIDs) != 1 || fake.silence.InstanceSilenceIDs["localhost"] != "s2" {
+		t.Fatalf("只应保留失败实例的静默ID, 实际 %v", fake.silence.InstanceSilenceIDs)
+	}
+
+	failLocalhost = false
+	deleted = nil
+	unsilence()
+	if len(deleted) != 1 || deleted[0] != "/api/v2/silence/s2" {
+		t.Fatalf("重试只应请求失败的实例, 实际 %v", deleted)
+	}
+	if !fake.expired {
+		t.Fatal("全部实例成功后应标记静默过期")
+	}
+}
@@ -47,8 +47,17 @@ type WebhookDao interface {
 	GetMonitorSendGroupList(ctx context.Context) ([]*model.MonitorSendGroup, error)
 	GetMonitorAlertEventByFingerprintId(ctx context.Context, fingerprintId string) (*model.MonitorAlertEvent, error)
 
+	GetAlertManagerPoolById(ctx context.Context, id int) (*model.MonitorAlertManagerPool, error)
+	GetMonitorSilenceById(ctx context.Context, id int) (*model.MonitorSilence, error)
+
 	CreateOrUpdateEvent(ctx context.Context, event *model.MonitorAlertEvent) error
 	UpdateMonitorAlertEvent(ctx context.Context, event *model.MonitorAlertEvent) error
+	// CreateEventSilence 保存静默记录并将告警事件关联到该记录
+	CreateEventSilence(ctx context.Context, silence *model.MonitorSilence, eventId int) error
+	// ExpireEventSilence 记录静默已过期并解除告警事件的关联，silence 为 nil 时只解除指定事件的关联
+	ExpireEventSilence(ctx context.Context, silence *model.MonitorSilence, eventId int) error
+	// UpdateSilenceInstanceIDs 更新静默记录中各AlertManager实例的静默ID
+	UpdateSilenceInstanceIDs(ctx context.Context, silence *model.MonitorSilence) error
 
 	FillTodayOnDutyUser(ctx context.Context, onDutyGroup *model.MonitorOnDutyGroup) (*model.MonitorOnDutyGroup, error)
 }
@@ -365,3 +374,104 @@ func (wd *webhookDao) GetMonitorAlertRuleList(ctx context.Context) ([]*model.Mon
 
 	return rules, nil
 }
+
+// GetAlertManagerPoolById 根据ID获取MonitorAlertManagerPool
+func (wd *webhookDao) GetAlertManagerPoolById(ctx context.Context, id int) (*model.MonitorAlertManagerPool, error) {
+	var pool model.MonitorAlertManagerPool
+
+	if err := wd.db.WithContext(ctx).Where("id = ?", id).First(&pool).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			wd.l.Warn("MonitorAlertManagerPool 未找到", zap.Int("id", id))
+			return nil, nil
+		}
+		wd.l.Error("获取 MonitorAlertManagerPool 失败", zap.Error(err), zap.Int("id", id))
+		return nil, fmt.Errorf("failed to get MonitorAlertManagerPool by id %d: %w", id, err)
+	}
+
+	return &pool, nil
+}
+
+// GetMonitorSilenceById 根据ID获取MonitorSilence
+func (wd *webhookDao) GetMonitorSilenceById(ctx context.Context, id int) (*model.MonitorSilence, error) {
+	var silence model.MonitorSilence
+
+	if err := wd.db.WithContext(ctx).Where("id = ?", id).First(&silence).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			wd.l.Warn("MonitorSilence 未找到", zap.Int("id", id))
+			return nil, nil
+		}
+		wd.l.Error("获取 MonitorSilence 失败", zap.Error(err), zap.Int("id", id))
+		return nil, fmt.Errorf("failed to get MonitorSilence by id %d: %w", id, err)
+	}
+
+	return &silence, nil
+}
+
+// CreateEventSilence 保存静默记录并将告警事件标记为已屏蔽
+func (wd *webhookDao) CreateEventSilence(ctx context.Context, silence *model.MonitorSilence, eventId int) error {
+	return wd.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(silence).Error; err != nil {
+			wd.l.Error("创建 MonitorSilence 失败", zap.Error(err), zap.Int("eventId", eventId))
+			return fmt.Errorf("failed to create MonitorSilence: %w", err)
+		}
+
+		if err := tx.Model(&model.MonitorAlertEvent{}).
+			Where("id = ?", eventId).
+			Updates(map[string]interface{}{
+				"silence_id": fmt.Sprintf("%d", silence.ID),
+				"status":     model.AlertEventStatusSilenced,
+			}).Error; err != nil {
+			wd.l.Error("关联 MonitorAlertEvent 与静默失败", zap.Error(err), zap.Int("eventId", eventId))
+			return fmt.Errorf("failed to link MonitorAlertEvent %d to silence: %w", eventId, err)
+		}
+
+		return nil
+	})
+}
+
+// ExpireEventSilence 更新静默的结束时间，并解除关联事件的静默，仍为已屏蔽状态的事件恢复为告警中
+func (wd *webhookDao) ExpireEventSilence(ctx context.Context, silence *model.MonitorSilence, eventId int) error {
+	return wd.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		column, value := "id", interface{}(eventId)
+
+		if silence != nil {
+			if err := tx.Model(&model.MonitorSilence{}).
+				Where("id = ?", silence.ID).
+				Update("ends_at", silence.EndsAt).Error; err != nil {
+				wd.l.Error("更新 MonitorSilence 结束时间失败", zap.Error(err), zap.Int("id", silence.ID))
+				return fmt.Errorf("failed to expire MonitorSilence %d: %w", silence.ID, err)
+			}
+			column, value = "silence_id", fmt.Sprintf("%d", silence.ID)
+		}
+
+		if err := tx.Model(&model.MonitorAlertEvent{}).
+			Where(column+" = ? AND status = ?", value, model.AlertEventStatusSilenced).
+			Update("status", model.AlertEventStatusFiring).Error; err != nil {
+			wd.l.Error("恢复 MonitorAlertEvent 状态失败", zap.Error(err), zap.Int("eventId", eventId))
+			return fmt.Errorf("failed to restore MonitorAlertEvent status: %w", err)
+		}
+
+		if err := tx.Model(&model.MonitorAlertEvent{}).
+			Where(column+" = ?", value).
+			Update("silence_id", "").Error; err != nil {
+			wd.l.Error("解除 MonitorAlertEvent 静默关联失败", zap.Error(err), zap.Int("eventId", eventId))
+			return fmt.Errorf("failed to unlink MonitorAlertEvent silence: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// UpdateSilenceInstanceIDs 更新静默记录中各AlertManager实例的静默ID
+func (wd *webhookDao) UpdateSilenceInstanceIDs(ctx context.Context, silence *model.MonitorSilence) error {
+	if err := wd.db.WithContext(ctx).
+		Model(&model.MonitorSilence{}).
+		Where("id = ?", silence.ID).
+		Select("instance_silence_ids").
+		Updates(silence).Error; err != nil {
+		wd.l.Error("更新 MonitorSilence 实例静默ID失败", zap.Error(err), zap.Int("id", silence.ID))
+		return fmt.Errorf("failed to update MonitorSilence %d instance silence ids: %w", silence.ID, err)
+	}
+
+	return nil
+}
@@ -25,10 +25,9 @@ package request
  *
  */
 
-// AlertmanagerSilenceResponse 表示告警管理器静默响应结构体
-type AlertmanagerSilenceResponse struct {
-	Status string `json:"status"`
-	Data   struct {
-		SilenceId string `json:"silenceId"`
-	} `json:"data"`
+// SilenceInstanceResult 表示静默请求在单个AlertManager实例上的执行结果
+type SilenceInstanceResult struct {
+	Instance  string `json:"instance"`            // AlertManager实例
+	SilenceID string `json:"silenceId,omitempty"` // 实例返回的静默ID
+	Error     string `json:"error,omitempty"`     // 失败原因，成功时为空
 }
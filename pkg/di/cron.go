@@ -30,6 +30,7 @@ import (
 	cn "github.com/GoSimplicity/AI-CloudOps/internal/cron"
 	"github.com/GoSimplicity/AI-CloudOps/internal/k8s/client"
 	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/cache"
+	alertService "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/service/alert"
 	"github.com/robfig/cron/v3"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
@@ -38,7 +39,7 @@ import (
 
 // InitAndRefreshK8sClient 初始化并启动定时刷新 Kubernetes 客户端
 // 返回 cron 调度器实例以便调用者可以在需要时停止它
-func InitAndRefreshK8sClient(K8sClient client.K8sClient, logger *zap.Logger, PromCache cache.MonitorCache, manager cn.CronManager) *cron.Cron {
+func InitAndRefreshK8sClient(K8sClient client.K8sClient, logger *zap.Logger, PromCache cache.MonitorCache, manager cn.CronManager, ruleExportService alertService.AlertManagerRuleExportService) *cron.Cron {
 	stdLogger := zap.NewStdLog(logger)
 
 	// 启用秒级调度，并集成日志记录和恢复中间件
@@ -89,6 +90,7 @@ func InitAndRefreshK8sClient(K8sClient client.K8sClient, logger *zap.Logger, Pro
 	// 从配置文件中获取 cron 表达式
 	k8sRefreshCron := viper.GetString("k8s.refresh_cron")               // 例如 "@every 15s"
 	prometheusRefreshCron := viper.GetString("prometheus.refresh_cron") // 例如 "@every 15s"
+	ruleExportCron := viper.GetString("prometheus.rule_export_cron")    // 例如 "@every 1m"
 
 	// 添加 Kubernetes 客户端定时刷新任务
 	if k8sRefreshCron != "" {
@@ -129,5 +131,20 @@ func InitAndRefreshK8sClient(K8sClient client.K8sClient, logger *zap.Logger, Pro
 		logger.Warn("InitAndRefreshK8sClient: 未配置 Prometheus 缓存刷新 cron 表达式")
 	}
 
+	// 添加 PrometheusRule 定时同步任务，使集群中的规则与平台保持一致
+	if ruleExportCron != "" {
+		_, err := c.AddFunc(ruleExportCron, func() {
+			taskCtx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			defer cancel()
+
+			if err := ruleExportService.SyncAllMonitorRuleExports(taskCtx); err != nil {
+				logger.Error("InitAndRefreshK8sClient: 定时同步 PrometheusRule 失败", zap.Error(err))
+			}
+		})
+		if err != nil {
+			logger.Error("InitAndRefreshK8sClient: 添加 PrometheusRule 定时同步任务失败", zap.Error(err))
+		}
+	}
+
 	return c
 }
@@ -66,5 +66,16 @@ func InitTables(db *gorm.DB) error {
 		&model.MonitorSendGroup{},
 		&model.MonitorOnDutyChange{},
 		&model.MonitorAlertEvent{},
+		&model.MonitorConfigVersion{},
+		&model.MonitorConfigPin{},
+		&model.MonitorConfigSyncState{},
+		&model.MonitorConfigApplied{},
+		&model.MonitorRuleTest{},
+		&model.MonitorRuleGroup{},
+		&model.MonitorRuleExport{},
+		&model.MonitorAlertRuleTemplate{},
+		&model.MonitorInhibitRule{},
+		&model.MonitorTimeInterval{},
+		&model.MonitorSilence{},
 	)
 }
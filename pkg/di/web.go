@@ -57,11 +57,22 @@ func InitGinServer(
 	alertPoolHdl *prometheusApi.AlertPoolHandler,
 	alertRuleHdl *prometheusApi.AlertRuleHandler,
 	configYamlHdl *prometheusApi.ConfigYamlHandler,
+	configVersionHdl *prometheusApi.ConfigVersionHandler,
+	configSyncHdl *prometheusApi.ConfigSyncHandler,
 	onDutyGroupHdl *prometheusApi.OnDutyGroupHandler,
 	recordRuleHdl *prometheusApi.RecordRuleHandler,
 	scrapePoolHdl *prometheusApi.ScrapePoolHandler,
 	scrapeJobHdl *prometheusApi.ScrapeJobHandler,
 	sendGroupHdl *prometheusApi.SendGroupHandler,
+	ruleTestHdl *prometheusApi.RuleTestHandler,
+	ruleGroupHdl *prometheusApi.RuleGroupHandler,
+	promQueryHdl *prometheusApi.PromQueryHandler,
+	ruleImportHdl *prometheusApi.RuleImportHandler,
+	ruleExportHdl *prometheusApi.RuleExportHandler,
+	ruleTemplateHdl *prometheusApi.RuleTemplateHandler,
+	inhibitRuleHdl *prometheusApi.InhibitRuleHandler,
+	timeIntervalHdl *prometheusApi.TimeIntervalHandler,
+	silenceHdl *prometheusApi.SilenceHandler,
 ) *gin.Engine {
 	server := gin.Default()
 	server.Use(m...)
@@ -73,11 +84,22 @@ func InitGinServer(
 	alertPoolHdl.RegisterRouters(server)
 	alertRuleHdl.RegisterRouters(server)
 	configYamlHdl.RegisterRouters(server)
+	configVersionHdl.RegisterRouters(server)
+	configSyncHdl.RegisterRouters(server)
 	onDutyGroupHdl.RegisterRouters(server)
 	recordRuleHdl.RegisterRouters(server)
 	scrapePoolHdl.RegisterRouters(server)
 	scrapeJobHdl.RegisterRouters(server)
 	sendGroupHdl.RegisterRouters(server)
+	ruleTestHdl.RegisterRouters(server)
+	ruleGroupHdl.RegisterRouters(server)
+	promQueryHdl.RegisterRouters(server)
+	ruleImportHdl.RegisterRouters(server)
+	ruleExportHdl.RegisterRouters(server)
+	ruleTemplateHdl.RegisterRouters(server)
+	inhibitRuleHdl.RegisterRouters(server)
+	timeIntervalHdl.RegisterRouters(server)
+	silenceHdl.RegisterRouters(server)
 	k8sClusterHdl.RegisterRouters(server)
 	k8sAppHdl.RegisterRouters(server)
 	k8sConfigMapHdl.RegisterRouters(server)
@@ -32,14 +32,18 @@ import (
 	k8sHandler "github.com/GoSimplicity/AI-CloudOps/internal/k8s/api"
 	"github.com/GoSimplicity/AI-CloudOps/internal/k8s/client"
 	k8sDao "github.com/GoSimplicity/AI-CloudOps/internal/k8s/dao/admin"
+	k8sAppDao "github.com/GoSimplicity/AI-CloudOps/internal/k8s/dao/uesr"
 	k8sAdminService "github.com/GoSimplicity/AI-CloudOps/internal/k8s/service/admin"
+	k8sAppService "github.com/GoSimplicity/AI-CloudOps/internal/k8s/service/uesr"
 	notAuthHandler "github.com/GoSimplicity/AI-CloudOps/internal/not_auth/api"
 	notAuthService "github.com/GoSimplicity/AI-CloudOps/internal/not_auth/service"
 	promHandler "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/api"
 	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/cache"
 	alertDao "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/alert"
+	configDao "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/config"
 	scrapeJobDao "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/scrape"
 	alertService "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/service/alert"
+	queryService "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/service/query"
 	scrapeJobService "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/service/scrape"
 	yamlService "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/service/yaml"
 	authHandler "github.com/GoSimplicity/AI-CloudOps/internal/system/api"
@@ -81,6 +85,10 @@ func InitWebServer() *Cmd {
 		cache.NewRuleConfigCache,
 		cache.NewRecordConfig,
 		cache.NewPromConfigCache,
+		cache.NewConfigVersionCache,
+		cache.NewConfigErrorCache,
+		cache.NewConfigSyncCache,
+		cache.NewConfigWatchCache,
 		cron.NewCronManager,
 		userHandler.NewUserHandler,
 		authHandler.NewAuthHandler,
@@ -107,6 +115,10 @@ func InitWebServer() *Cmd {
 		k8sAdminService.NewTaintService,
 		k8sAdminService.NewYamlTaskService,
 		k8sAdminService.NewYamlTemplateService,
+		k8sAppService.NewAppService,
+		k8sAppService.NewInstanceService,
+		k8sAppService.NewProjectService,
+		k8sAppService.NewCronjobService,
 		userService.NewUserService,
 		treeService.NewTreeService,
 		apiService.NewApiService,
@@ -114,6 +126,8 @@ func InitWebServer() *Cmd {
 		menuService.NewMenuService,
 		promHandler.NewAlertPoolHandler,
 		promHandler.NewConfigYamlHandler,
+		promHandler.NewConfigVersionHandler,
+		promHandler.NewConfigSyncHandler,
 		promHandler.NewOnDutyGroupHandler,
 		promHandler.NewRecordRuleHandler,
 		promHandler.NewAlertRuleHandler,
@@ -121,14 +135,32 @@ func InitWebServer() *Cmd {
 		promHandler.NewScrapeJobHandler,
 		promHandler.NewScrapePoolHandler,
 		promHandler.NewAlertEventHandler,
+		promHandler.NewRuleTestHandler,
+		promHandler.NewRuleGroupHandler,
+		promHandler.NewPromQueryHandler,
+		promHandler.NewRuleImportHandler,
+		promHandler.NewRuleExportHandler,
+		promHandler.NewRuleTemplateHandler,
+		promHandler.NewInhibitRuleHandler,
+		promHandler.NewTimeIntervalHandler,
+		promHandler.NewSilenceHandler,
 		alertService.NewAlertManagerEventService,
 		alertService.NewAlertManagerOnDutyService,
 		alertService.NewAlertManagerPoolService,
 		alertService.NewAlertManagerRecordService,
 		alertService.NewAlertManagerRuleService,
 		alertService.NewAlertManagerSendService,
+		alertService.NewAlertManagerRuleTestService,
+		alertService.NewAlertManagerRuleGroupService,
+		alertService.NewAlertManagerRuleImportService,
+		alertService.NewAlertManagerRuleExportService,
+		alertService.NewAlertManagerRuleTemplateService,
+		alertService.NewAlertManagerInhibitService,
+		alertService.NewAlertManagerTimeIntervalService,
+		alertService.NewAlertManagerSilenceService,
 		scrapeJobService.NewPrometheusScrapeService,
 		scrapeJobService.NewPrometheusPoolService,
+		queryService.NewPromQueryService,
 		treeService.NewAliResourceService,
 		alertDao.NewAlertManagerEventDAO,
 		alertDao.NewAlertManagerOnDutyDAO,
@@ -136,10 +168,22 @@ func InitWebServer() *Cmd {
 		alertDao.NewAlertManagerRecordDAO,
 		alertDao.NewAlertManagerRuleDAO,
 		alertDao.NewAlertManagerSendDAO,
+		alertDao.NewAlertManagerRuleTestDAO,
+		alertDao.NewAlertManagerRuleGroupDAO,
+		alertDao.NewAlertManagerRuleImportDAO,
+		alertDao.NewAlertManagerRuleExportDAO,
+		alertDao.NewAlertManagerRuleTemplateDAO,
+		alertDao.NewAlertManagerInhibitDAO,
+		alertDao.NewAlertManagerTimeIntervalDAO,
+		alertDao.NewAlertManagerSilenceDAO,
 		scrapeJobDao.NewScrapeJobDAO,
 		scrapeJobDao.NewScrapePoolDAO,
+		configDao.NewConfigVersionDAO,
+		configDao.NewConfigSyncDAO,
 		aliDao.NewAliResourceDAO,
 		yamlService.NewPrometheusConfigService,
+		yamlService.NewConfigVersionService,
+		yamlService.NewConfigSyncService,
 		notAuthService.NewNotAuthService,
 		userDao.NewUserDAO,
 		apiDao.NewApiDAO,
@@ -152,6 +196,10 @@ func InitWebServer() *Cmd {
 		k8sDao.NewClusterDAO,
 		k8sDao.NewYamlTemplateDAO,
 		k8sDao.NewYamlTaskDAO,
+		k8sAppDao.NewAppDAO,
+		k8sAppDao.NewInstanceDAO,
+		k8sAppDao.NewProjectDAO,
+		k8sAppDao.NewCronjobDAO,
 		nodeDao.NewTreeNodeDAO,
 		wire.Struct(new(Cmd), "*"),
 	)
@@ -11,14 +11,18 @@ import (
 	api7 "github.com/GoSimplicity/AI-CloudOps/internal/k8s/api"
 	"github.com/GoSimplicity/AI-CloudOps/internal/k8s/client"
 	"github.com/GoSimplicity/AI-CloudOps/internal/k8s/dao/admin"
+	"github.com/GoSimplicity/AI-CloudOps/internal/k8s/dao/uesr"
 	admin2 "github.com/GoSimplicity/AI-CloudOps/internal/k8s/service/admin"
+	uesr2 "github.com/GoSimplicity/AI-CloudOps/internal/k8s/service/uesr"
 	api6 "github.com/GoSimplicity/AI-CloudOps/internal/not_auth/api"
 	service3 "github.com/GoSimplicity/AI-CloudOps/internal/not_auth/service"
 	api8 "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/api"
 	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/cache"
 	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/alert"
+	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/config"
 	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/dao/scrape"
 	alert2 "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/service/alert"
+	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/service/query"
 	scrape2 "github.com/GoSimplicity/AI-CloudOps/internal/prometheus/service/scrape"
 	"github.com/GoSimplicity/AI-CloudOps/internal/prometheus/service/yaml"
 	api4 "github.com/GoSimplicity/AI-CloudOps/internal/system/api"
@@ -100,41 +104,85 @@ func InitWebServer() *Cmd {
 	k8sYamlTaskHandler := api7.NewK8sYamlTaskHandler(logger, yamlTaskService)
 	yamlTemplateService := admin2.NewYamlTemplateService(yamlTemplateDAO, yamlTaskDAO, k8sClient, logger)
 	k8sYamlTemplateHandler := api7.NewK8sYamlTemplateHandler(logger, yamlTemplateService)
-	k8sAppHandler := api7.NewK8sAppHandler(logger)
+	appDAO := uesr.NewAppDAO(db, logger)
+	instanceDAO := uesr.NewInstanceDAO(db, logger)
+	projectDAO := uesr.NewProjectDAO(db, logger)
+	appService := uesr2.NewAppService(appDAO, instanceDAO, projectDAO, clusterDAO, k8sClient, logger)
+	instanceService := uesr2.NewInstanceService(instanceDAO, appDAO, clusterDAO, k8sClient, logger)
+	projectService := uesr2.NewProjectService(projectDAO, appDAO, logger)
+	cronjobDAO := uesr.NewCronjobDAO(db, logger)
+	cronjobService := uesr2.NewCronjobService(cronjobDAO, clusterDAO, k8sClient, logger)
+	k8sAppHandler := api7.NewK8sAppHandler(logger, appService, instanceService, projectService, cronjobService)
 	alertManagerEventDAO := alert.NewAlertManagerEventDAO(db, logger, userDAO)
 	scrapePoolDAO := scrape.NewScrapePoolDAO(db, logger, userDAO)
 	scrapeJobDAO := scrape.NewScrapeJobDAO(db, logger, userDAO)
-	promConfigCache := cache.NewPromConfigCache(logger, scrapePoolDAO, scrapeJobDAO)
+	configVersionDAO := config.NewConfigVersionDAO(db, logger)
+	configVersionCache := cache.NewConfigVersionCache(logger, configVersionDAO)
+	configErrorCache := cache.NewConfigErrorCache(logger)
+	promConfigCache := cache.NewPromConfigCache(logger, scrapePoolDAO, scrapeJobDAO, configVersionCache, configErrorCache)
 	alertManagerPoolDAO := alert.NewAlertManagerPoolDAO(db, logger, userDAO)
 	alertManagerSendDAO := alert.NewAlertManagerSendDAO(db, logger, userDAO)
-	alertConfigCache := cache.NewAlertConfigCache(logger, alertManagerPoolDAO, alertManagerSendDAO)
+	alertManagerInhibitDAO := alert.NewAlertManagerInhibitDAO(db, logger)
+	alertManagerTimeIntervalDAO := alert.NewAlertManagerTimeIntervalDAO(db, logger)
+	alertConfigCache := cache.NewAlertConfigCache(logger, alertManagerPoolDAO, alertManagerSendDAO, alertManagerInhibitDAO, alertManagerTimeIntervalDAO, configVersionCache, configErrorCache)
 	alertManagerRuleDAO := alert.NewAlertManagerRuleDAO(db, logger, userDAO)
-	ruleConfigCache := cache.NewRuleConfigCache(logger, scrapePoolDAO, alertManagerRuleDAO)
 	alertManagerRecordDAO := alert.NewAlertManagerRecordDAO(db, logger, userDAO)
-	recordConfigCache := cache.NewRecordConfig(logger, scrapePoolDAO, alertManagerRecordDAO)
-	monitorCache := cache.NewMonitorCache(promConfigCache, alertConfigCache, ruleConfigCache, recordConfigCache, logger)
-	alertManagerEventService := alert2.NewAlertManagerEventService(alertManagerEventDAO, monitorCache, logger, userDAO)
+	alertManagerRuleGroupDAO := alert.NewAlertManagerRuleGroupDAO(db, logger)
+	ruleConfigCache := cache.NewRuleConfigCache(logger, scrapePoolDAO, alertManagerRuleDAO, alertManagerRecordDAO, alertManagerRuleGroupDAO, configVersionCache, configErrorCache)
+	recordConfigCache := cache.NewRecordConfig(logger, scrapePoolDAO, alertManagerRecordDAO, alertManagerRuleDAO, alertManagerRuleGroupDAO, configVersionCache, configErrorCache)
+	configSyncDAO := config.NewConfigSyncDAO(db, logger)
+	configSyncCache := cache.NewConfigSyncCache(logger, promConfigCache, alertConfigCache, ruleConfigCache, recordConfigCache, scrapePoolDAO, alertManagerPoolDAO, configSyncDAO, configVersionDAO)
+	configWatchCache := cache.NewConfigWatchCache()
+	monitorCache := cache.NewMonitorCache(promConfigCache, alertConfigCache, ruleConfigCache, recordConfigCache, configSyncCache, configWatchCache, logger)
+	alertManagerSilenceDAO := alert.NewAlertManagerSilenceDAO(db, logger)
+	alertManagerSilenceService := alert2.NewAlertManagerSilenceService(alertManagerSilenceDAO, alertManagerEventDAO, alertManagerSendDAO, alertManagerPoolDAO, logger, userDAO)
+	alertManagerEventService := alert2.NewAlertManagerEventService(alertManagerEventDAO, alertManagerSilenceService, monitorCache, logger, userDAO)
 	alertEventHandler := api8.NewAlertEventHandler(logger, alertManagerEventService)
 	alertManagerPoolService := alert2.NewAlertManagerPoolService(alertManagerPoolDAO, alertManagerSendDAO, monitorCache, logger, userDAO)
 	alertPoolHandler := api8.NewAlertPoolHandler(logger, alertManagerPoolService)
-	alertManagerRuleService := alert2.NewAlertManagerRuleService(alertManagerRuleDAO, monitorCache, logger, userDAO)
+	alertManagerRuleTestDAO := alert.NewAlertManagerRuleTestDAO(db, logger)
+	alertManagerRuleService := alert2.NewAlertManagerRuleService(alertManagerRuleDAO, alertManagerRuleTestDAO, alertManagerRuleGroupDAO, monitorCache, logger, userDAO)
 	alertRuleHandler := api8.NewAlertRuleHandler(logger, alertManagerRuleService)
-	configYamlService := yaml.NewPrometheusConfigService(promConfigCache, alertConfigCache, ruleConfigCache, recordConfigCache)
+	configYamlService := yaml.NewPrometheusConfigService(promConfigCache, alertConfigCache, ruleConfigCache, recordConfigCache, configErrorCache, configWatchCache)
 	configYamlHandler := api8.NewConfigYamlHandler(logger, configYamlService)
+	configVersionService := yaml.NewConfigVersionService(configVersionDAO, configVersionCache, monitorCache, userDAO, logger)
+	configVersionHandler := api8.NewConfigVersionHandler(logger, configVersionService)
+	configSyncService := yaml.NewConfigSyncService(configSyncCache, logger)
+	configSyncHandler := api8.NewConfigSyncHandler(logger, configSyncService)
 	alertManagerOnDutyDAO := alert.NewAlertManagerOnDutyDAO(db, logger, userDAO)
 	alertManagerOnDutyService := alert2.NewAlertManagerOnDutyService(alertManagerOnDutyDAO, alertManagerSendDAO, monitorCache, logger, userDAO)
 	onDutyGroupHandler := api8.NewOnDutyGroupHandler(logger, alertManagerOnDutyService)
-	alertManagerRecordService := alert2.NewAlertManagerRecordService(alertManagerRecordDAO, monitorCache, logger, userDAO)
+	alertManagerRecordService := alert2.NewAlertManagerRecordService(alertManagerRecordDAO, alertManagerRuleTestDAO, alertManagerRuleGroupDAO, monitorCache, logger, userDAO)
 	recordRuleHandler := api8.NewRecordRuleHandler(logger, alertManagerRecordService)
 	scrapePoolService := scrape2.NewPrometheusPoolService(scrapePoolDAO, monitorCache, logger, userDAO, scrapeJobDAO)
 	scrapePoolHandler := api8.NewScrapePoolHandler(logger, scrapePoolService)
-	scrapeJobService := scrape2.NewPrometheusScrapeService(scrapeJobDAO, monitorCache, logger, userDAO)
+	scrapeJobService := scrape2.NewPrometheusScrapeService(scrapeJobDAO, scrapePoolDAO, monitorCache, promConfigCache, logger, userDAO)
 	scrapeJobHandler := api8.NewScrapeJobHandler(logger, scrapeJobService)
-	alertManagerSendService := alert2.NewAlertManagerSendService(alertManagerSendDAO, alertManagerRuleDAO, monitorCache, logger, userDAO)
+	alertManagerSendService := alert2.NewAlertManagerSendService(alertManagerSendDAO, alertManagerRuleDAO, alertManagerTimeIntervalDAO, alertManagerPoolDAO, monitorCache, logger, userDAO)
 	sendGroupHandler := api8.NewSendGroupHandler(logger, alertManagerSendService)
-	engine := InitGinServer(v, userHandler, authHandler, treeHandler, notAuthHandler, k8sClusterHandler, k8sConfigMapHandler, k8sDeploymentHandler, k8sNamespaceHandler, k8sNodeHandler, k8sPodHandler, k8sSvcHandler, k8sTaintHandler, k8sYamlTaskHandler, k8sYamlTemplateHandler, k8sAppHandler, alertEventHandler, alertPoolHandler, alertRuleHandler, configYamlHandler, onDutyGroupHandler, recordRuleHandler, scrapePoolHandler, scrapeJobHandler, sendGroupHandler)
+	alertManagerRuleTestService := alert2.NewAlertManagerRuleTestService(alertManagerRuleTestDAO, alertManagerRuleDAO, alertManagerRecordDAO, logger, userDAO)
+	ruleTestHandler := api8.NewRuleTestHandler(logger, alertManagerRuleTestService)
+	alertManagerRuleGroupService := alert2.NewAlertManagerRuleGroupService(alertManagerRuleGroupDAO, scrapePoolDAO, monitorCache, ruleConfigCache, logger)
+	ruleGroupHandler := api8.NewRuleGroupHandler(logger, alertManagerRuleGroupService)
+	promQueryService := query.NewPromQueryService(scrapePoolDAO, alertManagerRuleDAO, alertManagerRuleGroupDAO, userDAO, casbinDAO, logger)
+	promQueryHandler := api8.NewPromQueryHandler(logger, promQueryService)
+	alertManagerRuleImportDAO := alert.NewAlertManagerRuleImportDAO(db, logger)
+	alertManagerRuleImportService := alert2.NewAlertManagerRuleImportService(alertManagerRuleImportDAO, alertManagerRuleTestDAO, scrapePoolDAO, monitorCache, logger)
+	ruleImportHandler := api8.NewRuleImportHandler(logger, alertManagerRuleImportService)
+	alertManagerRuleExportDAO := alert.NewAlertManagerRuleExportDAO(db, logger)
+	alertManagerRuleExportService := alert2.NewAlertManagerRuleExportService(alertManagerRuleExportDAO, scrapePoolDAO, clusterDAO, k8sClient, ruleConfigCache, userDAO, logger)
+	ruleExportHandler := api8.NewRuleExportHandler(logger, alertManagerRuleExportService)
+	alertManagerRuleTemplateDAO := alert.NewAlertManagerRuleTemplateDAO(db, logger)
+	alertManagerRuleTemplateService := alert2.NewAlertManagerRuleTemplateService(alertManagerRuleTemplateDAO, alertManagerRuleDAO, alertManagerRuleTestDAO, alertManagerRuleGroupDAO, scrapePoolDAO, treeNodeDAO, monitorCache, userDAO, logger)
+	ruleTemplateHandler := api8.NewRuleTemplateHandler(logger, alertManagerRuleTemplateService)
+	alertManagerInhibitService := alert2.NewAlertManagerInhibitService(alertManagerInhibitDAO, alertManagerPoolDAO, monitorCache, logger, userDAO)
+	inhibitRuleHandler := api8.NewInhibitRuleHandler(logger, alertManagerInhibitService)
+	alertManagerTimeIntervalService := alert2.NewAlertManagerTimeIntervalService(alertManagerTimeIntervalDAO, alertManagerPoolDAO, alertManagerSendDAO, monitorCache, logger, userDAO)
+	timeIntervalHandler := api8.NewTimeIntervalHandler(logger, alertManagerTimeIntervalService)
+	silenceHandler := api8.NewSilenceHandler(logger, alertManagerSilenceService)
+	engine := InitGinServer(v, userHandler, authHandler, treeHandler, notAuthHandler, k8sClusterHandler, k8sConfigMapHandler, k8sDeploymentHandler, k8sNamespaceHandler, k8sNodeHandler, k8sPodHandler, k8sSvcHandler, k8sTaintHandler, k8sYamlTaskHandler, k8sYamlTemplateHandler, k8sAppHandler, alertEventHandler, alertPoolHandler, alertRuleHandler, configYamlHandler, configVersionHandler, configSyncHandler, onDutyGroupHandler, recordRuleHandler, scrapePoolHandler, scrapeJobHandler, sendGroupHandler, ruleTestHandler, ruleGroupHandler, promQueryHandler, ruleImportHandler, ruleExportHandler, ruleTemplateHandler, inhibitRuleHandler, timeIntervalHandler, silenceHandler)
 	cronManager := cron.NewCronManager(logger, alertManagerOnDutyDAO)
-	cronCron := InitAndRefreshK8sClient(k8sClient, logger, monitorCache, cronManager)
+	cronCron := InitAndRefreshK8sClient(k8sClient, logger, monitorCache, cronManager, alertManagerRuleExportService)
 	cmd := &Cmd{
 		Server: engine,
 		Cron:   cronCron,
@@ -0,0 +1,423 @@
+package k8s
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sort"
+	"strings"
+)
+
+const (
+	AppLabelKey      = "app"      // 应用标签，Service 通过该标签选择应用下所有实例的 Pod
+	InstanceLabelKey = "instance" // 实例标签，Deployment 通过该标签选择自身的 Pod
+	CronjobLabelKey  = "cronjob"  // 定时任务标签，用于查询定时任务产生的 Pod
+)
+
+// ParseKeyValueList 将 key=value 格式的字符串列表解析为映射
+func ParseKeyValueList(kvs []string) (map[string]string, error) {
+	result := make(map[string]string, len(kvs))
+
+	for _, kv := range kvs {
+		kv = strings.TrimSpace(kv)
+		if kv == "" {
+			continue
+		}
+
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("无效的键值对: %s，格式应为 key=value", kv)
+		}
+
+		result[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+
+	return result, nil
+}
+
+// MergeContainerCore 合并容器核心配置，实例未设置的字段继承应用的配置
+func MergeContainerCore(app, instance model.ContainerCore) model.ContainerCore {
+	merged := instance
+
+	if len(merged.Envs) == 0 {
+		merged.Envs = app.Envs
+	}
+	if len(merged.Labels) == 0 {
+		merged.Labels = app.Labels
+	}
+	if len(merged.Commands) == 0 {
+		merged.Commands = app.Commands
+	}
+	if len(merged.Args) == 0 {
+		merged.Args = app.Args
+	}
+	if merged.CpuRequest == "" {
+		merged.CpuRequest = app.CpuRequest
+	}
+	if merged.CpuLimit == "" {
+		merged.CpuLimit = app.CpuLimit
+	}
+	if merged.MemoryRequest == "" {
+		merged.MemoryRequest = app.MemoryRequest
+	}
+	if merged.MemoryLimit == "" {
+		merged.MemoryLimit = app.MemoryLimit
+	}
+	if merged.VolumeJson == "" {
+		merged.VolumeJson = app.VolumeJson
+	}
+	if merged.PortJson == "" {
+		merged.PortJson = app.PortJson
+	}
+
+	return merged
+}
+
+// ParseServicePorts 解析 PortJson 为 Service 端口列表
+func ParseServicePorts(portJson string) ([]corev1.ServicePort, error) {
+	if strings.TrimSpace(portJson) == "" {
+		return nil, nil
+	}
+
+	var ports []corev1.ServicePort
+	if err := json.Unmarshal([]byte(portJson), &ports); err != nil {
+		return nil, fmt.Errorf("解析端口配置失败: %w", err)
+	}
+
+	for i := range ports {
+		if ports[i].Port <= 0 {
+			return nil, fmt.Errorf("端口配置第 %d 项的 port 无效", i+1)
+		}
+		if ports[i].Name == "" {
+			ports[i].Name = fmt.Sprintf("port-%d", ports[i].Port)
+		}
+		if ports[i].Protocol == "" {
+			ports[i].Protocol = corev1.ProtocolTCP
+		}
+		if ports[i].TargetPort.IntValue() == 0 && ports[i].TargetPort.StrVal == "" {
+			ports[i].TargetPort = intstr.FromInt32(ports[i].Port)
+		}
+	}
+
+	return ports, nil
+}
+
+// ParseVolumes 解析 VolumeJson 为 Pod 卷与容器挂载配置
+func ParseVolumes(volumeJson string) ([]corev1.Volume, []corev1.VolumeMount, error) {
+	if strings.TrimSpace(volumeJson) == "" {
+		return nil, nil, nil
+	}
+
+	var oneVolumes []model.K8sOneVolume
+	if err := json.Unmarshal([]byte(volumeJson), &oneVolumes); err != nil {
+		return nil, nil, fmt.Errorf("解析卷配置失败: %w", err)
+	}
+
+	volumes := make([]corev1.Volume, 0, len(oneVolumes))
+	mounts := make([]corev1.VolumeMount, 0, len(oneVolumes))
+
+	for _, v := range oneVolumes {
+		if v.Name == "" || v.MountPath == "" {
+			return nil, nil, fmt.Errorf("卷配置缺少名称或挂载路径")
+		}
+
+		volume := corev1.Volume{Name: v.Name}
+		switch v.Type {
+		case "hostPath":
+			hostPathType := corev1.HostPathType(v.HostPathType)
+			volume.HostPath = &corev1.HostPathVolumeSource{
+				Path: v.HostPath,
+				Type: &hostPathType,
+			}
+		case "configMap":
+			volume.ConfigMap = &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: v.CmName},
+			}
+		case "emptyDir":
+			volume.EmptyDir = &corev1.EmptyDirVolumeSource{}
+		case "pvc":
+			volume.PersistentVolumeClaim = &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: v.PvcName,
+			}
+		default:
+			return nil, nil, fmt.Errorf("不支持的卷类型: %s", v.Type)
+		}
+
+		volumes = append(volumes, volume)
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      v.Name,
+			MountPath: v.MountPath,
+			SubPath:   v.SubPath,
+		})
+	}
+
+	return volumes, mounts, nil
+}
+
+// BuildResourceRequirements 根据容器核心配置构建资源请求与限制
+func BuildResourceRequirements(core model.ContainerCore) (corev1.ResourceRequirements, error) {
+	requirements := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{},
+		Limits:   corev1.ResourceList{},
+	}
+
+	items := []struct {
+		value string
+		name  corev1.ResourceName
+		list  corev1.ResourceList
+	}{
+		{core.CpuRequest, corev1.ResourceCPU, requirements.Requests},
+		{core.MemoryRequest, corev1.ResourceMemory, requirements.Requests},
+		{core.CpuLimit, corev1.ResourceCPU, requirements.Limits},
+		{core.MemoryLimit, corev1.ResourceMemory, requirements.Limits},
+	}
+
+	for _, item := range items {
+		if item.value == "" {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(item.value)
+		if err != nil {
+			return requirements, fmt.Errorf("解析资源配置 %s=%s 失败: %w", item.name, item.value, err)
+		}
+		item.list[item.name] = quantity
+	}
+
+	return requirements, nil
+}
+
+// BuildContainer 根据容器核心配置构建容器
+func BuildContainer(name, image string, core model.ContainerCore) (corev1.Container, []corev1.Volume, error) {
+	envMap, err := ParseKeyValueList(core.Envs)
+	if err != nil {
+		return corev1.Container{}, nil, err
+	}
+
+	// 按名称排序，避免每次渲染顺序不同导致 Deployment 滚动更新
+	envNames := make([]string, 0, len(envMap))
+	for k := range envMap {
+		envNames = append(envNames, k)
+	}
+	sort.Strings(envNames)
+
+	envs := make([]corev1.EnvVar, 0, len(envNames))
+	for _, k := range envNames {
+		envs = append(envs, corev1.EnvVar{Name: k, Value: envMap[k]})
+	}
+
+	servicePorts, err := ParseServicePorts(core.PortJson)
+	if err != nil {
+		return corev1.Container{}, nil, err
+	}
+
+	containerPorts := make([]corev1.ContainerPort, 0, len(servicePorts))
+	for _, sp := range servicePorts {
+		containerPort := sp.TargetPort.IntValue()
+		if containerPort == 0 {
+			containerPort = int(sp.Port)
+		}
+		containerPorts = append(containerPorts, corev1.ContainerPort{
+			Name:          sp.Name,
+			ContainerPort: int32(containerPort),
+			Protocol:      sp.Protocol,
+		})
+	}
+
+	volumes, mounts, err := ParseVolumes(core.VolumeJson)
+	if err != nil {
+		return corev1.Container{}, nil, err
+	}
+
+	resources, err := BuildResourceRequirements(core)
+	if err != nil {
+		return corev1.Container{}, nil, err
+	}
+
+	container := corev1.Container{
+		Name:         name,
+		Image:        image,
+		Command:      core.Commands,
+		Args:         core.Args,
+		Env:          envs,
+		Ports:        containerPorts,
+		Resources:    resources,
+		VolumeMounts: mounts,
+	}
+
+	return container, volumes, nil
+}
+
+// InstanceDeploymentName 返回实例在集群中的 Deployment 名称
+// 实例名称只在应用内唯一，同一命名空间下的多个应用可能存在同名实例，因此加上应用名称作为前缀
+func InstanceDeploymentName(app *model.K8sApp, instance *model.K8sInstance) string {
+	return app.Name + "-" + instance.Name
+}
+
+// BuildDeploymentFromInstance 根据应用与实例渲染 Deployment
+func BuildDeploymentFromInstance(app *model.K8sApp, instance *model.K8sInstance) (*appsv1.Deployment, error) {
+	if instance.Image == "" {
+		return nil, fmt.Errorf("实例 %s 未设置镜像", instance.Name)
+	}
+
+	core := MergeContainerCore(app.ContainerCore, instance.ContainerCore)
+
+	labels, err := ParseKeyValueList(core.Labels)
+	if err != nil {
+		return nil, err
+	}
+	labels[AppLabelKey] = app.Name
+	labels[InstanceLabelKey] = instance.Name
+
+	container, volumes, err := BuildContainer(instance.Name, instance.Image, core)
+	if err != nil {
+		return nil, err
+	}
+
+	replicas := int32(instance.Replicas)
+	if replicas <= 0 {
+		replicas = 1
+	}
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      InstanceDeploymentName(app, instance),
+			Namespace: app.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					AppLabelKey:      app.Name,
+					InstanceLabelKey: instance.Name,
+				},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{container},
+					Volumes:    volumes,
+				},
+			},
+		},
+	}
+
+	return deployment, nil
+}
+
+// BuildServiceFromApp 根据应用渲染 Service，选择应用下所有实例的 Pod
+func BuildServiceFromApp(app *model.K8sApp) (*corev1.Service, error) {
+	ports, err := ParseServicePorts(app.PortJson)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ports) == 0 {
+		return nil, nil
+	}
+
+	labels, err := ParseKeyValueList(app.Labels)
+	if err != nil {
+		return nil, err
+	}
+	labels[AppLabelKey] = app.Name
+
+	serviceType := corev1.ServiceType(app.ServiceType)
+	if serviceType == "" {
+		serviceType = corev1.ServiceTypeClusterIP
+	}
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      app.Name,
+			Namespace: app.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     serviceType,
+			Selector: map[string]string{AppLabelKey: app.Name},
+			Ports:    ports,
+		},
+	}
+
+	return service, nil
+}
+
+// BuildCronJob 根据定时任务配置渲染 CronJob
+func BuildCronJob(cronjob *model.K8sCronjob) *batchv1.CronJob {
+	labels := map[string]string{CronjobLabelKey: cronjob.Name}
+
+	return &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cronjob.Name,
+			Namespace: cronjob.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule: cronjob.Schedule,
+			JobTemplate: batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: labels,
+						},
+						Spec: corev1.PodSpec{
+							RestartPolicy: corev1.RestartPolicyOnFailure,
+							Containers: []corev1.Container{
+								{
+									Name:    cronjob.Name,
+									Image:   cronjob.Image,
+									Command: cronjob.Commands,
+									Args:    cronjob.Args,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// GetDeploymentReadyStatus 获取 Deployment 的就绪状态，格式为 就绪副本数/期望副本数
+func GetDeploymentReadyStatus(deployment *appsv1.Deployment) string {
+	var desired int32 = 1
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+
+	return fmt.Sprintf("%d/%d", deployment.Status.ReadyReplicas, desired)
+}
@@ -0,0 +1,403 @@
+package prometheus
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	altconfig "github.com/prometheus/alertmanager/config"
+	al "github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/alertmanager/timeinterval"
+	pcc "github.com/prometheus/common/config"
+	pm "github.com/prometheus/common/model"
+	"net"
+	"net/mail"
+	"sort"
+	"strings"
+)
+
+// ParseAlertManagerMatchers 使用 AlertManager 的匹配器语法解析匹配条件，如 severity="critical"、instance=~"10\\..*"
+func ParseAlertManagerMatchers(list []string) (altconfig.Matchers, error) {
+	matchers := make(altconfig.Matchers, 0, len(list))
+	for _, s := range list {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		matcher, err := al.ParseMatcher(s)
+		if err != nil {
+			return nil, fmt.Errorf("无效的匹配条件 %q: %w", s, err)
+		}
+		matchers = append(matchers, matcher)
+	}
+
+	sort.Sort(al.Matchers(matchers))
+	return matchers, nil
+}
+
+// BuildInhibitRule 将抑制规则转换为 AlertManager 的 inhibit_rules 配置
+func BuildInhibitRule(rule *model.MonitorInhibitRule) (altconfig.InhibitRule, error) {
+	sourceMatchers, err := ParseAlertManagerMatchers(rule.SourceMatchers)
+	if err != nil {
+		return altconfig.InhibitRule{}, fmt.Errorf("源告警匹配条件错误: %w", err)
+	}
+	if len(sourceMatchers) == 0 {
+		return altconfig.InhibitRule{}, fmt.Errorf("源告警匹配条件不能为空")
+	}
+
+	targetMatchers, err := ParseAlertManagerMatchers(rule.TargetMatchers)
+	if err != nil {
+		return altconfig.InhibitRule{}, fmt.Errorf("目标告警匹配条件错误: %w", err)
+	}
+	if len(targetMatchers) == 0 {
+		return altconfig.InhibitRule{}, fmt.Errorf("目标告警匹配条件不能为空")
+	}
+
+	var equal pm.LabelNames
+	for _, name := range rule.EqualLabels {
+		labelName := pm.LabelName(strings.TrimSpace(name))
+		if !labelName.IsValid() {
+			return altconfig.InhibitRule{}, fmt.Errorf("无效的标签名: %q", name)
+		}
+		equal = append(equal, labelName)
+	}
+
+	return altconfig.InhibitRule{
+		SourceMatchers: sourceMatchers,
+		TargetMatchers: targetMatchers,
+		Equal:          equal,
+	}, nil
+}
+
+// amTimeInterval 与 AlertManager time_intervals 配置格式一致，用于复用 AlertManager 的解析和校验
+type amTimeInterval struct {
+	Times       []amTimeRange `json:"times,omitempty"`
+	Weekdays    []string      `json:"weekdays,omitempty"`
+	DaysOfMonth []string      `json:"days_of_month,omitempty"`
+	Months      []string      `json:"months,omitempty"`
+	Years       []string      `json:"years,omitempty"`
+	Location    string        `json:"location,omitempty"`
+}
+
+type amTimeRange struct {
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+}
+
+// BuildTimeInterval 将时间段转换为 AlertManager 的 time_intervals 配置，使用 AlertManager 的解析逻辑校验每个条件
+func BuildTimeInterval(ti *model.MonitorTimeInterval) (altconfig.TimeInterval, error) {
+	intervals := make([]timeinterval.TimeInterval, 0, len(ti.TimeIntervals))
+	for i, spec := range ti.TimeIntervals {
+		if len(spec.Times) == 0 && len(spec.Weekdays) == 0 && len(spec.DaysOfMonth) == 0 && len(spec.Months) == 0 && len(spec.Years) == 0 {
+			return altconfig.TimeInterval{}, fmt.Errorf("第 %d 组时间段条件不能为空", i+1)
+		}
+
+		am := amTimeInterval{
+			Weekdays:    spec.Weekdays,
+			DaysOfMonth: spec.DaysOfMonth,
+			Months:      spec.Months,
+			Years:       spec.Years,
+			Location:    spec.Location,
+		}
+		for _, tr := range spec.Times {
+			am.Times = append(am.Times, amTimeRange{StartTime: tr.StartTime, EndTime: tr.EndTime})
+		}
+
+		data, err := json.Marshal(am)
+		if err != nil {
+			return altconfig.TimeInterval{}, err
+		}
+
+		var interval timeinterval.TimeInterval
+		if err := json.Unmarshal(data, &interval); err != nil {
+			return altconfig.TimeInterval{}, fmt.Errorf("第 %d 组时间段条件无效: %w", i+1, err)
+		}
+		intervals = append(intervals, interval)
+	}
+
+	if len(intervals) == 0 {
+		return altconfig.TimeInterval{}, fmt.Errorf("时间段 %s 没有设置任何条件", ti.Name)
+	}
+
+	return altconfig.TimeInterval{
+		Name:          ti.Name,
+		TimeIntervals: intervals,
+	}, nil
+}
+
+// SendGroupNotifyTargets 根据发送组的通知方式判断需要生成哪些接收者配置
+// 未设置通知方式，或包含 email、webhook 以外的方式（im、phone、sms 等由平台处理）时保留平台 Webhook
+func SendGroupNotifyTargets(sendGroup *model.MonitorSendGroup) (platform, email, webhook bool) {
+	hasMethod := false
+	for _, method := range sendGroup.NotifyMethods {
+		switch strings.TrimSpace(method) {
+		case "":
+			continue
+		case model.NotifyMethodEmail:
+			email = true
+		case model.NotifyMethodWebhook:
+			webhook = true
+		default:
+			platform = true
+		}
+		hasMethod = true
+	}
+
+	if !hasMethod {
+		platform = true
+	}
+
+	return platform, email, webhook
+}
+
+// ValidateAlertManagerPoolSmtp 校验 AlertManager 实例的 SMTP 设置，设置了任一字段时必须同时设置服务器地址和发件人
+func ValidateAlertManagerPoolSmtp(pool *model.MonitorAlertManagerPool) error {
+	if pool.SmtpSmarthost == "" && pool.SmtpFrom == "" && pool.SmtpAuthUsername == "" && pool.SmtpAuthPassword == "" {
+		return nil
+	}
+
+	if pool.SmtpSmarthost == "" || pool.SmtpFrom == "" {
+		return errors.New("SMTP服务器地址和发件人地址必须同时设置")
+	}
+
+	host, port, err := net.SplitHostPort(pool.SmtpSmarthost)
+	if err != nil || host == "" || port == "" {
+		return fmt.Errorf("无效的SMTP服务器地址 %s，格式应为 host:port", pool.SmtpSmarthost)
+	}
+
+	if _, err := mail.ParseAddress(pool.SmtpFrom); err != nil {
+		return fmt.Errorf("无效的发件人地址 %s: %w", pool.SmtpFrom, err)
+	}
+
+	return nil
+}
+
+// ValidateSendGroupNotify 校验发送组的邮件和第三方 Webhook 通知设置
+func ValidateSendGroupNotify(sendGroup *model.MonitorSendGroup, pool *model.MonitorAlertManagerPool) error {
+	_, email, webhook := SendGroupNotifyTargets(sendGroup)
+
+	for _, to := range sendGroup.EmailTo {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return fmt.Errorf("无效的收件人地址 %s: %w", to, err)
+		}
+	}
+	if email {
+		if len(sendGroup.EmailTo) == 0 {
+			return errors.New("通知方式包含邮件时必须设置收件人")
+		}
+		if pool.SmtpSmarthost == "" || pool.SmtpFrom == "" {
+			return fmt.Errorf("AlertManager实例 %s 没有设置SMTP服务器，无法使用邮件通知", pool.Name)
+		}
+	}
+
+	for i, w := range sendGroup.Webhooks {
+		if _, err := BuildWebhookHTTPConfig(w); err != nil {
+			return fmt.Errorf("第 %d 个Webhook设置无效: %w", i+1, err)
+		}
+	}
+	if webhook && len(sendGroup.Webhooks) == 0 {
+		return errors.New("通知方式包含webhook时必须设置Webhook地址")
+	}
+
+	return nil
+}
+
+// BuildWebhookHTTPConfig 校验第三方 Webhook 地址并生成推送使用的 HTTP 客户端配置
+func BuildWebhookHTTPConfig(webhook model.SendGroupWebhook) (*pcc.HTTPClientConfig, error) {
+	webhookURL, err := ParseURL(webhook.Url)
+	if err != nil {
+		return nil, err
+	}
+	if (webhookURL.Scheme != "http" && webhookURL.Scheme != "https") || webhookURL.Host == "" {
+		return nil, fmt.Errorf("Webhook地址必须是 http 或 https 地址: %s", webhook.Url)
+	}
+
+	httpConfig := pcc.DefaultHTTPClientConfig
+
+	if webhook.BasicAuthUsername != "" {
+		httpConfig.BasicAuth = &pcc.BasicAuth{
+			Username: webhook.BasicAuthUsername,
+			Password: pcc.Secret(webhook.BasicAuthPassword),
+		}
+	}
+	if webhook.BearerToken != "" {
+		httpConfig.Authorization = &pcc.Authorization{
+			Type:        "Bearer",
+			Credentials: pcc.Secret(webhook.BearerToken),
+		}
+	}
+
+	httpConfig.TLSConfig = pcc.TLSConfig{
+		CA:                 webhook.TlsCaContent,
+		ServerName:         webhook.TlsServerName,
+		InsecureSkipVerify: webhook.TlsInsecureSkipVerify == 1,
+	}
+
+	if err := httpConfig.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &httpConfig, nil
+}
+
+// maxSendGroupRouteDepth 发送组子路由的最大嵌套层数
+const maxSendGroupRouteDepth = 5
+
+// ApplySendGroupRouting 将发送组的分组、等待时间、额外匹配条件和子路由设置到发送组的路由上
+// receivers 为同一 AlertManager 实例下发送组ID到接收者名称的映射，子路由可以将告警交给其他发送组
+func ApplySendGroupRouting(route *altconfig.Route, sendGroup *model.MonitorSendGroup, receivers map[int]string) error {
+	matchers, err := ParseAlertManagerMatchers(sendGroup.Matchers)
+	if err != nil {
+		return err
+	}
+	route.Matchers = append(route.Matchers, matchers...)
+
+	if err := applyRouteGrouping(route, sendGroup.GroupBy, sendGroup.GroupWait, sendGroup.GroupInterval, ""); err != nil {
+		return err
+	}
+
+	routes, err := buildSendGroupChildRoutes(sendGroup.Routes, receivers, 1)
+	if err != nil {
+		return err
+	}
+	route.Routes = routes
+
+	return nil
+}
+
+// buildSendGroupChildRoutes 递归生成子路由
+func buildSendGroupChildRoutes(childRoutes []model.SendGroupRoute, receivers map[int]string, depth int) ([]*altconfig.Route, error) {
+	if len(childRoutes) == 0 {
+		return nil, nil
+	}
+	if depth > maxSendGroupRouteDepth {
+		return nil, fmt.Errorf("子路由嵌套不能超过 %d 层", maxSendGroupRouteDepth)
+	}
+
+	routes := make([]*altconfig.Route, 0, len(childRoutes))
+	for i, childRoute := range childRoutes {
+		matchers, err := ParseAlertManagerMatchers(childRoute.Matchers)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 层第 %d 个子路由: %w", depth, i+1, err)
+		}
+		if len(matchers) == 0 {
+			return nil, fmt.Errorf("第 %d 层第 %d 个子路由必须设置匹配条件", depth, i+1)
+		}
+
+		route := &altconfig.Route{
+			Matchers: matchers,
+			Continue: childRoute.Continue,
+		}
+
+		if childRoute.ReceiverSendGroupID != 0 {
+			receiver, ok := receivers[childRoute.ReceiverSendGroupID]
+			if !ok {
+				return nil, fmt.Errorf("第 %d 层第 %d 个子路由的接收发送组 %d 不存在或不属于同一个AlertManager实例", depth, i+1, childRoute.ReceiverSendGroupID)
+			}
+			route.Receiver = receiver
+		}
+
+		if err := applyRouteGrouping(route, childRoute.GroupBy, childRoute.GroupWait, childRoute.GroupInterval, childRoute.RepeatInterval); err != nil {
+			return nil, fmt.Errorf("第 %d 层第 %d 个子路由: %w", depth, i+1, err)
+		}
+
+		route.Routes, err = buildSendGroupChildRoutes(childRoute.Routes, receivers, depth+1)
+		if err != nil {
+			return nil, err
+		}
+
+		routes = append(routes, route)
+	}
+
+	return routes, nil
+}
+
+// applyRouteGrouping 设置路由的分组标签和时间，为空的设置继承父路由
+func applyRouteGrouping(route *altconfig.Route, groupBy []string, groupWait, groupInterval, repeatInterval string) error {
+	if err := validateRouteGroupBy(groupBy); err != nil {
+		return err
+	}
+	if len(groupBy) > 0 {
+		route.GroupByStr = groupBy
+	}
+
+	var err error
+	if groupWait != "" {
+		if route.GroupWait, err = parseRouteDuration("group_wait", groupWait, true); err != nil {
+			return err
+		}
+	}
+	if groupInterval != "" {
+		if route.GroupInterval, err = parseRouteDuration("group_interval", groupInterval, false); err != nil {
+			return err
+		}
+	}
+	if repeatInterval != "" {
+		if route.RepeatInterval, err = parseRouteDuration("repeat_interval", repeatInterval, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateRouteGroupBy 校验分组标签，"..." 表示按所有标签分组，不能与其他标签同时使用
+func validateRouteGroupBy(groupBy []string) error {
+	seen := make(map[string]bool, len(groupBy))
+	for _, label := range groupBy {
+		if label == "..." {
+			if len(groupBy) > 1 {
+				return errors.New("分组标签 ... 不能与其他标签同时使用")
+			}
+			continue
+		}
+		if !pm.LabelName(label).IsValid() {
+			return fmt.Errorf("无效的分组标签: %q", label)
+		}
+		if seen[label] {
+			return fmt.Errorf("重复的分组标签: %s", label)
+		}
+		seen[label] = true
+	}
+
+	return nil
+}
+
+// parseRouteDuration 解析路由的时间设置，group_interval 和 repeat_interval 不能为0
+func parseRouteDuration(name, value string, allowZero bool) (*pm.Duration, error) {
+	duration, err := pm.ParseDuration(value)
+	if err != nil {
+		return nil, fmt.Errorf("无效的 %s: %w", name, err)
+	}
+	if !allowZero && duration == 0 {
+		return nil, fmt.Errorf("%s 不能为0", name)
+	}
+
+	return &duration, nil
+}
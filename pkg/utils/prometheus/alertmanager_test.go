@@ -0,0 +1,424 @@
+package prometheus
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	altconfig "github.com/prometheus/alertmanager/config"
+	pm "github.com/prometheus/common/model"
+)
+
+func TestBuildTimeInterval(t *testing.T) {
+	workHours := model.TimeIntervalSpec{
+		Times:    []model.TimeIntervalRange{{StartTime: "09:00", EndTime: "18:00"}},
+		Weekdays: []string{"monday:friday"},
+	}
+
+	tests := []struct {
+		name    string
+		specs   []model.TimeIntervalSpec
+		wantErr bool
+	}{
+		{name: "工作时间", specs: []model.TimeIntervalSpec{workHours}},
+		{name: "多组条件", specs: []model.TimeIntervalSpec{workHours, {DaysOfMonth: []string{"-1"}, Months: []string{"december"}, Years: []string{"2024:2030"}}}},
+		{name: "没有任何条件", specs: nil, wantErr: true},
+		{name: "空条件", specs: []model.TimeIntervalSpec{{}}, wantErr: true},
+		{name: "无效时间", specs: []model.TimeIntervalSpec{{Times: []model.TimeIntervalRange{{StartTime: "25:00", EndTime: "26:00"}}}}, wantErr: true},
+		{name: "开始时间晚于结束时间", specs: []model.TimeIntervalSpec{{Times: []model.TimeIntervalRange{{StartTime: "18:00", EndTime: "09:00"}}}}, wantErr: true},
+		{name: "无效星期", specs: []model.TimeIntervalSpec{{Weekdays: []string{"funday"}}}, wantErr: true},
+		{name: "无效时区", specs: []model.TimeIntervalSpec{{Weekdays: []string{"monday"}, Location: "Mars/Base"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ti, err := BuildTimeInterval(&model.MonitorTimeInterval{Name: "work", TimeIntervals: tt.specs})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BuildTimeInterval() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (ti.Name != "work" || len(ti.TimeIntervals) != len(tt.specs)) {
+				t.Errorf("BuildTimeInterval() = %+v", ti)
+			}
+		})
+	}
+}
+
+func TestBuildTimeIntervalContainsTime(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skipf("缺少时区数据: %v", err)
+	}
+
+	ti, err := BuildTimeInterval(&model.MonitorTimeInterval{
+		Name: "work",
+		TimeIntervals: []model.TimeIntervalSpec{{
+			Times:    []model.TimeIntervalRange{{StartTime: "09:00", EndTime: "18:00"}},
+			Weekdays: []string{"monday:friday"},
+			Location: "Asia/Shanghai",
+		}},
+	})
+	if err != nil {
+		t.Fatalf("BuildTimeInterval() err = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want bool
+	}{
+		{name: "周三上午", at: time.Date(2024, 5, 15, 10, 0, 0, 0, loc), want: true},
+		{name: "周三晚上", at: time.Date(2024, 5, 15, 20, 0, 0, 0, loc), want: false},
+		{name: "周六上午", at: time.Date(2024, 5, 18, 10, 0, 0, 0, loc), want: false},
+		{name: "UTC时间换算到时区", at: time.Date(2024, 5, 15, 2, 0, 0, 0, time.UTC), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ti.TimeIntervals[0].ContainsTime(tt.at); got != tt.want {
+				t.Errorf("ContainsTime(%s) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRouteGroupBy(t *testing.T) {
+	tests := []struct {
+		name    string
+		groupBy []string
+		wantErr bool
+	}{
+		{name: "不分组", groupBy: nil},
+		{name: "按标签分组", groupBy: []string{"alertname", "cluster"}},
+		{name: "按所有标签分组", groupBy: []string{"..."}},
+		{name: "所有标签与其他标签同时使用", groupBy: []string{"...", "alertname"}, wantErr: true},
+		{name: "无效标签", groupBy: []string{"alert-name"}, wantErr: true},
+		{name: "重复标签", groupBy: []string{"alertname", "alertname"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateRouteGroupBy(tt.groupBy); (err != nil) != tt.wantErr {
+				t.Errorf("validateRouteGroupBy(%v) err = %v, wantErr %v", tt.groupBy, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplySendGroupRouting(t *testing.T) {
+	receivers := map[int]string{1: "group-1", 2: "group-2"}
+
+	sendGroup := &model.MonitorSendGroup{
+		GroupBy:       []string{"alertname"},
+		GroupWait:     "0s",
+		GroupInterval: "5m",
+		Matchers:      []string{`severity=~"critical|warning"`},
+		Routes: []model.SendGroupRoute{{
+			Matchers:            []string{`env="prod"`},
+			ReceiverSendGroupID: 2,
+			RepeatInterval:      "1h",
+			Continue:            true,
+			Routes: []model.SendGroupRoute{{
+				Matchers: []string{`team="db"`},
+				GroupBy:  []string{"..."},
+			}},
+		}},
+	}
+
+	route := &altconfig.Route{Receiver: "group-1", Matchers: mustMatchers(t, `send_group_id="1"`)}
+	if err := ApplySendGroupRouting(route, sendGroup, receivers); err != nil {
+		t.Fatalf("ApplySendGroupRouting() err = %v", err)
+	}
+
+	if len(route.Matchers) != 2 || route.Matchers[0].String() != `send_group_id="1"` || route.Matchers[1].String() != `severity=~"critical|warning"` {
+		t.Errorf("route.Matchers = %v", route.Matchers)
+	}
+	if strings.Join(route.GroupByStr, ",") != "alertname" || *route.GroupWait != 0 || *route.GroupInterval != pm.Duration(5*time.Minute) {
+		t.Errorf("路由分组设置错误: %+v", route)
+	}
+	if route.RepeatInterval != nil {
+		t.Errorf("未设置的重复发送时间应继承父路由, got %v", route.RepeatInterval)
+	}
+
+	if len(route.Routes) != 1 {
+		t.Fatalf("len(route.Routes) = %d, want 1", len(route.Routes))
+	}
+	child := route.Routes[0]
+	if child.Receiver != "group-2" || !child.Continue || *child.RepeatInterval != pm.Duration(time.Hour) || child.GroupWait != nil {
+		t.Errorf("子路由设置错误: %+v", child)
+	}
+	if len(child.Routes) != 1 || child.Routes[0].Receiver != "" || strings.Join(child.Routes[0].GroupByStr, ",") != "..." {
+		t.Errorf("嵌套子路由设置错误: %+v", child.Routes)
+	}
+}
+
+func TestApplySendGroupRoutingInvalid(t *testing.T) {
+	receivers := map[int]string{1: "group-1"}
+
+	nested := model.SendGroupRoute{Matchers: []string{`a="1"`}}
+	for i := 0; i < maxSendGroupRouteDepth; i++ {
+		nested = model.SendGroupRoute{Matchers: []string{`a="1"`}, Routes: []model.SendGroupRoute{nested}}
+	}
+
+	tests := []struct {
+		name      string
+		sendGroup *model.MonitorSendGroup
+	}{
+		{name: "无效的匹配条件", sendGroup: &model.MonitorSendGroup{Matchers: []string{`severity=~"("`}}},
+		{name: "group_interval 为0", sendGroup: &model.MonitorSendGroup{GroupInterval: "0s"}},
+		{name: "无效的等待时间", sendGroup: &model.MonitorSendGroup{GroupWait: "soon"}},
+		{name: "子路由没有匹配条件", sendGroup: &model.MonitorSendGroup{Routes: []model.SendGroupRoute{{}}}},
+		{name: "接收发送组不属于同一实例", sendGroup: &model.MonitorSendGroup{Routes: []model.SendGroupRoute{{Matchers: []string{`a="1"`}, ReceiverSendGroupID: 9}}}},
+		{name: "子路由 repeat_interval 为0", sendGroup: &model.MonitorSendGroup{Routes: []model.SendGroupRoute{{Matchers: []string{`a="1"`}, RepeatInterval: "0s"}}}},
+		{name: "子路由嵌套过深", sendGroup: &model.MonitorSendGroup{Routes: []model.SendGroupRoute{nested}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ApplySendGroupRouting(&altconfig.Route{}, tt.sendGroup, receivers); err == nil {
+				t.Error("ApplySendGroupRouting() 应返回错误")
+			}
+		})
+	}
+}
+
+func mustMatchers(t *testing.T, list ...string) altconfig.Matchers {
+	t.Helper()
+
+	matchers, err := ParseAlertManagerMatchers(list)
+	if err != nil {
+		t.Fatalf("ParseAlertManagerMatchers() err = %v", err)
+	}
+	return matchers
+}
+
+func TestBuildInhibitRule(t *testing.T) {
+	tests := []struct {
+		name       string
+		rule       model.MonitorInhibitRule
+		wantSource []string
+		wantEqual  []string
+		wantErr    string
+	}{
+		{
+			name: "合法规则",
+			rule: model.MonitorInhibitRule{
+				SourceMatchers: []string{`severity="critical"`, `alertname="HostDown"`},
+				TargetMatchers: []string{`severity=~"warning|info"`},
+				EqualLabels:    []string{" instance "},
+			},
+			wantSource: []string{`alertname="HostDown"`, `severity="critical"`},
+			wantEqual:  []string{"instance"},
+		},
+		{
+			name:    "源匹配条件为空",
+			rule:    model.MonitorInhibitRule{TargetMatchers: []string{`severity="warning"`}},
+			wantErr: "源告警匹配条件不能为空",
+		},
+		{
+			name:    "目标匹配条件无效",
+			rule:    model.MonitorInhibitRule{SourceMatchers: []string{`severity="critical"`}, TargetMatchers: []string{`severity=~"("`}},
+			wantErr: "目标告警匹配条件错误",
+		},
+		{
+			name: "相等标签名无效",
+			rule: model.MonitorInhibitRule{
+				SourceMatchers: []string{`severity="critical"`},
+				TargetMatchers: []string{`severity="warning"`},
+				EqualLabels:    []string{"bad-label"},
+			},
+			wantErr: "无效的标签名",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BuildInhibitRule(&tt.rule)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("BuildInhibitRule() err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BuildInhibitRule() err = %v", err)
+			}
+			if len(got.SourceMatchers) != len(tt.wantSource) {
+				t.Fatalf("SourceMatchers = %v, want %v", got.SourceMatchers, tt.wantSource)
+			}
+			for i, m := range got.SourceMatchers {
+				if m.String() != tt.wantSource[i] {
+					t.Errorf("SourceMatchers[%d] = %s, want %s", i, m, tt.wantSource[i])
+				}
+			}
+			if len(got.Equal) != len(tt.wantEqual) || string(got.Equal[0]) != tt.wantEqual[0] {
+				t.Errorf("Equal = %v, want %v", got.Equal, tt.wantEqual)
+			}
+		})
+	}
+}
+
+func TestSendGroupNotifyTargets(t *testing.T) {
+	tests := []struct {
+		name                   string
+		methods                []string
+		platform, email, hooks bool
+	}{
+		{name: "未设置通知方式", platform: true},
+		{name: "只有邮件", methods: []string{model.NotifyMethodEmail}, email: true},
+		{name: "邮件和webhook", methods: []string{" email ", model.NotifyMethodWebhook, ""}, email: true, hooks: true},
+		{name: "包含平台通知", methods: []string{model.NotifyMethodIM, model.NotifyMethodWebhook}, platform: true, hooks: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			platform, email, webhook := SendGroupNotifyTargets(&model.MonitorSendGroup{NotifyMethods: tt.methods})
+			if platform != tt.platform || email != tt.email || webhook != tt.hooks {
+				t.Errorf("SendGroupNotifyTargets() = %v, %v, %v, want %v, %v, %v", platform, email, webhook, tt.platform, tt.email, tt.hooks)
+			}
+		})
+	}
+}
+
+func TestValidateAlertManagerPoolSmtp(t *testing.T) {
+	tests := []struct {
+		name    string
+		pool    model.MonitorAlertManagerPool
+		wantErr bool
+	}{
+		{name: "未设置SMTP"},
+		{name: "合法设置", pool: model.MonitorAlertManagerPool{SmtpSmarthost: "smtp.example.com:587", SmtpFrom: "alert@example.com"}},
+		{name: "只设置用户名", pool: model.MonitorAlertManagerPool{SmtpAuthUsername: "alert"}, wantErr: true},
+		{name: "服务器地址缺少端口", pool: model.MonitorAlertManagerPool{SmtpSmarthost: "smtp.example.com", SmtpFrom: "alert@example.com"}, wantErr: true},
+		{name: "发件人地址无效", pool: model.MonitorAlertManagerPool{SmtpSmarthost: "smtp.example.com:25", SmtpFrom: "alert"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateAlertManagerPoolSmtp(&tt.pool); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateAlertManagerPoolSmtp() err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSendGroupNotify(t *testing.T) {
+	smtpPool := &model.MonitorAlertManagerPool{Name: "am", SmtpSmarthost: "smtp.example.com:587", SmtpFrom: "alert@example.com"}
+	tests := []struct {
+		name      string
+		sendGroup model.MonitorSendGroup
+		pool      *model.MonitorAlertManagerPool
+		wantErr   string
+	}{
+		{
+			name:      "邮件通知",
+			sendGroup: model.MonitorSendGroup{NotifyMethods: []string{model.NotifyMethodEmail}, EmailTo: []string{"ops@example.com"}},
+			pool:      smtpPool,
+		},
+		{
+			name:      "邮件通知缺少收件人",
+			sendGroup: model.MonitorSendGroup{NotifyMethods: []string{model.NotifyMethodEmail}},
+			pool:      smtpPool,
+			wantErr:   "必须设置收件人",
+		},
+		{
+			name:      "实例没有设置SMTP",
+			sendGroup: model.MonitorSendGroup{NotifyMethods: []string{model.NotifyMethodEmail}, EmailTo: []string{"ops@example.com"}},
+			pool:      &model.MonitorAlertManagerPool{Name: "am"},
+			wantErr:   "没有设置SMTP服务器",
+		},
+		{
+			name:      "收件人地址无效",
+			sendGroup: model.MonitorSendGroup{EmailTo: []string{"ops"}},
+			pool:      smtpPool,
+			wantErr:   "无效的收件人地址",
+		},
+		{
+			name:      "webhook通知缺少地址",
+			sendGroup: model.MonitorSendGroup{NotifyMethods: []string{model.NotifyMethodWebhook}},
+			pool:      smtpPool,
+			wantErr:   "必须设置Webhook地址",
+		},
+		{
+			name:      "webhook地址无效",
+			sendGroup: model.MonitorSendGroup{NotifyMethods: []string{model.NotifyMethodWebhook}, Webhooks: []model.SendGroupWebhook{{Url: "ftp://example.com"}}},
+			pool:      smtpPool,
+			wantErr:   "第 1 个Webhook设置无效",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSendGroupNotify(&tt.sendGroup, tt.pool)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateSendGroupNotify() err = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateSendGroupNotify() err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuildWebhookHTTPConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		webhook    model.SendGroupWebhook
+		wantBasic  bool
+		wantBearer bool
+		wantErr    bool
+	}{
+		{name: "无鉴权", webhook: model.SendGroupWebhook{Url: "https://hooks.example.com/alert"}},
+		{name: "Basic Auth", webhook: model.SendGroupWebhook{Url: "http://hooks.example.com", BasicAuthUsername: "u", BasicAuthPassword: "p"}, wantBasic: true},
+		{name: "Bearer Token", webhook: model.SendGroupWebhook{Url: "https://hooks.example.com", BearerToken: "token", TlsInsecureSkipVerify: 1}, wantBearer: true},
+		{name: "同时设置两种鉴权", webhook: model.SendGroupWebhook{Url: "https://hooks.example.com", BasicAuthUsername: "u", BearerToken: "token"}, wantErr: true},
+		{name: "非HTTP地址", webhook: model.SendGroupWebhook{Url: "ftp://hooks.example.com"}, wantErr: true},
+		{name: "缺少主机", webhook: model.SendGroupWebhook{Url: "http://"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BuildWebhookHTTPConfig(tt.webhook)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BuildWebhookHTTPConfig() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if (got.BasicAuth != nil) != tt.wantBasic || (got.Authorization != nil) != tt.wantBearer {
+				t.Errorf("BuildWebhookHTTPConfig() BasicAuth = %v, Authorization = %v", got.BasicAuth, got.Authorization)
+			}
+			if got.TLSConfig.InsecureSkipVerify != (tt.webhook.TlsInsecureSkipVerify == 1) {
+				t.Errorf("InsecureSkipVerify = %v", got.TLSConfig.InsecureSkipVerify)
+			}
+		})
+	}
+}
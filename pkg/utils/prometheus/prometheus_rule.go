@@ -0,0 +1,123 @@
+package prometheus
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/cespare/xxhash/v2"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"regexp"
+	sigsYaml "sigs.k8s.io/yaml"
+	"strconv"
+	"strings"
+)
+
+// 平台同步的 PrometheusRule 资源上的标签，用于识别并清理不再需要的资源
+const (
+	PrometheusRuleManagedByLabel = "app.kubernetes.io/managed-by"
+	PrometheusRuleManagedBy      = "ai-cloudops"
+	PrometheusRulePoolLabel      = "ai-cloudops/pool-id"
+	// PrometheusRuleFieldManager 服务端应用时使用的字段管理者名称
+	PrometheusRuleFieldManager = "ai-cloudops"
+)
+
+// maxPrometheusRuleNameLength 资源名称中规则组部分的最大长度，留出前缀和哈希后缀的空间
+const maxPrometheusRuleNameLength = 200
+
+// PrometheusRuleGVR prometheus-operator 的 PrometheusRule 资源
+var PrometheusRuleGVR = schema.GroupVersionResource{
+	Group:    "monitoring.coreos.com",
+	Version:  "v1",
+	Resource: "prometheusrules",
+}
+
+var invalidResourceNameChars = regexp.MustCompile(`[^a-z0-9-.]+`)
+
+// PrometheusRuleName 根据采集池和规则组名称生成合法的资源名称，
+// 规则组名称需要转换时追加哈希后缀，避免不同规则组转换后名称相同
+func PrometheusRuleName(poolID int, groupName string) string {
+	name := invalidResourceNameChars.ReplaceAllString(strings.ToLower(groupName), "-")
+	name = strings.Trim(name, "-.")
+	if len(name) > maxPrometheusRuleNameLength {
+		name = strings.Trim(name[:maxPrometheusRuleNameLength], "-.")
+	}
+
+	if name != groupName || name == "" {
+		name = fmt.Sprintf("%s-%08x", name, uint32(xxhash.Sum64String(groupName)))
+	}
+
+	return fmt.Sprintf("cloudops-%d-%s", poolID, strings.TrimPrefix(name, "-"))
+}
+
+// PrometheusRuleSelector 选择平台为采集池同步的 PrometheusRule 资源
+func PrometheusRuleSelector(poolID int) string {
+	return fmt.Sprintf("%s=%s,%s=%d", PrometheusRuleManagedByLabel, PrometheusRuleManagedBy, PrometheusRulePoolLabel, poolID)
+}
+
+// BuildPrometheusRule 将一个规则组转换为 PrometheusRule 资源，group 按规则文件的 YAML 格式序列化，
+// 与 PrometheusRule 的 spec.groups 格式一致
+func BuildPrometheusRule(name, namespace string, poolID int, extraLabels map[string]string, group interface{}) (*unstructured.Unstructured, error) {
+	yamlData, err := yaml.Marshal(group)
+	if err != nil {
+		return nil, fmt.Errorf("序列化规则组失败: %w", err)
+	}
+
+	// 经过 JSON 转换，保证 unstructured 中只有 JSON 兼容的类型
+	jsonData, err := sigsYaml.YAMLToJSON(yamlData)
+	if err != nil {
+		return nil, fmt.Errorf("转换规则组失败: %w", err)
+	}
+
+	var spec map[string]interface{}
+	if err := json.Unmarshal(jsonData, &spec); err != nil {
+		return nil, fmt.Errorf("转换规则组失败: %w", err)
+	}
+
+	labels := make(map[string]interface{}, len(extraLabels)+2)
+	for k, v := range extraLabels {
+		labels[k] = v
+	}
+	labels[PrometheusRuleManagedByLabel] = PrometheusRuleManagedBy
+	labels[PrometheusRulePoolLabel] = strconv.Itoa(poolID)
+
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": PrometheusRuleGVR.GroupVersion().String(),
+			"kind":       "PrometheusRule",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+				"labels":    labels,
+			},
+			"spec": map[string]interface{}{
+				"groups": []interface{}{spec},
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,87 @@
+package prometheus
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func TestPrometheusRuleName(t *testing.T) {
+	tests := []struct {
+		name      string
+		groupName string
+		want      string
+	}{
+		{name: "合法名称保持不变", groupName: "node-exporter", want: "cloudops-1-node-exporter"},
+		{name: "合法名称包含点号", groupName: "node.rules", want: "cloudops-1-node.rules"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PrometheusRuleName(1, tt.groupName); got != tt.want {
+				t.Errorf("PrometheusRuleName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrometheusRuleNameSanitized(t *testing.T) {
+	tests := []struct {
+		name      string
+		groupName string
+		prefix    string
+	}{
+		{name: "大写字母", groupName: "Node", prefix: "cloudops-1-node-"},
+		{name: "非法字符", groupName: "cpu_usage rules", prefix: "cloudops-1-cpu-usage-rules-"},
+		{name: "首尾非法字符", groupName: "_cpu_", prefix: "cloudops-1-cpu-"},
+		{name: "全部为非法字符", groupName: "告警", prefix: "cloudops-1-"},
+		{name: "超长名称", groupName: strings.Repeat("a", 300), prefix: "cloudops-1-" + strings.Repeat("a", maxPrometheusRuleNameLength) + "-"},
+	}
+
+	seen := make(map[string]string)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := PrometheusRuleName(1, tt.groupName)
+			if !strings.HasPrefix(got, tt.prefix) || len(got) != len(tt.prefix)+8 {
+				t.Errorf("PrometheusRuleName() = %q, want prefix %q with hash suffix", got, tt.prefix)
+			}
+			if errs := validation.IsDNS1123Subdomain(got); len(errs) > 0 {
+				t.Errorf("PrometheusRuleName() = %q 不是合法的资源名称: %v", got, errs)
+			}
+			if other, ok := seen[got]; ok {
+				t.Errorf("%q 与 %q 生成了相同的名称 %q", tt.groupName, other, got)
+			}
+			seen[got] = tt.groupName
+		})
+	}
+
+	if PrometheusRuleName(1, "Node") == PrometheusRuleName(1, "NODE") {
+		t.Error("转换后相同的规则组名称应生成不同的资源名称")
+	}
+}
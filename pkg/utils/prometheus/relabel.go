@@ -0,0 +1,189 @@
+package prometheus
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	pm "github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+	"gopkg.in/yaml.v3"
+	"strings"
+)
+
+// relabel 配置所在的字段
+const (
+	RelabelFieldRelabel       = "relabel_configs"
+	RelabelFieldMetricRelabel = "metric_relabel_configs"
+	RelabelFieldWriteRelabel  = "write_relabel_configs"
+)
+
+// RelabelConfigError relabel 配置的校验错误，指出出错的字段和第几条规则
+type RelabelConfigError struct {
+	Field   string `json:"field"`   // 出错的字段，relabel_configs 或 metric_relabel_configs
+	Index   int    `json:"index"`   // 出错的规则序号，从1开始，0表示整个配置无法解析
+	Line    int    `json:"line"`    // 出错规则在YAML中的行号
+	Message string `json:"message"` // 错误信息
+}
+
+func (e *RelabelConfigError) Error() string {
+	if e.Index == 0 {
+		return fmt.Sprintf("%s 解析失败: %s", e.Field, e.Message)
+	}
+
+	return fmt.Sprintf("%s 第 %d 条规则（第 %d 行）错误: %s", e.Field, e.Index, e.Line, e.Message)
+}
+
+// ParseRelabelConfigs 逐条解析 relabel 配置并校验动作、标签和正则表达式，出错时返回 *RelabelConfigError
+func ParseRelabelConfigs(field, content string) ([]*relabel.Config, error) {
+	if strings.TrimSpace(content) == "" {
+		return nil, nil
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &root); err != nil {
+		return nil, &RelabelConfigError{Field: field, Message: err.Error()}
+	}
+
+	if len(root.Content) == 0 {
+		return nil, nil
+	}
+
+	list := root.Content[0]
+	if list.Kind != yaml.SequenceNode {
+		return nil, &RelabelConfigError{Field: field, Line: list.Line, Message: "配置必须是规则列表"}
+	}
+
+	configs := make([]*relabel.Config, 0, len(list.Content))
+	for i, node := range list.Content {
+		var cfg relabel.Config
+		if err := node.Decode(&cfg); err != nil {
+			return nil, &RelabelConfigError{Field: field, Index: i + 1, Line: node.Line, Message: err.Error()}
+		}
+		configs = append(configs, &cfg)
+	}
+
+	return configs, nil
+}
+
+// ValidateScrapeJobRelabel 检查采集任务的 relabel_configs 和 metric_relabel_configs
+func ValidateScrapeJobRelabel(job *model.MonitorScrapeJob) error {
+	if _, err := ParseRelabelConfigs(RelabelFieldRelabel, job.RelabelConfigsYamlString); err != nil {
+		return err
+	}
+
+	if _, err := ParseRelabelConfigs(RelabelFieldMetricRelabel, job.MetricRelabelConfigsYamlString); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// TargetInitialLabels 生成目标在 relabel 之前的标签，与 Prometheus 一样补充任务名、协议、路径、采集间隔和URL参数等默认标签
+func TargetInitialLabels(job *model.MonitorScrapeJob, target map[string]string) labels.Labels {
+	lb := labels.NewBuilder(labels.FromMap(target))
+
+	setDefault := func(name, value string) {
+		if lb.Get(name) == "" && value != "" {
+			lb.Set(name, value)
+		}
+	}
+
+	scheme := job.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+	metricsPath := job.MetricsPath
+	if metricsPath == "" {
+		metricsPath = "/metrics"
+	}
+
+	setDefault(pm.JobLabel, job.Name)
+	setDefault(pm.SchemeLabel, scheme)
+	setDefault(pm.MetricsPathLabel, metricsPath)
+	if job.ScrapeInterval > 0 {
+		setDefault(pm.ScrapeIntervalLabel, GenPromDuration(job.ScrapeInterval).String())
+	}
+	if job.ScrapeTimeout > 0 {
+		setDefault(pm.ScrapeTimeoutLabel, GenPromDuration(job.ScrapeTimeout).String())
+	}
+
+	params, _ := ParseScrapeParams(job.Params)
+	for name, values := range params {
+		if len(values) > 0 {
+			setDefault(pm.ParamLabelPrefix+name, values[0])
+		}
+	}
+
+	return lb.Labels()
+}
+
+// SimulateRelabelSteps 逐条执行 relabel 规则，记录每一步之后的标签，目标被丢弃时返回原因
+func SimulateRelabelSteps(lbls labels.Labels, configs []*relabel.Config) (labels.Labels, []*model.RelabelSimulateStep, bool, string) {
+	steps := make([]*model.RelabelSimulateStep, 0, len(configs))
+	current := lbls
+
+	for i, cfg := range configs {
+		lb := labels.NewBuilder(current)
+		keep := relabel.ProcessBuilder(lb, cfg)
+		current = lb.Labels()
+
+		steps = append(steps, &model.RelabelSimulateStep{
+			Index:  i + 1,
+			Action: string(cfg.Action),
+			Kept:   keep,
+			Labels: current.Map(),
+		})
+
+		if !keep {
+			return current, steps, false, fmt.Sprintf("第 %d 条规则（%s）丢弃了目标", i+1, cfg.Action)
+		}
+	}
+
+	if current.Get(pm.AddressLabel) == "" {
+		return current, steps, false, "relabel 后目标没有 __address__ 标签"
+	}
+
+	return current, steps, true, ""
+}
+
+// FinalTargetLabels 去掉 relabel 后以 __ 开头的内部标签，未设置 instance 时使用 __address__
+func FinalTargetLabels(lbls labels.Labels) map[string]string {
+	result := make(map[string]string)
+
+	lbls.Range(func(l labels.Label) {
+		if !strings.HasPrefix(l.Name, pm.ReservedLabelPrefix) {
+			result[l.Name] = l.Value
+		}
+	})
+
+	if _, ok := result[pm.InstanceLabel]; !ok {
+		result[pm.InstanceLabel] = lbls.Get(pm.AddressLabel)
+	}
+
+	return result
+}
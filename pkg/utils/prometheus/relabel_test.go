@@ -0,0 +1,224 @@
+package prometheus
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+func TestParseRelabelConfigs(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		wantLen   int
+		wantIndex int
+		wantLine  int
+		wantErr   bool
+	}{
+		{name: "空配置", content: "  \n", wantLen: 0},
+		{
+			name: "合法配置",
+			content: `- source_labels: [__meta_kubernetes_pod_name]
+  target_label: pod
+- action: labeldrop
+  regex: tmp_.*
+`,
+			wantLen: 2,
+		},
+		{name: "不是列表", content: "action: keep\n", wantErr: true, wantIndex: 0, wantLine: 1},
+		{name: "YAML格式错误", content: "- [unclosed\n", wantErr: true, wantIndex: 0},
+		{
+			name: "第二条规则动作错误",
+			content: `- action: keep
+  source_labels: [job]
+  regex: node
+- action: unknown
+`,
+			wantErr:   true,
+			wantIndex: 2,
+			wantLine:  4,
+		},
+		{
+			name: "正则表达式错误",
+			content: `- source_labels: [job]
+  regex: "("
+  action: drop
+`,
+			wantErr:   true,
+			wantIndex: 1,
+			wantLine:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configs, err := ParseRelabelConfigs(RelabelFieldRelabel, tt.content)
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("ParseRelabelConfigs() err = %v", err)
+				}
+				if len(configs) != tt.wantLen {
+					t.Fatalf("ParseRelabelConfigs() 返回 %d 条规则, want %d", len(configs), tt.wantLen)
+				}
+				return
+			}
+
+			var relabelErr *RelabelConfigError
+			if !errors.As(err, &relabelErr) {
+				t.Fatalf("ParseRelabelConfigs() err = %v, want *RelabelConfigError", err)
+			}
+			if relabelErr.Field != RelabelFieldRelabel || relabelErr.Index != tt.wantIndex {
+				t.Errorf("err = %+v, want field %s index %d", relabelErr, RelabelFieldRelabel, tt.wantIndex)
+			}
+			if tt.wantLine > 0 && relabelErr.Line != tt.wantLine {
+				t.Errorf("err.Line = %d, want %d", relabelErr.Line, tt.wantLine)
+			}
+		})
+	}
+}
+
+func TestValidateScrapeJobRelabel(t *testing.T) {
+	job := &model.MonitorScrapeJob{
+		RelabelConfigsYamlString:       "- target_label: env\n  replacement: prod\n",
+		MetricRelabelConfigsYamlString: "- action: drop\n  regex: (\n",
+	}
+
+	var relabelErr *RelabelConfigError
+	if err := ValidateScrapeJobRelabel(job); !errors.As(err, &relabelErr) || relabelErr.Field != RelabelFieldMetricRelabel {
+		t.Fatalf("ValidateScrapeJobRelabel() err = %v, want metric_relabel_configs error", err)
+	}
+}
+
+func TestTargetInitialLabels(t *testing.T) {
+	job := &model.MonitorScrapeJob{
+		Name:           "node",
+		ScrapeInterval: 30,
+		Params:         model.StringList{"module=http_2xx", "module=tcp"},
+	}
+
+	got := TargetInitialLabels(job, map[string]string{"__address__": "10.0.0.1:9100", "__scheme__": "https"}).Map()
+	want := map[string]string{
+		"__address__":         "10.0.0.1:9100",
+		"__scheme__":          "https",
+		"job":                 "node",
+		"__metrics_path__":    "/metrics",
+		"__scrape_interval__": "30s",
+		"__param_module":      "http_2xx",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TargetInitialLabels() = %v, want %v", got, want)
+	}
+}
+
+func TestSimulateRelabelSteps(t *testing.T) {
+	initial := labels.FromStrings("__address__", "10.0.0.1:9100", "__meta_env", "prod", "job", "node")
+
+	tests := []struct {
+		name       string
+		content    string
+		wantKept   bool
+		wantSteps  int
+		wantReason string
+		wantLabels map[string]string
+	}{
+		{
+			name:       "没有规则",
+			wantKept:   true,
+			wantLabels: map[string]string{"job": "node", "instance": "10.0.0.1:9100"},
+		},
+		{
+			name: "复制元标签",
+			content: `- source_labels: [__meta_env]
+  target_label: env
+`,
+			wantKept:   true,
+			wantSteps:  1,
+			wantLabels: map[string]string{"job": "node", "env": "prod", "instance": "10.0.0.1:9100"},
+		},
+		{
+			name: "第二条规则丢弃目标",
+			content: `- target_label: instance
+  replacement: node-1
+- source_labels: [__meta_env]
+  regex: prod
+  action: drop
+- target_label: never
+  replacement: reached
+`,
+			wantKept:   false,
+			wantSteps:  2,
+			wantReason: "第 2 条规则（drop）丢弃了目标",
+		},
+		{
+			name: "删除地址标签",
+			content: `- action: labeldrop
+  regex: __address__
+`,
+			wantKept:   false,
+			wantSteps:  1,
+			wantReason: "relabel 后目标没有 __address__ 标签",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configs, err := ParseRelabelConfigs(RelabelFieldRelabel, tt.content)
+			if err != nil {
+				t.Fatalf("ParseRelabelConfigs() err = %v", err)
+			}
+
+			result, steps, kept, reason := SimulateRelabelSteps(initial, configs)
+			if kept != tt.wantKept || reason != tt.wantReason {
+				t.Fatalf("SimulateRelabelSteps() kept = %v reason = %q, want %v %q", kept, reason, tt.wantKept, tt.wantReason)
+			}
+			if len(steps) != tt.wantSteps {
+				t.Fatalf("SimulateRelabelSteps() 返回 %d 步, want %d", len(steps), tt.wantSteps)
+			}
+			for i, step := range steps {
+				if step.Index != i+1 {
+					t.Errorf("steps[%d].Index = %d", i, step.Index)
+				}
+			}
+			if tt.wantLabels != nil {
+				if got := FinalTargetLabels(result); !reflect.DeepEqual(got, tt.wantLabels) {
+					t.Errorf("FinalTargetLabels() = %v, want %v", got, tt.wantLabels)
+				}
+			}
+		})
+	}
+}
+
+func TestFinalTargetLabelsKeepsInstance(t *testing.T) {
+	got := FinalTargetLabels(labels.FromStrings("__address__", "10.0.0.1:9100", "instance", "node-1"))
+	if want := map[string]string{"instance": "node-1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FinalTargetLabels() = %v, want %v", got, want)
+	}
+}
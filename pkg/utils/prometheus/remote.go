@@ -0,0 +1,223 @@
+package prometheus
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"errors"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"github.com/go-kit/log"
+	pcc "github.com/prometheus/common/config"
+	pm "github.com/prometheus/common/model"
+	pc "github.com/prometheus/prometheus/config"
+	"gopkg.in/yaml.v3"
+	"time"
+)
+
+// BuildRemoteWriteConfigs 根据采集池生成 remote_write 配置，未配置目标列表时使用 RemoteWriteUrl
+func BuildRemoteWriteConfigs(pool *model.MonitorScrapePool) ([]*pc.RemoteWriteConfig, error) {
+	endpoints := pool.RemoteWriteConfigs
+	if len(endpoints) == 0 {
+		if pool.RemoteWriteUrl == "" {
+			return nil, nil
+		}
+		endpoints = []model.RemoteWriteEndpoint{{
+			Url:                  pool.RemoteWriteUrl,
+			RemoteTimeoutSeconds: pool.RemoteTimeoutSeconds,
+		}}
+	}
+
+	configs := make([]*pc.RemoteWriteConfig, 0, len(endpoints))
+	for i, endpoint := range endpoints {
+		remoteURL, err := parseRemoteURL(endpoint.Url)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 个 remote_write: %w", i+1, err)
+		}
+
+		cfg := pc.DefaultRemoteWriteConfig
+		cfg.URL = remoteURL
+		cfg.Name = endpoint.Name
+		cfg.Headers = endpoint.Headers
+		cfg.HTTPClientConfig = remoteHTTPClientConfig(endpoint.BasicAuthUsername, endpoint.BasicAuthPassword, endpoint.BearerToken)
+		if endpoint.RemoteTimeoutSeconds > 0 {
+			cfg.RemoteTimeout = GenPromDuration(endpoint.RemoteTimeoutSeconds)
+		}
+
+		cfg.WriteRelabelConfigs, err = ParseRelabelConfigs(RelabelFieldWriteRelabel, endpoint.WriteRelabelConfigsYamlString)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 个 remote_write: %w", i+1, err)
+		}
+
+		if queue := endpoint.QueueConfig; queue != nil {
+			setPositive(&cfg.QueueConfig.Capacity, queue.Capacity)
+			setPositive(&cfg.QueueConfig.MaxShards, queue.MaxShards)
+			setPositive(&cfg.QueueConfig.MinShards, queue.MinShards)
+			setPositive(&cfg.QueueConfig.MaxSamplesPerSend, queue.MaxSamplesPerSend)
+			if queue.BatchSendDeadlineSeconds > 0 {
+				cfg.QueueConfig.BatchSendDeadline = GenPromDuration(queue.BatchSendDeadlineSeconds)
+			}
+			if queue.MinBackoffMs > 0 {
+				cfg.QueueConfig.MinBackoff = pm.Duration(time.Duration(queue.MinBackoffMs) * time.Millisecond)
+			}
+			if queue.MaxBackoffMs > 0 {
+				cfg.QueueConfig.MaxBackoff = pm.Duration(time.Duration(queue.MaxBackoffMs) * time.Millisecond)
+			}
+			cfg.QueueConfig.RetryOnRateLimit = queue.RetryOnRateLimit
+		}
+
+		cfg.MetadataConfig.Send = endpoint.SendMetadata != 2
+		if endpoint.MetadataSendIntervalSeconds > 0 {
+			cfg.MetadataConfig.SendInterval = GenPromDuration(endpoint.MetadataSendIntervalSeconds)
+		}
+
+		configs = append(configs, &cfg)
+	}
+
+	return configs, nil
+}
+
+// BuildRemoteReadConfigs 根据采集池生成 remote_read 配置，未配置目标列表时在启用告警的采集池上使用 RemoteReadUrl
+func BuildRemoteReadConfigs(pool *model.MonitorScrapePool) ([]*pc.RemoteReadConfig, error) {
+	endpoints := pool.RemoteReadConfigs
+	if len(endpoints) == 0 {
+		if pool.SupportAlert != 1 || pool.RemoteReadUrl == "" {
+			return nil, nil
+		}
+		endpoints = []model.RemoteReadEndpoint{{
+			Url:                  pool.RemoteReadUrl,
+			RemoteTimeoutSeconds: pool.RemoteTimeoutSeconds,
+		}}
+	}
+
+	configs := make([]*pc.RemoteReadConfig, 0, len(endpoints))
+	for i, endpoint := range endpoints {
+		remoteURL, err := parseRemoteURL(endpoint.Url)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 个 remote_read: %w", i+1, err)
+		}
+
+		cfg := pc.DefaultRemoteReadConfig
+		cfg.URL = remoteURL
+		cfg.Name = endpoint.Name
+		cfg.Headers = endpoint.Headers
+		cfg.ReadRecent = endpoint.ReadRecent
+		cfg.HTTPClientConfig = remoteHTTPClientConfig(endpoint.BasicAuthUsername, endpoint.BasicAuthPassword, endpoint.BearerToken)
+		if endpoint.RemoteTimeoutSeconds > 0 {
+			cfg.RemoteTimeout = GenPromDuration(endpoint.RemoteTimeoutSeconds)
+		}
+
+		if len(endpoint.RequiredMatchers) > 0 {
+			cfg.RequiredMatchers = make(pm.LabelSet, len(endpoint.RequiredMatchers))
+			for name, value := range endpoint.RequiredMatchers {
+				cfg.RequiredMatchers[pm.LabelName(name)] = pm.LabelValue(value)
+			}
+		}
+
+		configs = append(configs, &cfg)
+	}
+
+	return configs, nil
+}
+
+// ValidateRemoteConfigs 生成采集池的 remote_write 和 remote_read 配置，并使用 Prometheus 的加载逻辑校验
+// 敏感字段是否以原始值序列化不影响校验，序列化为 <secret> 时同样非空
+func ValidateRemoteConfigs(pool *model.MonitorScrapePool) error {
+	for _, endpoint := range pool.RemoteWriteConfigs {
+		if endpoint.BearerToken != "" && endpoint.BasicAuthUsername != "" {
+			return fmt.Errorf("remote_write %s 的 Bearer Token 和 Basic Auth 只能设置一个", endpoint.Url)
+		}
+	}
+	for _, endpoint := range pool.RemoteReadConfigs {
+		if endpoint.BearerToken != "" && endpoint.BasicAuthUsername != "" {
+			return fmt.Errorf("remote_read %s 的 Bearer Token 和 Basic Auth 只能设置一个", endpoint.Url)
+		}
+	}
+
+	writeConfigs, err := BuildRemoteWriteConfigs(pool)
+	if err != nil {
+		return err
+	}
+
+	readConfigs, err := BuildRemoteReadConfigs(pool)
+	if err != nil {
+		return err
+	}
+
+	content, err := yaml.Marshal(pc.Config{
+		RemoteWriteConfigs: writeConfigs,
+		RemoteReadConfigs:  readConfigs,
+	})
+	if err != nil {
+		return fmt.Errorf("序列化远程读写配置失败: %w", err)
+	}
+
+	if _, err := pc.Load(string(content), false, log.NewNopLogger()); err != nil {
+		return fmt.Errorf("远程读写配置校验失败: %w", err)
+	}
+
+	return nil
+}
+
+// parseRemoteURL 解析远程读写地址，必须包含协议和主机
+func parseRemoteURL(u string) (*pcc.URL, error) {
+	remoteURL, err := ParseURL(u)
+	if err != nil {
+		return nil, err
+	}
+
+	if remoteURL.Scheme == "" || remoteURL.Host == "" {
+		return nil, errors.New("地址必须包含协议和主机，例如 http://127.0.0.1:9090/api/v1/write")
+	}
+
+	return remoteURL, nil
+}
+
+// remoteHTTPClientConfig 生成远程读写使用的 HTTP 客户端配置
+func remoteHTTPClientConfig(username, password, bearerToken string) pcc.HTTPClientConfig {
+	httpConfig := pcc.DefaultHTTPClientConfig
+
+	if username != "" {
+		httpConfig.BasicAuth = &pcc.BasicAuth{
+			Username: username,
+			Password: pcc.Secret(password),
+		}
+	}
+	if bearerToken != "" {
+		httpConfig.Authorization = &pcc.Authorization{
+			Type:        "Bearer",
+			Credentials: pcc.Secret(bearerToken),
+		}
+	}
+
+	return httpConfig
+}
+
+// setPositive 值大于0时覆盖默认值
+func setPositive(target *int, value int) {
+	if value > 0 {
+		*target = value
+	}
+}
@@ -0,0 +1,155 @@
+package prometheus
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	pm "github.com/prometheus/common/model"
+	pc "github.com/prometheus/prometheus/config"
+)
+
+func TestBuildRemoteWriteConfigs(t *testing.T) {
+	t.Run("未配置远程写入", func(t *testing.T) {
+		configs, err := BuildRemoteWriteConfigs(&model.MonitorScrapePool{})
+		if err != nil || configs != nil {
+			t.Fatalf("BuildRemoteWriteConfigs() = %v, %v", configs, err)
+		}
+	})
+
+	t.Run("兼容旧的单个地址", func(t *testing.T) {
+		configs, err := BuildRemoteWriteConfigs(&model.MonitorScrapePool{RemoteWriteUrl: "http://10.0.0.1:9090/api/v1/write", RemoteTimeoutSeconds: 5})
+		if err != nil || len(configs) != 1 {
+			t.Fatalf("BuildRemoteWriteConfigs() = %v, %v", configs, err)
+		}
+		if configs[0].URL.String() != "http://10.0.0.1:9090/api/v1/write" || configs[0].RemoteTimeout != pm.Duration(5*time.Second) {
+			t.Errorf("remote_write = %+v", configs[0])
+		}
+	})
+
+	t.Run("完整配置", func(t *testing.T) {
+		configs, err := BuildRemoteWriteConfigs(&model.MonitorScrapePool{
+			RemoteWriteUrl: "http://ignored/api/v1/write",
+			RemoteWriteConfigs: []model.RemoteWriteEndpoint{{
+				Name:                          "thanos",
+				Url:                           "https://thanos/api/v1/receive",
+				BearerToken:                   "token",
+				WriteRelabelConfigsYamlString: "- action: drop\n  source_labels: [__name__]\n  regex: go_.*\n",
+				QueueConfig:                   &model.RemoteWriteQueueConfig{MaxShards: 10, BatchSendDeadlineSeconds: 10},
+				SendMetadata:                  2,
+			}},
+		})
+		if err != nil || len(configs) != 1 {
+			t.Fatalf("BuildRemoteWriteConfigs() = %v, %v", configs, err)
+		}
+
+		cfg := configs[0]
+		if cfg.Name != "thanos" || cfg.HTTPClientConfig.Authorization == nil || cfg.HTTPClientConfig.BasicAuth != nil {
+			t.Errorf("remote_write 鉴权设置错误: %+v", cfg.HTTPClientConfig)
+		}
+		if len(cfg.WriteRelabelConfigs) != 1 {
+			t.Errorf("len(WriteRelabelConfigs) = %d, want 1", len(cfg.WriteRelabelConfigs))
+		}
+		if cfg.QueueConfig.MaxShards != 10 || cfg.QueueConfig.Capacity != pc.DefaultQueueConfig.Capacity || cfg.QueueConfig.BatchSendDeadline != pm.Duration(10*time.Second) {
+			t.Errorf("QueueConfig = %+v", cfg.QueueConfig)
+		}
+		if cfg.MetadataConfig.Send {
+			t.Error("SendMetadata 为2时不应发送元数据")
+		}
+	})
+
+	errorTests := []struct {
+		name     string
+		endpoint model.RemoteWriteEndpoint
+	}{
+		{name: "地址缺少协议", endpoint: model.RemoteWriteEndpoint{Url: "10.0.0.1:9090/api/v1/write"}},
+		{name: "write_relabel 配置错误", endpoint: model.RemoteWriteEndpoint{Url: "http://10.0.0.1/api/v1/write", WriteRelabelConfigsYamlString: "- action: unknown\n"}},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := BuildRemoteWriteConfigs(&model.MonitorScrapePool{RemoteWriteConfigs: []model.RemoteWriteEndpoint{tt.endpoint}}); err == nil {
+				t.Error("BuildRemoteWriteConfigs() 应返回错误")
+			}
+		})
+	}
+}
+
+func TestBuildRemoteReadConfigs(t *testing.T) {
+	tests := []struct {
+		name    string
+		pool    model.MonitorScrapePool
+		wantLen int
+	}{
+		{name: "未启用告警时忽略旧的单个地址", pool: model.MonitorScrapePool{RemoteReadUrl: "http://10.0.0.1:9090/api/v1/read", SupportAlert: 2}, wantLen: 0},
+		{name: "启用告警时使用旧的单个地址", pool: model.MonitorScrapePool{RemoteReadUrl: "http://10.0.0.1:9090/api/v1/read", SupportAlert: 1}, wantLen: 1},
+		{name: "配置了目标列表", pool: model.MonitorScrapePool{RemoteReadConfigs: []model.RemoteReadEndpoint{
+			{Url: "http://a/api/v1/read", RequiredMatchers: map[string]string{"env": "prod"}},
+			{Url: "http://b/api/v1/read", ReadRecent: true},
+		}}, wantLen: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configs, err := BuildRemoteReadConfigs(&tt.pool)
+			if err != nil || len(configs) != tt.wantLen {
+				t.Fatalf("BuildRemoteReadConfigs() = %v, %v, want %d configs", configs, err, tt.wantLen)
+			}
+			if tt.wantLen == 2 && (configs[0].RequiredMatchers["env"] != "prod" || !configs[1].ReadRecent) {
+				t.Errorf("remote_read = %+v, %+v", configs[0], configs[1])
+			}
+		})
+	}
+}
+
+func TestValidateRemoteConfigs(t *testing.T) {
+	tests := []struct {
+		name    string
+		pool    model.MonitorScrapePool
+		wantErr bool
+	}{
+		{name: "没有远程读写", pool: model.MonitorScrapePool{}},
+		{name: "合法配置", pool: model.MonitorScrapePool{
+			RemoteWriteConfigs: []model.RemoteWriteEndpoint{{Name: "a", Url: "http://a/api/v1/write", BasicAuthUsername: "u", BasicAuthPassword: "p"}},
+			RemoteReadConfigs:  []model.RemoteReadEndpoint{{Url: "http://a/api/v1/read"}},
+		}},
+		{name: "同时设置 Token 和 Basic Auth", pool: model.MonitorScrapePool{
+			RemoteWriteConfigs: []model.RemoteWriteEndpoint{{Url: "http://a/api/v1/write", BasicAuthUsername: "u", BearerToken: "t"}},
+		}, wantErr: true},
+		{name: "remote_write 名称重复", pool: model.MonitorScrapePool{
+			RemoteWriteConfigs: []model.RemoteWriteEndpoint{{Name: "a", Url: "http://a/api/v1/write"}, {Name: "a", Url: "http://b/api/v1/write"}},
+		}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateRemoteConfigs(&tt.pool); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateRemoteConfigs() err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package prometheus
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	pm "github.com/prometheus/common/model"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// templateParamPattern 匹配模板中的 ${参数名}，与 Prometheus 注解使用的 {{ }} 模板语法互不冲突
+var templateParamPattern = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+// templateParamNamePattern 合法的参数名
+var templateParamNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// builtinTemplateParams 实例化时根据树节点自动填充的参数，不允许在模板中重新定义
+var builtinTemplateParams = map[string]bool{
+	model.RuleTemplateParamTreeNodeID:    true,
+	model.RuleTemplateParamTreeNodeTitle: true,
+}
+
+// ValidateRuleTemplate 检查模板的参数定义，以及模板中引用的参数是否都已定义
+func ValidateRuleTemplate(tpl *model.MonitorAlertRuleTemplate) error {
+	declared := make(map[string]bool, len(tpl.Parameters))
+	for _, param := range tpl.Parameters {
+		if !templateParamNamePattern.MatchString(param.Name) {
+			return fmt.Errorf("无效的参数名: %q", param.Name)
+		}
+		if builtinTemplateParams[param.Name] {
+			return fmt.Errorf("参数 %s 为内置参数，不能重新定义", param.Name)
+		}
+		if declared[param.Name] {
+			return fmt.Errorf("参数 %s 重复定义", param.Name)
+		}
+		declared[param.Name] = true
+
+		switch param.Type {
+		case model.RuleTemplateParamString, model.RuleTemplateParamNumber, model.RuleTemplateParamDuration:
+		default:
+			return fmt.Errorf("参数 %s 的类型 %q 不受支持", param.Name, param.Type)
+		}
+
+		// 非必填参数没有传值时使用默认值，因此默认值也必须符合参数类型
+		if param.Default != "" || !param.Required {
+			if err := checkTemplateParamValue(param, param.Default); err != nil {
+				return fmt.Errorf("参数 %s 的默认值无效: %w", param.Name, err)
+			}
+		}
+	}
+
+	for _, s := range ruleTemplateStrings(tpl) {
+		for _, match := range templateParamPattern.FindAllStringSubmatch(s, -1) {
+			if !declared[match[1]] && !builtinTemplateParams[match[1]] {
+				return fmt.Errorf("模板引用了未定义的参数: %s", match[0])
+			}
+		}
+	}
+
+	return nil
+}
+
+// ResolveTemplateParams 合并参数默认值、节点覆盖的参数和内置参数，得到渲染模板使用的参数值
+func ResolveTemplateParams(tpl *model.MonitorAlertRuleTemplate, overrides map[string]string, treeNode *model.TreeNode) (map[string]string, error) {
+	params := make(map[string]*model.RuleTemplateParam, len(tpl.Parameters))
+	for i := range tpl.Parameters {
+		params[tpl.Parameters[i].Name] = &tpl.Parameters[i]
+	}
+
+	for name := range overrides {
+		if _, ok := params[name]; !ok {
+			return nil, fmt.Errorf("模板没有定义参数 %s", name)
+		}
+	}
+
+	values := make(map[string]string, len(params)+len(builtinTemplateParams))
+	for _, param := range tpl.Parameters {
+		value, ok := overrides[param.Name]
+		if !ok || value == "" {
+			value = param.Default
+		}
+		if value == "" && param.Required {
+			return nil, fmt.Errorf("缺少必填参数 %s", param.Name)
+		}
+		if err := checkTemplateParamValue(param, value); err != nil {
+			return nil, fmt.Errorf("参数 %s 的值无效: %w", param.Name, err)
+		}
+		values[param.Name] = value
+	}
+
+	values[model.RuleTemplateParamTreeNodeID] = strconv.Itoa(treeNode.ID)
+	values[model.RuleTemplateParamTreeNodeTitle] = treeNode.Title
+
+	return values, nil
+}
+
+// RenderAlertRuleTemplate 使用参数值渲染模板，返回的告警规则只包含由模板决定的字段
+func RenderAlertRuleTemplate(tpl *model.MonitorAlertRuleTemplate, values map[string]string) (*model.MonitorAlertRule, error) {
+	render := func(s string) string {
+		return templateParamPattern.ReplaceAllStringFunc(s, func(m string) string {
+			return values[m[2:len(m)-1]]
+		})
+	}
+	renderList := func(list model.StringList) model.StringList {
+		if len(list) == 0 {
+			return nil
+		}
+		rendered := make(model.StringList, 0, len(list))
+		for _, s := range list {
+			rendered = append(rendered, render(s))
+		}
+		return rendered
+	}
+
+	rule := &model.MonitorAlertRule{
+		PoolID:      tpl.PoolID,
+		SendGroupID: tpl.SendGroupID,
+		TemplateID:  tpl.ID,
+		Expr:        render(tpl.Expr),
+		ForTime:     render(tpl.ForTime),
+		Severity:    render(tpl.Severity),
+		Labels:      renderList(tpl.Labels),
+		Annotations: renderList(tpl.Annotations),
+	}
+
+	if _, err := PromqlExprCheck(rule.Expr); err != nil {
+		return nil, fmt.Errorf("渲染后的表达式无效: %w", err)
+	}
+	if rule.ForTime != "" {
+		if _, err := pm.ParseDuration(rule.ForTime); err != nil {
+			return nil, fmt.Errorf("渲染后的持续时间无效: %w", err)
+		}
+	}
+
+	return rule, nil
+}
+
+// DiffRuleTemplateInstance 对比告警规则与按模板渲染的结果，返回不一致的字段
+func DiffRuleTemplateInstance(expected, actual *model.MonitorAlertRule) []*model.RuleTemplateDriftField {
+	var fields []*model.RuleTemplateDriftField
+	compare := func(field, e, a string) {
+		if e != a {
+			fields = append(fields, &model.RuleTemplateDriftField{Field: field, Expected: e, Actual: a})
+		}
+	}
+
+	compare("poolId", strconv.Itoa(expected.PoolID), strconv.Itoa(actual.PoolID))
+	compare("sendGroupId", strconv.Itoa(expected.SendGroupID), strconv.Itoa(actual.SendGroupID))
+	compare("expr", expected.Expr, actual.Expr)
+	compare("forTime", expected.ForTime, actual.ForTime)
+	compare("severity", expected.Severity, actual.Severity)
+	compare("labels", formatSortedPairs(expected.Labels), formatSortedPairs(actual.Labels))
+	compare("annotations", formatSortedPairs(expected.Annotations), formatSortedPairs(actual.Annotations))
+
+	return fields
+}
+
+// checkTemplateParamValue 检查参数值是否符合参数类型
+func checkTemplateParamValue(param model.RuleTemplateParam, value string) error {
+	switch param.Type {
+	case model.RuleTemplateParamString:
+		return nil
+	case model.RuleTemplateParamNumber:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("%q 不是有效的数字", value)
+		}
+	case model.RuleTemplateParamDuration:
+		if _, err := pm.ParseDuration(value); err != nil {
+			return fmt.Errorf("%q 不是有效的持续时间", value)
+		}
+	default:
+		return fmt.Errorf("不支持的参数类型: %s", param.Type)
+	}
+
+	return nil
+}
+
+// ruleTemplateStrings 返回模板中所有可以引用参数的字符串
+func ruleTemplateStrings(tpl *model.MonitorAlertRuleTemplate) []string {
+	s := []string{tpl.Expr, tpl.ForTime, tpl.Severity}
+	s = append(s, tpl.Labels...)
+	s = append(s, tpl.Annotations...)
+	return s
+}
+
+// formatSortedPairs 将 key=v 列表按键排序后拼接，使对比结果与列表顺序无关
+func formatSortedPairs(list model.StringList) string {
+	m := FromSliceTuMap(list)
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+m[k])
+	}
+	return strings.Join(pairs, "\n")
+}
@@ -0,0 +1,175 @@
+package prometheus
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+)
+
+func testRuleTemplate() *model.MonitorAlertRuleTemplate {
+	return &model.MonitorAlertRuleTemplate{
+		Model:       model.Model{ID: 3},
+		PoolID:      1,
+		SendGroupID: 2,
+		Expr:        `node_load1{node="${tree_node_title}"} > ${threshold}`,
+		ForTime:     "${for}",
+		Severity:    "${severity}",
+		Labels:      model.StringList{"tree_node=${tree_node_id}"},
+		Annotations: model.StringList{"summary=负载超过 ${threshold}，当前 {{ $value }}"},
+		Parameters: []model.RuleTemplateParam{
+			{Name: "threshold", Type: model.RuleTemplateParamNumber, Required: true},
+			{Name: "for", Type: model.RuleTemplateParamDuration, Default: "5m"},
+			{Name: "severity", Type: model.RuleTemplateParamString, Default: "warning"},
+		},
+	}
+}
+
+func TestValidateRuleTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(tpl *model.MonitorAlertRuleTemplate)
+		wantErr bool
+	}{
+		{name: "合法模板", modify: func(tpl *model.MonitorAlertRuleTemplate) {}},
+		{name: "非法参数名", modify: func(tpl *model.MonitorAlertRuleTemplate) { tpl.Parameters[0].Name = "1x" }, wantErr: true},
+		{name: "重新定义内置参数", modify: func(tpl *model.MonitorAlertRuleTemplate) { tpl.Parameters[0].Name = model.RuleTemplateParamTreeNodeID }, wantErr: true},
+		{name: "参数重复定义", modify: func(tpl *model.MonitorAlertRuleTemplate) { tpl.Parameters[1].Name = "threshold" }, wantErr: true},
+		{name: "不支持的类型", modify: func(tpl *model.MonitorAlertRuleTemplate) { tpl.Parameters[2].Type = "bool" }, wantErr: true},
+		{name: "默认值类型错误", modify: func(tpl *model.MonitorAlertRuleTemplate) { tpl.Parameters[1].Default = "soon" }, wantErr: true},
+		{name: "非必填数字参数没有默认值", modify: func(tpl *model.MonitorAlertRuleTemplate) { tpl.Parameters[0].Required = false }, wantErr: true},
+		{name: "引用未定义参数", modify: func(tpl *model.MonitorAlertRuleTemplate) { tpl.Severity = "${level}" }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tpl := testRuleTemplate()
+			tt.modify(tpl)
+			if err := ValidateRuleTemplate(tpl); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateRuleTemplate() err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveTemplateParams(t *testing.T) {
+	treeNode := &model.TreeNode{Model: model.Model{ID: 7}, Title: "web"}
+
+	tests := []struct {
+		name      string
+		overrides map[string]string
+		want      map[string]string
+		wantErr   bool
+	}{
+		{
+			name:      "使用默认值",
+			overrides: map[string]string{"threshold": "80"},
+			want:      map[string]string{"threshold": "80", "for": "5m", "severity": "warning", "tree_node_id": "7", "tree_node_title": "web"},
+		},
+		{
+			name:      "覆盖默认值",
+			overrides: map[string]string{"threshold": "0.95", "for": "10m", "severity": "critical"},
+			want:      map[string]string{"threshold": "0.95", "for": "10m", "severity": "critical", "tree_node_id": "7", "tree_node_title": "web"},
+		},
+		{
+			name:      "空值使用默认值",
+			overrides: map[string]string{"threshold": "80", "for": ""},
+			want:      map[string]string{"threshold": "80", "for": "5m", "severity": "warning", "tree_node_id": "7", "tree_node_title": "web"},
+		},
+		{name: "缺少必填参数", overrides: nil, wantErr: true},
+		{name: "未定义的参数", overrides: map[string]string{"threshold": "80", "level": "1"}, wantErr: true},
+		{name: "数字参数无效", overrides: map[string]string{"threshold": "high"}, wantErr: true},
+		{name: "持续时间参数无效", overrides: map[string]string{"threshold": "80", "for": "5"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveTemplateParams(testRuleTemplate(), tt.overrides, treeNode)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveTemplateParams() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResolveTemplateParams() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderAlertRuleTemplate(t *testing.T) {
+	values := map[string]string{"threshold": "80", "for": "5m", "severity": "warning", "tree_node_id": "7", "tree_node_title": "web"}
+
+	rule, err := RenderAlertRuleTemplate(testRuleTemplate(), values)
+	if err != nil {
+		t.Fatalf("RenderAlertRuleTemplate() err = %v", err)
+	}
+
+	want := &model.MonitorAlertRule{
+		PoolID:      1,
+		SendGroupID: 2,
+		TemplateID:  3,
+		Expr:        `node_load1{node="web"} > 80`,
+		ForTime:     "5m",
+		Severity:    "warning",
+		Labels:      model.StringList{"tree_node=7"},
+		Annotations: model.StringList{"summary=负载超过 80，当前 {{ $value }}"},
+	}
+	if !reflect.DeepEqual(rule, want) {
+		t.Errorf("RenderAlertRuleTemplate() = %+v, want %+v", rule, want)
+	}
+	if drift := DiffRuleTemplateInstance(want, rule); len(drift) != 0 {
+		t.Errorf("DiffRuleTemplateInstance() = %v, want none", drift)
+	}
+}
+
+func TestRenderAlertRuleTemplateInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[string]string
+	}{
+		{name: "渲染后表达式无效", values: map[string]string{"threshold": "80 >", "for": "5m"}},
+		{name: "渲染后持续时间无效", values: map[string]string{"threshold": "80", "for": "five"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := RenderAlertRuleTemplate(testRuleTemplate(), tt.values); err == nil {
+				t.Error("RenderAlertRuleTemplate() 应返回错误")
+			}
+		})
+	}
+}
+
+func TestDiffRuleTemplateInstance(t *testing.T) {
+	expected := &model.MonitorAlertRule{Expr: "up == 0", Labels: model.StringList{"a=1", "b=2"}}
+	actual := &model.MonitorAlertRule{Expr: "up == 1", Labels: model.StringList{"b=2", "a=1"}}
+
+	drift := DiffRuleTemplateInstance(expected, actual)
+	if len(drift) != 1 || drift[0].Field != "expr" {
+		t.Fatalf("DiffRuleTemplateInstance() = %+v, want only expr", drift)
+	}
+}
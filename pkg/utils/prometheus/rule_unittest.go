@@ -0,0 +1,345 @@
+package prometheus
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"github.com/GoSimplicity/AI-CloudOps/pkg/utils/general"
+	"github.com/go-kit/log"
+	pm "github.com/prometheus/common/model"
+	promLabels "github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/promql/promqltest"
+	"github.com/prometheus/prometheus/rules"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultRuleTestInterval 测试用例未指定间隔时使用的采样和评估间隔，与 promtool 一致
+const defaultRuleTestInterval = time.Minute
+
+// DefaultAlertRuleForTime 告警规则未设置持续时间时使用的默认值，生成规则文件、规则测试和回测都使用该值
+const DefaultAlertRuleForTime = pm.Duration(5 * time.Second)
+
+// AlertRuleForTime 解析告警规则的持续时间，为空时返回默认值，无法解析时返回默认值和错误
+func AlertRuleForTime(forTime string) (pm.Duration, error) {
+	if forTime == "" {
+		return DefaultAlertRuleForTime, nil
+	}
+
+	ft, err := pm.ParseDuration(forTime)
+	if err != nil {
+		return DefaultAlertRuleForTime, fmt.Errorf("解析告警规则持续时间失败: %w", err)
+	}
+
+	return ft, nil
+}
+
+// AlertRuleToRulefmt 将告警规则转换为规则文件格式
+// 持续时间无法解析时返回错误，同时返回使用默认持续时间的规则，生成规则文件时可以忽略错误继续使用
+func AlertRuleToRulefmt(rule *model.MonitorAlertRule) (rulefmt.Rule, error) {
+	ft, err := AlertRuleForTime(rule.ForTime)
+
+	return rulefmt.Rule{
+		Alert:       rule.Name,
+		Expr:        rule.Expr,
+		For:         ft,
+		Labels:      FromSliceTuMap(rule.Labels),
+		Annotations: FromSliceTuMap(rule.Annotations),
+	}, err
+}
+
+// RecordRuleToRulefmt 将预聚合规则转换为规则文件格式，记录的指标名称为 RecordName，Name 只是平台内的规则名称
+func RecordRuleToRulefmt(rule *model.MonitorRecordRule) rulefmt.Rule {
+	return rulefmt.Rule{
+		Record: rule.RecordName,
+		Expr:   rule.Expr,
+	}
+}
+
+// RunRuleTests 依次运行规则的测试用例，所有用例都通过时 Passed 为 true
+func RunRuleTests(ctx context.Context, rule rulefmt.Rule, tests []*model.MonitorRuleTest) *model.RuleTestRunResp {
+	resp := &model.RuleTestRunResp{Passed: true}
+
+	for _, test := range tests {
+		result := RunRuleTest(ctx, rule, test)
+		if !result.Passed {
+			resp.Passed = false
+		}
+		resp.Results = append(resp.Results, result)
+	}
+
+	return resp
+}
+
+// RunRuleTest 在内存存储中运行单个测试用例
+// 与 promtool test rules 相同，从0时刻开始按间隔加载输入序列并评估规则，直到评估时间
+func RunRuleTest(ctx context.Context, rule rulefmt.Rule, test *model.MonitorRuleTest) *model.RuleTestResult {
+	result := &model.RuleTestResult{
+		TestID: test.ID,
+		Name:   test.Name,
+	}
+
+	expected, got, err := runRuleTest(ctx, rule, test)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Expected = expected
+	result.Got = got
+	result.Passed = expected == got
+	if !result.Passed {
+		result.Diff = general.UnifiedDiff("expected", "got", expected, got)
+	}
+
+	return result
+}
+
+func runRuleTest(ctx context.Context, rule rulefmt.Rule, test *model.MonitorRuleTest) (string, string, error) {
+	interval, err := parseRuleTestDuration(test.Interval, defaultRuleTestInterval)
+	if err != nil {
+		return "", "", fmt.Errorf("解析采样间隔失败: %w", err)
+	}
+	if interval <= 0 {
+		return "", "", errors.New("采样间隔必须大于0")
+	}
+
+	evalTime, err := parseRuleTestDuration(test.EvalTime, 0)
+	if err != nil {
+		return "", "", fmt.Errorf("解析评估时间失败: %w", err)
+	}
+
+	if len(test.InputSeries) == 0 {
+		return "", "", errors.New("至少需要一条输入序列")
+	}
+
+	var load strings.Builder
+	fmt.Fprintf(&load, "load %s\n", pm.Duration(interval))
+	for _, series := range test.InputSeries {
+		fmt.Fprintf(&load, "  %s %s\n", series.Series, series.Values)
+	}
+
+	suite, err := promqltest.NewLazyLoader(load.String(), promqltest.LazyLoaderOpts{
+		EnableAtModifier:     true,
+		EnableNegativeOffset: true,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("解析输入序列失败: %w", err)
+	}
+	defer suite.Close()
+	suite.SubqueryInterval = interval
+
+	ruleItem, err := newRule(rule)
+	if err != nil {
+		return "", "", err
+	}
+
+	group := rules.NewGroup(rules.GroupOptions{
+		Name:     "unittest",
+		Interval: interval,
+		Rules:    []rules.Rule{ruleItem},
+		Opts: &rules.ManagerOptions{
+			QueryFunc:  rules.EngineQueryFunc(suite.QueryEngine(), suite.Storage()),
+			Appendable: suite.Storage(),
+			Queryable:  suite.Storage(),
+			Context:    suite.Context(),
+			NotifyFunc: func(context.Context, string, ...*rules.Alert) {},
+			Logger:     log.NewNopLogger(),
+		},
+	})
+
+	mint := time.Unix(0, 0).UTC()
+	maxt := mint.Add(evalTime)
+	for ts := mint; !ts.After(maxt); ts = ts.Add(interval) {
+		if err := ctx.Err(); err != nil {
+			return "", "", err
+		}
+
+		var loadErr error
+		suite.WithSamplesTill(ts, func(err error) {
+			if err != nil {
+				loadErr = err
+				return
+			}
+			group.Eval(suite.Context(), ts)
+		})
+		if loadErr != nil {
+			return "", "", fmt.Errorf("加载输入序列失败: %w", loadErr)
+		}
+		if err := ruleItem.LastError(); err != nil {
+			return "", "", fmt.Errorf("评估规则失败，时间 %s: %w", ts.Sub(mint), err)
+		}
+	}
+
+	if alertRule, ok := ruleItem.(*rules.AlertingRule); ok {
+		return formatExpectedAlerts(rule.Alert, test.ExpAlerts), formatFiringAlerts(alertRule), nil
+	}
+
+	expr := test.Expr
+	if expr == "" {
+		expr = rule.Record
+	}
+
+	got, err := queryRuleTestSamples(suite, expr, maxt)
+	if err != nil {
+		return "", "", err
+	}
+
+	expected, err := formatExpectedSamples(test.ExpSamples)
+	if err != nil {
+		return "", "", err
+	}
+
+	return expected, got, nil
+}
+
+// newRule 根据规则文件格式创建可评估的规则，告警规则直接标记为已恢复，以便生成 ALERTS 序列
+func newRule(rule rulefmt.Rule) (rules.Rule, error) {
+	expr, err := parser.ParseExpr(rule.Expr)
+	if err != nil {
+		return nil, fmt.Errorf("PromQL 表达式不合法: %w", err)
+	}
+
+	if rule.Alert != "" {
+		return rules.NewAlertingRule(
+			rule.Alert,
+			expr,
+			time.Duration(rule.For),
+			time.Duration(rule.KeepFiringFor),
+			promLabels.FromMap(rule.Labels),
+			promLabels.FromMap(rule.Annotations),
+			promLabels.EmptyLabels(),
+			"",
+			true,
+			log.NewNopLogger(),
+		), nil
+	}
+
+	return rules.NewRecordingRule(rule.Record, expr, promLabels.FromMap(rule.Labels)), nil
+}
+
+// queryRuleTestSamples 在评估时间执行查询，返回格式化后的样本
+func queryRuleTestSamples(suite *promqltest.LazyLoader, expr string, ts time.Time) (string, error) {
+	query, err := suite.QueryEngine().NewInstantQuery(suite.Context(), suite.Queryable(), nil, expr, ts)
+	if err != nil {
+		return "", fmt.Errorf("查询表达式不合法: %w", err)
+	}
+	defer query.Close()
+
+	res := query.Exec(suite.Context())
+	if res.Err != nil {
+		return "", fmt.Errorf("执行查询失败: %w", res.Err)
+	}
+
+	var lines []string
+	switch v := res.Value.(type) {
+	case promql.Vector:
+		for _, sample := range v {
+			lines = append(lines, formatRuleTestSample(sample.Metric, sample.F))
+		}
+	case promql.Scalar:
+		lines = append(lines, formatRuleTestSample(promLabels.EmptyLabels(), v.V))
+	default:
+		return "", fmt.Errorf("查询结果类型 %s 不支持，只支持瞬时向量和标量", res.Value.Type())
+	}
+
+	sort.Strings(lines)
+	return strings.Join(lines, "\n"), nil
+}
+
+func formatExpectedSamples(samples []model.RuleTestSample) (string, error) {
+	var lines []string
+	for _, sample := range samples {
+		lset, err := parser.ParseMetric(sample.Labels)
+		if err != nil {
+			return "", fmt.Errorf("解析期望样本的标签 %s 失败: %w", sample.Labels, err)
+		}
+		lines = append(lines, formatRuleTestSample(lset, sample.Value))
+	}
+
+	sort.Strings(lines)
+	return strings.Join(lines, "\n"), nil
+}
+
+func formatRuleTestSample(lset promLabels.Labels, value float64) string {
+	return lset.String() + " " + strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// formatFiringAlerts 返回评估时间处于触发状态的告警
+func formatFiringAlerts(rule *rules.AlertingRule) string {
+	var lines []string
+	for _, alert := range rule.ActiveAlerts() {
+		if alert.State == rules.StateFiring {
+			lines = append(lines, formatRuleTestAlert(alert.Labels, alert.Annotations))
+		}
+	}
+
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+func formatExpectedAlerts(alertName string, alerts []model.RuleTestAlert) string {
+	var lines []string
+	for _, alert := range alerts {
+		expLabels := make(map[string]string, len(alert.ExpLabels)+1)
+		for k, v := range alert.ExpLabels {
+			expLabels[k] = v
+		}
+		// 告警名称由 Prometheus 在评估时添加，用例中无需填写
+		expLabels[promLabels.AlertName] = alertName
+
+		lines = append(lines, formatRuleTestAlert(promLabels.FromMap(expLabels), promLabels.FromMap(alert.ExpAnnotations)))
+	}
+
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+func formatRuleTestAlert(lset, annotations promLabels.Labels) string {
+	return "labels: " + lset.String() + " annotations: " + annotations.String()
+}
+
+// parseRuleTestDuration 解析 Prometheus 格式的时间，为空时返回默认值
+func parseRuleTestDuration(value string, defaultValue time.Duration) (time.Duration, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	d, err := pm.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(d), nil
+}
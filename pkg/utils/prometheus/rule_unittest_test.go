@@ -0,0 +1,219 @@
+package prometheus
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+)
+
+func TestRecordRuleToRulefmtUsesRecordName(t *testing.T) {
+	rule := RecordRuleToRulefmt(&model.MonitorRecordRule{
+		Name:       "节点存活数",
+		RecordName: "job:up:sum",
+		Expr:       "sum by (job) (up)",
+	})
+
+	if rule.Record != "job:up:sum" {
+		t.Errorf("Record = %q, want job:up:sum", rule.Record)
+	}
+	if rule.Alert != "" {
+		t.Errorf("Alert = %q, want empty", rule.Alert)
+	}
+}
+
+func TestRunRuleTestRecordRule(t *testing.T) {
+	rule := RecordRuleToRulefmt(&model.MonitorRecordRule{
+		Name:       "节点存活数",
+		RecordName: "job:up:sum",
+		Expr:       "sum by (job) (up)",
+	})
+
+	tests := []struct {
+		name   string
+		test   *model.MonitorRuleTest
+		passed bool
+	}{
+		{
+			name: "查询记录的指标",
+			test: &model.MonitorRuleTest{
+				Name:        "sum",
+				InputSeries: []model.RuleTestSeries{{Series: `up{job="node", instance="a"}`, Values: "1x5"}, {Series: `up{job="node", instance="b"}`, Values: "1x5"}},
+				EvalTime:    "2m",
+				ExpSamples:  []model.RuleTestSample{{Labels: `job:up:sum{job="node"}`, Value: 2}},
+			},
+			passed: true,
+		},
+		{
+			name: "期望值不一致",
+			test: &model.MonitorRuleTest{
+				Name:        "mismatch",
+				InputSeries: []model.RuleTestSeries{{Series: `up{job="node", instance="a"}`, Values: "1x5"}},
+				EvalTime:    "2m",
+				ExpSamples:  []model.RuleTestSample{{Labels: `job:up:sum{job="node"}`, Value: 2}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := RunRuleTest(context.Background(), rule, tt.test)
+			if result.Passed != tt.passed {
+				t.Errorf("Passed = %v, want %v, error: %s, got: %s", result.Passed, tt.passed, result.Error, result.Got)
+			}
+		})
+	}
+}
+
+func TestAlertRuleForTime(t *testing.T) {
+	tests := []struct {
+		name    string
+		forTime string
+		want    string
+		wantErr bool
+	}{
+		{name: "未设置时使用默认值", want: "5s"},
+		{name: "合法持续时间", forTime: "2m", want: "2m"},
+		{name: "无法解析时使用默认值", forTime: "two minutes", want: "5s", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AlertRuleForTime(tt.forTime)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AlertRuleForTime() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.String() != tt.want {
+				t.Errorf("AlertRuleForTime() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunRuleTestAlertRule(t *testing.T) {
+	newRule := func(forTime string) *model.MonitorAlertRule {
+		return &model.MonitorAlertRule{
+			Name:        "HostDown",
+			Expr:        "up == 0",
+			ForTime:     forTime,
+			Labels:      []string{"severity=critical"},
+			Annotations: []string{"summary={{ $labels.instance }} 宕机"},
+		}
+	}
+	input := []model.RuleTestSeries{{Series: `up{job="node", instance="a"}`, Values: "0x5"}}
+	firing := []model.RuleTestAlert{{
+		ExpLabels:      map[string]string{"job": "node", "instance": "a", "severity": "critical"},
+		ExpAnnotations: map[string]string{"summary": "a 宕机"},
+	}}
+
+	tests := []struct {
+		name   string
+		rule   *model.MonitorAlertRule
+		test   *model.MonitorRuleTest
+		passed bool
+	}{
+		{
+			name:   "持续时间未满足时处于 pending",
+			rule:   newRule("1m"),
+			test:   &model.MonitorRuleTest{InputSeries: input, EvalTime: "0m"},
+			passed: true,
+		},
+		{
+			name:   "持续满足后触发",
+			rule:   newRule("1m"),
+			test:   &model.MonitorRuleTest{InputSeries: input, EvalTime: "1m", ExpAlerts: firing},
+			passed: true,
+		},
+		{
+			name:   "未设置持续时间时与生成的规则一样在第二次评估触发",
+			rule:   newRule(""),
+			test:   &model.MonitorRuleTest{InputSeries: input, EvalTime: "0m"},
+			passed: true,
+		},
+		{
+			name: "期望在 pending 时触发",
+			rule: newRule("1m"),
+			test: &model.MonitorRuleTest{InputSeries: input, EvalTime: "0m", ExpAlerts: firing},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := AlertRuleToRulefmt(tt.rule)
+			if err != nil {
+				t.Fatalf("AlertRuleToRulefmt() err = %v", err)
+			}
+
+			result := RunRuleTest(context.Background(), rule, tt.test)
+			if result.Error != "" {
+				t.Fatalf("RunRuleTest() error: %s", result.Error)
+			}
+			if result.Passed != tt.passed {
+				t.Errorf("Passed = %v, want %v, expected: %s, got: %s", result.Passed, tt.passed, result.Expected, result.Got)
+			}
+		})
+	}
+}
+
+func TestRunRuleTestAlertRuleDiff(t *testing.T) {
+	rule, err := AlertRuleToRulefmt(&model.MonitorAlertRule{
+		Name:        "HostDown",
+		Expr:        "up == 0",
+		ForTime:     "1m",
+		Annotations: []string{"summary={{ $labels.instance }} 宕机"},
+	})
+	if err != nil {
+		t.Fatalf("AlertRuleToRulefmt() err = %v", err)
+	}
+
+	result := RunRuleTest(context.Background(), rule, &model.MonitorRuleTest{
+		InputSeries: []model.RuleTestSeries{{Series: `up{job="node", instance="a"}`, Values: "0x5"}},
+		EvalTime:    "2m",
+		ExpAlerts: []model.RuleTestAlert{{
+			ExpLabels:      map[string]string{"job": "node", "instance": "b"},
+			ExpAnnotations: map[string]string{"summary": "b 宕机"},
+		}},
+	})
+	if result.Passed {
+		t.Fatal("RunRuleTest() 期望不一致时不应通过")
+	}
+
+	// 期望的告警自动带上 alertname，实际的注解经过模板渲染
+	wantExpected := `labels: {alertname="HostDown", instance="b", job="node"} annotations: {summary="b 宕机"}`
+	wantGot := `labels: {alertname="HostDown", instance="a", job="node"} annotations: {summary="a 宕机"}`
+	if result.Expected != wantExpected {
+		t.Errorf("Expected = %s, want %s", result.Expected, wantExpected)
+	}
+	if result.Got != wantGot {
+		t.Errorf("Got = %s, want %s", result.Got, wantGot)
+	}
+	if !strings.Contains(result.Diff, "-"+wantExpected) || !strings.Contains(result.Diff, "+"+wantGot) {
+		t.Errorf("Diff 缺少不一致的告警:\n%s", result.Diff)
+	}
+}
@@ -0,0 +1,105 @@
+package prometheus
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"errors"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	"net/url"
+	"strings"
+)
+
+// ValidateScrapeJobHTTPClient 检查采集任务的鉴权、TLS、代理、限制和URL参数配置
+func ValidateScrapeJobHTTPClient(job *model.MonitorScrapeJob) error {
+	hasBearer := job.BearerToken != "" || job.BearerTokenFile != ""
+	hasBasicAuth := job.BasicAuthUsername != "" || job.BasicAuthPassword != ""
+
+	if job.BearerToken != "" && job.BearerTokenFile != "" {
+		return errors.New("鉴权Token内容和Token文件只能设置一个")
+	}
+	if hasBearer && hasBasicAuth {
+		return errors.New("Bearer Token 和 Basic Auth 只能设置一个")
+	}
+	if job.BasicAuthPassword != "" && job.BasicAuthUsername == "" {
+		return errors.New("设置 Basic Auth 密码时必须指定用户名")
+	}
+
+	if job.TlsCaContent != "" && job.TlsCaFilePath != "" {
+		return errors.New("CA证书内容和CA证书文件只能设置一个")
+	}
+	if job.TlsCertContent != "" && job.TlsCertFilePath != "" {
+		return errors.New("客户端证书内容和证书文件只能设置一个")
+	}
+	if job.TlsKeyContent != "" && job.TlsKeyFilePath != "" {
+		return errors.New("客户端私钥内容和私钥文件只能设置一个")
+	}
+	hasCert := job.TlsCertContent != "" || job.TlsCertFilePath != ""
+	hasKey := job.TlsKeyContent != "" || job.TlsKeyFilePath != ""
+	if hasCert != hasKey {
+		return errors.New("客户端证书和私钥必须同时设置")
+	}
+
+	if job.ProxyUrl != "" {
+		proxyURL, err := url.Parse(job.ProxyUrl)
+		if err != nil {
+			return fmt.Errorf("代理地址格式错误: %w", err)
+		}
+		switch proxyURL.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return fmt.Errorf("不支持的代理协议: %s", proxyURL.Scheme)
+		}
+	}
+
+	if job.SampleLimit < 0 || job.TargetLimit < 0 || job.LabelLimit < 0 {
+		return errors.New("样本数、目标数和标签数上限不能为负数")
+	}
+
+	if _, err := ParseScrapeParams(job.Params); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ParseScrapeParams 将 key=value 格式的URL参数解析为 url.Values
+func ParseScrapeParams(params []string) (url.Values, error) {
+	if len(params) == 0 {
+		return nil, nil
+	}
+
+	values := make(url.Values)
+	for _, param := range params {
+		parts := strings.SplitN(param, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("URL参数 %s 格式错误，应为 key=value", param)
+		}
+		values.Add(strings.TrimSpace(parts[0]), parts[1])
+	}
+
+	return values, nil
+}
@@ -0,0 +1,90 @@
+package prometheus
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+)
+
+func TestValidateScrapeJobHTTPClient(t *testing.T) {
+	tests := []struct {
+		name    string
+		job     model.MonitorScrapeJob
+		wantErr bool
+	}{
+		{name: "没有设置", job: model.MonitorScrapeJob{}},
+		{name: "Basic Auth", job: model.MonitorScrapeJob{BasicAuthUsername: "admin", BasicAuthPassword: "secret"}},
+		{name: "客户端证书", job: model.MonitorScrapeJob{TlsCertFilePath: "/etc/cert.pem", TlsKeyContent: "key"}},
+		{name: "socks5 代理", job: model.MonitorScrapeJob{ProxyUrl: "socks5://10.0.0.1:1080"}},
+		{name: "Token 内容和文件同时设置", job: model.MonitorScrapeJob{BearerToken: "t", BearerTokenFile: "/f"}, wantErr: true},
+		{name: "Bearer 和 Basic Auth 同时设置", job: model.MonitorScrapeJob{BearerToken: "t", BasicAuthUsername: "admin"}, wantErr: true},
+		{name: "只有 Basic Auth 密码", job: model.MonitorScrapeJob{BasicAuthPassword: "secret"}, wantErr: true},
+		{name: "CA证书内容和文件同时设置", job: model.MonitorScrapeJob{TlsCaContent: "ca", TlsCaFilePath: "/ca"}, wantErr: true},
+		{name: "只有客户端证书", job: model.MonitorScrapeJob{TlsCertContent: "cert"}, wantErr: true},
+		{name: "不支持的代理协议", job: model.MonitorScrapeJob{ProxyUrl: "ftp://10.0.0.1"}, wantErr: true},
+		{name: "负数上限", job: model.MonitorScrapeJob{SampleLimit: -1}, wantErr: true},
+		{name: "URL参数格式错误", job: model.MonitorScrapeJob{Params: model.StringList{"module"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateScrapeJobHTTPClient(&tt.job); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateScrapeJobHTTPClient() err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseScrapeParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  []string
+		want    url.Values
+		wantErr bool
+	}{
+		{name: "没有参数", params: nil, want: nil},
+		{name: "同一个key多次出现", params: []string{"module=http_2xx", " target =a=b", "module=tcp"}, want: url.Values{"module": {"http_2xx", "tcp"}, "target": {"a=b"}}},
+		{name: "值可以为空", params: []string{"debug="}, want: url.Values{"debug": {""}}},
+		{name: "缺少等号", params: []string{"module"}, wantErr: true},
+		{name: "key为空", params: []string{" =x"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseScrapeParams(tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseScrapeParams() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseScrapeParams() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package prometheus
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"errors"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	pm "github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+	"strings"
+)
+
+// ValidateScrapeJobDiscovery 根据服务发现类型检查采集任务的必填字段
+func ValidateScrapeJobDiscovery(job *model.MonitorScrapeJob) error {
+	switch job.ServiceDiscoveryType {
+	case model.ServiceDiscoveryHttp, model.ServiceDiscoveryK8s:
+		// 服务树和 Kubernetes 服务发现沿用原有字段，不做额外检查
+	case model.ServiceDiscoveryStatic:
+		if len(job.StaticTargetGroups) == 0 {
+			return errors.New("静态配置必须包含至少一组采集目标")
+		}
+		if err := ValidateTargetGroups(job.StaticTargetGroups); err != nil {
+			return err
+		}
+	case model.ServiceDiscoveryFile:
+		if len(job.FileSdTargetGroups) == 0 && len(job.FileSdPaths) == 0 {
+			return errors.New("文件服务发现必须包含采集目标组或目标文件路径")
+		}
+		if err := ValidateTargetGroups(job.FileSdTargetGroups); err != nil {
+			return err
+		}
+		for _, path := range job.FileSdPaths {
+			if !strings.HasSuffix(path, ".json") && !strings.HasSuffix(path, ".yml") && !strings.HasSuffix(path, ".yaml") {
+				return fmt.Errorf("目标文件 %s 必须以 .json、.yml 或 .yaml 结尾", path)
+			}
+		}
+	case model.ServiceDiscoveryDns:
+		if len(job.DnsSdNames) == 0 {
+			return errors.New("DNS服务发现必须包含至少一个域名")
+		}
+		switch strings.ToUpper(job.DnsSdType) {
+		case "", "SRV":
+		case "A", "AAAA", "MX", "NS":
+			if job.DnsSdPort <= 0 {
+				return fmt.Errorf("DNS记录类型为 %s 时必须指定端口", strings.ToUpper(job.DnsSdType))
+			}
+		default:
+			return fmt.Errorf("不支持的DNS记录类型: %s", job.DnsSdType)
+		}
+	case model.ServiceDiscoveryConsul:
+		if job.ConsulServer == "" {
+			return errors.New("Consul服务发现必须指定Consul地址")
+		}
+		if job.ConsulScheme != "" && job.ConsulScheme != "http" && job.ConsulScheme != "https" {
+			return fmt.Errorf("不支持的Consul协议: %s", job.ConsulScheme)
+		}
+	default:
+		return fmt.Errorf("不支持的服务发现类型: %s", job.ServiceDiscoveryType)
+	}
+
+	return nil
+}
+
+// ValidateTargetGroups 检查采集目标地址和标签名是否合法
+func ValidateTargetGroups(groups []model.ScrapeTargetGroup) error {
+	for i, group := range groups {
+		if len(group.Targets) == 0 {
+			return fmt.Errorf("第 %d 组采集目标为空", i+1)
+		}
+
+		for _, target := range group.Targets {
+			if strings.TrimSpace(target) == "" {
+				return fmt.Errorf("第 %d 组包含空的采集目标", i+1)
+			}
+			if strings.Contains(target, "/") {
+				return fmt.Errorf("采集目标 %s 不能包含协议或路径，格式应为 host:port", target)
+			}
+		}
+
+		for name := range group.Labels {
+			if !pm.LabelName(name).IsValid() {
+				return fmt.Errorf("第 %d 组的标签名 %s 不合法", i+1, name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ToTargetGroups 将采集目标组转换为 Prometheus 的 targetgroup，source 用于区分同一任务下的不同目标组
+func ToTargetGroups(groups []model.ScrapeTargetGroup, source string) []*targetgroup.Group {
+	result := make([]*targetgroup.Group, 0, len(groups))
+
+	for i, group := range groups {
+		tg := &targetgroup.Group{
+			Source: fmt.Sprintf("%s/%d", source, i),
+			Labels: make(pm.LabelSet, len(group.Labels)),
+		}
+
+		for _, target := range group.Targets {
+			tg.Targets = append(tg.Targets, pm.LabelSet{pm.AddressLabel: pm.LabelValue(strings.TrimSpace(target))})
+		}
+		for name, value := range group.Labels {
+			tg.Labels[pm.LabelName(name)] = pm.LabelValue(value)
+		}
+
+		result = append(result, tg)
+	}
+
+	return result
+}
@@ -0,0 +1,213 @@
+package prometheus
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+	al "github.com/prometheus/alertmanager/pkg/labels"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// AlertManagerSilenceMatcher AlertManager v2 API 的静默匹配器
+type AlertManagerSilenceMatcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+	IsEqual bool   `json:"isEqual"`
+}
+
+// AlertManagerSilence AlertManager v2 API 提交的静默，ID 不为空时表示修改已有静默
+type AlertManagerSilence struct {
+	ID        string                       `json:"id,omitempty"`
+	Matchers  []AlertManagerSilenceMatcher `json:"matchers"`
+	StartsAt  time.Time                    `json:"startsAt"`
+	EndsAt    time.Time                    `json:"endsAt"`
+	CreatedBy string                       `json:"createdBy"`
+	Comment   string                       `json:"comment"`
+}
+
+// AlertManagerInstanceURL 拼接 AlertManager 实例地址，实例未带端口时使用默认端口
+func AlertManagerInstanceURL(instance string, defaultPort int) string {
+	if _, _, err := net.SplitHostPort(instance); err == nil {
+		return "http://" + instance
+	}
+
+	return "http://" + net.JoinHostPort(instance, strconv.Itoa(defaultPort))
+}
+
+// BuildSilenceMatchers 解析静默的匹配条件，至少要有一个匹配条件不能匹配空值，否则 AlertManager 会拒绝
+func BuildSilenceMatchers(list []string) ([]AlertManagerSilenceMatcher, error) {
+	matchers, err := ParseAlertManagerMatchers(list)
+	if err != nil {
+		return nil, err
+	}
+	if len(matchers) == 0 {
+		return nil, errors.New("静默至少需要一个匹配条件")
+	}
+
+	result := make([]AlertManagerSilenceMatcher, 0, len(matchers))
+	matchesEmpty := true
+	for _, m := range matchers {
+		if !m.Matches("") {
+			matchesEmpty = false
+		}
+		result = append(result, AlertManagerSilenceMatcher{
+			Name:    m.Name,
+			Value:   m.Value,
+			IsRegex: m.Type == al.MatchRegexp || m.Type == al.MatchNotRegexp,
+			IsEqual: m.Type == al.MatchEqual || m.Type == al.MatchRegexp,
+		})
+	}
+	if matchesEmpty {
+		return nil, errors.New("匹配条件全部可以匹配空值，会静默所有告警")
+	}
+
+	return result, nil
+}
+
+// AlertEventSilenceMatchers 根据告警事件的标签生成等值匹配条件，useName 为 true 时只按告警名称匹配
+func AlertEventSilenceMatchers(event *model.MonitorAlertEvent, useName bool) ([]string, error) {
+	labelsMap := FromSliceTuMap(event.Labels)
+	if useName {
+		alertName, ok := labelsMap["alertname"]
+		if !ok {
+			alertName = event.AlertName
+		}
+		if alertName == "" {
+			return nil, errors.New("告警事件缺少告警名称")
+		}
+		labelsMap = map[string]string{"alertname": alertName}
+	}
+	if len(labelsMap) == 0 {
+		return nil, errors.New("告警事件没有标签，无法生成匹配条件")
+	}
+
+	names := make([]string, 0, len(labelsMap))
+	for name := range labelsMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	matchers := make([]string, 0, len(names))
+	for _, name := range names {
+		matcher, err := al.NewMatcher(al.MatchEqual, name, labelsMap[name])
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, matcher.String())
+	}
+
+	return matchers, nil
+}
+
+// PostAlertManagerSilence 通过 v2 API 创建或修改静默，返回 AlertManager 的静默ID
+// 修改生效中的静默且匹配条件变化时，AlertManager 会过期原静默并返回新的ID
+func PostAlertManagerSilence(ctx context.Context, client *http.Client, baseURL string, silence *AlertManagerSilence) (string, error) {
+	data, err := json.Marshal(silence)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/api/v2/silences", bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	body, err := doAlertManagerRequest(client, req)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		SilenceID string `json:"silenceID"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("解析AlertManager响应失败: %w", err)
+	}
+	if result.SilenceID == "" {
+		return "", errors.New("AlertManager没有返回静默ID")
+	}
+
+	return result.SilenceID, nil
+}
+
+// ExpireAlertManagerSilence 通过 v2 API 使静默立即过期，静默已不存在时视为成功
+func ExpireAlertManagerSilence(ctx context.Context, client *http.Client, baseURL, silenceID string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, baseURL+"/api/v2/silence/"+url.PathEscape(silenceID), nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = doAlertManagerRequest(client, req)
+	var statusErr *alertManagerStatusError
+	if errors.As(err, &statusErr) && statusErr.status == http.StatusNotFound {
+		return nil
+	}
+
+	return err
+}
+
+// alertManagerStatusError AlertManager 返回的非成功状态
+type alertManagerStatusError struct {
+	status int
+	body   string
+}
+
+func (e *alertManagerStatusError) Error() string {
+	return fmt.Sprintf("AlertManager返回状态码 %d: %s", e.status, e.body)
+}
+
+// doAlertManagerRequest 发送请求并读取响应，非 2xx 状态返回 alertManagerStatusError
+func doAlertManagerRequest(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &alertManagerStatusError{status: resp.StatusCode, body: string(bytes.TrimSpace(body))}
+	}
+
+	return body, nil
+}
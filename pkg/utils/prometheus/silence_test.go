@@ -0,0 +1,216 @@
+package prometheus
+
+/*
+ * MIT License
+ *
+ * Copyright (c) 2024 Bamboo
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/GoSimplicity/AI-CloudOps/internal/model"
+)
+
+func TestBuildSilenceMatchers(t *testing.T) {
+	tests := []struct {
+		name    string
+		list    []string
+		want    []AlertManagerSilenceMatcher
+		wantErr bool
+	}{
+		{
+			name: "四种匹配方式，按标签名排序",
+			list: []string{`alertname="HostDown"`, `instance=~"10\\..*"`, `env!="test"`, `job!~"node|db"`},
+			want: []AlertManagerSilenceMatcher{
+				{Name: "alertname", Value: "HostDown", IsRegex: false, IsEqual: true},
+				{Name: "env", Value: "test", IsRegex: false, IsEqual: false},
+				{Name: "instance", Value: `10\..*`, IsRegex: true, IsEqual: true},
+				{Name: "job", Value: "node|db", IsRegex: true, IsEqual: false},
+			},
+		},
+		{name: "忽略空行", list: []string{"", `alertname="HostDown"`, "  "}, want: []AlertManagerSilenceMatcher{{Name: "alertname", Value: "HostDown", IsEqual: true}}},
+		{name: "没有匹配条件", list: nil, wantErr: true},
+		{name: "只有空行", list: []string{" "}, wantErr: true},
+		{name: "语法错误", list: []string{`alertname=~"("`}, wantErr: true},
+		{name: "全部匹配空值", list: []string{`env!="prod"`, `instance=~".*"`}, wantErr: true},
+		{name: "部分条件匹配空值", list: []string{`env!="prod"`, `alertname="HostDown"`}, want: []AlertManagerSilenceMatcher{
+			{Name: "alertname", Value: "HostDown", IsEqual: true},
+			{Name: "env", Value: "prod", IsEqual: false},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BuildSilenceMatchers(tt.list)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BuildSilenceMatchers() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BuildSilenceMatchers() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAlertEventSilenceMatchers(t *testing.T) {
+	tests := []struct {
+		name    string
+		event   *model.MonitorAlertEvent
+		useName bool
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "按全部标签排序",
+			event: &model.MonitorAlertEvent{Labels: model.StringList{"instance=10.0.0.1:9100", "alertname=HostDown"}},
+			want:  []string{`alertname="HostDown"`, `instance="10.0.0.1:9100"`},
+		},
+		{
+			name:    "只按告警名称",
+			event:   &model.MonitorAlertEvent{Labels: model.StringList{"instance=10.0.0.1:9100", "alertname=HostDown"}},
+			useName: true,
+			want:    []string{`alertname="HostDown"`},
+		},
+		{
+			name:    "标签中没有告警名称时使用事件的告警名称",
+			event:   &model.MonitorAlertEvent{AlertName: "HostDown", Labels: model.StringList{"instance=10.0.0.1:9100"}},
+			useName: true,
+			want:    []string{`alertname="HostDown"`},
+		},
+		{
+			name:  "标签值包含引号",
+			event: &model.MonitorAlertEvent{Labels: model.StringList{`summary=say "hi"`}},
+			want:  []string{`summary="say \"hi\""`},
+		},
+		{name: "没有告警名称", event: &model.MonitorAlertEvent{}, useName: true, wantErr: true},
+		{name: "没有标签", event: &model.MonitorAlertEvent{}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AlertEventSilenceMatchers(tt.event, tt.useName)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AlertEventSilenceMatchers() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AlertEventSilenceMatchers() = %v, want %v", got, tt.want)
+			}
+			// 生成的匹配条件可以重新解析为静默匹配器
+			if _, err := BuildSilenceMatchers(got); err != nil {
+				t.Errorf("BuildSilenceMatchers(%v) err = %v", got, err)
+			}
+		})
+	}
+}
+
+func TestAlertManagerInstanceURL(t *testing.T) {
+	tests := []struct {
+		instance string
+		want     string
+	}{
+		{instance: "10.0.0.1", want: "http://10.0.0.1:9093"},
+		{instance: "10.0.0.1:19093", want: "http://10.0.0.1:19093"},
+		{instance: "::1", want: "http://[::1]:9093"},
+		{instance: "alertmanager.local", want: "http://alertmanager.local:9093"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.instance, func(t *testing.T) {
+			if got := AlertManagerInstanceURL(tt.instance, 9093); got != tt.want {
+				t.Errorf("AlertManagerInstanceURL(%q) = %q, want %q", tt.instance, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpireAlertManagerSilence(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{name: "过期成功", status: http.StatusOK},
+		{name: "静默不存在视为成功", status: http.StatusNotFound},
+		{name: "AlertManager出错", status: http.StatusInternalServerError, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodDelete || r.URL.Path != "/api/v2/silence/abc" {
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			err := ExpireAlertManagerSilence(context.Background(), server.Client(), server.URL, "abc")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ExpireAlertManagerSilence() err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPostAlertManagerSilence(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    string
+		wantErr bool
+	}{
+		{name: "创建成功", status: http.StatusOK, body: `{"silenceID":"abc"}`, want: "abc"},
+		{name: "没有返回静默ID", status: http.StatusOK, body: `{}`, wantErr: true},
+		{name: "响应无法解析", status: http.StatusOK, body: `not json`, wantErr: true},
+		{name: "AlertManager拒绝", status: http.StatusBadRequest, body: `"invalid"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/api/v2/silences" {
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			got, err := PostAlertManagerSilence(context.Background(), server.Client(), server.URL, &AlertManagerSilence{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PostAlertManagerSilence() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("PostAlertManagerSilence() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}